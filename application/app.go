@@ -3,6 +3,7 @@ package application
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/cloudfoundry/bosh-bootloader/commands"
@@ -11,24 +12,47 @@ import (
 
 type CommandSet map[string]commands.Command
 
+var lockedCommands = map[string]bool{
+	commands.UpCommand:                        true,
+	commands.DestroyCommand:                   true,
+	commands.CreateLBsCommand:                 true,
+	commands.UpdateLBsCommand:                 true,
+	commands.DeleteLBsCommand:                 true,
+	commands.EncryptStateCommand:              true,
+	commands.DecryptStateCommand:              true,
+	commands.StateRollbackCommand:             true,
+	commands.MigrateStateCommand:              true,
+	commands.ImportStateCommand:               true,
+	commands.RotateDirectorCredentialsCommand: true,
+	commands.RotateDirectorCACommand:          true,
+	commands.RotateSSHKeyCommand:              true,
+}
+
 type usage interface {
 	Print()
 	PrintCommandUsage(command, message string)
 }
 
+type stateLocker interface {
+	Lock(command string, timeout time.Duration) (storage.LockInfo, error)
+	Unlock(info storage.LockInfo) error
+}
+
 type App struct {
 	commands      CommandSet
 	configuration Configuration
 	stateStore    stateStore
+	stateLocker   stateLocker
 	usage         usage
 }
 
 func New(commands CommandSet, configuration Configuration, stateStore stateStore,
-	usage usage) App {
+	stateLocker stateLocker, usage usage) App {
 	return App{
 		commands:      commands,
 		configuration: configuration,
 		stateStore:    stateStore,
+		stateLocker:   stateLocker,
 		usage:         usage,
 	}
 }
@@ -81,7 +105,44 @@ func (a App) execute() error {
 		return versionCommand.Execute([]string{}, storage.State{})
 	}
 
-	err = command.Execute(a.configuration.SubcommandFlags, a.configuration.State)
+	if a.locksState() {
+		return a.executeLocked(command)
+	}
+
+	return a.executeCommand(command, a.configuration.State)
+}
+
+// locksState reports whether the command writes the state and so has to hold
+// the lock. migrate-state --dry-run only reads it.
+func (a App) locksState() bool {
+	if a.configuration.Command == commands.MigrateStateCommand && commands.MigrateStateDryRun(a.configuration.SubcommandFlags) {
+		return false
+	}
+
+	return lockedCommands[a.configuration.Command]
+}
+
+func (a App) executeLocked(command commands.Command) error {
+	lockInfo, err := a.stateLocker.Lock(a.configuration.Command, a.configuration.Global.LockTimeout)
+	if err != nil {
+		return err
+	}
+
+	state, err := a.stateStore.Get()
+	if err == nil {
+		err = a.executeCommand(command, state)
+	}
+
+	unlockErr := a.stateLocker.Unlock(lockInfo)
+	if err != nil {
+		return err
+	}
+
+	return unlockErr
+}
+
+func (a App) executeCommand(command commands.Command, state storage.State) error {
+	err := command.Execute(a.configuration.SubcommandFlags, state)
 	if err != nil {
 		switch err.(type) {
 		case awserr.RequestFailure:
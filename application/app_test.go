@@ -2,6 +2,7 @@ package application_test
 
 import (
 	"errors"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/cloudfoundry/bosh-bootloader/application"
@@ -29,13 +30,14 @@ func (snkp setNewKeyPairName) Usage() string { return "" }
 
 var _ = Describe("App", func() {
 	var (
-		app        application.App
-		helpCmd    *fakes.Command
-		versionCmd *fakes.Command
-		someCmd    *fakes.Command
-		errorCmd   *fakes.Command
-		usage      *fakes.Usage
-		stateStore *fakes.StateStore
+		app         application.App
+		helpCmd     *fakes.Command
+		versionCmd  *fakes.Command
+		someCmd     *fakes.Command
+		errorCmd    *fakes.Command
+		usage       *fakes.Usage
+		stateStore  *fakes.StateStore
+		stateLocker *fakes.StateLocker
 	)
 
 	var NewAppWithConfiguration = func(configuration application.Configuration) application.App {
@@ -45,9 +47,12 @@ var _ = Describe("App", func() {
 			"some":                 someCmd,
 			"error":                errorCmd,
 			"set-new-keypair-name": setNewKeyPairName{},
+			"up":                   someCmd,
+			"migrate-state":        someCmd,
 		},
 			configuration,
 			stateStore,
+			stateLocker,
 			usage,
 		)
 	}
@@ -62,6 +67,7 @@ var _ = Describe("App", func() {
 
 		usage = &fakes.Usage{}
 		stateStore = &fakes.StateStore{}
+		stateLocker = &fakes.StateLocker{}
 
 		app = NewAppWithConfiguration(application.Configuration{})
 	})
@@ -98,6 +104,104 @@ var _ = Describe("App", func() {
 			})
 		})
 
+		Context("when the command mutates the state", func() {
+			var lockInfo storage.LockInfo
+
+			BeforeEach(func() {
+				lockInfo = storage.LockInfo{ID: "some-lock-id", Command: "up"}
+				stateLocker.LockCall.Returns.LockInfo = lockInfo
+				stateStore.GetCall.Returns.State = storage.State{EnvID: "some-locked-env-id"}
+
+				app = NewAppWithConfiguration(application.Configuration{
+					Command: "up",
+					Global: application.GlobalConfiguration{
+						LockTimeout: 5 * time.Minute,
+					},
+					State: storage.State{EnvID: "some-stale-env-id"},
+				})
+			})
+
+			It("holds the lock while executing the command with the current state", func() {
+				Expect(app.Run()).To(Succeed())
+
+				Expect(stateLocker.LockCall.CallCount).To(Equal(1))
+				Expect(stateLocker.LockCall.Receives.Command).To(Equal("up"))
+				Expect(stateLocker.LockCall.Receives.Timeout).To(Equal(5 * time.Minute))
+
+				Expect(stateStore.GetCall.CallCount).To(Equal(1))
+				Expect(someCmd.ExecuteCall.CallCount).To(Equal(1))
+				Expect(someCmd.ExecuteCall.Receives.State).To(Equal(storage.State{EnvID: "some-locked-env-id"}))
+
+				Expect(stateLocker.UnlockCall.CallCount).To(Equal(1))
+				Expect(stateLocker.UnlockCall.Receives.LockInfo).To(Equal(lockInfo))
+			})
+
+			It("does not lock commands that only read the state", func() {
+				app = NewAppWithConfiguration(application.Configuration{
+					Command: "some",
+				})
+
+				Expect(app.Run()).To(Succeed())
+				Expect(stateLocker.LockCall.CallCount).To(Equal(0))
+				Expect(stateStore.GetCall.CallCount).To(Equal(0))
+			})
+
+			It("locks migrate-state", func() {
+				app = NewAppWithConfiguration(application.Configuration{
+					Command: "migrate-state",
+				})
+
+				Expect(app.Run()).To(Succeed())
+				Expect(stateLocker.LockCall.CallCount).To(Equal(1))
+				Expect(stateLocker.LockCall.Receives.Command).To(Equal("migrate-state"))
+			})
+
+			It("does not lock migrate-state when it only plans the migration", func() {
+				app = NewAppWithConfiguration(application.Configuration{
+					Command:         "migrate-state",
+					SubcommandFlags: []string{"--dry-run"},
+					State:           storage.State{EnvID: "some-env-id"},
+				})
+
+				Expect(app.Run()).To(Succeed())
+				Expect(stateLocker.LockCall.CallCount).To(Equal(0))
+				Expect(stateStore.GetCall.CallCount).To(Equal(0))
+				Expect(someCmd.ExecuteCall.Receives.State).To(Equal(storage.State{EnvID: "some-env-id"}))
+			})
+
+			Context("failure cases", func() {
+				It("returns an error without executing the command when the lock cannot be acquired", func() {
+					stateLocker.LockCall.Returns.Error = errors.New("state is locked")
+
+					Expect(app.Run()).To(MatchError("state is locked"))
+					Expect(someCmd.ExecuteCall.CallCount).To(Equal(0))
+					Expect(stateLocker.UnlockCall.CallCount).To(Equal(0))
+				})
+
+				It("releases the lock when the state cannot be read", func() {
+					stateStore.GetCall.Returns.Error = errors.New("failed to read state")
+
+					Expect(app.Run()).To(MatchError("failed to read state"))
+					Expect(someCmd.ExecuteCall.CallCount).To(Equal(0))
+					Expect(stateLocker.UnlockCall.CallCount).To(Equal(1))
+				})
+
+				It("releases the lock when the command fails", func() {
+					someCmd.ExecuteCall.Returns.Error = errors.New("command failed")
+					stateLocker.UnlockCall.Returns.Error = errors.New("failed to unlock")
+
+					Expect(app.Run()).To(MatchError("command failed"))
+					Expect(stateLocker.UnlockCall.CallCount).To(Equal(1))
+				})
+
+				It("returns an error when the lock cannot be released", func() {
+					stateLocker.UnlockCall.Returns.Error = errors.New("failed to unlock")
+
+					Expect(app.Run()).To(MatchError("failed to unlock"))
+				})
+			})
+		})
+
 		Context("when subcommand flags contains help", func() {
 			DescribeTable("prints command specific usage when help subcommand flag is provided", func(helpFlag string) {
 				someCmd.UsageCall.Returns.Usage = "some usage message"
@@ -175,7 +279,7 @@ var _ = Describe("App", func() {
 					}, application.Configuration{
 						Command:         "some",
 						SubcommandFlags: []string{"-v"},
-					}, storage.Store{}, usage)
+					}, storage.Store{}, stateLocker, usage)
 
 					err := app.Run()
 					Expect(err).To(MatchError("unknown command: version"))
@@ -15,6 +15,25 @@ type CommandFinder interface {
 type commandFinder struct {
 }
 
+var globalFlagsWithValues = map[string]bool{
+	"--state-dir":                 true,
+	"-state-dir":                  true,
+	"--config":                    true,
+	"-config":                     true,
+	"--state-backend":             true,
+	"-state-backend":              true,
+	"--state-encryption-key-file": true,
+	"--lock-timeout":              true,
+	"-lock-timeout":               true,
+	"-state-encryption-key-file":  true,
+	"--vars-store":                true,
+	"--env":                       true,
+	"-env":                        true,
+	"--output":                    true,
+	"-output":                     true,
+	"-vars-store":                 true,
+}
+
 func NewCommandFinder() CommandFinder {
 	return commandFinder{}
 }
@@ -27,7 +46,7 @@ func (finder commandFinder) FindCommand(input []string) CommandFinderResult {
 	commandFound := false
 	for index, word := range input {
 		if !strings.HasPrefix(word, "-") {
-			if previousCommand != "--state-dir" && previousCommand != "-state-dir" {
+			if !globalFlagsWithValues[previousCommand] {
 				commandIndex = index
 				commandFound = true
 				break
@@ -25,6 +25,27 @@ var _ = Describe("CommandFinder", func() {
 		Entry("parses the first non-hyphenated word as the attempted command if --state-dir=x is provided",
 			[]string{"--state-dir=some-dir", "help", "--other-flag"},
 			application.CommandFinderResult{GlobalFlags: []string{"--state-dir=some-dir"}, Command: "help", OtherArgs: []string{"--other-flag"}}),
+		Entry("parses the first non-hyphenated word as the state backend if it directly follows state-backend",
+			[]string{"--state-backend", "s3://some-bucket", "delete-errthing", "--other-flag"},
+			application.CommandFinderResult{GlobalFlags: []string{"--state-backend", "s3://some-bucket"}, Command: "delete-errthing", OtherArgs: []string{"--other-flag"}}),
+		Entry("parses the first non-hyphenated word as the timeout if it directly follows lock-timeout",
+			[]string{"-lock-timeout", "5m", "up"},
+			application.CommandFinderResult{GlobalFlags: []string{"-lock-timeout", "5m"}, Command: "up", OtherArgs: []string{}}),
+		Entry("parses the first non-hyphenated word as the key file if it directly follows state-encryption-key-file",
+			[]string{"--state-encryption-key-file", "help", "delete-errthing", "--other-flag"},
+			application.CommandFinderResult{GlobalFlags: []string{"--state-encryption-key-file", "help"}, Command: "delete-errthing", OtherArgs: []string{"--other-flag"}}),
+		Entry("parses the first non-hyphenated word as the vars store if it directly follows vars-store",
+			[]string{"--vars-store", "help", "delete-errthing", "--other-flag"},
+			application.CommandFinderResult{GlobalFlags: []string{"--vars-store", "help"}, Command: "delete-errthing", OtherArgs: []string{"--other-flag"}}),
+		Entry("parses the first non-hyphenated word as the config file if it directly follows config",
+			[]string{"--config", "help", "delete-errthing", "--other-flag"},
+			application.CommandFinderResult{GlobalFlags: []string{"--config", "help"}, Command: "delete-errthing", OtherArgs: []string{"--other-flag"}}),
+		Entry("parses the first non-hyphenated word as the environment if it directly follows env",
+			[]string{"--env", "help", "delete-errthing", "--other-flag"},
+			application.CommandFinderResult{GlobalFlags: []string{"--env", "help"}, Command: "delete-errthing", OtherArgs: []string{"--other-flag"}}),
+		Entry("parses the first non-hyphenated word as the output format if it directly follows output",
+			[]string{"--output", "json", "lbs"},
+			application.CommandFinderResult{GlobalFlags: []string{"--output", "json"}, Command: "lbs", OtherArgs: []string{}}),
 		Entry("parses correctly if no global flags given",
 			[]string{"help", "foo", "--other-flag"},
 			application.CommandFinderResult{GlobalFlags: []string{}, Command: "help", OtherArgs: []string{"foo", "--other-flag"}}),
@@ -5,18 +5,27 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/cloudfoundry/bosh-bootloader/commands"
 	"github.com/cloudfoundry/bosh-bootloader/flags"
 )
 
 var getwd func() (string, error) = os.Getwd
 
 type CommandLineConfiguration struct {
-	Command          string
-	SubcommandFlags  []string
-	EndpointOverride string
-	StateDir         string
-	Debug            bool
+	Command                string
+	SubcommandFlags        []string
+	EndpointOverride       string
+	StateDir               string
+	Config                 string
+	StateBackend           string
+	StateEncryptionKeyFile string
+	LockTimeout            time.Duration
+	VarsStore              string
+	Env                    string
+	Output                 string
+	Debug                  bool
 
 	help    bool
 	version bool
@@ -83,21 +92,43 @@ func (c CommandLineParser) parseGlobalFlags(commandLineConfiguration CommandLine
 		return commandLineConfiguration, []string{}, err
 	}
 
+	globalFlags := newGlobalFlags(&commandLineConfiguration)
+
+	err := globalFlags.Parse(arguments)
+	if err != nil {
+		return CommandLineConfiguration{}, []string{}, err
+	}
+
+	return commandLineConfiguration, globalFlags.Args(), nil
+}
+
+// GlobalFlags returns the global flags, for generating shell completion.
+func GlobalFlags() flags.Flags {
+	return newGlobalFlags(&CommandLineConfiguration{})
+}
+
+func newGlobalFlags(commandLineConfiguration *CommandLineConfiguration) flags.Flags {
 	globalFlags := flags.New("global")
 
 	globalFlags.String(&commandLineConfiguration.EndpointOverride, "endpoint-override", "")
 	globalFlags.String(&commandLineConfiguration.StateDir, "state-dir", "")
+	globalFlags.String(&commandLineConfiguration.Config, "config", "")
+	globalFlags.String(&commandLineConfiguration.StateBackend, "state-backend", "")
+	globalFlags.String(&commandLineConfiguration.StateEncryptionKeyFile, "state-encryption-key-file", "")
+	globalFlags.Duration(&commandLineConfiguration.LockTimeout, "lock-timeout", 0)
+	globalFlags.String(&commandLineConfiguration.VarsStore, "vars-store", "")
+	globalFlags.String(&commandLineConfiguration.Env, "env", "")
+	globalFlags.String(&commandLineConfiguration.Output, "output", "")
 	globalFlags.Bool(&commandLineConfiguration.Debug, "d", "debug", false)
 
 	globalFlags.Bool(&commandLineConfiguration.help, "h", "help", false)
 	globalFlags.Bool(&commandLineConfiguration.version, "v", "version", false)
 
-	err := globalFlags.Parse(arguments)
-	if err != nil {
-		return CommandLineConfiguration{}, []string{}, err
-	}
+	globalFlags.Values("output", commands.TextOutput, commands.JSONOutput, commands.YAMLOutput)
+	globalFlags.Files("config", "state-encryption-key-file", "vars-store")
+	globalFlags.Directories("state-dir")
 
-	return commandLineConfiguration, globalFlags.Args(), nil
+	return globalFlags
 }
 
 func (c CommandLineParser) validateGlobalFlags(arguments []string) error {
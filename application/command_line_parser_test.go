@@ -2,10 +2,13 @@ package application_test
 
 import (
 	"errors"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/cloudfoundry/bosh-bootloader/application"
 	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
@@ -36,6 +39,13 @@ var _ = Describe("CommandLineParser", func() {
 			args := []string{
 				"--endpoint-override=some-endpoint-override",
 				"--state-dir", "some/state/dir",
+				"--config", "some/bbl.yml",
+				"--state-backend", "s3://some-bucket/some-prefix",
+				"--state-encryption-key-file", "some/key/file",
+				"--lock-timeout", "5m",
+				"--vars-store", "some/vars-store.yml",
+				"--env", "staging",
+				"--output", "json",
 				"--debug",
 				"up",
 				"--subcommand-flag", "some-value",
@@ -45,6 +55,13 @@ var _ = Describe("CommandLineParser", func() {
 
 			Expect(commandLineConfiguration.EndpointOverride).To(Equal("some-endpoint-override"))
 			Expect(commandLineConfiguration.StateDir).To(Equal("some/state/dir"))
+			Expect(commandLineConfiguration.Config).To(Equal("some/bbl.yml"))
+			Expect(commandLineConfiguration.StateBackend).To(Equal("s3://some-bucket/some-prefix"))
+			Expect(commandLineConfiguration.StateEncryptionKeyFile).To(Equal("some/key/file"))
+			Expect(commandLineConfiguration.LockTimeout).To(Equal(5 * time.Minute))
+			Expect(commandLineConfiguration.VarsStore).To(Equal("some/vars-store.yml"))
+			Expect(commandLineConfiguration.Env).To(Equal("staging"))
+			Expect(commandLineConfiguration.Output).To(Equal("json"))
 			Expect(commandLineConfiguration.Debug).To(BeTrue())
 		})
 
@@ -95,6 +112,36 @@ var _ = Describe("CommandLineParser", func() {
 			})
 		})
 
+		Context("when global flags are set through BBL_ environment variables", func() {
+			BeforeEach(func() {
+				os.Setenv("BBL_STATE_DIR", "some/env/state/dir")
+				os.Setenv("BBL_DEBUG", "true")
+				os.Setenv("BBL_LOCK_TIMEOUT", "2m")
+			})
+
+			AfterEach(func() {
+				os.Unsetenv("BBL_STATE_DIR")
+				os.Unsetenv("BBL_DEBUG")
+				os.Unsetenv("BBL_LOCK_TIMEOUT")
+			})
+
+			It("reads them from the environment", func() {
+				commandLineConfiguration, err := commandLineParser.Parse([]string{"up"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(commandLineConfiguration.StateDir).To(Equal("some/env/state/dir"))
+				Expect(commandLineConfiguration.Debug).To(BeTrue())
+				Expect(commandLineConfiguration.LockTimeout).To(Equal(2 * time.Minute))
+			})
+
+			It("prefers the flags given on the command line", func() {
+				commandLineConfiguration, err := commandLineParser.Parse([]string{"--state-dir", "some/state/dir", "up"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(commandLineConfiguration.StateDir).To(Equal("some/state/dir"))
+			})
+		})
+
 		DescribeTable("when a command is requested using a flag", func(commandLineArgument string, desiredCommand string) {
 			commandLineConfiguration, err := commandLineParser.Parse([]string{
 				commandLineArgument,
@@ -173,4 +220,18 @@ var _ = Describe("CommandLineParser", func() {
 			})
 		})
 	})
+
+	Describe("GlobalFlags", func() {
+		It("registers the global flags with the values they take", func() {
+			visited := map[string]flags.Flag{}
+			application.GlobalFlags().VisitAll(func(flag flags.Flag) {
+				visited[flag.Name] = flag
+			})
+
+			Expect(visited).To(HaveKeyWithValue("help", flags.Flag{Name: "help", Short: "h", Switch: true}))
+			Expect(visited).To(HaveKeyWithValue("output", flags.Flag{Name: "output", Values: []string{"text", "json", "yaml"}}))
+			Expect(visited).To(HaveKeyWithValue("state-dir", flags.Flag{Name: "state-dir", Directories: true}))
+			Expect(visited).To(HaveKeyWithValue("vars-store", flags.Flag{Name: "vars-store", Files: true}))
+		})
+	})
 })
@@ -1,11 +1,24 @@
 package application
 
-import "github.com/cloudfoundry/bosh-bootloader/storage"
+import (
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
 
 type GlobalConfiguration struct {
-	EndpointOverride string
-	StateDir         string
-	Debug            bool
+	EndpointOverride       string
+	StateDir               string
+	ConfigPath             string
+	StateBackend           string
+	StateEncryptionKeyFile string
+	StatePassphrase        []byte
+	LockTimeout            time.Duration
+	VarsStore              string
+	Env                    string
+	Output                 string
+	Debug                  bool
 }
 
 type StringSlice []string
@@ -26,4 +39,5 @@ type Configuration struct {
 	Command         string
 	SubcommandFlags StringSlice
 	State           storage.State
+	ConfigFile      commands.ConfigFile
 }
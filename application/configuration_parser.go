@@ -1,14 +1,35 @@
 package application
 
-import "github.com/cloudfoundry/bosh-bootloader/storage"
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 
-var getState func(string) (storage.State, error) = storage.GetState
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const StatePassphraseEnvironmentVariable = "BBL_STATE_PASSPHRASE"
+
+var (
+	getState          func(string) (storage.State, error)                  = storage.GetState
+	getEncryptedState func(string, []byte) (storage.State, error)          = storage.GetEncryptedState
+	getBackendState   func(storage.Backend, []byte) (storage.State, error) = storage.GetBackendState
+	newStateBackend   func(string, string) (storage.Backend, error)        = storage.NewBackend
+	resolveStateVars  func(storage.State, string) (storage.State, error)   = storage.ResolveStateVars
+	getenv            func(string) string                                  = os.Getenv
+	readConfigFile    func(string) ([]byte, error)                         = ioutil.ReadFile
+)
 
 type commandLineParser interface {
 	Parse(arguments []string) (CommandLineConfiguration, error)
 }
 
 type stateStore interface {
+	Get() (storage.State, error)
 	Set(state storage.State) error
 }
 
@@ -30,25 +51,136 @@ func (p ConfigurationParser) Parse(arguments []string) (Configuration, error) {
 
 	configuration := Configuration{
 		Global: GlobalConfiguration{
-			StateDir:         commandLineConfiguration.StateDir,
-			EndpointOverride: commandLineConfiguration.EndpointOverride,
-			Debug:            commandLineConfiguration.Debug,
+			StateDir:               commandLineConfiguration.StateDir,
+			StateBackend:           commandLineConfiguration.StateBackend,
+			StateEncryptionKeyFile: commandLineConfiguration.StateEncryptionKeyFile,
+			LockTimeout:            commandLineConfiguration.LockTimeout,
+			VarsStore:              commandLineConfiguration.VarsStore,
+			Env:                    commandLineConfiguration.Env,
+			Output:                 commandLineConfiguration.Output,
+			EndpointOverride:       commandLineConfiguration.EndpointOverride,
+			Debug:                  commandLineConfiguration.Debug,
 		},
 		Command:         commandLineConfiguration.Command,
 		SubcommandFlags: commandLineConfiguration.SubcommandFlags,
 		State:           storage.State{},
 	}
 
+	if configuration.Global.Env != "" {
+		err = storage.ValidateEnvName(configuration.Global.Env)
+		if err != nil {
+			return Configuration{}, err
+		}
+	}
+
+	if configuration.Global.Output == "" {
+		configuration.Global.Output = commands.TextOutput
+	}
+
+	err = commands.ValidateOutput(configuration.Global.Output)
+	if err != nil {
+		return Configuration{}, err
+	}
+
 	if !p.isHelpOrVersion(configuration.Command, configuration.SubcommandFlags) {
-		configuration.State, err = getState(configuration.Global.StateDir)
+		configuration.ConfigFile, configuration.Global.ConfigPath, err = p.configFile(commandLineConfiguration.Config, configuration.Global.StateDir)
 		if err != nil {
 			return Configuration{}, err
 		}
+
+		if configFlags := configuration.ConfigFile.Flags(configuration.Command); len(configFlags) > 0 {
+			configuration.SubcommandFlags = append(configFlags, configuration.SubcommandFlags...)
+		}
+
+		configuration.Global.StatePassphrase, err = p.statePassphrase(configuration.Global.StateEncryptionKeyFile)
+		if err != nil {
+			return Configuration{}, err
+		}
+
+		if configuration.Command != commands.ValidateStateCommand && configuration.Command != commands.EnvsCommand {
+			configuration.State, err = p.getState(configuration.Global)
+			if err != nil {
+				return Configuration{}, err
+			}
+		}
 	}
 
 	return configuration, nil
 }
 
+func (p ConfigurationParser) getState(global GlobalConfiguration) (storage.State, error) {
+	state, err := p.readState(global)
+	if err != nil {
+		return storage.State{}, err
+	}
+
+	return resolveStateVars(state, global.VarsStore)
+}
+
+func (ConfigurationParser) readState(global GlobalConfiguration) (storage.State, error) {
+	if global.StateBackend != "" || global.Env != "" {
+		backend, err := newStateBackend(global.StateDir, global.StateBackend)
+		if err != nil {
+			return storage.State{}, err
+		}
+
+		return getBackendState(storage.NewEnvBackend(backend, global.Env), global.StatePassphrase)
+	}
+
+	if global.StatePassphrase != nil {
+		return getEncryptedState(global.StateDir, global.StatePassphrase)
+	}
+
+	return getState(global.StateDir)
+}
+
+// configFile reads the bbl.yml given with --config, or the one in the state
+// directory when there is one.
+func (ConfigurationParser) configFile(path, stateDir string) (commands.ConfigFile, string, error) {
+	explicit := path != ""
+	if !explicit {
+		path = filepath.Join(stateDir, commands.ConfigFileName)
+	}
+
+	contents, err := readConfigFile(path)
+	if os.IsNotExist(err) && !explicit {
+		return commands.ConfigFile{}, "", nil
+	}
+	if err != nil {
+		return commands.ConfigFile{}, "", fmt.Errorf("error reading config file: %v", err)
+	}
+
+	var configFile commands.ConfigFile
+	err = yaml.Unmarshal(contents, &configFile)
+	if err != nil {
+		return commands.ConfigFile{}, "", fmt.Errorf("error parsing config file %q: %v", path, err)
+	}
+
+	return configFile, path, nil
+}
+
+func (ConfigurationParser) statePassphrase(keyFile string) ([]byte, error) {
+	if keyFile != "" {
+		key, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading state encryption key file: %v", err)
+		}
+
+		key = []byte(strings.TrimRight(string(key), "\r\n"))
+		if len(key) == 0 {
+			return nil, fmt.Errorf("state encryption key file %q is empty", keyFile)
+		}
+
+		return key, nil
+	}
+
+	if passphrase := getenv(StatePassphraseEnvironmentVariable); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+
+	return nil, nil
+}
+
 func (ConfigurationParser) isHelpOrVersion(command string, subcommandFlags StringSlice) bool {
 	if command == "help" || command == "version" {
 		return true
@@ -2,6 +2,8 @@ package application_test
 
 import (
 	"errors"
+	"io/ioutil"
+	"os"
 
 	"github.com/cloudfoundry/bosh-bootloader/application"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
@@ -23,10 +25,19 @@ var _ = Describe("ConfigurationParser", func() {
 		application.SetGetState(func(dir string) (storage.State, error) {
 			return storage.State{Version: 1}, nil
 		})
+		application.SetGetenv(func(string) string {
+			return ""
+		})
 	})
 
 	AfterEach(func() {
 		application.ResetGetState()
+		application.ResetGetEncryptedState()
+		application.ResetGetBackendState()
+		application.ResetNewStateBackend()
+		application.ResetGetenv()
+		application.ResetResolveStateVars()
+		application.ResetReadConfigFile()
 	})
 
 	Describe("Parse", func() {
@@ -37,6 +48,7 @@ var _ = Describe("ConfigurationParser", func() {
 				SubcommandFlags:  []string{"--some-flag", "some-value"},
 				StateDir:         "some/state/dir",
 				EndpointOverride: "some-endpoint-override",
+				Output:           "json",
 				Debug:            true,
 			}
 			configuration, err := configurationParser.Parse([]string{"up"})
@@ -47,12 +59,151 @@ var _ = Describe("ConfigurationParser", func() {
 			Expect(configuration.Global).To(Equal(application.GlobalConfiguration{
 				EndpointOverride: "some-endpoint-override",
 				StateDir:         "some/state/dir",
+				Output:           "json",
 				Debug:            true,
 			}))
 
 			Expect(commandLineParser.ParseCall.Receives.Arguments).To(Equal([]string{"up"}))
 		})
 
+		It("defaults the output format to text", func() {
+			commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+				Command: "up",
+			}
+			configuration, err := configurationParser.Parse([]string{"up"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(configuration.Global.Output).To(Equal("text"))
+		})
+
+		It("returns an error when the output format is not supported", func() {
+			commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+				Command: "up",
+				Output:  "xml",
+			}
+			_, err := configurationParser.Parse([]string{"up"})
+			Expect(err).To(MatchError(`--output must be one of text, json or yaml, got "xml"`))
+		})
+
+		Describe("config file", func() {
+			var readConfigFilePath string
+
+			BeforeEach(func() {
+				application.SetReadConfigFile(func(path string) ([]byte, error) {
+					readConfigFilePath = path
+					return []byte(`
+iaas: gcp
+gcp:
+  project-id: some-project-id
+  zone: some-zone
+lb:
+  type: cf
+  domain: some-domain
+`), nil
+				})
+			})
+
+			It("reads bbl.yml from the state dir and puts its options before the flags", func() {
+				commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+					StateDir:        "some/state/dir",
+					Command:         "up",
+					SubcommandFlags: []string{"--gcp-zone", "some-other-zone"},
+				}
+
+				configuration, err := configurationParser.Parse([]string{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(readConfigFilePath).To(Equal("some/state/dir/bbl.yml"))
+				Expect(configuration.Global.ConfigPath).To(Equal("some/state/dir/bbl.yml"))
+				Expect(configuration.ConfigFile.GCP.ProjectID).To(Equal("some-project-id"))
+				Expect(configuration.SubcommandFlags).To(Equal(application.StringSlice{
+					"--iaas=gcp",
+					"--gcp-project-id=some-project-id",
+					"--gcp-zone=some-zone",
+					"--gcp-zone", "some-other-zone",
+				}))
+			})
+
+			It("puts the load balancer options before the flags of create-lbs", func() {
+				commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+					Command: "create-lbs",
+				}
+
+				configuration, err := configurationParser.Parse([]string{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(configuration.SubcommandFlags).To(Equal(application.StringSlice{"--type=cf", "--domain=some-domain"}))
+			})
+
+			It("leaves the flags of other commands alone", func() {
+				commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+					Command:         "destroy",
+					SubcommandFlags: []string{"--no-confirm"},
+				}
+
+				configuration, err := configurationParser.Parse([]string{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(configuration.SubcommandFlags).To(Equal(application.StringSlice{"--no-confirm"}))
+			})
+
+			It("reads the config file given with --config", func() {
+				commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+					StateDir: "some/state/dir",
+					Config:   "some/bbl.yml",
+					Command:  "up",
+				}
+
+				configuration, err := configurationParser.Parse([]string{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(readConfigFilePath).To(Equal("some/bbl.yml"))
+				Expect(configuration.Global.ConfigPath).To(Equal("some/bbl.yml"))
+			})
+
+			It("does not require a bbl.yml in the state dir", func() {
+				application.SetReadConfigFile(func(path string) ([]byte, error) {
+					return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+				})
+				commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+					StateDir: "some/state/dir",
+					Command:  "up",
+				}
+
+				configuration, err := configurationParser.Parse([]string{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(configuration.Global.ConfigPath).To(BeEmpty())
+				Expect(configuration.SubcommandFlags).To(BeEmpty())
+			})
+
+			It("returns an error when the config file given with --config does not exist", func() {
+				application.SetReadConfigFile(func(path string) ([]byte, error) {
+					return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+				})
+				commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+					Config:  "some/bbl.yml",
+					Command: "up",
+				}
+
+				_, err := configurationParser.Parse([]string{})
+				Expect(err).To(MatchError("error reading config file: open some/bbl.yml: file does not exist"))
+			})
+
+			It("returns an error when the config file is not valid yaml", func() {
+				application.SetReadConfigFile(func(string) ([]byte, error) {
+					return []byte("%%%"), nil
+				})
+				commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+					StateDir: "some/state/dir",
+					Command:  "up",
+				}
+
+				_, err := configurationParser.Parse([]string{})
+				Expect(err).To(MatchError(ContainSubstring(`error parsing config file "some/state/dir/bbl.yml"`)))
+			})
+		})
+
 		Describe("state management", func() {
 			It("returns a configuration with the state from the state store", func() {
 				commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
@@ -67,6 +218,251 @@ var _ = Describe("ConfigurationParser", func() {
 				}))
 			})
 
+			Context("when the state is encrypted", func() {
+				var (
+					encryptedStateDir        string
+					encryptedStatePassphrase []byte
+				)
+
+				BeforeEach(func() {
+					application.SetGetEncryptedState(func(dir string, passphrase []byte) (storage.State, error) {
+						encryptedStateDir = dir
+						encryptedStatePassphrase = passphrase
+						return storage.State{Version: 2, EnvID: "some-encrypted-env"}, nil
+					})
+				})
+
+				It("decrypts the state with the contents of the state encryption key file", func() {
+					keyFile, err := ioutil.TempFile("", "")
+					Expect(err).NotTo(HaveOccurred())
+					defer os.Remove(keyFile.Name())
+
+					_, err = keyFile.WriteString("some-passphrase\n")
+					Expect(err).NotTo(HaveOccurred())
+
+					commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+						StateDir:               "some/state/dir",
+						StateEncryptionKeyFile: keyFile.Name(),
+						Command:                "up",
+					}
+
+					configuration, err := configurationParser.Parse([]string{})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(encryptedStateDir).To(Equal("some/state/dir"))
+					Expect(encryptedStatePassphrase).To(Equal([]byte("some-passphrase")))
+					Expect(configuration.Global.StateEncryptionKeyFile).To(Equal(keyFile.Name()))
+					Expect(configuration.Global.StatePassphrase).To(Equal([]byte("some-passphrase")))
+					Expect(configuration.State.EnvID).To(Equal("some-encrypted-env"))
+				})
+
+				It("decrypts the state with the passphrase from the environment", func() {
+					application.SetGetenv(func(name string) string {
+						if name == "BBL_STATE_PASSPHRASE" {
+							return "some-env-passphrase"
+						}
+						return ""
+					})
+
+					commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+						StateDir: "some/state/dir",
+						Command:  "up",
+					}
+
+					configuration, err := configurationParser.Parse([]string{})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(encryptedStatePassphrase).To(Equal([]byte("some-env-passphrase")))
+					Expect(configuration.State.EnvID).To(Equal("some-encrypted-env"))
+				})
+
+				Context("failure cases", func() {
+					It("returns an error when the state encryption key file cannot be read", func() {
+						commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+							StateEncryptionKeyFile: "/some/missing/key-file",
+							Command:                "up",
+						}
+
+						_, err := configurationParser.Parse([]string{})
+						Expect(err).To(MatchError(ContainSubstring("error reading state encryption key file")))
+					})
+
+					It("returns an error when the state encryption key file is empty", func() {
+						keyFile, err := ioutil.TempFile("", "")
+						Expect(err).NotTo(HaveOccurred())
+						defer os.Remove(keyFile.Name())
+
+						commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+							StateEncryptionKeyFile: keyFile.Name(),
+							Command:                "up",
+						}
+
+						_, err = configurationParser.Parse([]string{})
+						Expect(err).To(MatchError(ContainSubstring("is empty")))
+					})
+
+					It("returns an error when the encrypted state cannot be read", func() {
+						application.SetGetenv(func(string) string {
+							return "some-env-passphrase"
+						})
+						application.SetGetEncryptedState(func(string, []byte) (storage.State, error) {
+							return storage.State{}, errors.New("failed to decrypt state")
+						})
+
+						commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+							Command: "up",
+						}
+
+						_, err := configurationParser.Parse([]string{})
+						Expect(err).To(MatchError("failed to decrypt state"))
+					})
+				})
+			})
+
+			Context("when a vars store is provided", func() {
+				It("resolves the references in the state from the vars store", func() {
+					var varsStorePath string
+					application.SetResolveStateVars(func(state storage.State, path string) (storage.State, error) {
+						varsStorePath = path
+						state.BOSH.DirectorPassword = "some-director-password"
+						return state, nil
+					})
+
+					commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+						StateDir:  "some/state/dir",
+						VarsStore: "some/vars-store.yml",
+						Command:   "up",
+					}
+
+					configuration, err := configurationParser.Parse([]string{})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(varsStorePath).To(Equal("some/vars-store.yml"))
+					Expect(configuration.Global.VarsStore).To(Equal("some/vars-store.yml"))
+					Expect(configuration.State.BOSH.DirectorPassword).To(Equal("some-director-password"))
+				})
+
+				It("returns an error when the references cannot be resolved", func() {
+					application.SetResolveStateVars(func(storage.State, string) (storage.State, error) {
+						return storage.State{}, errors.New("failed to resolve vars")
+					})
+
+					commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+						Command: "up",
+					}
+
+					_, err := configurationParser.Parse([]string{})
+					Expect(err).To(MatchError("failed to resolve vars"))
+				})
+			})
+
+			Context("when a state backend is provided", func() {
+				var (
+					newStateBackendStateDir string
+					newStateBackendURL      string
+					backendStatePassphrase  []byte
+				)
+
+				BeforeEach(func() {
+					application.SetNewStateBackend(func(stateDir, stateBackend string) (storage.Backend, error) {
+						newStateBackendStateDir = stateDir
+						newStateBackendURL = stateBackend
+						return storage.NewLocalBackend("some-backend-dir"), nil
+					})
+					application.SetGetBackendState(func(backend storage.Backend, passphrase []byte) (storage.State, error) {
+						backendStatePassphrase = passphrase
+						return storage.State{Version: 2, EnvID: backend.String()}, nil
+					})
+				})
+
+				It("reads the state from the state backend", func() {
+					application.SetGetenv(func(string) string {
+						return "some-env-passphrase"
+					})
+
+					commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+						StateDir:     "some/state/dir",
+						StateBackend: "s3://some-bucket/some-prefix",
+						Command:      "up",
+					}
+
+					configuration, err := configurationParser.Parse([]string{})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(newStateBackendStateDir).To(Equal("some/state/dir"))
+					Expect(newStateBackendURL).To(Equal("s3://some-bucket/some-prefix"))
+					Expect(backendStatePassphrase).To(Equal([]byte("some-env-passphrase")))
+					Expect(configuration.Global.StateBackend).To(Equal("s3://some-bucket/some-prefix"))
+					Expect(configuration.State.EnvID).To(Equal("some-backend-dir"))
+				})
+
+				Context("failure cases", func() {
+					It("returns an error when the state backend is invalid", func() {
+						application.SetNewStateBackend(func(string, string) (storage.Backend, error) {
+							return nil, errors.New("invalid state backend")
+						})
+
+						commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+							StateBackend: "ftp://some-bucket",
+							Command:      "up",
+						}
+
+						_, err := configurationParser.Parse([]string{})
+						Expect(err).To(MatchError("invalid state backend"))
+					})
+
+					It("returns an error when the state cannot be read from the state backend", func() {
+						application.SetGetBackendState(func(storage.Backend, []byte) (storage.State, error) {
+							return storage.State{}, errors.New("failed to read state")
+						})
+
+						commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+							StateBackend: "s3://some-bucket",
+							Command:      "up",
+						}
+
+						_, err := configurationParser.Parse([]string{})
+						Expect(err).To(MatchError("failed to read state"))
+					})
+				})
+			})
+
+			Context("when an environment is provided", func() {
+				It("reads the state of the environment from under envs in the state directory", func() {
+					var newStateBackendStateDir string
+					application.SetNewStateBackend(func(stateDir, stateBackend string) (storage.Backend, error) {
+						newStateBackendStateDir = stateDir
+						return storage.NewLocalBackend(stateDir), nil
+					})
+					application.SetGetBackendState(func(backend storage.Backend, passphrase []byte) (storage.State, error) {
+						return storage.State{Version: 2, EnvID: backend.String()}, nil
+					})
+
+					commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+						StateDir: "some/state/dir",
+						Env:      "staging",
+						Command:  "up",
+					}
+
+					configuration, err := configurationParser.Parse([]string{})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(newStateBackendStateDir).To(Equal("some/state/dir"))
+					Expect(configuration.Global.Env).To(Equal("staging"))
+					Expect(configuration.State.EnvID).To(Equal("some/state/dir/envs/staging"))
+				})
+
+				It("returns an error when the environment name is invalid", func() {
+					commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+						Env:     "../staging",
+						Command: "up",
+					}
+
+					_, err := configurationParser.Parse([]string{})
+					Expect(err).To(MatchError(ContainSubstring(`invalid environment name "../staging"`)))
+				})
+			})
+
 			DescribeTable("help, version, help flags does not try parse state", func(command string, subcommandFlags []string) {
 				commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
 					Command:         command,
@@ -106,6 +502,32 @@ var _ = Describe("ConfigurationParser", func() {
 
 				Expect(err).To(MatchError("failed to read state"))
 			})
+
+			It("does not read the state for validate-state, so that it can report why the state cannot be read", func() {
+				application.SetGetState(func(dir string) (storage.State, error) {
+					return storage.State{}, errors.New("failed to read state")
+				})
+				commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+					Command: "validate-state",
+				}
+
+				configuration, err := configurationParser.Parse([]string{"validate-state"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(configuration.State).To(Equal(storage.State{}))
+			})
+
+			It("does not read the state for envs, which reads the state of every environment itself", func() {
+				application.SetGetState(func(dir string) (storage.State, error) {
+					return storage.State{}, errors.New("failed to read state")
+				})
+				commandLineParser.ParseCall.Returns.CommandLineConfiguration = application.CommandLineConfiguration{
+					Command: "envs",
+				}
+
+				configuration, err := configurationParser.Parse([]string{"envs"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(configuration.State).To(Equal(storage.State{}))
+			})
 		})
 	})
 })
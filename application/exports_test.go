@@ -1,6 +1,7 @@
 package application
 
 import (
+	"io/ioutil"
 	"os"
 
 	"github.com/cloudfoundry/bosh-bootloader/storage"
@@ -21,3 +22,51 @@ func SetGetState(f func(string) (storage.State, error)) {
 func ResetGetState() {
 	getState = storage.GetState
 }
+
+func SetGetEncryptedState(f func(string, []byte) (storage.State, error)) {
+	getEncryptedState = f
+}
+
+func ResetGetEncryptedState() {
+	getEncryptedState = storage.GetEncryptedState
+}
+
+func SetGetenv(f func(string) string) {
+	getenv = f
+}
+
+func ResetGetenv() {
+	getenv = os.Getenv
+}
+
+func SetGetBackendState(f func(storage.Backend, []byte) (storage.State, error)) {
+	getBackendState = f
+}
+
+func ResetGetBackendState() {
+	getBackendState = storage.GetBackendState
+}
+
+func SetNewStateBackend(f func(string, string) (storage.Backend, error)) {
+	newStateBackend = f
+}
+
+func ResetNewStateBackend() {
+	newStateBackend = storage.NewBackend
+}
+
+func SetReadConfigFile(f func(string) ([]byte, error)) {
+	readConfigFile = f
+}
+
+func ResetReadConfigFile() {
+	readConfigFile = ioutil.ReadFile
+}
+
+func SetResolveStateVars(f func(storage.State, string) (storage.State, error)) {
+	resolveStateVars = f
+}
+
+func ResetResolveStateVars() {
+	resolveStateVars = storage.ResolveStateVars
+}
@@ -3,21 +3,26 @@ package application
 import (
 	"fmt"
 	"os"
-	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
 
 type StateValidator struct {
-	stateDir string
+	backend storage.Backend
 }
 
 func NewStateValidator(stateDir string) StateValidator {
-	return StateValidator{stateDir: stateDir}
+	return NewBackendStateValidator(storage.NewLocalBackend(stateDir))
+}
+
+func NewBackendStateValidator(backend storage.Backend) StateValidator {
+	return StateValidator{backend: backend}
 }
 
 func (s StateValidator) Validate() error {
-	_, err := os.Stat(filepath.Join(s.stateDir, "bbl-state.json"))
+	_, err := s.backend.Read(storage.StateFileName)
 	if os.IsNotExist(err) {
-		return fmt.Errorf("bbl-state.json not found in %q, ensure you're running this command in the proper state directory or create a new environment with bbl up", s.stateDir)
+		return fmt.Errorf("bbl-state.json not found in %q, ensure you're running this command in the proper state directory or create a new environment with bbl up", s.backend.String())
 	}
 	if err != nil {
 		return err
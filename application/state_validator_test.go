@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 
 	"github.com/cloudfoundry/bosh-bootloader/application"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -40,6 +41,15 @@ var _ = Describe("StateValidator", func() {
 		Expect(err).To(MatchError(expectedError))
 	})
 
+	Context("when the state validator uses a backend", func() {
+		It("returns an error naming the backend when the state file cannot be found", func() {
+			stateValidator = application.NewBackendStateValidator(storage.NewLocalBackend(tempDirectory))
+
+			err := stateValidator.Validate()
+			Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("bbl-state.json not found in %q", tempDirectory))))
+		})
+	})
+
 	Context("failure cases", func() {
 		It("returns an error when permission denied", func() {
 			err := os.Chmod(tempDirectory, os.FileMode(0))
@@ -0,0 +1,125 @@
+package cloudformation
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/cloudfoundry/bosh-bootloader/aws/cloudformation/templates"
+)
+
+func (s StackManager) ChangeSet(name string, template templates.Template, tags Tags, sleepInterval time.Duration) ([]StackChange, error) {
+	_, err := s.Describe(name)
+	switch err {
+	case StackNotFound:
+		return templateChanges(template), nil
+	case nil:
+	default:
+		return nil, err
+	}
+
+	s.logger.Step("creating cloudformation change set")
+
+	templateJson, err := json.Marshal(&template)
+	if err != nil {
+		return nil, err
+	}
+
+	changeSetName := fmt.Sprintf("bbl-plan-%d", time.Now().Unix())
+	_, err = s.cloudFormationClient().CreateChangeSet(&cloudformation.CreateChangeSetInput{
+		StackName:     aws.String(name),
+		ChangeSetName: aws.String(changeSetName),
+		ChangeSetType: aws.String(cloudformation.ChangeSetTypeUpdate),
+		Capabilities:  []*string{aws.String("CAPABILITY_IAM"), aws.String("CAPABILITY_NAMED_IAM")},
+		TemplateBody:  aws.String(string(templateJson)),
+		Tags:          tags.toAWSTags(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := s.describeChangeSet(name, changeSetName, sleepInterval)
+
+	_, deleteErr := s.cloudFormationClient().DeleteChangeSet(&cloudformation.DeleteChangeSetInput{
+		StackName:     aws.String(name),
+		ChangeSetName: aws.String(changeSetName),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if deleteErr != nil {
+		return nil, deleteErr
+	}
+
+	return changes, nil
+}
+
+func (s StackManager) describeChangeSet(name, changeSetName string, sleepInterval time.Duration) ([]StackChange, error) {
+	changes := []StackChange{}
+	input := &cloudformation.DescribeChangeSetInput{
+		StackName:     aws.String(name),
+		ChangeSetName: aws.String(changeSetName),
+	}
+
+	for {
+		output, err := s.cloudFormationClient().DescribeChangeSet(input)
+		if err != nil {
+			return nil, err
+		}
+
+		switch aws.StringValue(output.Status) {
+		case cloudformation.ChangeSetStatusCreateComplete:
+		case cloudformation.ChangeSetStatusFailed:
+			reason := aws.StringValue(output.StatusReason)
+			if strings.Contains(reason, "didn't contain changes") || strings.Contains(reason, "No updates are to be performed") {
+				return changes, nil
+			}
+			return nil, fmt.Errorf("failed to create change set for cloudformation stack %q: %s", name, reason)
+		default:
+			s.logger.Dot()
+			time.Sleep(sleepInterval)
+			continue
+		}
+
+		for _, change := range output.Changes {
+			if change.ResourceChange == nil {
+				continue
+			}
+
+			changes = append(changes, StackChange{
+				Action:            aws.StringValue(change.ResourceChange.Action),
+				LogicalResourceID: aws.StringValue(change.ResourceChange.LogicalResourceId),
+				ResourceType:      aws.StringValue(change.ResourceChange.ResourceType),
+				Replacement:       aws.StringValue(change.ResourceChange.Replacement),
+			})
+		}
+
+		if output.NextToken == nil {
+			return changes, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+func templateChanges(template templates.Template) []StackChange {
+	logicalIDs := []string{}
+	for logicalID := range template.Resources {
+		logicalIDs = append(logicalIDs, logicalID)
+	}
+	sort.Strings(logicalIDs)
+
+	changes := []StackChange{}
+	for _, logicalID := range logicalIDs {
+		changes = append(changes, StackChange{
+			Action:            cloudformation.ChangeActionAdd,
+			LogicalResourceID: logicalID,
+			ResourceType:      template.Resources[logicalID].Type,
+		})
+	}
+
+	return changes
+}
@@ -0,0 +1,158 @@
+package cloudformation_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awscloudformation "github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/cloudfoundry/bosh-bootloader/aws/cloudformation"
+	"github.com/cloudfoundry/bosh-bootloader/aws/cloudformation/templates"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ChangeSet", func() {
+	var (
+		cloudFormationClient *fakes.CloudFormationClient
+		clientProvider       *fakes.ClientProvider
+		logger               *fakes.Logger
+		manager              cloudformation.StackManager
+		template             templates.Template
+	)
+
+	BeforeEach(func() {
+		clientProvider = &fakes.ClientProvider{}
+		cloudFormationClient = &fakes.CloudFormationClient{}
+		logger = &fakes.Logger{}
+		clientProvider.GetCloudFormationClientCall.Returns.CloudFormationClient = cloudFormationClient
+		manager = cloudformation.NewStackManager(clientProvider, logger)
+
+		template = templates.Template{
+			Resources: map[string]templates.Resource{
+				"NATInstance":     {Type: "AWS::EC2::Instance"},
+				"InternalSubnet1": {Type: "AWS::EC2::Subnet"},
+			},
+		}
+
+		cloudFormationClient.DescribeStacksCall.Returns.Output = &awscloudformation.DescribeStacksOutput{
+			Stacks: []*awscloudformation.Stack{{
+				StackName:   aws.String("some-stack-name"),
+				StackStatus: aws.String(awscloudformation.StackStatusUpdateComplete),
+			}},
+		}
+	})
+
+	It("creates a change set for the stack and returns its resource changes", func() {
+		statuses := []string{awscloudformation.ChangeSetStatusCreatePending, awscloudformation.ChangeSetStatusCreateComplete}
+		cloudFormationClient.DescribeChangeSetCall.Stub = func(input *awscloudformation.DescribeChangeSetInput) (*awscloudformation.DescribeChangeSetOutput, error) {
+			status := statuses[0]
+			statuses = statuses[1:]
+
+			return &awscloudformation.DescribeChangeSetOutput{
+				Status: aws.String(status),
+				Changes: []*awscloudformation.Change{{
+					ResourceChange: &awscloudformation.ResourceChange{
+						Action:            aws.String("Modify"),
+						LogicalResourceId: aws.String("NATInstance"),
+						ResourceType:      aws.String("AWS::EC2::Instance"),
+						Replacement:       aws.String("True"),
+					},
+				}},
+			}, nil
+		}
+
+		changes, err := manager.ChangeSet("some-stack-name", template, cloudformation.Tags{
+			{Key: "bbl-env-id", Value: "some-env-id"},
+		}, 0)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(changes).To(Equal([]cloudformation.StackChange{{
+			Action:            "Modify",
+			LogicalResourceID: "NATInstance",
+			ResourceType:      "AWS::EC2::Instance",
+			Replacement:       "True",
+		}}))
+
+		input := cloudFormationClient.CreateChangeSetCall.Receives.Input
+		Expect(input.StackName).To(Equal(aws.String("some-stack-name")))
+		Expect(aws.StringValue(input.ChangeSetName)).To(HavePrefix("bbl-plan-"))
+		Expect(input.ChangeSetType).To(Equal(aws.String("UPDATE")))
+		Expect(input.Tags).To(Equal([]*awscloudformation.Tag{{
+			Key:   aws.String("bbl-env-id"),
+			Value: aws.String("some-env-id"),
+		}}))
+
+		Expect(cloudFormationClient.DescribeChangeSetCall.CallCount).To(Equal(2))
+		Expect(logger.DotCall.CallCount).To(Equal(1))
+		Expect(logger.StepCall.Messages).To(Equal([]string{"creating cloudformation change set"}))
+
+		Expect(cloudFormationClient.DeleteChangeSetCall.CallCount).To(Equal(1))
+		Expect(cloudFormationClient.DeleteChangeSetCall.Receives.Input.ChangeSetName).To(Equal(input.ChangeSetName))
+	})
+
+	It("returns no changes when the change set is empty", func() {
+		cloudFormationClient.DescribeChangeSetCall.Returns.Output = &awscloudformation.DescribeChangeSetOutput{
+			Status:       aws.String(awscloudformation.ChangeSetStatusFailed),
+			StatusReason: aws.String("The submitted information didn't contain changes. Submit different information to create a change set."),
+		}
+
+		changes, err := manager.ChangeSet("some-stack-name", template, cloudformation.Tags{}, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(BeEmpty())
+		Expect(cloudFormationClient.DeleteChangeSetCall.CallCount).To(Equal(1))
+	})
+
+	It("returns every resource of the template as an addition when the stack does not exist", func() {
+		cloudFormationClient.DescribeStacksCall.Returns.Error = awserr.NewRequestFailure(awserr.New("ValidationError", fmt.Sprintf("Stack with id %s does not exist", "some-stack-name"), errors.New("")), 400, "0")
+
+		changes, err := manager.ChangeSet("some-stack-name", template, cloudformation.Tags{}, 0)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(changes).To(Equal([]cloudformation.StackChange{
+			{Action: "Add", LogicalResourceID: "InternalSubnet1", ResourceType: "AWS::EC2::Subnet"},
+			{Action: "Add", LogicalResourceID: "NATInstance", ResourceType: "AWS::EC2::Instance"},
+		}))
+		Expect(cloudFormationClient.CreateChangeSetCall.CallCount).To(Equal(0))
+	})
+
+	Context("failure cases", func() {
+		It("returns an error when the stack cannot be described", func() {
+			cloudFormationClient.DescribeStacksCall.Returns.Error = errors.New("failed to describe stack")
+
+			_, err := manager.ChangeSet("some-stack-name", template, cloudformation.Tags{}, 0)
+			Expect(err).To(MatchError("failed to describe stack"))
+		})
+
+		It("returns an error when the change set cannot be created", func() {
+			cloudFormationClient.CreateChangeSetCall.Returns.Error = errors.New("failed to create change set")
+
+			_, err := manager.ChangeSet("some-stack-name", template, cloudformation.Tags{}, 0)
+			Expect(err).To(MatchError("failed to create change set"))
+		})
+
+		It("returns an error and deletes the change set when it fails", func() {
+			cloudFormationClient.DescribeChangeSetCall.Returns.Output = &awscloudformation.DescribeChangeSetOutput{
+				Status:       aws.String(awscloudformation.ChangeSetStatusFailed),
+				StatusReason: aws.String("some-reason"),
+			}
+
+			_, err := manager.ChangeSet("some-stack-name", template, cloudformation.Tags{}, 0)
+			Expect(err).To(MatchError(`failed to create change set for cloudformation stack "some-stack-name": some-reason`))
+			Expect(cloudFormationClient.DeleteChangeSetCall.CallCount).To(Equal(1))
+		})
+
+		It("returns an error when the change set cannot be deleted", func() {
+			cloudFormationClient.DescribeChangeSetCall.Returns.Output = &awscloudformation.DescribeChangeSetOutput{
+				Status: aws.String(awscloudformation.ChangeSetStatusCreateComplete),
+			}
+			cloudFormationClient.DeleteChangeSetCall.Returns.Error = errors.New("failed to delete change set")
+
+			_, err := manager.ChangeSet("some-stack-name", template, cloudformation.Tags{}, 0)
+			Expect(err).To(MatchError("failed to delete change set"))
+		})
+	})
+})
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/cloudfoundry/bosh-bootloader/aws/cloudformation/templates"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
 
 const bblTagKey = "bbl-env-id"
@@ -21,6 +22,7 @@ type stackManager interface {
 	Describe(stackName string) (Stack, error)
 	Delete(stackName string) error
 	GetPhysicalIDForResource(stackName string, logicalResourceID string) (string, error)
+	ChangeSet(stackName string, template templates.Template, tags Tags, sleepInterval time.Duration) ([]StackChange, error)
 }
 
 type InfrastructureManager struct {
@@ -36,23 +38,17 @@ func NewInfrastructureManager(builder templateBuilder, stackManager stackManager
 }
 
 func (m InfrastructureManager) Create(keyPairName string, numberOfAvailabilityZones int, stackName,
-	lbType, lbCertificateARN, envID string) (Stack, error) {
+	lbType, lbCertificateARN, envID string, fragments []storage.CloudFormationFragment) (Stack, error) {
 
-	iamUserName := generateIAMUserName(envID)
-
-	stackExists, err := m.Exists(stackName)
+	iamUserName, err := m.iamUserName(stackName, envID)
 	if err != nil {
 		return Stack{}, err
 	}
 
-	if stackExists {
-		iamUserName, err = m.stackManager.GetPhysicalIDForResource(stackName, "BOSHUser")
-		if err != nil {
-			return Stack{}, err
-		}
+	template, err := m.template(keyPairName, numberOfAvailabilityZones, lbType, lbCertificateARN, iamUserName, envID, fragments)
+	if err != nil {
+		return Stack{}, err
 	}
-
-	template := m.templateBuilder.Build(keyPairName, numberOfAvailabilityZones, lbType, lbCertificateARN, iamUserName, envID)
 	tags := Tags{
 		{
 			Key:   bblTagKey,
@@ -71,15 +67,34 @@ func (m InfrastructureManager) Create(keyPairName string, numberOfAvailabilityZo
 	return m.stackManager.Describe(stackName)
 }
 
+func (m InfrastructureManager) Plan(keyPairName string, numberOfAvailabilityZones int, stackName,
+	lbType, lbCertificateARN, envID string, fragments []storage.CloudFormationFragment) ([]StackChange, error) {
+
+	iamUserName, err := m.iamUserName(stackName, envID)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := m.template(keyPairName, numberOfAvailabilityZones, lbType, lbCertificateARN, iamUserName, envID, fragments)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.stackManager.ChangeSet(stackName, template, Tags{{Key: bblTagKey, Value: envID}}, 5*time.Second)
+}
+
 func (m InfrastructureManager) Update(keyPairName string, numberOfAvailabilityZones int, stackName, lbType,
-	lbCertificateARN, envID string) (Stack, error) {
+	lbCertificateARN, envID string, fragments []storage.CloudFormationFragment) (Stack, error) {
 
 	iamUserName, err := m.stackManager.GetPhysicalIDForResource(stackName, "BOSHUser")
 	if err != nil {
 		return Stack{}, err
 	}
 
-	template := m.templateBuilder.Build(keyPairName, numberOfAvailabilityZones, lbType, lbCertificateARN, iamUserName, envID)
+	template, err := m.template(keyPairName, numberOfAvailabilityZones, lbType, lbCertificateARN, iamUserName, envID, fragments)
+	if err != nil {
+		return Stack{}, err
+	}
 
 	if err := m.stackManager.Update(stackName, template, Tags{{Key: bblTagKey, Value: envID}}); err != nil {
 		return Stack{}, err
@@ -123,6 +138,33 @@ func (m InfrastructureManager) Delete(stackName string) error {
 	return nil
 }
 
+func (m InfrastructureManager) iamUserName(stackName, envID string) (string, error) {
+	stackExists, err := m.Exists(stackName)
+	if err != nil {
+		return "", err
+	}
+
+	if !stackExists {
+		return generateIAMUserName(envID), nil
+	}
+
+	return m.stackManager.GetPhysicalIDForResource(stackName, "BOSHUser")
+}
+
 func generateIAMUserName(envID string) string {
 	return fmt.Sprintf("bosh-iam-user-%s", strings.Replace(envID, ":", "-", -1))
 }
+
+// template builds the stack template and merges the cloudformation fragments
+// kept in the state into it.
+func (m InfrastructureManager) template(keyPairName string, numberOfAvailabilityZones int, lbType, lbCertificateARN,
+	iamUserName, envID string, fragments []storage.CloudFormationFragment) (templates.Template, error) {
+	template := m.templateBuilder.Build(keyPairName, numberOfAvailabilityZones, lbType, lbCertificateARN, iamUserName, envID)
+
+	parsedFragments, err := templates.ParseFragments(fragments)
+	if err != nil {
+		return templates.Template{}, err
+	}
+
+	return templates.MergeFragments(template, parsedFragments)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/cloudfoundry/bosh-bootloader/aws/cloudformation"
 	"github.com/cloudfoundry/bosh-bootloader/aws/cloudformation/templates"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -17,6 +18,7 @@ var _ = Describe("InfrastructureManager", func() {
 		builder               *fakes.TemplateBuilder
 		stackManager          *fakes.StackManager
 		infrastructureManager cloudformation.InfrastructureManager
+		fragments             []storage.CloudFormationFragment
 	)
 
 	BeforeEach(func() {
@@ -29,6 +31,10 @@ var _ = Describe("InfrastructureManager", func() {
 
 		stackManager = &fakes.StackManager{}
 
+		fragments = []storage.CloudFormationFragment{
+			{Name: "some-fragment.yml", Contents: "Resources:\n  SomeDatabase:\n    Type: AWS::RDS::DBInstance\n"},
+		}
+
 		infrastructureManager = cloudformation.NewInfrastructureManager(builder, stackManager)
 	})
 
@@ -48,7 +54,7 @@ var _ = Describe("InfrastructureManager", func() {
 			}
 
 			stack, err := infrastructureManager.Create("some-key-pair-name", 2, "some-stack-name",
-				"some-lb-type", "some-lb-certificate-arn", "some-env-id-time-stamp")
+				"some-lb-type", "some-lb-certificate-arn", "some-env-id-time-stamp", nil)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(stack).To(Equal(cloudformation.Stack{Name: "some-stack-name"}))
@@ -78,11 +84,21 @@ var _ = Describe("InfrastructureManager", func() {
 			Expect(stackManager.DescribeCall.Receives.StackName).To(Equal("some-stack-name"))
 		})
 
+		It("merges the cloudformation fragments into the template", func() {
+			_, err := infrastructureManager.Create("some-key-pair-name", 2, "some-stack-name",
+				"some-lb-type", "some-lb-certificate-arn", "some-env-id-time-stamp", fragments)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stackManager.CreateOrUpdateCall.Receives.Template.Resources).To(Equal(map[string]templates.Resource{
+				"SomeDatabase": {Type: "AWS::RDS::DBInstance"},
+			}))
+		})
+
 		It("honors the iam user name from an existing stack", func() {
 			stackManager.GetPhysicalIDForResourceCall.Returns.PhysicalResourceID = "some-bosh-user-id"
 
 			_, err := infrastructureManager.Create("some-key-pair-name", 2, "some-stack-name",
-				"some-lb-type", "some-lb-certificate-arn", "some-env-id-time:stamp")
+				"some-lb-type", "some-lb-certificate-arn", "some-env-id-time:stamp", nil)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(stackManager.GetPhysicalIDForResourceCall.Receives.StackName).To(Equal("some-stack-name"))
@@ -95,14 +111,31 @@ var _ = Describe("InfrastructureManager", func() {
 			It("returns an error when stack can't be created or updated", func() {
 				stackManager.CreateOrUpdateCall.Returns.Error = errors.New("stack create or update failed")
 
-				_, err := infrastructureManager.Create("some-key-pair-name", 0, "some-stack-name", "", "", "")
+				_, err := infrastructureManager.Create("some-key-pair-name", 0, "some-stack-name", "", "", "", nil)
 				Expect(err).To(MatchError("stack create or update failed"))
 			})
 
+			It("returns an error when a cloudformation fragment is invalid", func() {
+				fragments = []storage.CloudFormationFragment{{Name: "some-fragment.yml", Contents: "Transform: AWS::Serverless-2016-10-31\n"}}
+
+				_, err := infrastructureManager.Create("some-key-pair-name", 0, "some-stack-name", "", "", "", fragments)
+				Expect(err).To(MatchError(`cloudformation fragment some-fragment.yml: unsupported section "Transform", expected Parameters, Mappings, Conditions, Resources or Outputs`))
+				Expect(stackManager.CreateOrUpdateCall.Receives.StackName).To(BeEmpty())
+			})
+
+			It("returns an error when a cloudformation fragment redefines a resource of the template", func() {
+				builder.BuildCall.Returns.Template.Resources = map[string]templates.Resource{
+					"SomeDatabase": {Type: "AWS::EC2::Instance"},
+				}
+				_, err := infrastructureManager.Create("some-key-pair-name", 0, "some-stack-name", "", "", "", fragments)
+				Expect(err).To(MatchError(`cloudformation fragment some-fragment.yml defines resource "SomeDatabase", which is already defined by bbl`))
+				Expect(stackManager.CreateOrUpdateCall.Receives.StackName).To(BeEmpty())
+			})
+
 			It("returns an error when waiting for stack completion fails", func() {
 				stackManager.WaitForCompletionCall.Returns.Error = errors.New("stack wait for completion failed")
 
-				_, err := infrastructureManager.Create("some-key-pair-name", 0, "some-stack-name", "", "", "")
+				_, err := infrastructureManager.Create("some-key-pair-name", 0, "some-stack-name", "", "", "", nil)
 				Expect(err).To(MatchError("stack wait for completion failed"))
 			})
 
@@ -110,7 +143,7 @@ var _ = Describe("InfrastructureManager", func() {
 				stackManager.GetPhysicalIDForResourceCall.Returns.Error = errors.New("get physical id for resource failed")
 
 				_, err := infrastructureManager.Create("some-key-pair-name", 2, "some-stack-name",
-					"some-lb-type", "some-lb-certificate-arn", "some-env-id-time:stamp")
+					"some-lb-type", "some-lb-certificate-arn", "some-env-id-time:stamp", nil)
 				Expect(err).To(MatchError("get physical id for resource failed"))
 
 			})
@@ -119,7 +152,7 @@ var _ = Describe("InfrastructureManager", func() {
 				It("returns an error when describing the stack fails", func() {
 					stackManager.DescribeCall.Returns.Error = errors.New("stack describe failed")
 
-					_, err := infrastructureManager.Create("some-key-pair-name", 0, "some-stack-name", "", "", "")
+					_, err := infrastructureManager.Create("some-key-pair-name", 0, "some-stack-name", "", "", "", nil)
 					Expect(err).To(MatchError("stack describe failed"))
 				})
 			})
@@ -135,13 +168,82 @@ var _ = Describe("InfrastructureManager", func() {
 						return cloudformation.Stack{}, errors.New("stack describe failed")
 					}
 
-					_, err := infrastructureManager.Create("some-key-pair-name", 0, "some-stack-name", "", "", "")
+					_, err := infrastructureManager.Create("some-key-pair-name", 0, "some-stack-name", "", "", "", nil)
 					Expect(err).To(MatchError("stack describe failed"))
 				})
 			})
 		})
 	})
 
+	Describe("Plan", func() {
+		It("returns the changes applying the template would make to the stack", func() {
+			stackManager.DescribeCall.Returns.Error = cloudformation.StackNotFound
+			stackManager.ChangeSetCall.Returns.Changes = []cloudformation.StackChange{
+				{Action: "Add", LogicalResourceID: "NATInstance", ResourceType: "AWS::EC2::Instance"},
+			}
+
+			changes, err := infrastructureManager.Plan("some-key-pair-name", 2, "some-stack-name",
+				"some-lb-type", "some-lb-certificate-arn", "some-env-id-time:stamp", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(changes).To(Equal([]cloudformation.StackChange{
+				{Action: "Add", LogicalResourceID: "NATInstance", ResourceType: "AWS::EC2::Instance"},
+			}))
+			Expect(builder.BuildCall.Receives.KeyPairName).To(Equal("some-key-pair-name"))
+			Expect(builder.BuildCall.Receives.NumberOfAZs).To(Equal(2))
+			Expect(builder.BuildCall.Receives.IAMUserName).To(Equal("bosh-iam-user-some-env-id-time-stamp"))
+
+			Expect(stackManager.ChangeSetCall.Receives.StackName).To(Equal("some-stack-name"))
+			Expect(stackManager.ChangeSetCall.Receives.Template).To(Equal(templates.Template{
+				AWSTemplateFormatVersion: "some-template-version",
+				Description:              "some-description",
+			}))
+			Expect(stackManager.ChangeSetCall.Receives.Tags).To(Equal(cloudformation.Tags{
+				{
+					Key:   "bbl-env-id",
+					Value: "some-env-id-time:stamp",
+				},
+			}))
+			Expect(stackManager.ChangeSetCall.Receives.SleepInterval).To(Equal(5 * time.Second))
+
+			Expect(stackManager.CreateOrUpdateCall.Receives.StackName).To(BeEmpty())
+		})
+
+		It("honors the iam user name from an existing stack", func() {
+			stackManager.GetPhysicalIDForResourceCall.Returns.PhysicalResourceID = "some-bosh-user-id"
+
+			_, err := infrastructureManager.Plan("some-key-pair-name", 2, "some-stack-name",
+				"some-lb-type", "some-lb-certificate-arn", "some-env-id-time:stamp", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(builder.BuildCall.Receives.IAMUserName).To(Equal("some-bosh-user-id"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when a cloudformation fragment is invalid", func() {
+				fragments = []storage.CloudFormationFragment{{Name: "some-fragment.yml", Contents: "Transform: AWS::Serverless-2016-10-31\n"}}
+
+				_, err := infrastructureManager.Plan("some-key-pair-name", 0, "some-stack-name", "", "", "", fragments)
+				Expect(err).To(MatchError(`cloudformation fragment some-fragment.yml: unsupported section "Transform", expected Parameters, Mappings, Conditions, Resources or Outputs`))
+				Expect(stackManager.ChangeSetCall.Receives.StackName).To(BeEmpty())
+			})
+
+			It("returns an error when the change set fails", func() {
+				stackManager.ChangeSetCall.Returns.Error = errors.New("change set failed")
+
+				_, err := infrastructureManager.Plan("some-key-pair-name", 0, "some-stack-name", "", "", "", nil)
+				Expect(err).To(MatchError("change set failed"))
+			})
+
+			It("returns an error when describing the stack fails", func() {
+				stackManager.DescribeCall.Returns.Error = errors.New("stack describe failed")
+
+				_, err := infrastructureManager.Plan("some-key-pair-name", 0, "some-stack-name", "", "", "", nil)
+				Expect(err).To(MatchError("stack describe failed"))
+			})
+		})
+	})
+
 	Describe("Update", func() {
 		BeforeEach(func() {
 			stackManager.DescribeCall.Returns.Stack = cloudformation.Stack{Name: "some-stack-name"}
@@ -150,7 +252,7 @@ var _ = Describe("InfrastructureManager", func() {
 		It("updates the stack and returns the stack", func() {
 			stackManager.GetPhysicalIDForResourceCall.Returns.PhysicalResourceID = "some-bosh-user-id"
 
-			stack, err := infrastructureManager.Update("some-key-pair-name", 2, "some-stack-name", "some-lb-type", "some-lb-certificate-arn", "some-env-id-time:stamp")
+			stack, err := infrastructureManager.Update("some-key-pair-name", 2, "some-stack-name", "some-lb-type", "some-lb-certificate-arn", "some-env-id-time:stamp", nil)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(stackManager.GetPhysicalIDForResourceCall.Receives.StackName).To(Equal("some-stack-name"))
@@ -187,21 +289,29 @@ var _ = Describe("InfrastructureManager", func() {
 			It("returns an error when it cannot get physical id for BOSHUser", func() {
 				stackManager.GetPhysicalIDForResourceCall.Returns.Error = errors.New("failed to get physical id for resource")
 
-				_, err := infrastructureManager.Update("some-key-pair-name", 2, "some-stack-name", "some-lb-type", "some-lb-certificate-arn", "some-env-id-time:stamp")
+				_, err := infrastructureManager.Update("some-key-pair-name", 2, "some-stack-name", "some-lb-type", "some-lb-certificate-arn", "some-env-id-time:stamp", nil)
 				Expect(err).To(MatchError("failed to get physical id for resource"))
 			})
 
+			It("returns an error when a cloudformation fragment is invalid", func() {
+				fragments = []storage.CloudFormationFragment{{Name: "some-fragment.yml", Contents: "Transform: AWS::Serverless-2016-10-31\n"}}
+
+				_, err := infrastructureManager.Update("some-key-pair-name", 2, "some-stack-name", "some-lb-type", "some-lb-certificate-arn", "some-env-id-time:stamp", fragments)
+				Expect(err).To(MatchError(`cloudformation fragment some-fragment.yml: unsupported section "Transform", expected Parameters, Mappings, Conditions, Resources or Outputs`))
+				Expect(stackManager.UpdateCall.Receives.StackName).To(BeEmpty())
+			})
+
 			It("returns an error when the update stack call fails", func() {
 				stackManager.UpdateCall.Returns.Error = errors.New("stack update call failed")
 
-				_, err := infrastructureManager.Update("some-key-pair-name", 2, "some-stack-name", "some-lb-type", "some-lb-certificate-arn", "some-env-id-time:stamp")
+				_, err := infrastructureManager.Update("some-key-pair-name", 2, "some-stack-name", "some-lb-type", "some-lb-certificate-arn", "some-env-id-time:stamp", nil)
 				Expect(err).To(MatchError("stack update call failed"))
 			})
 
 			It("returns an error when the wait for completion call fails", func() {
 				stackManager.WaitForCompletionCall.Returns.Error = errors.New("failed to wait for completion")
 
-				_, err := infrastructureManager.Update("some-key-pair-name", 2, "some-stack-name", "some-lb-type", "some-lb-certificate-arn", "some-env-id-time:stamp")
+				_, err := infrastructureManager.Update("some-key-pair-name", 2, "some-stack-name", "some-lb-type", "some-lb-certificate-arn", "some-env-id-time:stamp", nil)
 				Expect(err).To(MatchError("failed to wait for completion"))
 			})
 		})
@@ -5,3 +5,10 @@ type Stack struct {
 	Status  string
 	Outputs map[string]string
 }
+
+type StackChange struct {
+	Action            string
+	LogicalResourceID string
+	ResourceType      string
+	Replacement       string
+}
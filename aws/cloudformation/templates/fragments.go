@@ -0,0 +1,269 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// FragmentsDir is the directory of the state dir of an environment whose
+// CloudFormation fragments are merged into the template bbl generates.
+const FragmentsDir = "cloudformation"
+
+var fragmentSections = map[string]bool{
+	"Parameters": true,
+	"Mappings":   true,
+	"Conditions": true,
+	"Resources":  true,
+	"Outputs":    true,
+}
+
+var (
+	shortFormTagPattern = regexp.MustCompile(`(?:^\s*|:\s+|-\s+|[\[{,]\s*)(![A-Za-z][^\s\[\]{},]*)`)
+	blockScalarPattern  = regexp.MustCompile(`(?:^\s*|:\s+|-\s+)[|>][-+0-9]*\s*$`)
+)
+
+// Fragment is a part of a CloudFormation template read from a JSON or YAML
+// file, e.g. extra resources and their outputs.
+type Fragment struct {
+	Name     string
+	Template Template
+}
+
+type FragmentLoader struct {
+	dir string
+}
+
+func NewFragmentLoader(dir string) FragmentLoader {
+	return FragmentLoader{
+		dir: dir,
+	}
+}
+
+// Load reads the *.json, *.yml and *.yaml fragments of the directory in the
+// order of their names and checks that they can be merged. It returns false
+// when the directory does not exist, so that the fragments already kept in
+// the state are merged.
+func (l FragmentLoader) Load() ([]storage.CloudFormationFragment, bool, error) {
+	if _, err := os.Stat(l.dir); err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	var paths []string
+	for _, pattern := range []string{"*.json", "*.yml", "*.yaml"} {
+		matches, err := filepath.Glob(filepath.Join(l.dir, pattern))
+		if err != nil {
+			return nil, false, err
+		}
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+
+	fragments := []storage.CloudFormationFragment{}
+	for _, path := range paths {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, false, err
+		}
+
+		fragment := storage.CloudFormationFragment{Name: filepath.Base(path), Contents: string(contents)}
+		if _, err := ParseFragments([]storage.CloudFormationFragment{fragment}); err != nil {
+			return nil, false, err
+		}
+
+		fragments = append(fragments, fragment)
+	}
+
+	return fragments, true, nil
+}
+
+// ParseFragments parses the fragments kept in the state. Sections and keys
+// that bbl cannot merge are rejected rather than dropped.
+func ParseFragments(fragments []storage.CloudFormationFragment) ([]Fragment, error) {
+	parsed := []Fragment{}
+	for _, fragment := range fragments {
+		template, err := parseFragment(fragment.Name, []byte(fragment.Contents))
+		if err != nil {
+			return nil, fmt.Errorf("cloudformation fragment %s: %s", fragment.Name, err)
+		}
+
+		parsed = append(parsed, Fragment{Name: fragment.Name, Template: template})
+	}
+
+	return parsed, nil
+}
+
+func parseFragment(name string, contents []byte) (Template, error) {
+	if filepath.Ext(name) != ".json" {
+		if line, tag := shortFormTag(contents); tag != "" {
+			return Template{}, fmt.Errorf("line %d: YAML tag %s is not supported, use the long form of intrinsic functions, e.g. Ref: rather than !Ref", line, tag)
+		}
+
+		var document interface{}
+		if err := yaml.Unmarshal(contents, &document); err != nil {
+			return Template{}, err
+		}
+
+		var err error
+		contents, err = json.Marshal(jsonCompatible(document))
+		if err != nil {
+			return Template{}, err
+		}
+	}
+
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(contents, &sections); err != nil {
+		return Template{}, err
+	}
+
+	for name := range sections {
+		if !fragmentSections[name] {
+			return Template{}, fmt.Errorf("unsupported section %q, expected Parameters, Mappings, Conditions, Resources or Outputs", name)
+		}
+	}
+
+	var template Template
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&template); err != nil {
+		return Template{}, err
+	}
+
+	return template, nil
+}
+
+// shortFormTag finds the first YAML tag such as !Ref or !GetAtt, the short
+// form of intrinsic functions, which the YAML decoder drops without an error.
+// It skips comments, quoted strings and block scalars.
+func shortFormTag(contents []byte) (int, string) {
+	blockIndent := -1
+	for i, line := range strings.Split(string(contents), "\n") {
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if blockIndent >= 0 {
+			if strings.TrimSpace(line) == "" || indent > blockIndent {
+				continue
+			}
+			blockIndent = -1
+		}
+
+		line = stripQuotesAndComment(line)
+		if match := shortFormTagPattern.FindStringSubmatch(line); match != nil {
+			return i + 1, match[1]
+		}
+
+		if blockScalarPattern.MatchString(line) {
+			blockIndent = indent
+		}
+	}
+
+	return 0, ""
+}
+
+// stripQuotesAndComment removes the quoted strings and the comment of a line
+// of YAML.
+func stripQuotesAndComment(line string) string {
+	var stripped []byte
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return string(stripped)
+		default:
+			stripped = append(stripped, c)
+		}
+	}
+
+	return string(stripped)
+}
+
+// jsonCompatible turns the maps decoded from YAML, which are keyed by
+// interface{}, into maps keyed by string.
+func jsonCompatible(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for key, item := range value {
+			m[fmt.Sprint(key)] = jsonCompatible(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range value {
+			value[i] = jsonCompatible(item)
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+// MergeFragments merges the fragments into the template in order. A fragment
+// cannot redefine a logical ID of the template or of an earlier fragment.
+func MergeFragments(template Template, fragments []Fragment) (Template, error) {
+	definedBy := map[string]string{}
+	for _, id := range logicalIDs(template) {
+		definedBy[id] = "bbl"
+	}
+
+	for _, fragment := range fragments {
+		ids := logicalIDs(fragment.Template)
+		for _, id := range ids {
+			if owner, ok := definedBy[id]; ok {
+				return Template{}, fmt.Errorf("cloudformation fragment %s defines %s, which is already defined by %s", fragment.Name, id, owner)
+			}
+		}
+
+		for _, id := range ids {
+			definedBy[id] = "cloudformation fragment " + fragment.Name
+		}
+
+		template = template.Merge(fragment.Template)
+	}
+
+	return template, nil
+}
+
+// logicalIDs lists the names a template defines in each section, e.g.
+// resource "BOSHEIP", in a stable order.
+func logicalIDs(template Template) []string {
+	ids := []string{}
+	for name := range template.Parameters {
+		ids = append(ids, fmt.Sprintf("parameter %q", name))
+	}
+	for name := range template.Mappings {
+		ids = append(ids, fmt.Sprintf("mapping %q", name))
+	}
+	for name := range template.Conditions {
+		ids = append(ids, fmt.Sprintf("condition %q", name))
+	}
+	for name := range template.Resources {
+		ids = append(ids, fmt.Sprintf("resource %q", name))
+	}
+	for name := range template.Outputs {
+		ids = append(ids, fmt.Sprintf("output %q", name))
+	}
+	sort.Strings(ids)
+
+	return ids
+}
@@ -0,0 +1,342 @@
+package templates_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/aws/cloudformation/templates"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fragments", func() {
+	Describe("FragmentLoader", func() {
+		var (
+			dir    string
+			loader templates.FragmentLoader
+		)
+
+		writeFragment := func(name, contents string) {
+			err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			loader = templates.NewFragmentLoader(dir)
+		})
+
+		It("loads the json and yaml fragments of the directory in name order", func() {
+			writeFragment("b-database.yml", "Resources:\n  Database:\n    Type: AWS::RDS::DBInstance\n")
+			writeFragment("a-security-group.json", `{"Resources": {"DatabaseSecurityGroup": {"Type": "AWS::EC2::SecurityGroup"}}}`)
+			writeFragment("README.md", "some-readme")
+
+			fragments, found, err := loader.Load()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(found).To(BeTrue())
+			Expect(fragments).To(Equal([]storage.CloudFormationFragment{
+				{
+					Name:     "a-security-group.json",
+					Contents: `{"Resources": {"DatabaseSecurityGroup": {"Type": "AWS::EC2::SecurityGroup"}}}`,
+				},
+				{
+					Name:     "b-database.yml",
+					Contents: "Resources:\n  Database:\n    Type: AWS::RDS::DBInstance\n",
+				},
+			}))
+		})
+
+		It("returns no fragments when the directory is empty", func() {
+			fragments, found, err := loader.Load()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(found).To(BeTrue())
+			Expect(fragments).To(BeEmpty())
+		})
+
+		It("returns not found when the directory does not exist", func() {
+			loader = templates.NewFragmentLoader(filepath.Join(dir, "missing"))
+
+			fragments, found, err := loader.Load()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(found).To(BeFalse())
+			Expect(fragments).To(BeEmpty())
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when a fragment is invalid", func() {
+				writeFragment("invalid.json", "{")
+
+				_, _, err := loader.Load()
+				Expect(err).To(MatchError(ContainSubstring("cloudformation fragment invalid.json: ")))
+			})
+
+			It("returns an error when a fragment cannot be read", func() {
+				err := os.Mkdir(filepath.Join(dir, "directory.json"), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, err = loader.Load()
+				Expect(err).To(MatchError(ContainSubstring("is a directory")))
+			})
+		})
+	})
+
+	Describe("ParseFragments", func() {
+		parse := func(name, contents string) (templates.Template, error) {
+			fragments, err := templates.ParseFragments([]storage.CloudFormationFragment{{Name: name, Contents: contents}})
+			if err != nil {
+				return templates.Template{}, err
+			}
+
+			Expect(fragments).To(HaveLen(1))
+			Expect(fragments[0].Name).To(Equal(name))
+
+			return fragments[0].Template, nil
+		}
+
+		It("parses yaml fragments", func() {
+			template, err := parse("database.yml", `Parameters:
+  DatabasePassword:
+    Type: String
+    NoEcho: true
+    MinLength: 8
+    AllowedPattern: "[a-zA-Z0-9]*"
+    ConstraintDescription: letters and digits
+Conditions:
+  HasDatabase:
+    Fn::Equals: [{Ref: DatabasePassword}, ""]
+Resources:
+  Database:
+    Type: AWS::RDS::DBInstance
+    Condition: HasDatabase
+    DeletionPolicy: Snapshot
+    UpdateReplacePolicy: Snapshot
+    Properties:
+      MasterUserPassword:
+        Ref: DatabasePassword
+      VPCSecurityGroups:
+      - Fn::GetAtt: [DatabaseSecurityGroup, GroupId]
+Outputs:
+  DatabaseAddress:
+    Description: address of the database
+    Condition: HasDatabase
+    Value:
+      Fn::GetAtt: [Database, Endpoint.Address]
+    Export:
+      Name: database-address
+`)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(template).To(Equal(templates.Template{
+				Parameters: map[string]templates.Parameter{
+					"DatabasePassword": {
+						Type:                  "String",
+						NoEcho:                true,
+						MinLength:             float64(8),
+						AllowedPattern:        "[a-zA-Z0-9]*",
+						ConstraintDescription: "letters and digits",
+					},
+				},
+				Conditions: map[string]interface{}{
+					"HasDatabase": map[string]interface{}{
+						"Fn::Equals": []interface{}{map[string]interface{}{"Ref": "DatabasePassword"}, ""},
+					},
+				},
+				Resources: map[string]templates.Resource{
+					"Database": {
+						Type:                "AWS::RDS::DBInstance",
+						Condition:           "HasDatabase",
+						DeletionPolicy:      "Snapshot",
+						UpdateReplacePolicy: "Snapshot",
+						Properties: map[string]interface{}{
+							"MasterUserPassword": map[string]interface{}{"Ref": "DatabasePassword"},
+							"VPCSecurityGroups": []interface{}{
+								map[string]interface{}{"Fn::GetAtt": []interface{}{"DatabaseSecurityGroup", "GroupId"}},
+							},
+						},
+					},
+				},
+				Outputs: map[string]templates.Output{
+					"DatabaseAddress": {
+						Description: "address of the database",
+						Condition:   "HasDatabase",
+						Value:       map[string]interface{}{"Fn::GetAtt": []interface{}{"Database", "Endpoint.Address"}},
+						Export:      map[string]interface{}{"Name": "database-address"},
+					},
+				},
+			}))
+		})
+
+		It("parses json fragments", func() {
+			template, err := parse("security-group.json", `{
+  "Resources": {
+    "DatabaseSecurityGroup": {
+      "Type": "AWS::EC2::SecurityGroup",
+      "Properties": {"GroupDescription": "Database", "VpcId": {"Ref": "VPC"}}
+    }
+  }
+}`)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(template).To(Equal(templates.Template{
+				Resources: map[string]templates.Resource{
+					"DatabaseSecurityGroup": {
+						Type: "AWS::EC2::SecurityGroup",
+						Properties: map[string]interface{}{
+							"GroupDescription": "Database",
+							"VpcId":            map[string]interface{}{"Ref": "VPC"},
+						},
+					},
+				},
+			}))
+		})
+
+		It("allows an exclamation mark in strings, comments and block scalars", func() {
+			template, err := parse("database.yml", `Resources:
+  Database:
+    Type: AWS::RDS::DBInstance # !Ref is not supported
+    Properties:
+      DBName: "!database"
+      Tags:
+      - Key: Note
+        Value: |
+          !important
+          - !Ref Database
+`)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(template.Resources["Database"].Properties).To(Equal(map[string]interface{}{
+				"DBName": "!database",
+				"Tags": []interface{}{
+					map[string]interface{}{"Key": "Note", "Value": "!important\n- !Ref Database\n"},
+				},
+			}))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when a fragment is not valid json", func() {
+				_, err := parse("invalid.json", "{")
+				Expect(err).To(MatchError(ContainSubstring("cloudformation fragment invalid.json: ")))
+			})
+
+			It("returns an error when a fragment is not valid yaml", func() {
+				_, err := parse("invalid.yaml", "Resources: [")
+				Expect(err).To(MatchError(ContainSubstring("cloudformation fragment invalid.yaml: yaml: ")))
+			})
+
+			It("returns an error when a fragment has a section bbl cannot merge", func() {
+				_, err := parse("transform.yml", "Transform: AWS::Serverless-2016-10-31\n")
+				Expect(err).To(MatchError(`cloudformation fragment transform.yml: unsupported section "Transform", expected Parameters, Mappings, Conditions, Resources or Outputs`))
+			})
+
+			It("returns an error when a fragment has a key bbl would drop", func() {
+				_, err := parse("database.yml", "Resources:\n  Database:\n    Type: AWS::RDS::DBInstance\n    Conditon: HasDatabase\n")
+				Expect(err).To(MatchError(ContainSubstring(`cloudformation fragment database.yml: json: unknown field "Conditon"`)))
+			})
+
+			It("returns an error when a yaml fragment uses the short form of an intrinsic function", func() {
+				_, err := parse("database.yml", "Resources:\n  Database:\n    Type: AWS::RDS::DBInstance\n    Properties:\n      DBSubnetGroupName: !Ref SubnetGroup\n")
+				Expect(err).To(MatchError("cloudformation fragment database.yml: line 5: YAML tag !Ref is not supported, use the long form of intrinsic functions, e.g. Ref: rather than !Ref"))
+			})
+
+			It("returns an error when a yaml fragment uses a short form tag in a flow sequence", func() {
+				_, err := parse("database.yml", "Outputs:\n  DatabaseAddress:\n    Value: [!GetAtt Database.Endpoint.Address]\n")
+				Expect(err).To(MatchError(ContainSubstring("line 3: YAML tag !GetAtt is not supported")))
+			})
+		})
+	})
+
+	Describe("MergeFragments", func() {
+		var template templates.Template
+
+		BeforeEach(func() {
+			template = templates.Template{
+				Description: "some-description",
+				Resources: map[string]templates.Resource{
+					"VPC": {Type: "AWS::EC2::VPC"},
+				},
+				Outputs: map[string]templates.Output{
+					"VPCID": {Value: templates.Ref{"VPC"}},
+				},
+			}
+		})
+
+		It("merges the fragments into the template", func() {
+			merged, err := templates.MergeFragments(template, []templates.Fragment{
+				{
+					Name: "some-fragment.yml",
+					Template: templates.Template{
+						Resources: map[string]templates.Resource{
+							"Database": {Type: "AWS::RDS::DBInstance"},
+						},
+						Outputs: map[string]templates.Output{
+							"DatabaseAddress": {Value: "some-address"},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(merged.Description).To(Equal("some-description"))
+			Expect(merged.Resources).To(Equal(map[string]templates.Resource{
+				"VPC":      {Type: "AWS::EC2::VPC"},
+				"Database": {Type: "AWS::RDS::DBInstance"},
+			}))
+			Expect(merged.Outputs).To(HaveKey("DatabaseAddress"))
+		})
+
+		It("allows the same name in different sections", func() {
+			_, err := templates.MergeFragments(template, []templates.Fragment{
+				{
+					Name: "some-fragment.yml",
+					Template: templates.Template{
+						Outputs: map[string]templates.Output{
+							"VPC": {Value: templates.Ref{"VPC"}},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when a fragment redefines a logical id of the template", func() {
+				_, err := templates.MergeFragments(template, []templates.Fragment{
+					{
+						Name: "some-fragment.yml",
+						Template: templates.Template{
+							Outputs: map[string]templates.Output{
+								"VPCID": {Value: "some-vpc-id"},
+							},
+						},
+					},
+				})
+				Expect(err).To(MatchError(`cloudformation fragment some-fragment.yml defines output "VPCID", which is already defined by bbl`))
+			})
+
+			It("returns an error when a fragment redefines a logical id of an earlier fragment", func() {
+				fragment := templates.Fragment{
+					Name: "some-fragment.yml",
+					Template: templates.Template{
+						Resources: map[string]templates.Resource{
+							"Database": {Type: "AWS::RDS::DBInstance"},
+						},
+					},
+				}
+				otherFragment := fragment
+				otherFragment.Name = "some-other-fragment.yml"
+
+				_, err := templates.MergeFragments(template, []templates.Fragment{fragment, otherFragment})
+				Expect(err).To(MatchError(`cloudformation fragment some-other-fragment.yml defines resource "Database", which is already defined by cloudformation fragment some-fragment.yml`))
+			})
+		})
+	})
+})
@@ -21,7 +21,7 @@ func (s InternalSubnetTemplateBuilder) InternalSubnet(azIndex int, suffix, cidrB
 				Value: Ref{subnetName},
 			},
 			fmt.Sprintf("%sAZ", subnetName): Output{
-				FnGetAtt{
+				Value: FnGetAtt{
 					[]string{
 						subnetName,
 						"AvailabilityZone",
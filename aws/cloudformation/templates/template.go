@@ -35,6 +35,7 @@ type Template struct {
 	Description              string                 `json:",omitempty"`
 	Parameters               map[string]Parameter   `json:",omitempty"`
 	Mappings                 map[string]interface{} `json:",omitempty"`
+	Conditions               map[string]interface{} `json:",omitempty"`
 	Resources                map[string]Resource    `json:",omitempty"`
 	Outputs                  map[string]Output      `json:",omitempty"`
 }
@@ -48,6 +49,10 @@ func (t Template) Merge(templates ...Template) Template {
 		t.Mappings = map[string]interface{}{}
 	}
 
+	if t.Conditions == nil {
+		t.Conditions = map[string]interface{}{}
+	}
+
 	if t.Resources == nil {
 		t.Resources = map[string]Resource{}
 	}
@@ -65,6 +70,10 @@ func (t Template) Merge(templates ...Template) Template {
 			t.Mappings[name] = mapping
 		}
 
+		for name, condition := range template.Conditions {
+			t.Conditions[name] = condition
+		}
+
 		for name, resource := range template.Resources {
 			t.Resources[name] = resource
 		}
@@ -78,22 +87,36 @@ func (t Template) Merge(templates ...Template) Template {
 }
 
 type Output struct {
-	Value interface{}
+	Description string `json:",omitempty"`
+	Value       interface{}
+	Export      interface{} `json:",omitempty"`
+	Condition   string      `json:",omitempty"`
 }
 
 type Parameter struct {
-	Type        string
-	Default     string
-	Description string `json:",omitempty"`
+	Type                  string
+	Default               interface{} `json:",omitempty"`
+	Description           string      `json:",omitempty"`
+	AllowedValues         interface{} `json:",omitempty"`
+	AllowedPattern        string      `json:",omitempty"`
+	ConstraintDescription string      `json:",omitempty"`
+	MinLength             interface{} `json:",omitempty"`
+	MaxLength             interface{} `json:",omitempty"`
+	MinValue              interface{} `json:",omitempty"`
+	MaxValue              interface{} `json:",omitempty"`
+	NoEcho                interface{} `json:",omitempty"`
 }
 
 type Resource struct {
-	Type           string
-	Properties     interface{} `json:",omitempty"`
-	DependsOn      interface{} `json:",omitempty"`
-	CreationPolicy interface{} `json:",omitempty"`
-	UpdatePolicy   interface{} `json:",omitempty"`
-	DeletionPolicy interface{} `json:",omitempty"`
+	Type                string
+	Properties          interface{} `json:",omitempty"`
+	DependsOn           interface{} `json:",omitempty"`
+	CreationPolicy      interface{} `json:",omitempty"`
+	UpdatePolicy        interface{} `json:",omitempty"`
+	DeletionPolicy      interface{} `json:",omitempty"`
+	UpdateReplacePolicy interface{} `json:",omitempty"`
+	Metadata            interface{} `json:",omitempty"`
+	Condition           string      `json:",omitempty"`
 }
 
 type SecurityGroup struct {
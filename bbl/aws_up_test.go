@@ -68,11 +68,13 @@ func (b *fakeBOSHDirector) GetCloudConfigEndpointFail() bool {
 func (b *fakeBOSHDirector) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
 	switch request.URL.Path {
 	case "/info":
-		responseWriter.Write([]byte(`{
+		username, _, _ := request.BasicAuth()
+		responseWriter.Write([]byte(fmt.Sprintf(`{
 			"name": "some-bosh-director",
 			"uuid": "some-uuid",
-			"version": "some-version"
-		}`))
+			"version": "some-version",
+			"user": %q
+		}`, username)))
 
 		return
 	case "/cloud_configs":
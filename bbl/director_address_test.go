@@ -46,6 +46,28 @@ var _ = Describe("director-address", func() {
 		Expect(session.Out.Contents()).To(ContainSubstring("some-director-url"))
 	})
 
+	It("prints the director address as json with --output json", func() {
+		state := []byte(`{
+			"bosh": {
+				"directorAddress": "some-director-url"
+			}
+		}`)
+		err := ioutil.WriteFile(filepath.Join(tempDirectory, storage.StateFileName), state, os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		args := []string{
+			"--state-dir", tempDirectory,
+			"--output", "json",
+			"director-address",
+		}
+
+		session, err := gexec.Start(exec.Command(pathToBBL, args...), GinkgoWriter, GinkgoWriter)
+
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session).Should(gexec.Exit(0))
+		Expect(session.Out.Contents()).To(MatchJSON(`{"property": "director address", "value": "some-director-url"}`))
+	})
+
 	Context("failure cases", func() {
 		It("returns a non zero exit code when the bbl-state.json does not exist", func() {
 			tempDirectory, err := ioutil.TempDir("", "")
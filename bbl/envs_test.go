@@ -0,0 +1,84 @@
+package main_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("named environments", func() {
+	var tempDirectory string
+
+	writeState := func(dir, contents string) {
+		err := os.MkdirAll(dir, os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(dir, storage.StateFileName), []byte(contents), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		tempDirectory, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		writeState(tempDirectory, `{"version": 2, "iaas": "aws", "envID": "default-env-id"}`)
+		writeState(filepath.Join(tempDirectory, "envs", "staging"), `{
+			"version": 2,
+			"iaas": "gcp",
+			"gcp": {"region": "us-east1"},
+			"envID": "staging-env-id",
+			"bosh": {"directorAddress": "https://10.0.0.6:25555"}
+		}`)
+		writeState(filepath.Join(tempDirectory, "envs", "production"), `{
+			"version": 2,
+			"iaas": "aws",
+			"aws": {"region": "eu-west-1"},
+			"envID": "production-env-id"
+		}`)
+	})
+
+	runBBL := func(exitCode int, args ...string) *gexec.Session {
+		cmd := exec.Command(pathToBBL, append([]string{"--state-dir", tempDirectory}, args...)...)
+
+		session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session, "10s").Should(gexec.Exit(exitCode))
+
+		return session
+	}
+
+	It("reads the state of the environment passed with --env", func() {
+		session := runBBL(0, "--env", "staging", "env-id")
+		Expect(session.Out.Contents()).To(ContainSubstring("staging-env-id"))
+
+		session = runBBL(0, "env-id")
+		Expect(session.Out.Contents()).To(ContainSubstring("default-env-id"))
+	})
+
+	It("lists the environments in the state directory", func() {
+		session := runBBL(0, "envs")
+
+		lines := string(session.Out.Contents())
+		Expect(lines).To(MatchRegexp(`NAME\s+IAAS\s+REGION\s+ENV ID\s+DIRECTOR ADDRESS`))
+		Expect(lines).To(MatchRegexp(`production\s+aws\s+eu-west-1\s+production-env-id`))
+		Expect(lines).To(MatchRegexp(`staging\s+gcp\s+us-east1\s+staging-env-id\s+https://10.0.0.6:25555`))
+	})
+
+	It("reports a missing environment by its directory", func() {
+		session := runBBL(1, "--env", "qa", "env-id")
+		Expect(session.Err.Contents()).To(ContainSubstring(filepath.Join(tempDirectory, "envs", "qa")))
+	})
+
+	It("rejects environment names that are not a single directory", func() {
+		session := runBBL(1, "--env", "../staging", "env-id")
+		Expect(session.Err.Contents()).To(ContainSubstring(`invalid environment name "../staging"`))
+	})
+})
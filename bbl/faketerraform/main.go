@@ -44,6 +44,11 @@ func main() {
 		fmt.Print(string(body))
 	}
 
+	if os.Args[1] == "plan" {
+		fmt.Println("+ google_compute_network.bbl-network")
+		fmt.Println("Plan: 1 to add, 0 to change, 0 to destroy.")
+	}
+
 	if os.Args[1] == "apply" || os.Args[1] == "destroy" {
 		postArgs, err := json.Marshal(os.Args[1:])
 		if err != nil {
@@ -229,6 +229,26 @@ var _ = Describe("bbl up gcp", func() {
 		Expect(session.Out.Contents()).To(ContainSubstring("terraform apply"))
 	})
 
+	It("prints the terraform plan with --dry-run without writing the state", func() {
+		args := []string{
+			"--state-dir", tempDirectory,
+			"plan",
+			"--iaas", "gcp",
+			"--gcp-service-account-key", serviceAccountKeyPath,
+			"--gcp-project-id", "some-project-id",
+			"--gcp-zone", "some-zone",
+			"--gcp-region", "us-west1",
+		}
+
+		session := executeCommand(args, 0)
+
+		Expect(session.Out.Contents()).To(ContainSubstring("+ google_compute_network.bbl-network"))
+		Expect(session.Out.Contents()).To(ContainSubstring("Plan: 1 to add, 0 to change, 0 to destroy"))
+
+		_, err := os.Stat(filepath.Join(tempDirectory, storage.StateFileName))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
 	It("invokes bosh-init", func() {
 		args := []string{
 			"--state-dir", tempDirectory,
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 
 	"golang.org/x/crypto/ssh"
 
@@ -39,21 +40,43 @@ var (
 func main() {
 	// Command Set
 	commandSet := application.CommandSet{
-		commands.HelpCommand:             nil,
-		commands.VersionCommand:          nil,
-		commands.UpCommand:               nil,
-		commands.DestroyCommand:          nil,
-		commands.DirectorAddressCommand:  nil,
-		commands.DirectorUsernameCommand: nil,
-		commands.DirectorPasswordCommand: nil,
-		commands.DirectorCACertCommand:   nil,
-		commands.BOSHCACertCommand:       nil,
-		commands.SSHKeyCommand:           nil,
-		commands.CreateLBsCommand:        nil,
-		commands.UpdateLBsCommand:        nil,
-		commands.DeleteLBsCommand:        nil,
-		commands.LBsCommand:              nil,
-		commands.EnvIDCommand:            nil,
+		commands.HelpCommand:                      nil,
+		commands.VersionCommand:                   nil,
+		commands.UpCommand:                        nil,
+		commands.DestroyCommand:                   nil,
+		commands.DirectorAddressCommand:           nil,
+		commands.DirectorUsernameCommand:          nil,
+		commands.DirectorPasswordCommand:          nil,
+		commands.DirectorCACertCommand:            nil,
+		commands.BOSHCACertCommand:                nil,
+		commands.SSHKeyCommand:                    nil,
+		commands.CreateLBsCommand:                 nil,
+		commands.UpdateLBsCommand:                 nil,
+		commands.DeleteLBsCommand:                 nil,
+		commands.LBsCommand:                       nil,
+		commands.OutputsCommand:                   nil,
+		commands.EnvIDCommand:                     nil,
+		commands.EncryptStateCommand:              nil,
+		commands.DecryptStateCommand:              nil,
+		commands.ForceUnlockCommand:               nil,
+		commands.StateHistoryCommand:              nil,
+		commands.StateRollbackCommand:             nil,
+		commands.MigrateStateCommand:              nil,
+		commands.ValidateStateCommand:             nil,
+		commands.ExportStateCommand:               nil,
+		commands.ImportStateCommand:               nil,
+		commands.EnvsCommand:                      nil,
+		commands.PrintEnvCommand:                  nil,
+		commands.PlanCommand:                      nil,
+		commands.SSHConfigCommand:                 nil,
+		commands.RotateDirectorCredentialsCommand: nil,
+		commands.RotateDirectorCACommand:          nil,
+		commands.RotateSSHKeyCommand:              nil,
+		commands.DoctorCommand:                    nil,
+		commands.CompletionCommand:                nil,
+		commands.ConfigCommand:                    nil,
+		commands.CloudConfigCommand:               nil,
+		commands.UpdateCloudConfigCommand:         nil,
 	}
 
 	// Utilities
@@ -67,6 +90,10 @@ func main() {
 	// Usage Command
 	usage := commands.NewUsage(os.Stdout)
 	storage.GetStateLogger = stderrLogger
+	storage.BBLVersion = Version
+	if storage.BBLVersion == "" {
+		storage.BBLVersion = commands.BBLDevVersion
+	}
 
 	commandLineParser := application.NewCommandLineParser(usage.Print, commandSet)
 	configurationParser := application.NewConfigurationParser(commandLineParser)
@@ -75,8 +102,19 @@ func main() {
 		fail(err)
 	}
 
-	stateStore := storage.NewStore(configuration.Global.StateDir)
-	stateValidator := application.NewStateValidator(configuration.Global.StateDir)
+	workspaceBackend, err := storage.NewBackend(configuration.Global.StateDir, configuration.Global.StateBackend)
+	if err != nil {
+		fail(err)
+	}
+	stateBackend := storage.NewEnvBackend(workspaceBackend, configuration.Global.Env)
+
+	plaintextStateStore := storage.NewBackendStore(stateBackend, nil).ForCommand(configuration.Command).WithVarsStore(configuration.Global.VarsStore)
+	stateStore := plaintextStateStore
+	if configuration.Global.StatePassphrase != nil {
+		stateStore = storage.NewBackendStore(stateBackend, configuration.Global.StatePassphrase).ForCommand(configuration.Command).WithVarsStore(configuration.Global.VarsStore)
+	}
+	stateValidator := application.NewBackendStateValidator(stateBackend)
+	stateLocker := storage.NewLocker(stateBackend, storage.LockHolder())
 
 	// Amazon
 	awsConfiguration := aws.Config{
@@ -99,6 +137,8 @@ func main() {
 	availabilityZoneRetriever := ec2.NewAvailabilityZoneRetriever(clientProvider)
 	templateBuilder := templates.NewTemplateBuilder(logger)
 	stackManager := cloudformation.NewStackManager(clientProvider, logger)
+	fragmentLoader := templates.NewFragmentLoader(filepath.Join(storage.EnvDir(configuration.Global.StateDir, configuration.Global.Env),
+		templates.FragmentsDir))
 	infrastructureManager := cloudformation.NewInfrastructureManager(templateBuilder, stackManager)
 	certificateUploader := iam.NewCertificateUploader(clientProvider)
 	certificateDescriber := iam.NewCertificateDescriber(clientProvider)
@@ -162,6 +202,8 @@ func main() {
 	// Terraform
 	terraformCmd := terraform.NewCmd(os.Stderr)
 	terraformExecutor := terraform.NewExecutor(terraformCmd, configuration.Global.Debug)
+	terraformOverridesLoader := terraform.NewOverridesLoader(filepath.Join(storage.EnvDir(configuration.Global.StateDir, configuration.Global.Env),
+		terraform.OverridesDir))
 	terraformOutputter := terraform.NewOutputter(terraformCmd)
 
 	// BOSH
@@ -174,7 +216,7 @@ func main() {
 	awsUp := commands.NewAWSUp(
 		credentialValidator, infrastructureManager, keyPairSynchronizer, boshinitExecutor,
 		stringGenerator, cloudConfigurator, availabilityZoneRetriever, certificateDescriber,
-		cloudConfigManager, boshClientProvider, stateStore, clientProvider)
+		cloudConfigManager, boshClientProvider, stateStore, clientProvider, fragmentLoader, logger)
 
 	awsCreateLBs := commands.NewAWSCreateLBs(
 		logger, credentialValidator, certificateManager, infrastructureManager,
@@ -196,14 +238,17 @@ func main() {
 	gcpDeleteLBs := commands.NewGCPDeleteLBs(terraformOutputter, gcpCloudConfigGenerator, zones, logger,
 		boshClientProvider, stateStore, terraformExecutor)
 
-	gcpUp := commands.NewGCPUp(stateStore, gcpKeyPairUpdater, gcpClientProvider, terraformExecutor, boshinitExecutor, stringGenerator, logger, boshClientProvider, gcpCloudConfigGenerator, terraformOutputter, zones)
+	gcpUp := commands.NewGCPUp(stateStore, gcpKeyPairUpdater, gcpClientProvider, terraformExecutor, boshinitExecutor, stringGenerator, logger, boshClientProvider, gcpCloudConfigGenerator, terraformOutputter,
+		terraformOverridesLoader, zones)
 	envGetter := commands.NewEnvGetter()
 
 	// Commands
 	commandSet[commands.HelpCommand] = commands.NewUsage(os.Stdout)
 	commandSet[commands.VersionCommand] = commands.NewVersion(Version, os.Stdout)
 
-	commandSet[commands.UpCommand] = commands.NewUp(awsUp, gcpUp, envGetter, envIDGenerator)
+	up := commands.NewUp(awsUp, gcpUp, envIDGenerator)
+	commandSet[commands.UpCommand] = up
+	commandSet[commands.PlanCommand] = commands.NewPlan(up)
 
 	commandSet[commands.DestroyCommand] = commands.NewDestroy(
 		credentialValidator, logger, os.Stdin, boshinitExecutor, vpcStatusChecker, stackManager,
@@ -214,31 +259,63 @@ func main() {
 	commandSet[commands.CreateLBsCommand] = commands.NewCreateLBs(awsCreateLBs, gcpCreateLBs, stateValidator)
 	commandSet[commands.UpdateLBsCommand] = commands.NewUpdateLBs(awsUpdateLBs, gcpUpdateLBs, certificateValidator, stateValidator, logger)
 	commandSet[commands.DeleteLBsCommand] = commands.NewDeleteLBs(gcpDeleteLBs, awsDeleteLBs, logger, stateValidator)
-	commandSet[commands.LBsCommand] = commands.NewLBs(credentialValidator, stateValidator, infrastructureManager, terraformOutputter, os.Stdout)
-	commandSet[commands.DirectorAddressCommand] = commands.NewStateQuery(logger, stateValidator, commands.DirectorAddressPropertyName, func(state storage.State) string {
+	commandSet[commands.LBsCommand] = commands.NewLBs(credentialValidator, stateValidator, infrastructureManager, terraformOutputter, configuration.Global.Output, os.Stdout)
+	commandSet[commands.OutputsCommand] = commands.NewOutputs(credentialValidator, stateValidator, infrastructureManager, terraformOutputter, configuration.Global.Output, os.Stdout)
+	commandSet[commands.DirectorAddressCommand] = commands.NewStateQuery(logger, stateValidator, configuration.Global.Output, commands.DirectorAddressPropertyName, func(state storage.State) string {
 		return state.BOSH.DirectorAddress
 	})
-	commandSet[commands.DirectorUsernameCommand] = commands.NewStateQuery(logger, stateValidator, commands.DirectorUsernamePropertyName, func(state storage.State) string {
+	commandSet[commands.DirectorUsernameCommand] = commands.NewStateQuery(logger, stateValidator, configuration.Global.Output, commands.DirectorUsernamePropertyName, func(state storage.State) string {
 		return state.BOSH.DirectorUsername
 	})
-	commandSet[commands.DirectorPasswordCommand] = commands.NewStateQuery(logger, stateValidator, commands.DirectorPasswordPropertyName, func(state storage.State) string {
+	commandSet[commands.DirectorPasswordCommand] = commands.NewStateQuery(logger, stateValidator, configuration.Global.Output, commands.DirectorPasswordPropertyName, func(state storage.State) string {
 		return state.BOSH.DirectorPassword
 	})
-	commandSet[commands.DirectorCACertCommand] = commands.NewStateQuery(logger, stateValidator, commands.DirectorCACertPropertyName, func(state storage.State) string {
-		return state.BOSH.DirectorSSLCA
+	commandSet[commands.DirectorCACertCommand] = commands.NewStateQuery(logger, stateValidator, configuration.Global.Output, commands.DirectorCACertPropertyName, func(state storage.State) string {
+		return state.BOSH.DirectorSSLCABundle()
 	})
-	commandSet[commands.BOSHCACertCommand] = commands.NewStateQuery(logger, stateValidator, commands.BOSHCACertPropertyName, func(state storage.State) string {
+	commandSet[commands.BOSHCACertCommand] = commands.NewStateQuery(logger, stateValidator, configuration.Global.Output, commands.BOSHCACertPropertyName, func(state storage.State) string {
 		fmt.Fprintln(os.Stderr, "'bosh-ca-cert' has been deprecated and will be removed in future versions of bbl, please use 'director-ca-cert'")
-		return state.BOSH.DirectorSSLCA
+		return state.BOSH.DirectorSSLCABundle()
 	})
-	commandSet[commands.SSHKeyCommand] = commands.NewStateQuery(logger, stateValidator, commands.SSHKeyPropertyName, func(state storage.State) string {
+	commandSet[commands.SSHKeyCommand] = commands.NewStateQuery(logger, stateValidator, configuration.Global.Output, commands.SSHKeyPropertyName, func(state storage.State) string {
 		return state.KeyPair.PrivateKey
 	})
-	commandSet[commands.EnvIDCommand] = commands.NewStateQuery(logger, stateValidator, commands.EnvIDPropertyName, func(state storage.State) string {
+	commandSet[commands.EnvIDCommand] = commands.NewStateQuery(logger, stateValidator, configuration.Global.Output, commands.EnvIDPropertyName, func(state storage.State) string {
 		return state.EnvID
 	})
-
-	app := application.New(commandSet, configuration, stateStore, usage)
+	commandSet[commands.PrintEnvCommand] = commands.NewPrintEnv(stateValidator,
+		storage.EnvDir(configuration.Global.StateDir, configuration.Global.Env), os.Stdout)
+	commandSet[commands.SSHConfigCommand] = commands.NewSSHConfig(stateValidator,
+		storage.EnvDir(configuration.Global.StateDir, configuration.Global.Env), os.Stdout)
+	commandSet[commands.RotateDirectorCredentialsCommand] = commands.NewRotateDirectorCredentials(logger, stateValidator, stateStore,
+		stringGenerator, boshinitExecutor, boshClientProvider, credentialValidator, infrastructureManager, terraformOutputter)
+	commandSet[commands.RotateDirectorCACommand] = commands.NewRotateDirectorCA(logger, stateValidator, stateStore, stringGenerator,
+		boshinitExecutor, sslKeyPairGenerator, credentialValidator, infrastructureManager, terraformOutputter)
+	commandSet[commands.RotateSSHKeyCommand] = commands.NewRotateSSHKey(logger, stateValidator, stateStore, stringGenerator,
+		boshinitExecutor, keyPairSynchronizer, awsKeyPairDeleter, gcpKeyPairUpdater, gcpKeyPairDeleter, uuidGenerator,
+		credentialValidator, infrastructureManager, availabilityZoneRetriever, certificateDescriber, terraformOutputter)
+
+	commandSet[commands.EncryptStateCommand] = commands.NewEncryptState(logger, stateValidator, stateStore, configuration.Global.StatePassphrase != nil)
+	commandSet[commands.DecryptStateCommand] = commands.NewDecryptState(logger, stateValidator, plaintextStateStore)
+	commandSet[commands.ForceUnlockCommand] = commands.NewForceUnlock(logger, stateLocker)
+	commandSet[commands.StateHistoryCommand] = commands.NewStateHistory(stateValidator, stateStore, os.Stdout)
+	commandSet[commands.StateRollbackCommand] = commands.NewStateRollback(logger, stateValidator, stateStore)
+	commandSet[commands.MigrateStateCommand] = commands.NewMigrateState(logger, stateValidator, stateStore, os.Stdout)
+	commandSet[commands.DoctorCommand] = commands.NewDoctor(stateValidator, credentialValidator, infrastructureManager,
+		terraformOutputter, boshClientProvider, os.Stdout)
+	commandSet[commands.CloudConfigCommand] = commands.NewCloudConfig(stateValidator, credentialValidator, infrastructureManager,
+		availabilityZoneRetriever, cloudConfigurator, cloudConfigGenerator, terraformOutputter, gcpCloudConfigGenerator, zones, os.Stdout)
+	commandSet[commands.UpdateCloudConfigCommand] = commands.NewUpdateCloudConfig(logger, stateValidator, boshClientProvider,
+		credentialValidator, infrastructureManager, availabilityZoneRetriever, cloudConfigurator, cloudConfigGenerator,
+		terraformOutputter, gcpCloudConfigGenerator, zones)
+	commandSet[commands.ConfigCommand] = commands.NewConfig(configuration.ConfigFile, configuration.Global.ConfigPath, envGetter, os.Stdout)
+	commandSet[commands.CompletionCommand] = commands.NewCompletion(commandSet, application.GlobalFlags(), os.Stdout)
+	commandSet[commands.ValidateStateCommand] = commands.NewValidateState(stateValidator, stateStore, os.Stdout)
+	commandSet[commands.ExportStateCommand] = commands.NewExportState(stateValidator, os.Stdout)
+	commandSet[commands.ImportStateCommand] = commands.NewImportState(logger, stateStore, os.Stdin, os.Stdout)
+	commandSet[commands.EnvsCommand] = commands.NewEnvs(storage.NewWorkspace(workspaceBackend, configuration.Global.StatePassphrase), os.Stdout)
+
+	app := application.New(commandSet, configuration, stateStore, stateLocker, usage)
 
 	err = app.Run()
 	if err != nil {
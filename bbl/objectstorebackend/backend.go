@@ -0,0 +1,229 @@
+package objectstorebackend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Backend struct {
+	mutex   sync.Mutex
+	objects map[string][]byte
+}
+
+func New() *Backend {
+	return &Backend{
+		objects: make(map[string][]byte),
+	}
+}
+
+func (b *Backend) Start() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(b.ServeHTTP))
+}
+
+func (b *Backend) Get(bucket, key string) ([]byte, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	contents, ok := b.objects[bucket+"/"+key]
+	return contents, ok
+}
+
+func (b *Backend) Set(bucket, key string, contents []byte) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.objects[bucket+"/"+key] = contents
+}
+
+func (b *Backend) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch {
+	case strings.HasPrefix(req.URL.Path, "/upload/storage/v1/b/"):
+		b.serveGCSUpload(w, req)
+	case strings.HasPrefix(req.URL.Path, "/storage/v1/b/") && strings.HasSuffix(req.URL.Path, "/o"):
+		b.serveGCSList(w, req)
+	case strings.HasPrefix(req.URL.Path, "/storage/v1/b/"):
+		b.serveGCSObject(w, req)
+	case req.URL.Query().Get("list-type") == "2":
+		b.serveS3List(w, req)
+	default:
+		b.serveS3Object(w, req)
+	}
+}
+
+func (b *Backend) serveS3Object(w http.ResponseWriter, req *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 2)
+	if len(parts) != 2 {
+		log.Println("unexpected request recieved: ", req.URL.Path)
+		w.WriteHeader(http.StatusTeapot)
+		return
+	}
+
+	bucket, key := parts[0], parts[1]
+	switch req.Method {
+	case "GET":
+		contents, ok := b.Get(bucket, key)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(contents)
+	case "PUT":
+		contents, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if !b.put(bucket, key, contents, req.Header.Get("If-None-Match") == "*") {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>PreconditionFailed</Code><Message>At least one of the pre-conditions you specified did not hold</Message></Error>`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case "DELETE":
+		b.delete(bucket, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (b *Backend) serveS3List(w http.ResponseWriter, req *http.Request) {
+	bucket := strings.Trim(req.URL.Path, "/")
+	prefix := req.URL.Query().Get("prefix")
+
+	response := `<?xml version="1.0" encoding="UTF-8"?><ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">`
+	response += fmt.Sprintf("<Name>%s</Name><Prefix>%s</Prefix><Delimiter>/</Delimiter><IsTruncated>false</IsTruncated>", bucket, prefix)
+	for _, commonPrefix := range b.list(bucket, prefix) {
+		response += fmt.Sprintf("<CommonPrefixes><Prefix>%s</Prefix></CommonPrefixes>", commonPrefix)
+	}
+	response += "</ListBucketResult>"
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(response))
+}
+
+func (b *Backend) serveGCSList(w http.ResponseWriter, req *http.Request) {
+	bucket := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/storage/v1/b/"), "/o")
+	prefixes := b.list(bucket, req.URL.Query().Get("prefix"))
+
+	response, err := json.Marshal(map[string]interface{}{
+		"kind":     "storage#objects",
+		"prefixes": prefixes,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(response)
+}
+
+func (b *Backend) serveGCSObject(w http.ResponseWriter, req *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/storage/v1/b/"), "/o/", 2)
+	if len(parts) != 2 {
+		log.Println("unexpected request recieved: ", req.URL.Path)
+		w.WriteHeader(http.StatusTeapot)
+		return
+	}
+
+	bucket, key := parts[0], parts[1]
+	switch req.Method {
+	case "GET":
+		contents, ok := b.Get(bucket, key)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": {"code": 404, "message": "No such object"}}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(contents)
+	case "DELETE":
+		if _, ok := b.Get(bucket, key); !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		b.delete(bucket, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (b *Backend) serveGCSUpload(w http.ResponseWriter, req *http.Request) {
+	bucket := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/upload/storage/v1/b/"), "/o")
+	key := req.URL.Query().Get("name")
+	if req.Method != "POST" || key == "" {
+		log.Println("unexpected request recieved: ", req.URL.Path)
+		w.WriteHeader(http.StatusTeapot)
+		return
+	}
+
+	contents, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if !b.put(bucket, key, contents, req.URL.Query().Get("ifGenerationMatch") == "0") {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		w.Write([]byte(`{"error": {"code": 412, "message": "Precondition Failed"}}`))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"kind": "storage#object"}`))
+}
+
+func (b *Backend) put(bucket, key string, contents []byte, onlyIfMissing bool) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, ok := b.objects[bucket+"/"+key]; ok && onlyIfMissing {
+		return false
+	}
+
+	b.objects[bucket+"/"+key] = contents
+	return true
+}
+
+func (b *Backend) list(bucket, prefix string) []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	seen := map[string]bool{}
+	prefixes := []string{}
+	for object := range b.objects {
+		key := strings.TrimPrefix(object, bucket+"/")
+		if key == object || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		rest := strings.TrimPrefix(key, prefix)
+		if i := strings.Index(rest, "/"); i >= 0 {
+			commonPrefix := prefix + rest[:i+1]
+			if !seen[commonPrefix] {
+				seen[commonPrefix] = true
+				prefixes = append(prefixes, commonPrefix)
+			}
+		}
+	}
+	sort.Strings(prefixes)
+
+	return prefixes
+}
+
+func (b *Backend) delete(bucket, key string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	delete(b.objects, bucket+"/"+key)
+}
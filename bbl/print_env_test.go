@@ -0,0 +1,80 @@
+package main_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("print-env", func() {
+	var (
+		tempDirectory string
+		tmpDir        string
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		tempDirectory, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		tmpDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(tempDirectory, storage.StateFileName), []byte(`{
+			"version": 2,
+			"iaas": "aws",
+			"envID": "some-env-id",
+			"keyPair": {"name": "some-keypair", "privateKey": "some-private-key", "publicKey": "some-public-key"},
+			"bosh": {
+				"directorAddress": "https://10.0.0.6:25555",
+				"directorUsername": "some-username",
+				"directorPassword": "some-password",
+				"directorSSLCA": "some-ca-cert"
+			}
+		}`), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	runBBL := func(exitCode int, args ...string) *gexec.Session {
+		cmd := exec.Command(pathToBBL, append([]string{"--state-dir", tempDirectory}, args...)...)
+		cmd.Env = append(os.Environ(), "TMPDIR="+tmpDir)
+
+		session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session, "10s").Should(gexec.Exit(exitCode))
+
+		return session
+	}
+
+	It("prints exports that target the director with the BOSH CLI", func() {
+		session := runBBL(0, "print-env")
+
+		envDir := filepath.Join(tempDirectory, "bbl-some-env-id-env")
+		caCertPath := filepath.Join(envDir, "director-ca-cert.pem")
+		Expect(session.Out.Contents()).To(ContainSubstring("export BOSH_ENVIRONMENT='https://10.0.0.6:25555'"))
+		Expect(session.Out.Contents()).To(ContainSubstring("export BOSH_CLIENT='some-username'"))
+		Expect(session.Out.Contents()).To(ContainSubstring("export BOSH_CLIENT_SECRET='some-password'"))
+		Expect(session.Out.Contents()).To(ContainSubstring("export BOSH_CA_CERT='" + caCertPath + "'"))
+		Expect(session.Out.Contents()).To(ContainSubstring("export BOSH_GW_PRIVATE_KEY='" + filepath.Join(envDir, "ssh-key") + "'"))
+
+		caCert, err := ioutil.ReadFile(caCertPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(caCert)).To(Equal("some-ca-cert"))
+
+		dirs, err := filepath.Glob(filepath.Join(tmpDir, "bbl-*"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dirs).To(BeEmpty())
+	})
+
+	It("prints the variables as json with --json", func() {
+		session := runBBL(0, "print-env", "--json")
+		Expect(session.Out.Contents()).To(ContainSubstring(`"BOSH_CLIENT": "some-username"`))
+	})
+})
@@ -0,0 +1,101 @@
+package main_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("bbl rotate-director-credentials", func() {
+	var (
+		tempDirectory              string
+		pathToTerraform            string
+		fakeTerraformBackendServer *httptest.Server
+		fakeBOSHServer             *httptest.Server
+	)
+
+	BeforeEach(func() {
+		var err error
+		fakeBOSHServer = httptest.NewServer(&fakeBOSHDirector{})
+
+		fakeTerraformBackendServer = httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+			switch request.URL.Path {
+			case "/output/external_ip":
+				responseWriter.Write([]byte("127.0.0.1"))
+			default:
+				responseWriter.Write([]byte("some-" + strings.TrimPrefix(request.URL.Path, "/output/")))
+			}
+		}))
+
+		pathToFakeTerraform, err := gexec.Build("github.com/cloudfoundry/bosh-bootloader/bbl/faketerraform",
+			"--ldflags", "-X main.backendURL="+fakeTerraformBackendServer.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		pathToTerraform = filepath.Join(filepath.Dir(pathToFakeTerraform), "terraform")
+		err = os.Rename(pathToFakeTerraform, pathToTerraform)
+		Expect(err).NotTo(HaveOccurred())
+
+		os.Setenv("PATH", strings.Join([]string{filepath.Dir(pathToTerraform), os.Getenv("PATH")}, ":"))
+
+		tempDirectory, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		writeStateJson(storage.State{
+			Version: 2,
+			IAAS:    "gcp",
+			EnvID:   "some-env-id",
+			GCP: storage.GCP{
+				ServiceAccountKey: serviceAccountKey,
+				ProjectID:         "some-project-id",
+				Zone:              "some-zone",
+				Region:            "us-west1",
+			},
+			TFState: "some-tf-state",
+			BOSH: storage.BOSH{
+				DirectorName:     "some-director",
+				DirectorAddress:  fakeBOSHServer.URL,
+				DirectorUsername: "some-director-username",
+				DirectorPassword: "some-director-password",
+				Credentials: map[string]string{
+					"natsUsername": "some-nats-username",
+					"natsPassword": "some-nats-password",
+					"hmPassword":   "some-hm-password",
+				},
+			},
+		}, tempDirectory)
+	})
+
+	AfterEach(func() {
+		fakeBOSHServer.Close()
+		fakeTerraformBackendServer.Close()
+	})
+
+	It("redeploys the director and saves the rotated credentials", func() {
+		session := executeCommand([]string{
+			"--state-dir", tempDirectory,
+			"rotate-director-credentials",
+			"--credential", "director,nats",
+		}, 0)
+
+		Expect(session.Out.Contents()).To(ContainSubstring("bosh-init was called with"))
+		Expect(session.Out.Contents()).To(ContainSubstring("rotated director, nats"))
+
+		state := readStateJson(tempDirectory)
+		Expect(state.BOSH.DirectorUsername).To(Equal("some-director-username"))
+		Expect(state.BOSH.DirectorPassword).To(HavePrefix("p-"))
+		Expect(state.BOSH.Credentials["natsUsername"]).To(Equal("some-nats-username"))
+		Expect(state.BOSH.Credentials["natsPassword"]).To(HavePrefix("nats-"))
+		Expect(state.BOSH.Credentials["natsPassword"]).NotTo(Equal("some-nats-password"))
+		Expect(state.BOSH.Credentials["hmPassword"]).To(Equal("some-hm-password"))
+		Expect(state.BOSH.State).To(HaveKey("md5checksum"))
+	})
+})
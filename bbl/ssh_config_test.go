@@ -0,0 +1,74 @@
+package main_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("ssh-config", func() {
+	var tempDirectory string
+
+	BeforeEach(func() {
+		var err error
+
+		tempDirectory, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(tempDirectory, storage.StateFileName), []byte(`{
+			"version": 2,
+			"iaas": "gcp",
+			"envID": "some-env-id",
+			"keyPair": {"privateKey": "some-private-key", "publicKey": "some-public-key"},
+			"bosh": {"directorAddress": "https://203.0.113.10:25555"}
+		}`), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("writes the ssh key to the state dir and adds a host stanza to the given ssh config", func() {
+		sshConfigPath := filepath.Join(tempDirectory, "ssh_config")
+
+		cmd := exec.Command(pathToBBL, "--state-dir", tempDirectory, "ssh-config", "--append-to", sshConfigPath, "--proxy-jump", "bastion")
+		session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session, "10s").Should(gexec.Exit(0))
+
+		keyPath := filepath.Join(tempDirectory, "bbl-some-env-id-ssh-key")
+		sshKey, err := ioutil.ReadFile(keyPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(sshKey)).To(Equal("some-private-key"))
+
+		sshConfig, err := ioutil.ReadFile(sshConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(sshConfig)).To(ContainSubstring("Host bbl-some-env-id\n  HostName 203.0.113.10\n  User vcap\n"))
+		Expect(string(sshConfig)).To(ContainSubstring("  IdentityFile " + keyPath + "\n"))
+		Expect(string(sshConfig)).To(ContainSubstring("  ProxyJump bastion\n"))
+	})
+
+	It("writes the ssh key to the directory of the environment given with --env", func() {
+		envDir := filepath.Join(tempDirectory, "envs", "staging")
+		Expect(os.MkdirAll(envDir, os.ModePerm)).To(Succeed())
+
+		err := os.Rename(filepath.Join(tempDirectory, storage.StateFileName), filepath.Join(envDir, storage.StateFileName))
+		Expect(err).NotTo(HaveOccurred())
+
+		cmd := exec.Command(pathToBBL, "--state-dir", tempDirectory, "--env", "staging", "ssh-config")
+		session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session, "10s").Should(gexec.Exit(0))
+
+		keyPath := filepath.Join(envDir, "bbl-some-env-id-ssh-key")
+		Expect(session.Out.Contents()).To(ContainSubstring("  IdentityFile " + keyPath + "\n"))
+
+		_, err = os.Stat(keyPath)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = os.Stat(filepath.Join(tempDirectory, "bbl-some-env-id-ssh-key"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+})
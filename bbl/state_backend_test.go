@@ -0,0 +1,79 @@
+package main_test
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+
+	"github.com/cloudfoundry/bosh-bootloader/bbl/objectstorebackend"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("state backend", func() {
+	var (
+		objectStore  *objectstorebackend.Backend
+		server       *httptest.Server
+		stateBackend string
+	)
+
+	BeforeEach(func() {
+		objectStore = objectstorebackend.New()
+		server = objectStore.Start()
+
+		stateBackend = fmt.Sprintf("s3://some-bucket/some-env?region=us-east-1&endpoint=%s", server.URL)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	runBBL := func(exitCode int, env []string, args ...string) *gexec.Session {
+		cmd := exec.Command(pathToBBL, append([]string{"--state-backend", stateBackend}, args...)...)
+		cmd.Env = append(os.Environ(), append([]string{
+			"AWS_ACCESS_KEY_ID=some-access-key-id",
+			"AWS_SECRET_ACCESS_KEY=some-secret-access-key",
+		}, env...)...)
+
+		session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session).Should(gexec.Exit(exitCode))
+
+		return session
+	}
+
+	It("reads the state from the object store", func() {
+		objectStore.Set("some-bucket", "some-env/"+storage.StateFileName, []byte(`{
+			"version": 2,
+			"bosh": {
+				"directorAddress": "some-director-url"
+			}
+		}`))
+
+		session := runBBL(0, []string{}, "director-address")
+		Expect(session.Out.Contents()).To(ContainSubstring("some-director-url"))
+	})
+
+	It("writes the state to the object store", func() {
+		objectStore.Set("some-bucket", "some-env/"+storage.StateFileName, []byte(`{
+			"version": 2,
+			"bosh": {
+				"directorPassword": "some-director-password"
+			}
+		}`))
+
+		runBBL(0, []string{"BBL_STATE_PASSPHRASE=some-passphrase"}, "encrypt-state")
+
+		contents, ok := objectStore.Get("some-bucket", "some-env/"+storage.StateFileName)
+		Expect(ok).To(BeTrue())
+		Expect(storage.IsEncrypted(contents)).To(BeTrue())
+	})
+
+	It("fails when the state is missing from the object store", func() {
+		session := runBBL(1, []string{}, "director-address")
+		Expect(session.Err.Contents()).To(ContainSubstring(`bbl-state.json not found in "s3://some-bucket/some-env"`))
+	})
+})
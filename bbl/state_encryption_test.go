@@ -0,0 +1,77 @@
+package main_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("state encryption", func() {
+	var (
+		tempDirectory string
+		keyFile       string
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		tempDirectory, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		keyFile = filepath.Join(tempDirectory, "state-key")
+		err = ioutil.WriteFile(keyFile, []byte("some-passphrase\n"), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		state := []byte(`{
+			"version": 2,
+			"bosh": {
+				"directorAddress": "some-director-url",
+				"directorPassword": "some-director-password"
+			}
+		}`)
+		err = ioutil.WriteFile(filepath.Join(tempDirectory, storage.StateFileName), state, os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	runBBL := func(exitCode int, env []string, args ...string) *gexec.Session {
+		cmd := exec.Command(pathToBBL, append([]string{"--state-dir", tempDirectory}, args...)...)
+		cmd.Env = append(os.Environ(), env...)
+
+		session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session).Should(gexec.Exit(exitCode))
+
+		return session
+	}
+
+	It("encrypts and decrypts the state file", func() {
+		runBBL(0, []string{}, "--state-encryption-key-file", keyFile, "encrypt-state")
+
+		contents, err := ioutil.ReadFile(filepath.Join(tempDirectory, storage.StateFileName))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).NotTo(ContainSubstring("some-director-password"))
+
+		session := runBBL(1, []string{}, "director-address")
+		Expect(session.Err.Contents()).To(ContainSubstring("bbl-state.json is encrypted"))
+
+		session = runBBL(0, []string{"BBL_STATE_PASSPHRASE=some-passphrase"}, "director-address")
+		Expect(session.Out.Contents()).To(ContainSubstring("some-director-url"))
+
+		runBBL(0, []string{"BBL_STATE_PASSPHRASE=some-passphrase"}, "decrypt-state")
+
+		contents, err = ioutil.ReadFile(filepath.Join(tempDirectory, storage.StateFileName))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(ContainSubstring("some-director-password"))
+	})
+
+	It("fails to encrypt the state when no passphrase is provided", func() {
+		session := runBBL(1, []string{}, "encrypt-state")
+		Expect(session.Err.Contents()).To(ContainSubstring("a passphrase must be provided"))
+	})
+})
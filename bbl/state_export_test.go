@@ -0,0 +1,85 @@
+package main_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("state export", func() {
+	var (
+		sourceDirectory string
+		targetDirectory string
+		secretsDir      string
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		sourceDirectory, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		targetDirectory, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		secretsDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(sourceDirectory, storage.StateFileName), []byte(`{
+			"version": 2,
+			"iaas": "aws",
+			"aws": {"accessKeyId": "some-access-key-id", "secretAccessKey": "some-secret-access-key", "region": "some-region"},
+			"keyPair": {"name": "some-keypair", "privateKey": "some-private-key", "publicKey": "some-public-key"},
+			"envID": "some-env-id"
+		}`), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	runBBL := func(exitCode int, stdin string, args ...string) *gexec.Session {
+		cmd := exec.Command(pathToBBL, args...)
+		cmd.Stdin = bytes.NewBufferString(stdin)
+
+		session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session, "10s").Should(gexec.Exit(exitCode))
+
+		return session
+	}
+
+	It("exports a redacted state and imports it with the secrets", func() {
+		exportFile := filepath.Join(secretsDir, "export.json")
+		session := runBBL(0, "", "--state-dir", sourceDirectory, "export-state", "--redact", "--output", exportFile)
+		Expect(session.Out.Contents()).To(BeEmpty())
+
+		contents, err := ioutil.ReadFile(exportFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(ContainSubstring(`"secretAccessKey": "((redacted:aws.secretAccessKey))"`))
+		Expect(string(contents)).To(ContainSubstring(`"publicKey": "some-public-key"`))
+		Expect(string(contents)).NotTo(ContainSubstring("some-private-key"))
+
+		err = ioutil.WriteFile(filepath.Join(secretsDir, "keyPair.privateKey"), []byte("some-private-key\n"), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		session = runBBL(0, "some-access-key-id\nsome-secret-access-key\n", "--state-dir", targetDirectory, "import-state", "--file", exportFile, "--secrets-dir", secretsDir)
+		Expect(session.Out.Contents()).To(ContainSubstring("aws.accessKeyId: aws.secretAccessKey: "))
+
+		imported, err := storage.GetState(targetDirectory)
+		Expect(err).NotTo(HaveOccurred())
+
+		original, err := storage.GetState(sourceDirectory)
+		Expect(err).NotTo(HaveOccurred())
+
+		imported.BBLVersion = ""
+		Expect(imported).To(Equal(original))
+
+		session = runBBL(1, "", "--state-dir", targetDirectory, "import-state", "--file", exportFile)
+		Expect(session.Err.Contents()).To(ContainSubstring("bbl-state.json already exists"))
+	})
+})
@@ -0,0 +1,57 @@
+package main_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("state history", func() {
+	var tempDirectory string
+
+	BeforeEach(func() {
+		var err error
+
+		tempDirectory, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(tempDirectory, storage.StateFileName), []byte(`{"version": 2, "envID": "some-env-id"}`), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	runBBL := func(exitCode int, env []string, args ...string) *gexec.Session {
+		cmd := exec.Command(pathToBBL, append([]string{"--state-dir", tempDirectory}, args...)...)
+		cmd.Env = append(os.Environ(), env...)
+
+		session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session, "10s").Should(gexec.Exit(exitCode))
+
+		return session
+	}
+
+	It("lists the recorded versions and rolls back to one of them", func() {
+		runBBL(0, []string{"BBL_STATE_PASSPHRASE=some-passphrase"}, "encrypt-state")
+		runBBL(0, []string{"BBL_STATE_PASSPHRASE=some-passphrase"}, "decrypt-state")
+
+		session := runBBL(0, []string{}, "state-history")
+		Expect(session.Out.Contents()).To(MatchRegexp(`3\s+\S+\s+decrypt-state`))
+		Expect(session.Out.Contents()).To(MatchRegexp(`2\s+\S+\s+encrypt-state`))
+		Expect(session.Out.Contents()).To(MatchRegexp(`1\s+\S+\s+\(existing state\)`))
+
+		runBBL(0, []string{"BBL_STATE_PASSPHRASE=some-passphrase"}, "state-rollback", "--to", "2")
+
+		contents, err := ioutil.ReadFile(filepath.Join(tempDirectory, storage.StateFileName))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(storage.IsEncrypted(contents)).To(BeTrue())
+
+		session = runBBL(0, []string{"BBL_STATE_PASSPHRASE=some-passphrase"}, "state-history")
+		Expect(session.Out.Contents()).To(MatchRegexp(`4\s+\S+\s+state-rollback`))
+	})
+})
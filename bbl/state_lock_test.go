@@ -0,0 +1,65 @@
+package main_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("state locking", func() {
+	var tempDirectory string
+
+	BeforeEach(func() {
+		var err error
+
+		tempDirectory, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(tempDirectory, storage.StateFileName), []byte(`{"version": 2, "envID": "some-env-id"}`), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		lock := []byte(`{
+			"id": "some-lock-id",
+			"holder": "some-user@some-host (pid 123)",
+			"command": "up",
+			"createdAt": "2017-01-02T03:04:05Z"
+		}`)
+		err = ioutil.WriteFile(filepath.Join(tempDirectory, storage.LockFileName), lock, os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	runBBL := func(exitCode int, args ...string) *gexec.Session {
+		cmd := exec.Command(pathToBBL, append([]string{"--state-dir", tempDirectory}, args...)...)
+		cmd.Env = append(os.Environ(), "BBL_STATE_PASSPHRASE=some-passphrase")
+
+		session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session, "10s").Should(gexec.Exit(exitCode))
+
+		return session
+	}
+
+	It("refuses to run mutating commands until the lock is removed", func() {
+		session := runBBL(1, "encrypt-state")
+		Expect(session.Err.Contents()).To(ContainSubstring(`bbl-state.json is locked by some-user@some-host (pid 123) running "up" since 2017-01-02T03:04:05Z`))
+
+		session = runBBL(0, "force-unlock")
+		Expect(session.Out.Contents()).To(ContainSubstring(`removed lock held by some-user@some-host (pid 123) running "up"`))
+
+		runBBL(0, "encrypt-state")
+
+		_, err := os.Stat(filepath.Join(tempDirectory, storage.LockFileName))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("allows read-only commands while the state is locked", func() {
+		session := runBBL(0, "env-id")
+		Expect(session.Out.Contents()).To(ContainSubstring("some-env-id"))
+	})
+})
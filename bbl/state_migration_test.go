@@ -0,0 +1,77 @@
+package main_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("state migration", func() {
+	var (
+		tempDirectory      string
+		pathToVersionedBBL string
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		tempDirectory, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		pathToVersionedBBL, err = gexec.Build("github.com/cloudfoundry/bosh-bootloader/bbl",
+			"--ldflags", "-X main.Version=1.2.3")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	runBBL := func(exitCode int, args ...string) *gexec.Session {
+		cmd := exec.Command(pathToVersionedBBL, append([]string{"--state-dir", tempDirectory}, args...)...)
+
+		session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session, "10s").Should(gexec.Exit(exitCode))
+
+		return session
+	}
+
+	writeState := func(contents string) {
+		err := ioutil.WriteFile(filepath.Join(tempDirectory, storage.StateFileName), []byte(contents), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("prints the migration with --dry-run and applies it without", func() {
+		writeState(`{"version": 1, "envID": "some-env-id"}`)
+
+		session := runBBL(0, "migrate-state", "--dry-run")
+		Expect(session.Out.Contents()).To(ContainSubstring("bbl-state.json would be migrated from version 1 to version 2"))
+		Expect(session.Out.Contents()).To(ContainSubstring(`+ iaas: "aws"`))
+		Expect(session.Out.Contents()).To(ContainSubstring("~ version: 1 -> 2"))
+
+		contents, err := ioutil.ReadFile(filepath.Join(tempDirectory, storage.StateFileName))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(contents).To(MatchJSON(`{"version": 1, "envID": "some-env-id"}`))
+
+		runBBL(0, "migrate-state")
+
+		contents, err = ioutil.ReadFile(filepath.Join(tempDirectory, storage.StateFileName))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(ContainSubstring(`"version":2`))
+		Expect(string(contents)).To(ContainSubstring(`"bblVersion":"1.2.3"`))
+		Expect(string(contents)).To(ContainSubstring(`"iaas":"aws"`))
+
+		session = runBBL(0, "migrate-state", "--dry-run")
+		Expect(session.Out.Contents()).To(ContainSubstring("bbl-state.json is already at version 2"))
+	})
+
+	It("refuses a state file written by a newer bbl", func() {
+		writeState(`{"version": 2, "bblVersion": "2.0.0", "envID": "some-env-id"}`)
+
+		session := runBBL(1, "env-id")
+		Expect(session.Err.Contents()).To(ContainSubstring("bbl-state.json version 2 was written by bbl 2.0.0, which is newer than this bbl (1.2.3, supports state version 2), please upgrade bbl"))
+	})
+})
@@ -0,0 +1,71 @@
+package main_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("state validation", func() {
+	var tempDirectory string
+
+	BeforeEach(func() {
+		var err error
+
+		tempDirectory, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	runBBL := func(exitCode int, args ...string) *gexec.Session {
+		cmd := exec.Command(pathToBBL, append([]string{"--state-dir", tempDirectory}, args...)...)
+
+		session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session, "10s").Should(gexec.Exit(exitCode))
+
+		return session
+	}
+
+	writeState := func(contents string) {
+		err := ioutil.WriteFile(filepath.Join(tempDirectory, storage.StateFileName), []byte(contents), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("reports every problem in a hand-edited state that other commands cannot read", func() {
+		writeState(`{"version": 2, "iaas": "gcp", "gcp": {"projectID": 12}, "lb": {"type": "cf"}}`)
+
+		runBBL(1, "env-id")
+
+		session := runBBL(1, "validate-state")
+		Expect(session.Out.Contents()).To(ContainSubstring(`gcp.projectID: must be a string, got 12`))
+		Expect(session.Err.Contents()).To(ContainSubstring("bbl-state.json has 1 problem(s)"))
+
+		writeState(`{"version": 2, "iaas": "gcp", "gcp": {"projectID": "some-project"}, "lb": {"type": "cf"}}`)
+
+		session = runBBL(1, "validate-state")
+		Expect(session.Out.Contents()).To(ContainSubstring("gcp.serviceAccountKey: must be set"))
+		Expect(session.Out.Contents()).To(ContainSubstring("lb.cert: must be set"))
+	})
+
+	It("accepts a valid state", func() {
+		writeState(`{"version": 2, "iaas": "aws", "aws": {"accessKeyId": "some-id", "secretAccessKey": "some-secret", "region": "some-region"}}`)
+
+		session := runBBL(0, "validate-state")
+		Expect(session.Out.Contents()).To(ContainSubstring("bbl-state.json is valid"))
+	})
+
+	It("prints the state schema", func() {
+		session := runBBL(0, "validate-state", "--schema")
+
+		var schema map[string]interface{}
+		Expect(json.Unmarshal(session.Out.Contents(), &schema)).To(Succeed())
+		Expect(schema["properties"]).To(HaveKey("bosh"))
+	})
+})
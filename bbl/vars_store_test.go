@@ -0,0 +1,71 @@
+package main_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("vars store", func() {
+	var (
+		tempDirectory string
+		varsStorePath string
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		tempDirectory, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		varsStorePath = filepath.Join(tempDirectory, "vars-store.yml")
+
+		err = ioutil.WriteFile(filepath.Join(tempDirectory, storage.StateFileName), []byte(`{
+			"version": 1,
+			"envID": "some-env-id",
+			"keyPair": {"name": "some-keypair", "privateKey": "some-private-key", "publicKey": "some-public-key"},
+			"bosh": {"directorUsername": "some-username", "directorPassword": "some-password", "credentials": {"hmPassword": "some-hm-password"}}
+		}`), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	runBBL := func(exitCode int, args ...string) *gexec.Session {
+		cmd := exec.Command(pathToBBL, append([]string{"--state-dir", tempDirectory}, args...)...)
+
+		session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session, "10s").Should(gexec.Exit(exitCode))
+
+		return session
+	}
+
+	It("moves generated credentials out of bbl-state.json and resolves them when reading the state", func() {
+		runBBL(0, "--vars-store", varsStorePath, "migrate-state")
+
+		contents, err := ioutil.ReadFile(filepath.Join(tempDirectory, storage.StateFileName))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(ContainSubstring(`"directorPassword":"((director_password))"`))
+		Expect(string(contents)).NotTo(ContainSubstring("some-private-key"))
+		Expect(string(contents)).NotTo(ContainSubstring("some-hm-password"))
+
+		vars, err := ioutil.ReadFile(varsStorePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(vars)).To(ContainSubstring("director_password: some-password"))
+		Expect(string(vars)).To(ContainSubstring("hm_password: some-hm-password"))
+
+		session := runBBL(0, "--vars-store", varsStorePath, "director-password")
+		Expect(session.Out.Contents()).To(ContainSubstring("some-password"))
+
+		session = runBBL(0, "--vars-store", varsStorePath, "ssh-key")
+		Expect(session.Out.Contents()).To(ContainSubstring("some-private-key"))
+
+		session = runBBL(1, "director-password")
+		Expect(session.Err.Contents()).To(ContainSubstring("pass its path with --vars-store"))
+	})
+})
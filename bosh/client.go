@@ -18,6 +18,7 @@ type Info struct {
 	Name    string `json:"name"`
 	UUID    string `json:"uuid"`
 	Version string `json:"version"`
+	User    string `json:"user"`
 }
 
 type client struct {
@@ -49,6 +50,7 @@ func (c client) Info() (Info, error) {
 	if err != nil {
 		return Info{}, err
 	}
+	request.SetBasicAuth(c.username, c.password)
 
 	response, err := c.httpClient.Do(request)
 	if err != nil {
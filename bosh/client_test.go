@@ -33,6 +33,25 @@ var _ = Describe("Client", func() {
 			}))
 		})
 
+		It("authenticates so the director reports the user it accepted", func() {
+			var username, password string
+			fakeBOSH := httptest.NewTLSServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+				username, password, _ = request.BasicAuth()
+				responseWriter.Write([]byte(`{
+					"name": "some-bosh-director",
+					"user": "some-username"
+				}`))
+			}))
+
+			client := bosh.NewClient(fakeBOSH.URL, "some-username", "some-password")
+			info, err := client.Info()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.User).To(Equal("some-username"))
+
+			Expect(username).To(Equal("some-username"))
+			Expect(password).To(Equal("some-password"))
+		})
+
 		Context("failure cases", func() {
 			It("returns an error when the response is not StatusOK", func() {
 				fakeBOSH := httptest.NewTLSServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
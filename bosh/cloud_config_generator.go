@@ -1,9 +1,15 @@
 package bosh
 
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
 type CloudConfigInput struct {
 	AZs     []string
 	Subnets []SubnetInput
 	LBs     []LoadBalancerExtension
+
+	// OpsFiles are applied to the generated cloud config before it is
+	// uploaded.
+	OpsFiles []storage.OpsFile
 }
 
 type SubnetInput struct {
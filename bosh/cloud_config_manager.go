@@ -31,6 +31,11 @@ func (c CloudConfigManager) Update(input CloudConfigInput, boshClient Client) er
 		return err
 	}
 
+	manifestYAML, err = ApplyCloudConfigOpsFiles(manifestYAML, input.OpsFiles)
+	if err != nil {
+		return err
+	}
+
 	c.logger.Step("applying cloud config")
 	if err := boshClient.UpdateCloudConfig(manifestYAML); err != nil {
 		return err
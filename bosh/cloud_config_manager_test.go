@@ -7,6 +7,7 @@ import (
 
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -92,6 +93,17 @@ var _ = Describe("CloudConfigManager", func() {
 			Expect(cloudConfigGenerator.GenerateCall.Receives.CloudConfigInput).To(Equal(cloudConfigInput))
 		})
 
+		It("applies the ops files of the input to the cloud config", func() {
+			cloudConfigInput.OpsFiles = []storage.OpsFile{
+				{Path: "ops.yml", Contents: "- type: replace\n  path: /vm_types/name=some-vm-type/cloud_properties?/instance_type\n  value: m4.large\n"},
+			}
+
+			err := cloudConfigManager.Update(cloudConfigInput, boshClient)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(boshClient.UpdateCloudConfigCall.Receives.Yaml)).To(ContainSubstring("cloud_properties:\n    instance_type: m4.large\n"))
+		})
+
 		Context("failure cases", func() {
 			It("returns an error when the generate fails", func() {
 				cloudConfigGenerator.GenerateCall.Returns.Error = errors.New("generate failed")
@@ -100,6 +112,16 @@ var _ = Describe("CloudConfigManager", func() {
 				Expect(err).To(MatchError("generate failed"))
 			})
 
+			It("returns an error when an ops file does not apply to the cloud config", func() {
+				cloudConfigInput.OpsFiles = []storage.OpsFile{
+					{Path: "ops.yml", Contents: "- type: remove\n  path: /networks/name=missing\n"},
+				}
+
+				err := cloudConfigManager.Update(cloudConfigInput, boshClient)
+				Expect(err).To(MatchError(ContainSubstring("cloud config ops file ops.yml does not match the generated cloud config")))
+				Expect(boshClient.UpdateCloudConfigCall.CallCount).To(Equal(0))
+			})
+
 			It("returns an error when the bosh client fails to upload the cloud config", func() {
 				boshClient.UpdateCloudConfigCall.Returns.Error = errors.New("failed to upload")
 				err := cloudConfigManager.Update(cloudConfigInput, boshClient)
@@ -0,0 +1,27 @@
+package bosh
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/patch"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// ApplyCloudConfigOpsFiles patches a generated cloud config with the ops files
+// kept in the state, in order. Without ops files the cloud config is returned
+// as it is.
+func ApplyCloudConfigOpsFiles(cloudConfig []byte, opsFiles []storage.OpsFile) ([]byte, error) {
+	for _, opsFile := range opsFiles {
+		ops, err := patch.Parse([]byte(opsFile.Contents))
+		if err != nil {
+			return nil, fmt.Errorf("cloud config ops file %s: %s", opsFile.Path, err)
+		}
+
+		cloudConfig, err = patch.Apply(cloudConfig, ops)
+		if err != nil {
+			return nil, fmt.Errorf("cloud config ops file %s does not match the generated cloud config: %s", opsFile.Path, err)
+		}
+	}
+
+	return cloudConfig, nil
+}
@@ -0,0 +1,57 @@
+package bosh_test
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ApplyCloudConfigOpsFiles", func() {
+	var cloudConfig []byte
+
+	BeforeEach(func() {
+		cloudConfig = []byte(`vm_types:
+- name: default
+vm_extensions: []
+`)
+	})
+
+	It("applies the ops files in order", func() {
+		patched, err := bosh.ApplyCloudConfigOpsFiles(cloudConfig, []storage.OpsFile{
+			{Path: "extensions.yml", Contents: "- type: replace\n  path: /vm_extensions/-\n  value:\n    name: some-extension\n"},
+			{Path: "vm-types.yml", Contents: "- type: replace\n  path: /vm_types/name=default/name\n  value: small\n"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(string(patched)).To(Equal(`vm_extensions:
+- name: some-extension
+vm_types:
+- name: small
+`))
+	})
+
+	It("returns the cloud config unchanged without ops files", func() {
+		patched, err := bosh.ApplyCloudConfigOpsFiles(cloudConfig, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(patched).To(Equal(cloudConfig))
+	})
+
+	Context("failure cases", func() {
+		It("returns an error when an ops file is invalid", func() {
+			_, err := bosh.ApplyCloudConfigOpsFiles(cloudConfig, []storage.OpsFile{
+				{Path: "invalid.yml", Contents: "some-invalid-ops"},
+			})
+			Expect(err).To(MatchError(ContainSubstring("cloud config ops file invalid.yml: ops file is not a YAML list of operations")))
+		})
+
+		It("returns an error when an ops file does not match the cloud config", func() {
+			_, err := bosh.ApplyCloudConfigOpsFiles(cloudConfig, []storage.OpsFile{
+				{Path: "stale.yml", Contents: "- type: remove\n  path: /disk_types\n"},
+			})
+			Expect(err).To(MatchError(`cloud config ops file stale.yml does not match the generated cloud config: operation 1 (remove /disk_types): expected to find a map key "disk_types" for path "/disk_types" (found map keys: "vm_extensions", "vm_types")`))
+		})
+	})
+})
@@ -21,8 +21,10 @@ type DeployInput struct {
 	State                       State
 	InfrastructureConfiguration InfrastructureConfiguration
 	SSLKeyPair                  ssl.KeyPair
+	TrustedCerts                string
 	EC2KeyPair                  ec2.KeyPair
 	Credentials                 map[string]string
+	OpsFiles                    []storage.OpsFile
 }
 
 type InfrastructureConfiguration struct {
@@ -68,6 +70,7 @@ func NewDeployInput(state storage.State, infrastructureConfiguration Infrastruct
 		InfrastructureConfiguration: infrastructureConfiguration,
 		SSLKeyPair:                  ssl.KeyPair{},
 		EC2KeyPair:                  ec2.KeyPair{},
+		OpsFiles:                    state.DirectorOpsFiles,
 	}
 
 	if !state.KeyPair.IsEmpty() {
@@ -85,6 +88,10 @@ func NewDeployInput(state storage.State, infrastructureConfiguration Infrastruct
 		deployInput.SSLKeyPair.Certificate = []byte(state.BOSH.DirectorSSLCertificate)
 		deployInput.SSLKeyPair.PrivateKey = []byte(state.BOSH.DirectorSSLPrivateKey)
 
+		if state.BOSH.DirectorSSLRotationPhase != "" {
+			deployInput.TrustedCerts = state.BOSH.DirectorSSLCABundle()
+		}
+
 		if deployInput.DirectorName == "" {
 			deployInput.DirectorName = "my-bosh"
 		}
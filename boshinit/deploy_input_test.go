@@ -100,6 +100,24 @@ var _ = Describe("DeployInput", func() {
 			}))
 		})
 
+		It("trusts both director CAs while the director CA is being rotated", func() {
+			state.BOSH.DirectorSSLCA = "some-ca\n"
+			state.BOSH.DirectorSSLRotationPhase = storage.DirectorSSLRotationTrust
+			state.BOSH.DirectorSSLRotationCA = "some-new-ca\n"
+
+			deployInput, err := boshinit.NewDeployInput(state, infrastructureConfiguration, fakeStringGenerator, envID, iaas)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deployInput.TrustedCerts).To(Equal("some-ca\nsome-new-ca\n"))
+		})
+
+		It("passes on the director ops files kept in the state", func() {
+			state.DirectorOpsFiles = []storage.OpsFile{{Path: "some-ops-file.yml", Contents: "some-contents"}}
+
+			deployInput, err := boshinit.NewDeployInput(state, infrastructureConfiguration, fakeStringGenerator, envID, iaas)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deployInput.OpsFiles).To(Equal([]storage.OpsFile{{Path: "some-ops-file.yml", Contents: "some-contents"}}))
+		})
+
 		Context("when existing state contains bosh state without director name", func() {
 			It("sets director name to my-bosh", func() {
 				state.BOSH.DirectorName = ""
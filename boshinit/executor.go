@@ -1,7 +1,10 @@
 package boshinit
 
 import (
+	"fmt"
+
 	"github.com/cloudfoundry/bosh-bootloader/boshinit/manifests"
+	"github.com/cloudfoundry/bosh-bootloader/patch"
 	"gopkg.in/yaml.v2"
 )
 
@@ -53,6 +56,7 @@ func (e Executor) Delete(boshInitManifest string, boshInitState State, ec2Privat
 func (e Executor) Deploy(input DeployInput) (DeployOutput, error) {
 	manifest, manifestProperties, err := e.manifestBuilder.Build(input.IAAS, manifests.ManifestProperties{
 		SSLKeyPair:       input.SSLKeyPair,
+		TrustedCerts:     input.TrustedCerts,
 		DirectorName:     input.DirectorName,
 		DirectorUsername: input.DirectorUsername,
 		DirectorPassword: input.DirectorPassword,
@@ -87,6 +91,20 @@ func (e Executor) Deploy(input DeployInput) (DeployOutput, error) {
 		return DeployOutput{}, err
 	}
 
+	for _, opsFile := range input.OpsFiles {
+		e.logger.Step("applying director ops file %s", opsFile.Path)
+
+		ops, err := patch.Parse([]byte(opsFile.Contents))
+		if err != nil {
+			return DeployOutput{}, fmt.Errorf("director ops file %s: %s", opsFile.Path, err)
+		}
+
+		manifestYAML, err = patch.Apply(manifestYAML, ops)
+		if err != nil {
+			return DeployOutput{}, fmt.Errorf("director ops file %s does not match the director manifest of this bbl version: %s", opsFile.Path, err)
+		}
+	}
+
 	e.logger.Step("deploying bosh director")
 	state, err := e.deployCommand.Execute(manifestYAML, input.EC2KeyPair.PrivateKey, input.State)
 	if err != nil {
@@ -8,6 +8,7 @@ import (
 	"github.com/cloudfoundry/bosh-bootloader/boshinit/manifests"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
 	"github.com/cloudfoundry/bosh-bootloader/ssl"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -307,7 +308,55 @@ var _ = Describe("Executor", func() {
 			Expect(logger.StepCall.Receives.Message).To(Equal("deploying bosh director"))
 		})
 
+		It("passes the trusted certs on to the manifest", func() {
+			_, err := executor.Deploy(boshinit.DeployInput{
+				IAAS:                        "aws",
+				InfrastructureConfiguration: awsInfrastructureConfiguration,
+				TrustedCerts:                "some-ca\nsome-new-ca\n",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(manifestBuilder.BuildCall.Receives.Properties.TrustedCerts).To(Equal("some-ca\nsome-new-ca\n"))
+		})
+
+		It("applies the director ops files to the manifest before deploying it", func() {
+			output, err := executor.Deploy(boshinit.DeployInput{
+				IAAS:                        "aws",
+				InfrastructureConfiguration: awsInfrastructureConfiguration,
+				OpsFiles: []storage.OpsFile{
+					{Path: "rename.yml", Contents: "- {type: replace, path: /name, value: renamed-bosh}"},
+					{Path: "tags.yml", Contents: "- type: replace\n  path: /tags?/env\n  value: staging\n"},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(deployCommandRunner.ExecuteCall.Receives.Manifest)).To(ContainSubstring("name: renamed-bosh\n"))
+			Expect(string(deployCommandRunner.ExecuteCall.Receives.Manifest)).To(ContainSubstring("tags:\n  env: staging\n"))
+			Expect(output.BOSHInitManifest).To(Equal(string(deployCommandRunner.ExecuteCall.Receives.Manifest)))
+			Expect(logger.StepCall.Messages).To(ContainElement("applying director ops file rename.yml"))
+		})
+
 		Context("failure cases", func() {
+			Context("when a director ops file is invalid", func() {
+				It("returns an error naming the ops file", func() {
+					_, err := executor.Deploy(boshinit.DeployInput{
+						OpsFiles: []storage.OpsFile{{Path: "invalid.yml", Contents: "- {type: test, path: /name}"}},
+					})
+					Expect(err).To(MatchError(`director ops file invalid.yml: operation 1 (test /name): unknown type "test", expected "replace" or "remove"`))
+					Expect(deployCommandRunner.ExecuteCall.CallCount).To(Equal(0))
+				})
+			})
+
+			Context("when a director ops file no longer matches the manifest", func() {
+				It("returns an error naming the ops file and the path", func() {
+					_, err := executor.Deploy(boshinit.DeployInput{
+						OpsFiles: []storage.OpsFile{{Path: "stale.yml", Contents: "- {type: remove, path: /jobs/name=removed}"}},
+					})
+					Expect(err).To(MatchError(ContainSubstring("director ops file stale.yml does not match the director manifest of this bbl version: operation 1 (remove /jobs/name=removed): ")))
+					Expect(deployCommandRunner.ExecuteCall.CallCount).To(Equal(0))
+				})
+			})
+
 			Context("when the manifest cannot be built", func() {
 				It("returns an error", func() {
 					manifestBuilder.BuildCall.Returns.Error = errors.New("failed to build manifest")
@@ -47,6 +47,7 @@ type DirectorJobProperties struct {
 	DB                          PostgresProperties       `yaml:"db"`
 	UserManagement              UserManagementProperties `yaml:"user_management"`
 	SSL                         SSLProperties            `yaml:"ssl"`
+	TrustedCerts                string                   `yaml:"trusted_certs,omitempty"`
 	DefaultSSHOptions           DefaultSSHOptions        `yaml:"default_ssh_options"`
 }
 
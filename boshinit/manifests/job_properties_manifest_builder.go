@@ -109,6 +109,7 @@ func (j JobPropertiesManifestBuilder) Director(iaas string, manifestProperties M
 			Cert: string(manifestProperties.SSLKeyPair.Certificate),
 			Key:  string(manifestProperties.SSLKeyPair.PrivateKey),
 		},
+		TrustedCerts: manifestProperties.TrustedCerts,
 		DefaultSSHOptions: DefaultSSHOptions{
 			GatewayHost: manifestProperties.ExternalIP,
 		},
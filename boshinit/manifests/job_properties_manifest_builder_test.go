@@ -160,6 +160,13 @@ var _ = Describe("JobPropertiesManifestBuilder", func() {
 			Entry("for aws", "aws", "aws_cpi"),
 			Entry("for aws", "gcp", "google_cpi"),
 		)
+
+		It("adds the trusted certs", func() {
+			director := jobPropertiesManifestBuilder.Director("aws", manifests.ManifestProperties{
+				TrustedCerts: "some-ca\nsome-new-ca\n",
+			})
+			Expect(director.TrustedCerts).To(Equal("some-ca\nsome-new-ca\n"))
+		})
 	})
 
 	Describe("HM", func() {
@@ -30,6 +30,7 @@ type ManifestProperties struct {
 	CACommonName     string
 	ExternalIP       string
 	SSLKeyPair       ssl.KeyPair
+	TrustedCerts     string
 	Credentials      InternalCredentials
 	AWS              ManifestPropertiesAWS
 	GCP              ManifestPropertiesGCP
@@ -110,7 +110,8 @@ func (c AWSCreateLBs) Execute(config AWSCreateLBsConfig, state storage.State) er
 	state.Stack.CertificateName = certificateName
 	state.Stack.LBType = config.LBType
 
-	if err := c.updateStackAndBOSH(state.AWS.Region, certificateName, state.KeyPair.Name, state.Stack.Name, config.LBType, boshClient, state.EnvID); err != nil {
+	if err := c.updateStackAndBOSH(state.AWS.Region, certificateName, state.KeyPair.Name, state.Stack.Name, config.LBType, boshClient, state.EnvID, state.CloudConfigOpsFiles,
+		state.CloudFormationFragments); err != nil {
 		return err
 	}
 
@@ -144,7 +145,8 @@ func (c AWSCreateLBs) checkFastFails(newLBType string, currentLBType string, sta
 
 func (c AWSCreateLBs) updateStackAndBOSH(
 	awsRegion string, certificateName string, keyPairName string, stackName string,
-	lbType string, boshClient bosh.Client, envID string,
+	lbType string, boshClient bosh.Client, envID string, cloudConfigOpsFiles []storage.OpsFile,
+	fragments []storage.CloudFormationFragment,
 ) error {
 
 	availabilityZones, err := c.availabilityZoneRetriever.Retrieve(awsRegion)
@@ -154,12 +156,13 @@ func (c AWSCreateLBs) updateStackAndBOSH(
 
 	certificate, err := c.certificateManager.Describe(certificateName)
 
-	stack, err := c.infrastructureManager.Update(keyPairName, len(availabilityZones), stackName, lbType, certificate.ARN, envID)
+	stack, err := c.infrastructureManager.Update(keyPairName, len(availabilityZones), stackName, lbType, certificate.ARN, envID, fragments)
 	if err != nil {
 		return err
 	}
 
 	cloudConfigInput := c.boshCloudConfigurator.Configure(stack, availabilityZones)
+	cloudConfigInput.OpsFiles = cloudConfigOpsFiles
 
 	err = c.cloudConfigManager.Update(cloudConfigInput, boshClient)
 	if err != nil {
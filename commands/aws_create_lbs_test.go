@@ -73,6 +73,9 @@ var _ = Describe("AWS Create LBs", func() {
 					DirectorPassword: "some-director-password",
 				},
 				EnvID: "some-env-id-timestamp",
+				CloudFormationFragments: []storage.CloudFormationFragment{
+					{Name: "database.yml", Contents: "some-contents"},
+				},
 			}
 
 			command = commands.NewAWSCreateLBs(logger, credentialValidator, certificateManager, infrastructureManager,
@@ -141,6 +144,7 @@ var _ = Describe("AWS Create LBs", func() {
 			Expect(infrastructureManager.UpdateCall.Receives.LBType).To(Equal("concourse"))
 			Expect(infrastructureManager.UpdateCall.Receives.LBCertificateARN).To(Equal("some-certificate-arn"))
 			Expect(infrastructureManager.UpdateCall.Receives.EnvID).To(Equal("some-env-id-timestamp"))
+			Expect(infrastructureManager.UpdateCall.Receives.Fragments).To(Equal(incomingState.CloudFormationFragments))
 		})
 
 		It("names the loadbalancer without EnvID when EnvID is not set", func() {
@@ -69,6 +69,7 @@ func (c AWSDeleteLBs) Execute(state storage.State) error {
 
 	cloudConfigInput := c.boshCloudConfigurator.Configure(stack, azs)
 	cloudConfigInput.LBs = nil
+	cloudConfigInput.OpsFiles = state.CloudConfigOpsFiles
 
 	boshClient := c.boshClientProvider.Client(state.BOSH.DirectorAddress, state.BOSH.DirectorUsername, state.BOSH.DirectorPassword)
 
@@ -77,7 +78,8 @@ func (c AWSDeleteLBs) Execute(state storage.State) error {
 		return err
 	}
 
-	_, err = c.infrastructureManager.Update(state.KeyPair.Name, len(azs), state.Stack.Name, "", "", state.EnvID)
+	_, err = c.infrastructureManager.Update(state.KeyPair.Name, len(azs), state.Stack.Name, "", "", state.EnvID,
+		state.CloudFormationFragments)
 	if err != nil {
 		return err
 	}
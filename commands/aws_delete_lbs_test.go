@@ -62,6 +62,9 @@ var _ = Describe("Delete LBs", func() {
 				Name: "some-keypair",
 			},
 			EnvID: "some-env-id",
+			CloudFormationFragments: []storage.CloudFormationFragment{
+				{Name: "database.yml", Contents: "some-contents"},
+			},
 		}
 
 		infrastructureManager.ExistsCall.Returns.Exists = true
@@ -120,6 +123,7 @@ var _ = Describe("Delete LBs", func() {
 			Expect(infrastructureManager.UpdateCall.Receives.LBType).To(Equal(""))
 			Expect(infrastructureManager.UpdateCall.Receives.LBCertificateARN).To(Equal(""))
 			Expect(infrastructureManager.UpdateCall.Receives.EnvID).To(Equal("some-env-id"))
+			Expect(infrastructureManager.UpdateCall.Receives.Fragments).To(Equal(incomingState.CloudFormationFragments))
 
 			Expect(certificateManager.DeleteCall.Receives.CertificateName).To(Equal("some-certificate"))
 
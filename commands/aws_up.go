@@ -22,13 +22,21 @@ type keyPairSynchronizer interface {
 }
 
 type infrastructureManager interface {
-	Create(keyPairName string, numberOfAZs int, stackName, lbType, lbCertificateARN, envID string) (cloudformation.Stack, error)
-	Update(keyPairName string, numberOfAZs int, stackName, lbType, lbCertificateARN, envID string) (cloudformation.Stack, error)
+	Create(keyPairName string, numberOfAZs int, stackName, lbType, lbCertificateARN, envID string,
+		fragments []storage.CloudFormationFragment) (cloudformation.Stack, error)
+	Update(keyPairName string, numberOfAZs int, stackName, lbType, lbCertificateARN, envID string,
+		fragments []storage.CloudFormationFragment) (cloudformation.Stack, error)
+	Plan(keyPairName string, numberOfAZs int, stackName, lbType, lbCertificateARN, envID string,
+		fragments []storage.CloudFormationFragment) ([]cloudformation.StackChange, error)
 	Exists(stackName string) (bool, error)
 	Delete(stackName string) error
 	Describe(stackName string) (cloudformation.Stack, error)
 }
 
+type cloudFormationFragmentLoader interface {
+	Load() ([]storage.CloudFormationFragment, bool, error)
+}
+
 type boshDeployer interface {
 	Deploy(boshinit.DeployInput) (boshinit.DeployOutput, error)
 }
@@ -74,12 +82,15 @@ type AWSUp struct {
 	envIDGenerator            envIDGenerator
 	stateStore                stateStore
 	configProvider            configProvider
+	fragmentLoader            cloudFormationFragmentLoader
+	logger                    logger
 }
 
 type AWSUpConfig struct {
 	AccessKeyID     string
 	SecretAccessKey string
 	Region          string
+	DryRun          bool
 }
 
 func NewAWSUp(
@@ -88,7 +99,7 @@ func NewAWSUp(
 	boshCloudConfigurator boshCloudConfigurator, availabilityZoneRetriever availabilityZoneRetriever,
 	certificateDescriber certificateDescriber, cloudConfigManager cloudConfigManager,
 	boshClientProvider boshClientProvider, stateStore stateStore,
-	configProvider configProvider) AWSUp {
+	configProvider configProvider, fragmentLoader cloudFormationFragmentLoader, logger logger) AWSUp {
 
 	return AWSUp{
 		credentialValidator:       credentialValidator,
@@ -103,12 +114,28 @@ func NewAWSUp(
 		boshClientProvider:        boshClientProvider,
 		stateStore:                stateStore,
 		configProvider:            configProvider,
+		fragmentLoader:            fragmentLoader,
+		logger:                    logger,
 	}
 }
 
 func (u AWSUp) Execute(config AWSUpConfig, state storage.State) error {
 	state.IAAS = "aws"
 
+	// The fragments of the environment replace the ones kept in the state,
+	// which the other commands that update the stack merge again.
+	fragments, found, err := u.fragmentLoader.Load()
+	if err != nil {
+		return err
+	}
+	if found {
+		state.CloudFormationFragments = fragments
+	}
+
+	if config.DryRun {
+		return u.plan(config, state)
+	}
+
 	if u.awsCredentialsPresent(config) {
 		state.AWS.AccessKeyID = config.AccessKeyID
 		state.AWS.SecretAccessKey = config.SecretAccessKey
@@ -130,7 +157,7 @@ func (u AWSUp) Execute(config AWSUpConfig, state storage.State) error {
 		return u.awsMissingCredentials(config)
 	}
 
-	err := u.checkForFastFails(state)
+	err = u.checkForFastFails(state)
 	if err != nil {
 		return err
 	}
@@ -181,7 +208,8 @@ func (u AWSUp) Execute(config AWSUpConfig, state storage.State) error {
 		certificateARN = certificate.ARN
 	}
 
-	stack, err := u.infrastructureManager.Create(state.KeyPair.Name, len(availabilityZones), state.Stack.Name, state.Stack.LBType, certificateARN, state.EnvID)
+	stack, err := u.infrastructureManager.Create(state.KeyPair.Name, len(availabilityZones), state.Stack.Name, state.Stack.LBType, certificateARN, state.EnvID,
+		state.CloudFormationFragments)
 	if err != nil {
 		return err
 	}
@@ -233,6 +261,7 @@ func (u AWSUp) Execute(config AWSUpConfig, state storage.State) error {
 		state.BOSH.DirectorPassword)
 
 	cloudConfigInput := u.boshCloudConfigurator.Configure(stack, availabilityZones)
+	cloudConfigInput.OpsFiles = state.CloudConfigOpsFiles
 
 	err = u.cloudConfigManager.Update(cloudConfigInput, boshClient)
 	if err != nil {
@@ -247,6 +276,97 @@ func (u AWSUp) Execute(config AWSUpConfig, state storage.State) error {
 	return nil
 }
 
+func (u AWSUp) plan(config AWSUpConfig, state storage.State) error {
+	if u.awsCredentialsPresent(config) {
+		state.AWS.AccessKeyID = config.AccessKeyID
+		state.AWS.SecretAccessKey = config.SecretAccessKey
+		state.AWS.Region = config.Region
+		u.configProvider.SetConfig(aws.Config{
+			AccessKeyID:     config.AccessKeyID,
+			SecretAccessKey: config.SecretAccessKey,
+			Region:          config.Region,
+		})
+	} else if u.awsCredentialsNotPresent(config) {
+		err := u.credentialValidator.ValidateAWS()
+		if err != nil {
+			return err
+		}
+	} else {
+		return u.awsMissingCredentials(config)
+	}
+
+	err := u.checkForFastFails(state)
+	if err != nil {
+		return err
+	}
+
+	if state.KeyPair.Name == "" {
+		state.KeyPair.Name = fmt.Sprintf("keypair-%s", state.EnvID)
+	}
+
+	if state.Stack.Name == "" {
+		state.Stack.Name = fmt.Sprintf("stack-%s", strings.Replace(state.EnvID, ":", "-", -1))
+	}
+
+	availabilityZones, err := u.availabilityZoneRetriever.Retrieve(state.AWS.Region)
+	if err != nil {
+		return err
+	}
+
+	var certificateARN string
+	if lbExists(state.Stack.LBType) {
+		certificate, err := u.certificateDescriber.Describe(state.Stack.CertificateName)
+		if err != nil {
+			return err
+		}
+		certificateARN = certificate.ARN
+	}
+
+	changes, err := u.infrastructureManager.Plan(state.KeyPair.Name, len(availabilityZones), state.Stack.Name, state.Stack.LBType, certificateARN, state.EnvID,
+		state.CloudFormationFragments)
+	if err != nil {
+		return err
+	}
+
+	var add, change, destroy int
+	u.logger.Step("planned changes to cloudformation stack %q", state.Stack.Name)
+	for _, stackChange := range changes {
+		switch stackChange.Action {
+		case "Add":
+			add++
+			u.logger.Println(fmt.Sprintf("+ %s (%s)", stackChange.LogicalResourceID, stackChange.ResourceType))
+		case "Remove":
+			destroy++
+			u.logger.Println(fmt.Sprintf("- %s (%s)", stackChange.LogicalResourceID, stackChange.ResourceType))
+		default:
+			change++
+			u.logger.Println(fmt.Sprintf("~ %s (%s)%s", stackChange.LogicalResourceID, stackChange.ResourceType, replacementNote(stackChange.Replacement)))
+		}
+	}
+	u.logger.Println(planSummary(add, change, destroy))
+
+	return nil
+}
+
+func replacementNote(replacement string) string {
+	switch replacement {
+	case "True":
+		return ", replaced"
+	case "Conditional":
+		return ", may be replaced"
+	default:
+		return ""
+	}
+}
+
+func planSummary(add, change, destroy int) string {
+	if add == 0 && change == 0 && destroy == 0 {
+		return "No changes, the infrastructure is up to date"
+	}
+
+	return fmt.Sprintf("Plan: %d to add, %d to change, %d to destroy", add, change, destroy)
+}
+
 func (u AWSUp) checkForFastFails(state storage.State) error {
 	stackExists, err := u.infrastructureManager.Exists(state.Stack.Name)
 	if err != nil {
@@ -37,6 +37,8 @@ var _ = Describe("AWSUp", func() {
 			boshInitCredentials       map[string]string
 			stateStore                *fakes.StateStore
 			clientProvider            *fakes.ClientProvider
+			fragmentLoader            *fakes.FragmentLoader
+			logger                    *fakes.Logger
 		)
 
 		BeforeEach(func() {
@@ -95,12 +97,14 @@ var _ = Describe("AWSUp", func() {
 
 			stateStore = &fakes.StateStore{}
 			clientProvider = &fakes.ClientProvider{}
+			fragmentLoader = &fakes.FragmentLoader{}
+			logger = &fakes.Logger{}
 
 			command = commands.NewAWSUp(
 				credentialValidator, infrastructureManager, keyPairSynchronizer, boshDeployer,
 				stringGenerator, cloudConfigurator, availabilityZoneRetriever, certificateDescriber,
 				cloudConfigManager, boshClientProvider, stateStore,
-				clientProvider,
+				clientProvider, fragmentLoader, logger,
 			)
 
 			boshInitCredentials = map[string]string{
@@ -200,6 +204,67 @@ var _ = Describe("AWSUp", func() {
 			Expect(infrastructureManager.CreateCall.Returns.Error).To(BeNil())
 		})
 
+		Context("cloudformation fragments", func() {
+			var state storage.State
+
+			BeforeEach(func() {
+				state = storage.State{
+					AWS: storage.AWS{
+						Region:          "some-aws-region",
+						SecretAccessKey: "some-secret-access-key",
+						AccessKeyID:     "some-access-key-id",
+					},
+					EnvID: "bbl-lake-time-stamp",
+					CloudFormationFragments: []storage.CloudFormationFragment{
+						{Name: "some-old-fragment.yml", Contents: "some-old-contents"},
+					},
+				}
+			})
+
+			It("merges the fragments of the environment and keeps them in the state", func() {
+				fragments := []storage.CloudFormationFragment{
+					{Name: "database.yml", Contents: "some-contents"},
+				}
+				fragmentLoader.LoadCall.Returns.Fragments = fragments
+				fragmentLoader.LoadCall.Returns.Found = true
+
+				err := command.Execute(commands.AWSUpConfig{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fragmentLoader.LoadCall.CallCount).To(Equal(1))
+				Expect(infrastructureManager.CreateCall.Receives.Fragments).To(Equal(fragments))
+				Expect(stateStore.SetCall.Receives.State.CloudFormationFragments).To(Equal(fragments))
+			})
+
+			It("removes the fragments kept in the state when the fragments directory is empty", func() {
+				fragmentLoader.LoadCall.Returns.Fragments = []storage.CloudFormationFragment{}
+				fragmentLoader.LoadCall.Returns.Found = true
+
+				err := command.Execute(commands.AWSUpConfig{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(infrastructureManager.CreateCall.Receives.Fragments).To(BeEmpty())
+				Expect(stateStore.SetCall.Receives.State.CloudFormationFragments).To(BeEmpty())
+			})
+
+			It("merges the fragments kept in the state when the fragments directory does not exist", func() {
+				err := command.Execute(commands.AWSUpConfig{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(infrastructureManager.CreateCall.Receives.Fragments).To(Equal(state.CloudFormationFragments))
+				Expect(stateStore.SetCall.Receives.State.CloudFormationFragments).To(Equal(state.CloudFormationFragments))
+			})
+
+			It("returns an error when the fragments cannot be loaded", func() {
+				fragmentLoader.LoadCall.Returns.Error = errors.New("failed to load fragments")
+
+				err := command.Execute(commands.AWSUpConfig{}, state)
+				Expect(err).To(MatchError("failed to load fragments"))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+				Expect(infrastructureManager.CreateCall.CallCount).To(Equal(0))
+			})
+		})
+
 		It("deploys bosh", func() {
 			infrastructureManager.ExistsCall.Returns.Exists = true
 
@@ -494,6 +559,109 @@ var _ = Describe("AWSUp", func() {
 			})
 		})
 
+		Describe("dry run", func() {
+			var lines []string
+
+			BeforeEach(func() {
+				lines = []string{}
+				logger.PrintlnCall.Stub = func(message string) {
+					lines = append(lines, message)
+				}
+
+				availabilityZoneRetriever.RetrieveCall.Returns.AZs = []string{"some-az-1", "some-az-2"}
+				infrastructureManager.PlanCall.Returns.Changes = []cloudformation.StackChange{
+					{Action: "Add", LogicalResourceID: "InternalSubnet3", ResourceType: "AWS::EC2::Subnet"},
+					{Action: "Modify", LogicalResourceID: "NATInstance", ResourceType: "AWS::EC2::Instance", Replacement: "True"},
+					{Action: "Modify", LogicalResourceID: "BOSHSecurityGroup", ResourceType: "AWS::EC2::SecurityGroup", Replacement: "False"},
+					{Action: "Remove", LogicalResourceID: "ConcourseLoadBalancer", ResourceType: "AWS::ElasticLoadBalancing::LoadBalancer"},
+				}
+			})
+
+			It("prints the change set of the stack without changing anything", func() {
+				err := command.Execute(commands.AWSUpConfig{DryRun: true}, storage.State{
+					EnvID: "bbl-lake-time:stamp",
+					AWS: storage.AWS{
+						Region: "some-aws-region",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(infrastructureManager.PlanCall.CallCount).To(Equal(1))
+				Expect(infrastructureManager.PlanCall.Receives.KeyPairName).To(Equal("keypair-bbl-lake-time:stamp"))
+				Expect(infrastructureManager.PlanCall.Receives.StackName).To(Equal("stack-bbl-lake-time-stamp"))
+				Expect(infrastructureManager.PlanCall.Receives.NumberOfAvailabilityZones).To(Equal(2))
+				Expect(infrastructureManager.PlanCall.Receives.EnvID).To(Equal("bbl-lake-time:stamp"))
+
+				Expect(logger.StepCall.Messages).To(Equal([]string{`planned changes to cloudformation stack "stack-bbl-lake-time-stamp"`}))
+				Expect(lines).To(Equal([]string{
+					"+ InternalSubnet3 (AWS::EC2::Subnet)",
+					"~ NATInstance (AWS::EC2::Instance), replaced",
+					"~ BOSHSecurityGroup (AWS::EC2::SecurityGroup)",
+					"- ConcourseLoadBalancer (AWS::ElasticLoadBalancing::LoadBalancer)",
+					"Plan: 1 to add, 2 to change, 1 to destroy",
+				}))
+
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+				Expect(keyPairSynchronizer.SyncCall.Receives.KeyPair).To(Equal(ec2.KeyPair{}))
+				Expect(infrastructureManager.CreateCall.CallCount).To(Equal(0))
+				Expect(boshDeployer.DeployCall.Receives.Input).To(Equal(boshinit.DeployInput{}))
+				Expect(cloudConfigManager.UpdateCall.Receives.CloudConfigInput).To(Equal(bosh.CloudConfigInput{}))
+			})
+
+			It("plans with the fragments of the environment", func() {
+				fragments := []storage.CloudFormationFragment{
+					{Name: "database.yml", Contents: "some-contents"},
+				}
+				fragmentLoader.LoadCall.Returns.Fragments = fragments
+				fragmentLoader.LoadCall.Returns.Found = true
+
+				err := command.Execute(commands.AWSUpConfig{DryRun: true}, storage.State{
+					EnvID: "bbl-lake-time:stamp",
+					AWS: storage.AWS{
+						Region: "some-aws-region",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(infrastructureManager.PlanCall.Receives.Fragments).To(Equal(fragments))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+
+			It("uses the credentials from the flags without storing them", func() {
+				err := command.Execute(commands.AWSUpConfig{
+					AccessKeyID:     "some-access-key-id",
+					SecretAccessKey: "some-secret-access-key",
+					Region:          "some-region",
+					DryRun:          true,
+				}, storage.State{EnvID: "some-env-id"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(clientProvider.SetConfigCall.Receives.Config).To(Equal(aws.Config{
+					AccessKeyID:     "some-access-key-id",
+					SecretAccessKey: "some-secret-access-key",
+					Region:          "some-region",
+				}))
+				Expect(availabilityZoneRetriever.RetrieveCall.Receives.Region).To(Equal("some-region"))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+
+			It("reports when there are no changes", func() {
+				infrastructureManager.PlanCall.Returns.Changes = []cloudformation.StackChange{}
+
+				err := command.Execute(commands.AWSUpConfig{DryRun: true}, storage.State{EnvID: "some-env-id"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(lines).To(Equal([]string{"No changes, the infrastructure is up to date"}))
+			})
+
+			It("returns an error when the change set cannot be created", func() {
+				infrastructureManager.PlanCall.Returns.Error = errors.New("failed to create change set")
+
+				err := command.Execute(commands.AWSUpConfig{DryRun: true}, storage.State{EnvID: "some-env-id"})
+				Expect(err).To(MatchError("failed to create change set"))
+			})
+		})
+
 		Describe("state manipulation", func() {
 			Context("iaas", func() {
 				It("writes iaas aws to state", func() {
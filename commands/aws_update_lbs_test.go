@@ -143,6 +143,9 @@ var _ = Describe("AWS Update LBs", func() {
 					Name: "some-key-pair",
 				},
 				EnvID: "some-env-id-timestamp",
+				CloudFormationFragments: []storage.CloudFormationFragment{
+					{Name: "database.yml", Contents: "some-contents"},
+				},
 			})
 
 			Expect(availabilityZoneRetriever.RetrieveCall.Receives.Region).To(Equal("some-region"))
@@ -155,6 +158,9 @@ var _ = Describe("AWS Update LBs", func() {
 			Expect(infrastructureManager.UpdateCall.Receives.LBType).To(Equal("concourse"))
 			Expect(infrastructureManager.UpdateCall.Receives.LBCertificateARN).To(Equal("some-certificate-arn"))
 			Expect(infrastructureManager.UpdateCall.Receives.EnvID).To(Equal("some-env-id-timestamp"))
+			Expect(infrastructureManager.UpdateCall.Receives.Fragments).To(Equal([]storage.CloudFormationFragment{
+				{Name: "database.yml", Contents: "some-contents"},
+			}))
 		})
 
 		It("names the loadbalancer without EnvID when EnvID is not set", func() {
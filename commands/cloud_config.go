@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"io"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	CloudConfigCommand = "cloud-config"
+)
+
+type CloudConfig struct {
+	stateValidator         stateValidator
+	environmentCloudConfig environmentCloudConfig
+	stdout                 io.Writer
+}
+
+func NewCloudConfig(stateValidator stateValidator, credentialValidator credentialValidator, infrastructureManager infrastructureManager,
+	availabilityZoneRetriever availabilityZoneRetriever, boshCloudConfigurator boshCloudConfigurator,
+	boshCloudConfigGenerator boshCloudConfigGenerator, terraformOutputter terraformOutputter,
+	gcpCloudConfigGenerator gcpCloudConfigGenerator, zones zones, stdout io.Writer) CloudConfig {
+	return CloudConfig{
+		stateValidator: stateValidator,
+		environmentCloudConfig: newEnvironmentCloudConfig(credentialValidator, infrastructureManager, availabilityZoneRetriever,
+			boshCloudConfigurator, boshCloudConfigGenerator, terraformOutputter, gcpCloudConfigGenerator, zones),
+		stdout: stdout,
+	}
+}
+
+func (c CloudConfig) Execute(subcommandFlags []string, state storage.State) error {
+	err := c.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	cloudConfig, err := c.environmentCloudConfig.yaml(state)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.stdout.Write(cloudConfig)
+	return err
+}
@@ -0,0 +1,272 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/aws/cloudformation"
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/cloudconfig/gcp"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CloudConfig", func() {
+	var (
+		stateValidator            *fakes.StateValidator
+		credentialValidator       *fakes.CredentialValidator
+		infrastructureManager     *fakes.InfrastructureManager
+		availabilityZoneRetriever *fakes.AvailabilityZoneRetriever
+		boshCloudConfigurator     *fakes.BoshCloudConfigurator
+		cloudConfigGenerator      *fakes.CloudConfigGenerator
+		terraformOutputter        *fakes.TerraformOutputter
+		gcpCloudConfigGenerator   *fakes.GCPCloudConfigGenerator
+		zones                     *fakes.Zones
+		stdout                    *bytes.Buffer
+		command                   commands.CloudConfig
+	)
+
+	BeforeEach(func() {
+		stateValidator = &fakes.StateValidator{}
+		credentialValidator = &fakes.CredentialValidator{}
+		infrastructureManager = &fakes.InfrastructureManager{}
+		availabilityZoneRetriever = &fakes.AvailabilityZoneRetriever{}
+		boshCloudConfigurator = &fakes.BoshCloudConfigurator{}
+		cloudConfigGenerator = &fakes.CloudConfigGenerator{}
+		terraformOutputter = &fakes.TerraformOutputter{}
+		gcpCloudConfigGenerator = &fakes.GCPCloudConfigGenerator{}
+		zones = &fakes.Zones{}
+		stdout = bytes.NewBuffer([]byte{})
+
+		command = commands.NewCloudConfig(stateValidator, credentialValidator, infrastructureManager, availabilityZoneRetriever,
+			boshCloudConfigurator, cloudConfigGenerator, terraformOutputter, gcpCloudConfigGenerator, zones, stdout)
+	})
+
+	Describe("Execute", func() {
+		Context("on aws", func() {
+			var state storage.State
+
+			BeforeEach(func() {
+				state = storage.State{
+					IAAS: "aws",
+					AWS: storage.AWS{
+						Region: "some-region",
+					},
+					Stack: storage.Stack{
+						Name: "some-stack-name",
+					},
+				}
+
+				infrastructureManager.DescribeCall.Returns.Stack = cloudformation.Stack{
+					Name: "some-stack-name",
+					Outputs: map[string]string{
+						"InternalSubnet1AZ": "some-az-1",
+					},
+				}
+				availabilityZoneRetriever.RetrieveCall.Returns.AZs = []string{"some-az-1", "some-az-2"}
+				boshCloudConfigurator.ConfigureCall.Returns.CloudConfigInput = bosh.CloudConfigInput{
+					AZs: []string{"some-az-1", "some-az-2"},
+				}
+				cloudConfigGenerator.GenerateCall.Returns.CloudConfig = bosh.CloudConfig{
+					AZs: []bosh.AZ{
+						{Name: "z1", CloudProperties: bosh.AZCloudProperties{AvailabilityZone: "some-az-1"}},
+					},
+				}
+			})
+
+			It("prints the cloud config generated from the stack", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(credentialValidator.ValidateAWSCall.CallCount).To(Equal(1))
+				Expect(infrastructureManager.DescribeCall.Receives.StackName).To(Equal("some-stack-name"))
+				Expect(availabilityZoneRetriever.RetrieveCall.Receives.Region).To(Equal("some-region"))
+				Expect(boshCloudConfigurator.ConfigureCall.Receives.Stack.Name).To(Equal("some-stack-name"))
+				Expect(boshCloudConfigurator.ConfigureCall.Receives.AZs).To(Equal([]string{"some-az-1", "some-az-2"}))
+				Expect(cloudConfigGenerator.GenerateCall.Receives.CloudConfigInput.AZs).To(Equal([]string{"some-az-1", "some-az-2"}))
+
+				Expect(stdout.String()).To(Equal(`azs:
+- name: z1
+  cloud_properties:
+    availability_zone: some-az-1
+`))
+			})
+
+			Context("failure cases", func() {
+				It("returns an error when the aws credentials are missing", func() {
+					credentialValidator.ValidateAWSCall.Returns.Error = errors.New("aws credentials missing")
+
+					err := command.Execute([]string{}, state)
+					Expect(err).To(MatchError("aws credentials missing"))
+				})
+
+				It("returns an error when there is no stack", func() {
+					state.Stack.Name = ""
+
+					err := command.Execute([]string{}, state)
+					Expect(err).To(MatchError(commands.BBLNotFound))
+				})
+
+				It("returns an error when the stack cannot be described", func() {
+					infrastructureManager.DescribeCall.Returns.Error = errors.New("failed to describe stack")
+
+					err := command.Execute([]string{}, state)
+					Expect(err).To(MatchError("failed to describe stack"))
+				})
+
+				It("returns an error when the availability zones cannot be retrieved", func() {
+					availabilityZoneRetriever.RetrieveCall.Returns.Error = errors.New("failed to retrieve azs")
+
+					err := command.Execute([]string{}, state)
+					Expect(err).To(MatchError("failed to retrieve azs"))
+				})
+
+				It("returns an error when the cloud config cannot be generated", func() {
+					cloudConfigGenerator.GenerateCall.Returns.Error = errors.New("failed to generate cloud config")
+
+					err := command.Execute([]string{}, state)
+					Expect(err).To(MatchError("failed to generate cloud config"))
+				})
+			})
+		})
+
+		Context("on gcp", func() {
+			var state storage.State
+
+			BeforeEach(func() {
+				state = storage.State{
+					IAAS: "gcp",
+					GCP: storage.GCP{
+						Region: "some-region",
+					},
+					TFState: "some-tf-state",
+				}
+
+				terraformOutputter.GetCall.Stub = func(outputName string) (string, error) {
+					return "some-" + outputName, nil
+				}
+				zones.GetCall.Returns.Zones = []string{"some-zone-1", "some-zone-2"}
+				gcpCloudConfigGenerator.GenerateCall.Returns.CloudConfig = gcp.CloudConfig{
+					AZs: []gcp.AZ{
+						{Name: "z1", CloudProperties: gcp.AZCloudProperties{Zone: "some-zone-1"}},
+					},
+				}
+			})
+
+			It("prints the cloud config generated from the terraform outputs", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(credentialValidator.ValidateGCPCall.CallCount).To(Equal(0))
+				Expect(terraformOutputter.GetCall.Receives.TFState).To(Equal("some-tf-state"))
+				Expect(zones.GetCall.Receives.Region).To(Equal("some-region"))
+				Expect(gcpCloudConfigGenerator.GenerateCall.Receives.CloudConfigInput).To(Equal(gcp.CloudConfigInput{
+					AZs:            []string{"some-zone-1", "some-zone-2"},
+					Tags:           []string{"some-internal_tag_name"},
+					NetworkName:    "some-network_name",
+					SubnetworkName: "some-subnetwork_name",
+				}))
+
+				Expect(stdout.String()).To(Equal(`azs:
+- name: z1
+  cloud_properties:
+    zone: some-zone-1
+`))
+			})
+
+			It("applies the cloud config ops files of the state", func() {
+				state.CloudConfigOpsFiles = []storage.OpsFile{
+					{Path: "ops.yml", Contents: "- type: replace\n  path: /azs/name=z1/cloud_properties/zone\n  value: some-other-zone\n"},
+				}
+
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stdout.String()).To(Equal(`azs:
+- cloud_properties:
+    zone: some-other-zone
+  name: z1
+`))
+			})
+
+			It("includes the concourse target pool when a concourse lb is attached", func() {
+				state.LB.Type = "concourse"
+
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(gcpCloudConfigGenerator.GenerateCall.Receives.CloudConfigInput.ConcourseTargetPool).To(Equal("some-concourse_target_pool"))
+			})
+
+			It("includes the cf backends when a cf lb is attached", func() {
+				state.LB.Type = "cf"
+
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(gcpCloudConfigGenerator.GenerateCall.Receives.CloudConfigInput.CFBackends).To(Equal(gcp.CFBackends{
+					Router:    "some-router_backend_service",
+					SSHProxy:  "some-ssh_proxy_target_pool",
+					TCPRouter: "some-tcp_router_target_pool",
+					WS:        "some-ws_target_pool",
+				}))
+			})
+
+			Context("failure cases", func() {
+				It("returns an error when there is no terraform state", func() {
+					state.TFState = ""
+
+					err := command.Execute([]string{}, state)
+					Expect(err).To(MatchError(commands.BBLNotFound))
+				})
+
+				It("returns an error when a terraform output cannot be read", func() {
+					terraformOutputter.GetCall.Stub = func(outputName string) (string, error) {
+						if outputName == "subnetwork_name" {
+							return "", errors.New("failed to get output")
+						}
+						return "", nil
+					}
+
+					err := command.Execute([]string{}, state)
+					Expect(err).To(MatchError("failed to get output"))
+				})
+
+				It("returns an error when the cloud config cannot be generated", func() {
+					gcpCloudConfigGenerator.GenerateCall.Returns.Error = errors.New("failed to generate cloud config")
+
+					err := command.Execute([]string{}, state)
+					Expect(err).To(MatchError("failed to generate cloud config"))
+				})
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the state is not valid", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("state not found")
+
+				err := command.Execute([]string{}, storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError("state not found"))
+			})
+
+			It("returns an error when the iaas is unknown", func() {
+				err := command.Execute([]string{}, storage.State{IAAS: "some-iaas"})
+				Expect(err).To(MatchError(`cannot generate a cloud config on iaas "some-iaas"`))
+			})
+
+			It("returns an error when the cloud config cannot be marshaled", func() {
+				commands.SetMarshal(func(interface{}) ([]byte, error) {
+					return nil, errors.New("failed to marshal")
+				})
+				defer commands.ResetMarshal()
+
+				err := command.Execute([]string{}, storage.State{IAAS: "gcp", TFState: "some-tf-state"})
+				Expect(err).To(MatchError("failed to marshal"))
+			})
+		})
+	})
+})
@@ -3,17 +3,22 @@ package commands
 const (
 	UpCommandUsage = `Deploys BOSH director on an IAAS
 
-  --iaas                     IAAS to deploy your BOSH Director onto. Valid options: "gcp", "aws" (Defaults to environment variable BBL_IAAS)
-  --name                     Name to assign to your BOSH Director (optional, will be randomly generated)
-
-  --aws-access-key-id        AWS Access Key ID to use (Defaults to environment variable BBL_AWS_ACCESS_KEY_ID)
-  --aws-secret-access-key    AWS Secret Access Key to use (Defaults to environment variable BBL_AWS_SECRET_ACCESS_KEY)
-  --aws-region               AWS region to use (Defaults to environment variable BBL_AWS_REGION)
-
-  --gcp-service-account-key  GCP Service Access Key to use (Defaults to environment variable BBL_GCP_SERVICE_ACCOUNT_KEY)
-  --gcp-project-id           GCP Project ID to use (Defaults to environment variable BBL_GCP_PROJECT_ID)
-  --gcp-zone                 GCP Zone to use (Defaults to environment variable BBL_GCP_ZONE)
-  --gcp-region               GCP Region to use (Defaults to environment variable BBL_GCP_REGION)`
+  --iaas                       IAAS to deploy your BOSH Director onto. Valid options: "gcp", "aws"
+  --name                       Name to assign to your BOSH Director (optional, will be randomly generated)
+  --dry-run                    Prints the infrastructure changes without applying them, deploying the director or writing bbl-state.json
+  --director-ops-file          Path to an ops file to apply to the director manifest (may be given more than once)
+  --no-director-ops-files      Removes the director ops files kept in bbl-state.json
+  --cloud-config-ops-file      Path to an ops file to apply to the cloud config (may be given more than once)
+  --no-cloud-config-ops-files  Removes the cloud config ops files kept in bbl-state.json
+
+  --aws-access-key-id          AWS Access Key ID to use
+  --aws-secret-access-key      AWS Secret Access Key to use
+  --aws-region                 AWS region to use
+
+  --gcp-service-account-key    GCP Service Access Key to use
+  --gcp-project-id             GCP Project ID to use
+  --gcp-zone                   GCP Zone to use
+  --gcp-region                 GCP Region to use`
 
 	DestroyCommandUsage = `Tears down BOSH director infrastructure
 
@@ -43,6 +48,8 @@ const (
 
 	LBsCommandUsage = "Prints attached load balancer(s)"
 
+	OutputsCommandUsage = "Prints the outputs of the cloudformation stack or terraform state, including the outputs of terraform override files"
+
 	VersionCommandUsage = "Prints version"
 
 	UsageCommandUsage = "Prints helpful message for the given command"
@@ -58,6 +65,77 @@ const (
 	DirectorAddressCommandUsage = "Prints BOSH director address"
 
 	DirectorCACertCommandUsage = "Prints BOSH director CA certificate"
+
+	EncryptStateCommandUsage = "Encrypts bbl-state.json with the passphrase from --state-encryption-key-file or BBL_STATE_PASSPHRASE"
+
+	DecryptStateCommandUsage = "Decrypts bbl-state.json and stores it as plaintext"
+
+	ForceUnlockCommandUsage = "Removes the lock on bbl-state.json left behind by an interrupted command"
+
+	StateHistoryCommandUsage = "Lists the versions of bbl-state.json kept in the state history"
+
+	StateRollbackCommandUsage = `Restores a version of bbl-state.json from the state history
+
+  --to  Version to restore, as listed by bbl state-history`
+
+	MigrateStateCommandUsage = `Migrates bbl-state.json to the state version of this bbl
+
+  [--dry-run]  Prints the changes the migration would make without writing bbl-state.json`
+
+	ValidateStateCommandUsage = `Reports structural and semantic problems in bbl-state.json
+
+  [--schema]  Prints the JSON schema of bbl-state.json instead`
+
+	ExportStateCommandUsage = `Prints a copy of bbl-state.json
+
+  [--redact]  Replaces every secret with a placeholder
+  [--output]  Path to write the export to instead of stdout`
+
+	ImportStateCommandUsage = `Creates bbl-state.json from a file written by bbl export-state
+
+  --file           Path to the export
+  [--secrets-dir]  Directory with one file per redacted secret, named after its placeholder; missing secrets are prompted for`
+
+	EnvsCommandUsage = "Lists the environments stored under envs/ in the state directory, selected with bbl --env NAME"
+
+	PlanCommandUsage = `Prints the infrastructure changes bbl up would make, without applying them
+
+  Accepts the same options as bbl up`
+
+	PrintEnvCommandUsage = `Prints environment variables that target the BOSH director with the BOSH CLI
+
+  [--shell]  Shell to print the variables for: bash, fish or powershell (Defaults to bash)
+  [--json]   Prints the variables as a JSON object instead`
+
+	SSHConfigCommandUsage = `Writes the director SSH key to the state directory and prints an OpenSSH Host stanza for the director
+
+  [--host]        Host alias of the stanza (Defaults to bbl-ENV_ID)
+  [--proxy-jump]  Jump host to reach the director through, e.g. user@bastion.example.com
+  [--append-to]   SSH config file to add the stanza to instead of printing it, e.g. ~/.ssh/config`
+
+	RotateDirectorCredentialsCommandUsage = `Regenerates director and internal passwords, redeploys the director and saves them once the director accepts them
+
+  [--credential]  Comma separated list of director, mbus, nats, postgres, registry, blobstore-director, blobstore-agent and hm (Defaults to all)`
+
+	CompletionCommandUsage = `Prints a completion script for the given shell
+
+  bash  Load it with: source <(bbl completion bash)
+  zsh   Load it with: source <(bbl completion zsh)
+  fish  Load it with: bbl completion fish | source`
+
+	ConfigCommandUsage = `Prints the options of up and create-lbs merged from flags, bbl.yml and BBL_ environment variables, and where each value came from
+
+  show  Prints the effective options, accepting the options of up and create-lbs to show how flags override them`
+
+	DoctorCommandUsage = "Checks the state file, IaaS credentials, infrastructure, director, certificates and SSH key of the environment"
+
+	RotateDirectorCACommandUsage = "Advances the director CA rotation by one phase: trust a new CA, switch the director certificate, drop the previous CA"
+
+	RotateSSHKeyCommandUsage = "Creates a new SSH key pair, redeploys the director with it and deletes the previous key pair"
+
+	CloudConfigCommandUsage = "Prints the cloud config bbl uploads to the director, generated from the infrastructure of the environment"
+
+	UpdateCloudConfigCommandUsage = "Regenerates the cloud config of the environment and uploads it to the director, without touching the infrastructure or the director"
 )
 
 func (Up) Usage() string { return UpCommandUsage }
@@ -72,8 +150,52 @@ func (DeleteLBs) Usage() string { return DeleteLBsCommandUsage }
 
 func (LBs) Usage() string { return LBsCommandUsage }
 
+func (Outputs) Usage() string { return OutputsCommandUsage }
+
 func (Version) Usage() string { return VersionCommandUsage }
 
+func (EncryptState) Usage() string { return EncryptStateCommandUsage }
+
+func (DecryptState) Usage() string { return DecryptStateCommandUsage }
+
+func (ForceUnlock) Usage() string { return ForceUnlockCommandUsage }
+
+func (StateHistory) Usage() string { return StateHistoryCommandUsage }
+
+func (StateRollback) Usage() string { return StateRollbackCommandUsage }
+
+func (MigrateState) Usage() string { return MigrateStateCommandUsage }
+
+func (ValidateState) Usage() string { return ValidateStateCommandUsage }
+
+func (ExportState) Usage() string { return ExportStateCommandUsage }
+
+func (ImportState) Usage() string { return ImportStateCommandUsage }
+
+func (Envs) Usage() string { return EnvsCommandUsage }
+
+func (PrintEnv) Usage() string { return PrintEnvCommandUsage }
+
+func (Plan) Usage() string { return PlanCommandUsage }
+
+func (SSHConfig) Usage() string { return SSHConfigCommandUsage }
+
+func (RotateDirectorCredentials) Usage() string { return RotateDirectorCredentialsCommandUsage }
+
+func (Completion) Usage() string { return CompletionCommandUsage }
+
+func (Config) Usage() string { return ConfigCommandUsage }
+
+func (Doctor) Usage() string { return DoctorCommandUsage }
+
+func (RotateDirectorCA) Usage() string { return RotateDirectorCACommandUsage }
+
+func (RotateSSHKey) Usage() string { return RotateSSHKeyCommandUsage }
+
+func (CloudConfig) Usage() string { return CloudConfigCommandUsage }
+
+func (UpdateCloudConfig) Usage() string { return UpdateCloudConfigCommandUsage }
+
 func (Usage) Usage() string { return UsageCommandUsage }
 
 func (s StateQuery) Usage() string {
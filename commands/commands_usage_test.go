@@ -16,17 +16,22 @@ var _ = Describe("Commands Usage", func() {
 				usageText := upCmd.Usage()
 				Expect(usageText).To(Equal(`Deploys BOSH director on an IAAS
 
-  --iaas                     IAAS to deploy your BOSH Director onto. Valid options: "gcp", "aws" (Defaults to environment variable BBL_IAAS)
-  --name                     Name to assign to your BOSH Director (optional, will be randomly generated)
+  --iaas                       IAAS to deploy your BOSH Director onto. Valid options: "gcp", "aws"
+  --name                       Name to assign to your BOSH Director (optional, will be randomly generated)
+  --dry-run                    Prints the infrastructure changes without applying them, deploying the director or writing bbl-state.json
+  --director-ops-file          Path to an ops file to apply to the director manifest (may be given more than once)
+  --no-director-ops-files      Removes the director ops files kept in bbl-state.json
+  --cloud-config-ops-file      Path to an ops file to apply to the cloud config (may be given more than once)
+  --no-cloud-config-ops-files  Removes the cloud config ops files kept in bbl-state.json
 
-  --aws-access-key-id        AWS Access Key ID to use (Defaults to environment variable BBL_AWS_ACCESS_KEY_ID)
-  --aws-secret-access-key    AWS Secret Access Key to use (Defaults to environment variable BBL_AWS_SECRET_ACCESS_KEY)
-  --aws-region               AWS region to use (Defaults to environment variable BBL_AWS_REGION)
+  --aws-access-key-id          AWS Access Key ID to use
+  --aws-secret-access-key      AWS Secret Access Key to use
+  --aws-region                 AWS region to use
 
-  --gcp-service-account-key  GCP Service Access Key to use (Defaults to environment variable BBL_GCP_SERVICE_ACCOUNT_KEY)
-  --gcp-project-id           GCP Project ID to use (Defaults to environment variable BBL_GCP_PROJECT_ID)
-  --gcp-zone                 GCP Zone to use (Defaults to environment variable BBL_GCP_ZONE)
-  --gcp-region               GCP Region to use (Defaults to environment variable BBL_GCP_REGION)`))
+  --gcp-service-account-key    GCP Service Access Key to use
+  --gcp-project-id             GCP Project ID to use
+  --gcp-zone                   GCP Zone to use
+  --gcp-region                 GCP Region to use`))
 			})
 		})
 	})
@@ -99,11 +104,156 @@ var _ = Describe("Commands Usage", func() {
 		})
 	})
 
+	Describe("State Rollback", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.StateRollback{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Restores a version of bbl-state.json from the state history
+
+  --to  Version to restore, as listed by bbl state-history`))
+			})
+		})
+	})
+
+	Describe("Migrate State", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.MigrateState{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Migrates bbl-state.json to the state version of this bbl
+
+  [--dry-run]  Prints the changes the migration would make without writing bbl-state.json`))
+			})
+		})
+	})
+
+	Describe("Completion", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.Completion{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Prints a completion script for the given shell
+
+  bash  Load it with: source <(bbl completion bash)
+  zsh   Load it with: source <(bbl completion zsh)
+  fish  Load it with: bbl completion fish | source`))
+			})
+		})
+	})
+
+	Describe("Config", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.Config{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Prints the options of up and create-lbs merged from flags, bbl.yml and BBL_ environment variables, and where each value came from
+
+  show  Prints the effective options, accepting the options of up and create-lbs to show how flags override them`))
+			})
+		})
+	})
+
+	Describe("Validate State", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.ValidateState{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Reports structural and semantic problems in bbl-state.json
+
+  [--schema]  Prints the JSON schema of bbl-state.json instead`))
+			})
+		})
+	})
+
+	Describe("Export State", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.ExportState{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Prints a copy of bbl-state.json
+
+  [--redact]  Replaces every secret with a placeholder
+  [--output]  Path to write the export to instead of stdout`))
+			})
+		})
+	})
+
+	Describe("Import State", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.ImportState{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Creates bbl-state.json from a file written by bbl export-state
+
+  --file           Path to the export
+  [--secrets-dir]  Directory with one file per redacted secret, named after its placeholder; missing secrets are prompted for`))
+			})
+		})
+	})
+
+	Describe("Plan", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.Plan{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Prints the infrastructure changes bbl up would make, without applying them
+
+  Accepts the same options as bbl up`))
+			})
+		})
+	})
+
+	Describe("Print Env", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.PrintEnv{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Prints environment variables that target the BOSH director with the BOSH CLI
+
+  [--shell]  Shell to print the variables for: bash, fish or powershell (Defaults to bash)
+  [--json]   Prints the variables as a JSON object instead`))
+			})
+		})
+	})
+
+	Describe("SSH Config", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.SSHConfig{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Writes the director SSH key to the state directory and prints an OpenSSH Host stanza for the director
+
+  [--host]        Host alias of the stanza (Defaults to bbl-ENV_ID)
+  [--proxy-jump]  Jump host to reach the director through, e.g. user@bastion.example.com
+  [--append-to]   SSH config file to add the stanza to instead of printing it, e.g. ~/.ssh/config`))
+			})
+		})
+	})
+
+	Describe("Rotate Director Credentials", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.RotateDirectorCredentials{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Regenerates director and internal passwords, redeploys the director and saves them once the director accepts them
+
+  [--credential]  Comma separated list of director, mbus, nats, postgres, registry, blobstore-director, blobstore-agent and hm (Defaults to all)`))
+			})
+		})
+	})
+
 	DescribeTable("command description", func(command commands.Command, expectedDescription string) {
 		usageText := command.Usage()
 		Expect(usageText).To(Equal(expectedDescription))
 	},
 		Entry("LBs", commands.LBs{}, "Prints attached load balancer(s)"),
+		Entry("outputs", commands.Outputs{}, "Prints the outputs of the cloudformation stack or terraform state, including the outputs of terraform override files"),
+		Entry("doctor", commands.Doctor{}, "Checks the state file, IaaS credentials, infrastructure, director, certificates and SSH key of the environment"),
+		Entry("rotate-director-ca", commands.RotateDirectorCA{}, "Advances the director CA rotation by one phase: trust a new CA, switch the director certificate, drop the previous CA"),
+		Entry("rotate-ssh-key", commands.RotateSSHKey{}, "Creates a new SSH key pair, redeploys the director with it and deletes the previous key pair"),
+		Entry("cloud-config", commands.CloudConfig{}, "Prints the cloud config bbl uploads to the director, generated from the infrastructure of the environment"),
+		Entry("update-cloud-config", commands.UpdateCloudConfig{}, "Regenerates the cloud config of the environment and uploads it to the director, without touching the infrastructure or the director"),
 		Entry("director-address", newStateQuery("director address"), "Prints BOSH director address"),
 		Entry("director-password", newStateQuery("director password"), "Prints BOSH director password"),
 		Entry("director-username", newStateQuery("director username"), "Prints BOSH director username"),
@@ -112,9 +262,14 @@ var _ = Describe("Commands Usage", func() {
 		Entry("env-id", newStateQuery("environment id"), "Prints environment ID"),
 		Entry("ssh-key", newStateQuery("ssh key"), "Prints SSH private key"),
 		Entry("version", commands.Version{}, "Prints version"),
+		Entry("encrypt-state", commands.EncryptState{}, "Encrypts bbl-state.json with the passphrase from --state-encryption-key-file or BBL_STATE_PASSPHRASE"),
+		Entry("decrypt-state", commands.DecryptState{}, "Decrypts bbl-state.json and stores it as plaintext"),
+		Entry("state-history", commands.StateHistory{}, "Lists the versions of bbl-state.json kept in the state history"),
+		Entry("force-unlock", commands.ForceUnlock{}, "Removes the lock on bbl-state.json left behind by an interrupted command"),
+		Entry("envs", commands.Envs{}, "Lists the environments stored under envs/ in the state directory, selected with bbl --env NAME"),
 	)
 })
 
 func newStateQuery(propertyName string) commands.StateQuery {
-	return commands.NewStateQuery(nil, nil, propertyName, nil)
+	return commands.NewStateQuery(nil, nil, commands.TextOutput, propertyName, nil)
 }
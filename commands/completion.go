@@ -0,0 +1,374 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	CompletionCommand = "completion"
+)
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// Usage strings list one flag per line, e.g. "  [--cert]  Path to SSL
+// certificate" or "  --help  [-h]  Print usage". The completion scripts
+// describe the registered flags with those lines.
+var usageFlagPattern = regexp.MustCompile(`^\s+\[?--([a-z0-9-]+)\]?(?:\s+\[-([a-z])\])?\s*(.*)$`)
+
+// flagsDefiner is implemented by the commands that take flags, so that the
+// completion scripts can offer the flags the command registers.
+type flagsDefiner interface {
+	Flags() flags.Flags
+}
+
+type Completion struct {
+	commands    map[string]Command
+	globalFlags flags.Flags
+	stdout      io.Writer
+}
+
+type completionFlag struct {
+	flags.Flag
+	description string
+}
+
+type completionCommand struct {
+	name        string
+	description string
+	flags       []completionFlag
+	arguments   []string
+}
+
+func NewCompletion(commands map[string]Command, globalFlags flags.Flags, stdout io.Writer) Completion {
+	return Completion{
+		commands:    commands,
+		globalFlags: globalFlags,
+		stdout:      stdout,
+	}
+}
+
+func (c Completion) Execute(subcommandFlags []string, state storage.State) error {
+	if len(subcommandFlags) != 1 {
+		return errors.New("completion requires a shell: bash, zsh or fish")
+	}
+
+	globalFlags := completionFlags(c.globalFlags, UsageHeader)
+	completionCommands := c.completionCommands()
+
+	var script string
+	switch subcommandFlags[0] {
+	case "bash":
+		script = bashCompletion(globalFlags, completionCommands)
+	case "zsh":
+		script = zshCompletion(globalFlags, completionCommands)
+	case "fish":
+		script = fishCompletion(globalFlags, completionCommands)
+	default:
+		return fmt.Errorf("cannot generate completion for %q, choose one of bash, zsh or fish", subcommandFlags[0])
+	}
+
+	fmt.Fprint(c.stdout, script)
+	return nil
+}
+
+func (c Completion) completionCommands() []completionCommand {
+	names := []string{}
+	for name := range c.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	completionCommands := []completionCommand{}
+	for _, name := range names {
+		usage := c.commands[name].Usage()
+
+		command := completionCommand{
+			name:        name,
+			description: strings.SplitN(usage, "\n", 2)[0],
+		}
+
+		if definer, ok := c.commands[name].(flagsDefiner); ok {
+			commandFlags := definer.Flags()
+
+			// A command that takes the flags of another command, like plan
+			// does those of up, is described by the usage of that command.
+			if owner, ok := c.commands[commandFlags.Name()]; ok && commandFlags.Name() != name {
+				usage = owner.Usage() + "\n" + usage
+			}
+			command.flags = completionFlags(commandFlags, usage)
+		}
+
+		switch name {
+		case HelpCommand:
+			command.description = UsageCommandUsage
+			command.arguments = names
+		case CompletionCommand:
+			command.arguments = completionShells
+		case ConfigCommand:
+			command.arguments = []string{"show"}
+		}
+
+		completionCommands = append(completionCommands, command)
+	}
+
+	return completionCommands
+}
+
+func completionFlags(registered flags.Flags, usage string) []completionFlag {
+	descriptions := map[string]string{}
+	for _, line := range strings.Split(usage, "\n") {
+		matches := usageFlagPattern.FindStringSubmatch(line)
+		if matches != nil {
+			descriptions[matches[1]] = strings.TrimSpace(matches[3])
+		}
+	}
+
+	completionFlags := []completionFlag{}
+	registered.VisitAll(func(flag flags.Flag) {
+		completionFlags = append(completionFlags, completionFlag{
+			Flag:        flag,
+			description: descriptions[flag.Name],
+		})
+	})
+
+	return completionFlags
+}
+
+func bashCompletion(globalFlags []completionFlag, completionCommands []completionCommand) string {
+	script := bytes.NewBuffer([]byte{})
+
+	fmt.Fprintln(script, "# bash completion for bbl")
+	fmt.Fprintln(script, "#")
+	fmt.Fprintln(script, "# Load it with: source <(bbl completion bash)")
+	fmt.Fprintln(script)
+	fmt.Fprintln(script, "_bbl() {")
+	fmt.Fprintln(script, `  local cur prev command i`)
+	fmt.Fprintln(script, `  cur="${COMP_WORDS[COMP_CWORD]}"`)
+	fmt.Fprintln(script, `  prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(script, `  command=""`)
+	fmt.Fprintln(script)
+	fmt.Fprintln(script, `  for ((i = 1; i < COMP_CWORD; i++)); do`)
+	fmt.Fprintln(script, `    case "${COMP_WORDS[i]}" in`)
+	if valueFlags := bashValueFlagNames(globalFlags); valueFlags != "" {
+		fmt.Fprintf(script, "      %s) ((i++)) ;;\n", valueFlags)
+	}
+	fmt.Fprintln(script, `      -*) ;;`)
+	fmt.Fprintln(script, `      *) command="${COMP_WORDS[i]}"; break ;;`)
+	fmt.Fprintln(script, `    esac`)
+	fmt.Fprintln(script, `  done`)
+	fmt.Fprintln(script)
+	fmt.Fprintln(script, `  case "$command" in`)
+
+	words := []string{}
+	for _, command := range completionCommands {
+		words = append(words, command.name)
+	}
+	bashCommandCase(script, `""`, globalFlags, append(words, flagWords(globalFlags)...))
+
+	for _, command := range completionCommands {
+		bashCommandCase(script, command.name, command.flags, append(command.arguments, flagWords(command.flags)...))
+	}
+
+	fmt.Fprintln(script, `  esac`)
+	fmt.Fprintln(script, "}")
+	fmt.Fprintln(script)
+	fmt.Fprintln(script, "complete -F _bbl bbl")
+
+	return script.String()
+}
+
+func bashCommandCase(script io.Writer, pattern string, flags []completionFlag, words []string) {
+	fmt.Fprintf(script, "    %s)\n", pattern)
+
+	valueCases := []string{}
+	for _, flag := range flags {
+		switch {
+		case flag.Switch:
+			continue
+		case flag.Values != nil:
+			valueCases = append(valueCases, fmt.Sprintf(`--%s) COMPREPLY=($(compgen -W "%s" -- "$cur")); return ;;`, flag.Name, strings.Join(flag.Values, " ")))
+		case flag.Files:
+			valueCases = append(valueCases, fmt.Sprintf(`--%s) compopt -o filenames 2>/dev/null; COMPREPLY=($(compgen -f -- "$cur")); return ;;`, flag.Name))
+		case flag.Directories:
+			valueCases = append(valueCases, fmt.Sprintf(`--%s) compopt -o filenames 2>/dev/null; COMPREPLY=($(compgen -d -- "$cur")); return ;;`, flag.Name))
+		default:
+			valueCases = append(valueCases, fmt.Sprintf(`--%s) return ;;`, flag.Name))
+		}
+	}
+
+	if len(valueCases) > 0 {
+		fmt.Fprintln(script, `      case "$prev" in`)
+		for _, valueCase := range valueCases {
+			fmt.Fprintf(script, "        %s\n", valueCase)
+		}
+		fmt.Fprintln(script, `      esac`)
+	}
+
+	fmt.Fprintf(script, "      COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(words, " "))
+	fmt.Fprintln(script, `      ;;`)
+}
+
+func bashValueFlagNames(flags []completionFlag) string {
+	names := []string{}
+	for _, flag := range flags {
+		if !flag.Switch {
+			names = append(names, "--"+flag.Name)
+		}
+	}
+
+	return strings.Join(names, "|")
+}
+
+func flagWords(flags []completionFlag) []string {
+	words := []string{}
+	for _, flag := range flags {
+		words = append(words, "--"+flag.Name)
+	}
+
+	return words
+}
+
+func zshCompletion(globalFlags []completionFlag, completionCommands []completionCommand) string {
+	script := bytes.NewBuffer([]byte{})
+
+	fmt.Fprintln(script, "#compdef bbl")
+	fmt.Fprintln(script, "#")
+	fmt.Fprintln(script, "# Load it with: source <(bbl completion zsh)")
+	fmt.Fprintln(script)
+	fmt.Fprintln(script, "_bbl() {")
+	fmt.Fprintln(script, "  local context state state_descr line")
+	fmt.Fprintln(script, "  typeset -A opt_args")
+	fmt.Fprintln(script, "  local -a commands")
+	fmt.Fprintln(script, "  commands=(")
+	for _, command := range completionCommands {
+		fmt.Fprintf(script, "    %s\n", bashQuote(command.name+":"+command.description))
+	}
+	fmt.Fprintln(script, "  )")
+	fmt.Fprintln(script)
+	fmt.Fprintln(script, "  _arguments -C \\")
+	for _, flag := range globalFlags {
+		fmt.Fprintf(script, "    %s \\\n", zshFlagSpec(flag))
+	}
+	fmt.Fprintln(script, "    '1: :->command' \\")
+	fmt.Fprintln(script, "    '*:: :->arguments'")
+	fmt.Fprintln(script)
+	fmt.Fprintln(script, "  case $state in")
+	fmt.Fprintln(script, "    command)")
+	fmt.Fprintln(script, "      _describe -t commands 'bbl command' commands")
+	fmt.Fprintln(script, "      ;;")
+	fmt.Fprintln(script, "    arguments)")
+	fmt.Fprintln(script, "      case $words[1] in")
+
+	for _, command := range completionCommands {
+		switch {
+		case command.name == HelpCommand:
+			fmt.Fprintf(script, "        %s)\n", command.name)
+			fmt.Fprintln(script, "          _describe -t commands 'bbl command' commands")
+			fmt.Fprintln(script, "          ;;")
+		case len(command.arguments) > 0:
+			fmt.Fprintf(script, "        %s)\n", command.name)
+			fmt.Fprintf(script, "          _values %s %s\n", bashQuote(command.name), strings.Join(command.arguments, " "))
+			fmt.Fprintln(script, "          ;;")
+		case len(command.flags) > 0:
+			fmt.Fprintf(script, "        %s)\n", command.name)
+			fmt.Fprint(script, "          _arguments")
+			for _, flag := range command.flags {
+				fmt.Fprintf(script, " \\\n            %s", zshFlagSpec(flag))
+			}
+			fmt.Fprintln(script)
+			fmt.Fprintln(script, "          ;;")
+		}
+	}
+
+	fmt.Fprintln(script, "      esac")
+	fmt.Fprintln(script, "      ;;")
+	fmt.Fprintln(script, "  esac")
+	fmt.Fprintln(script, "}")
+	fmt.Fprintln(script)
+	fmt.Fprintln(script, `if [ "$funcstack[1]" = "_bbl" ]; then`)
+	fmt.Fprintln(script, `  _bbl "$@"`)
+	fmt.Fprintln(script, "else")
+	fmt.Fprintln(script, "  compdef _bbl bbl")
+	fmt.Fprintln(script, "fi")
+
+	return script.String()
+}
+
+func zshFlagSpec(flag completionFlag) string {
+	description := strings.NewReplacer("[", `\[`, "]", `\]`, ":", `\:`).Replace(flag.description)
+
+	switch {
+	case flag.Switch:
+		return bashQuote(fmt.Sprintf("--%s[%s]", flag.Name, description))
+	case flag.Values != nil:
+		return bashQuote(fmt.Sprintf("--%s=[%s]:%s:(%s)", flag.Name, description, flag.Name, strings.Join(flag.Values, " ")))
+	case flag.Files:
+		return bashQuote(fmt.Sprintf("--%s=[%s]:file:_files", flag.Name, description))
+	case flag.Directories:
+		return bashQuote(fmt.Sprintf("--%s=[%s]:directory:_files -/", flag.Name, description))
+	default:
+		return bashQuote(fmt.Sprintf("--%s=[%s]: :", flag.Name, description))
+	}
+}
+
+func fishCompletion(globalFlags []completionFlag, completionCommands []completionCommand) string {
+	script := bytes.NewBuffer([]byte{})
+
+	fmt.Fprintln(script, "# fish completion for bbl")
+	fmt.Fprintln(script, "#")
+	fmt.Fprintln(script, "# Load it with: bbl completion fish | source")
+	fmt.Fprintln(script)
+	fmt.Fprintln(script, "complete -c bbl -f")
+
+	for _, command := range completionCommands {
+		fmt.Fprintf(script, "complete -c bbl -n '__fish_use_subcommand' -a %s -d %s\n", command.name, fishQuote(command.description))
+	}
+
+	for _, flag := range globalFlags {
+		fmt.Fprintf(script, "complete -c bbl -n '__fish_use_subcommand' %s\n", fishFlagSpec(flag))
+	}
+
+	for _, command := range completionCommands {
+		condition := fishQuote("__fish_seen_subcommand_from " + command.name)
+
+		if len(command.arguments) > 0 {
+			fmt.Fprintf(script, "complete -c bbl -n %s -a %s\n", condition, fishQuote(strings.Join(command.arguments, " ")))
+		}
+
+		for _, flag := range command.flags {
+			fmt.Fprintf(script, "complete -c bbl -n %s %s\n", condition, fishFlagSpec(flag))
+		}
+	}
+
+	return script.String()
+}
+
+func fishFlagSpec(flag completionFlag) string {
+	spec := "-l " + flag.Name
+	if flag.Short != "" {
+		spec += " -s " + flag.Short
+	}
+
+	switch {
+	case flag.Switch:
+	case flag.Values != nil:
+		spec += " -x -a " + fishQuote(strings.Join(flag.Values, " "))
+	case flag.Files:
+		spec += " -r -F"
+	case flag.Directories:
+		spec += " -x -a '(__fish_complete_directories)'"
+	default:
+		spec += " -x"
+	}
+
+	return spec + " -d " + fishQuote(flag.description)
+}
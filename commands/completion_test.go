@@ -0,0 +1,158 @@
+package commands_test
+
+import (
+	"bytes"
+
+	"github.com/cloudfoundry/bosh-bootloader/application"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Completion", func() {
+	var (
+		stdout  *bytes.Buffer
+		command commands.Completion
+	)
+
+	BeforeEach(func() {
+		stdout = bytes.NewBuffer([]byte{})
+
+		command = commands.NewCompletion(map[string]commands.Command{
+			commands.HelpCommand:        commands.Usage{},
+			commands.CompletionCommand:  commands.Completion{},
+			commands.UpCommand:          commands.Up{},
+			commands.PlanCommand:        commands.Plan{},
+			commands.CreateLBsCommand:   commands.CreateLBs{},
+			commands.ExportStateCommand: commands.ExportState{},
+			commands.LBsCommand:         commands.LBs{},
+		}, application.GlobalFlags(), stdout)
+	})
+
+	Describe("Execute", func() {
+		Context("bash", func() {
+			var script string
+
+			BeforeEach(func() {
+				err := command.Execute([]string{"bash"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				script = stdout.String()
+			})
+
+			It("registers a completion function for bbl", func() {
+				Expect(script).To(HavePrefix("# bash completion for bbl\n"))
+				Expect(script).To(HaveSuffix("complete -F _bbl bbl\n"))
+			})
+
+			It("completes the commands and global flags before a command", func() {
+				Expect(script).To(ContainSubstring(`COMPREPLY=($(compgen -W "completion create-lbs export-state help lbs plan up --config --debug --endpoint-override --env --help`))
+				Expect(script).To(ContainSubstring(`--output) COMPREPLY=($(compgen -W "text json yaml" -- "$cur")); return ;;`))
+				Expect(script).To(ContainSubstring(`--state-dir) compopt -o filenames 2>/dev/null; COMPREPLY=($(compgen -d -- "$cur")); return ;;`))
+			})
+
+			It("skips the values of global flags when looking for the command", func() {
+				Expect(script).To(ContainSubstring("      --config|--endpoint-override|--env|--lock-timeout|--output|--state-backend|--state-dir|--state-encryption-key-file|--vars-store) ((i++)) ;;\n"))
+			})
+
+			It("completes the values of enumerated flags and paths", func() {
+				Expect(script).To(ContainSubstring(`--iaas) COMPREPLY=($(compgen -W "aws gcp" -- "$cur")); return ;;`))
+				Expect(script).To(ContainSubstring(`--type) COMPREPLY=($(compgen -W "cf concourse" -- "$cur")); return ;;`))
+				Expect(script).To(ContainSubstring(`--cert) compopt -o filenames 2>/dev/null; COMPREPLY=($(compgen -f -- "$cur")); return ;;`))
+				Expect(script).To(ContainSubstring(`--name) return ;;`))
+			})
+
+			It("completes the path of export-state --output", func() {
+				Expect(script).To(ContainSubstring(`    export-state)
+      case "$prev" in
+        --output) compopt -o filenames 2>/dev/null; COMPREPLY=($(compgen -f -- "$cur")); return ;;
+      esac
+      COMPREPLY=($(compgen -W "--output --redact" -- "$cur"))
+`))
+			})
+
+			It("completes every flag a command registers", func() {
+				Expect(script).To(ContainSubstring(`COMPREPLY=($(compgen -W "--cert --chain --domain --key --skip-if-exists --type" -- "$cur"))`))
+			})
+
+			It("completes the flags of up for plan", func() {
+				Expect(script).To(ContainSubstring("    plan)\n      case \"$prev\" in\n        --aws-access-key-id) return ;;\n"))
+				Expect(script).To(ContainSubstring(`        --director-ops-file) compopt -o filenames 2>/dev/null; COMPREPLY=($(compgen -f -- "$cur")); return ;;`))
+			})
+
+			It("completes command names for help and shells for completion", func() {
+				Expect(script).To(ContainSubstring(`COMPREPLY=($(compgen -W "completion create-lbs export-state help lbs plan up" -- "$cur"))`))
+				Expect(script).To(ContainSubstring(`COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur"))`))
+			})
+		})
+
+		Context("zsh", func() {
+			var script string
+
+			BeforeEach(func() {
+				err := command.Execute([]string{"zsh"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				script = stdout.String()
+			})
+
+			It("defines a completion function for bbl", func() {
+				Expect(script).To(HavePrefix("#compdef bbl\n"))
+				Expect(script).To(ContainSubstring("  compdef _bbl bbl\n"))
+			})
+
+			It("describes the commands", func() {
+				Expect(script).To(ContainSubstring("    'up:Deploys BOSH director on an IAAS'\n"))
+				Expect(script).To(ContainSubstring("    'help:Prints helpful message for the given command'\n"))
+			})
+
+			It("completes the flags of each command", func() {
+				Expect(script).To(ContainSubstring(`'--iaas=[IAAS to deploy your BOSH Director onto. Valid options\: "gcp", "aws"]:iaas:(aws gcp)'`))
+				Expect(script).To(ContainSubstring(`'--cert=[Path to SSL certificate (required when type="cf")]:file:_files'`))
+				Expect(script).To(ContainSubstring(`'--skip-if-exists[Skips creating load balancer(s) if it is already attached (optional)]'`))
+				Expect(script).To(ContainSubstring(`'--state-dir=[Directory containing bbl-state.json]:directory:_files -/'`))
+				Expect(script).To(ContainSubstring(`'--name=[Name to assign to your BOSH Director (optional, will be randomly generated)]: :'`))
+				Expect(script).To(ContainSubstring(`_values 'completion' bash zsh fish`))
+			})
+		})
+
+		Context("fish", func() {
+			var script string
+
+			BeforeEach(func() {
+				err := command.Execute([]string{"fish"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				script = stdout.String()
+			})
+
+			It("completes the commands and global flags before a command", func() {
+				Expect(script).To(HavePrefix("# fish completion for bbl\n"))
+				Expect(script).To(ContainSubstring("complete -c bbl -n '__fish_use_subcommand' -a up -d 'Deploys BOSH director on an IAAS'\n"))
+				Expect(script).To(ContainSubstring("complete -c bbl -n '__fish_use_subcommand' -l help -s h -d 'Print usage'\n"))
+				Expect(script).To(ContainSubstring("complete -c bbl -n '__fish_use_subcommand' -l output -x -a 'text json yaml' -d "))
+			})
+
+			It("completes the flags of each command", func() {
+				Expect(script).To(ContainSubstring("complete -c bbl -n '__fish_seen_subcommand_from up' -l iaas -x -a 'aws gcp' -d "))
+				Expect(script).To(ContainSubstring("complete -c bbl -n '__fish_seen_subcommand_from create-lbs' -l key -r -F -d 'Path to SSL certificate key (required when type=\"cf\")'\n"))
+				Expect(script).To(ContainSubstring("complete -c bbl -n '__fish_seen_subcommand_from export-state' -l redact -d 'Replaces every secret with a placeholder'\n"))
+				Expect(script).To(ContainSubstring("complete -c bbl -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish'\n"))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when no shell is given", func() {
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("completion requires a shell: bash, zsh or fish"))
+			})
+
+			It("returns an error when the shell is not supported", func() {
+				err := command.Execute([]string{"powershell"}, storage.State{})
+				Expect(err).To(MatchError(`cannot generate completion for "powershell", choose one of bash, zsh or fish`))
+			})
+		})
+	})
+})
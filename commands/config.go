@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	ConfigCommand = "config"
+
+	ConfigFileName = "bbl.yml"
+)
+
+// ConfigFile holds the options of up and create-lbs read from bbl.yml. Its
+// values sit below the flags given on the command line and above the BBL_
+// environment variables.
+type ConfigFile struct {
+	IAAS string        `yaml:"iaas,omitempty"`
+	Name string        `yaml:"name,omitempty"`
+	AWS  ConfigFileAWS `yaml:"aws,omitempty"`
+	GCP  ConfigFileGCP `yaml:"gcp,omitempty"`
+	LB   ConfigFileLB  `yaml:"lb,omitempty"`
+}
+
+type ConfigFileAWS struct {
+	AccessKeyID     string `yaml:"access-key-id,omitempty"`
+	SecretAccessKey string `yaml:"secret-access-key,omitempty"`
+	Region          string `yaml:"region,omitempty"`
+}
+
+type ConfigFileGCP struct {
+	ServiceAccountKey string `yaml:"service-account-key,omitempty"`
+	ProjectID         string `yaml:"project-id,omitempty"`
+	Zone              string `yaml:"zone,omitempty"`
+	Region            string `yaml:"region,omitempty"`
+}
+
+type ConfigFileLB struct {
+	Type   string `yaml:"type,omitempty"`
+	Cert   string `yaml:"cert,omitempty"`
+	Key    string `yaml:"key,omitempty"`
+	Chain  string `yaml:"chain,omitempty"`
+	Domain string `yaml:"domain,omitempty"`
+}
+
+type configOption struct {
+	command string
+	flag    string
+	secret  bool
+	value   func(ConfigFile) string
+}
+
+var configOptions = []configOption{
+	{command: UpCommand, flag: "iaas", value: func(c ConfigFile) string { return c.IAAS }},
+	{command: UpCommand, flag: "name", value: func(c ConfigFile) string { return c.Name }},
+	{command: UpCommand, flag: "aws-access-key-id", value: func(c ConfigFile) string { return c.AWS.AccessKeyID }},
+	{command: UpCommand, flag: "aws-secret-access-key", secret: true, value: func(c ConfigFile) string { return c.AWS.SecretAccessKey }},
+	{command: UpCommand, flag: "aws-region", value: func(c ConfigFile) string { return c.AWS.Region }},
+	{command: UpCommand, flag: "gcp-service-account-key", secret: true, value: func(c ConfigFile) string { return c.GCP.ServiceAccountKey }},
+	{command: UpCommand, flag: "gcp-project-id", value: func(c ConfigFile) string { return c.GCP.ProjectID }},
+	{command: UpCommand, flag: "gcp-zone", value: func(c ConfigFile) string { return c.GCP.Zone }},
+	{command: UpCommand, flag: "gcp-region", value: func(c ConfigFile) string { return c.GCP.Region }},
+	{command: CreateLBsCommand, flag: "type", value: func(c ConfigFile) string { return c.LB.Type }},
+	{command: CreateLBsCommand, flag: "cert", value: func(c ConfigFile) string { return c.LB.Cert }},
+	{command: CreateLBsCommand, flag: "key", value: func(c ConfigFile) string { return c.LB.Key }},
+	{command: CreateLBsCommand, flag: "chain", value: func(c ConfigFile) string { return c.LB.Chain }},
+	{command: CreateLBsCommand, flag: "domain", value: func(c ConfigFile) string { return c.LB.Domain }},
+}
+
+// Flags returns the options for command set in the config file as flags. They
+// go before the flags given on the command line, which the flags package then
+// lets win.
+func (c ConfigFile) Flags(command string) []string {
+	if command == PlanCommand {
+		command = UpCommand
+	}
+
+	configFlags := []string{}
+	for _, option := range configOptions {
+		if option.command == command && option.value(c) != "" {
+			configFlags = append(configFlags, fmt.Sprintf("--%s=%s", option.flag, option.value(c)))
+		}
+	}
+
+	return configFlags
+}
+
+type Config struct {
+	configFile ConfigFile
+	configPath string
+	envGetter  envGetter
+	stdout     io.Writer
+}
+
+func NewConfig(configFile ConfigFile, configPath string, envGetter envGetter, stdout io.Writer) Config {
+	return Config{
+		configFile: configFile,
+		configPath: configPath,
+		envGetter:  envGetter,
+		stdout:     stdout,
+	}
+}
+
+func (c Config) Execute(subcommandFlags []string, state storage.State) error {
+	if len(subcommandFlags) == 0 || subcommandFlags[0] != "show" {
+		return errors.New("config requires a subcommand: show")
+	}
+
+	flagValues, err := c.parseFlags(subcommandFlags[1:])
+	if err != nil {
+		return err
+	}
+
+	if c.configPath == "" {
+		fmt.Fprintf(c.stdout, "%s: not found\n", ConfigFileName)
+	} else {
+		fmt.Fprintf(c.stdout, "%s: %s\n", ConfigFileName, c.configPath)
+	}
+
+	command := ""
+	for _, option := range configOptions {
+		if option.command != command {
+			command = option.command
+			fmt.Fprintf(c.stdout, "\n%s\n", command)
+		}
+
+		value, source := c.effectiveValue(option, flagValues[option.flag])
+		if option.secret && value != "" {
+			value = "<redacted>"
+		}
+
+		line := fmt.Sprintf("  %-24s  %-32s  %s", option.flag, source, value)
+		fmt.Fprintln(c.stdout, strings.TrimRight(line, " "))
+	}
+
+	return nil
+}
+
+func (Config) Flags() flags.Flags {
+	return configShowFlags(map[string]*string{})
+}
+
+func (c Config) parseFlags(subcommandFlags []string) (map[string]string, error) {
+	values := map[string]*string{}
+
+	err := configShowFlags(values).Parse(subcommandFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	flagValues := map[string]string{}
+	for flag, value := range values {
+		flagValues[flag] = *value
+	}
+
+	return flagValues, nil
+}
+
+func (c Config) effectiveValue(option configOption, flagValue string) (string, string) {
+	if flagValue != "" {
+		return flagValue, "flag --" + option.flag
+	}
+
+	if value := option.value(c.configFile); value != "" {
+		return value, ConfigFileName
+	}
+
+	if envName := flags.EnvName(option.command, option.flag); envName != "" {
+		if value := c.envGetter.Get(envName); value != "" {
+			return value, "env " + envName
+		}
+	}
+
+	return "", "unset"
+}
+
+// configShowFlags registers the options of config show, adding the value of
+// each to values.
+func configShowFlags(values map[string]*string) flags.Flags {
+	showFlags := flags.New("config show")
+
+	for _, option := range configOptions {
+		values[option.flag] = new(string)
+		showFlags.String(values[option.flag], option.flag, "")
+	}
+
+	return showFlags
+}
@@ -0,0 +1,129 @@
+package commands_test
+
+import (
+	"bytes"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config", func() {
+	var (
+		envGetter  *fakes.EnvGetter
+		stdout     *bytes.Buffer
+		configFile commands.ConfigFile
+		command    commands.Config
+	)
+
+	BeforeEach(func() {
+		envGetter = &fakes.EnvGetter{
+			Values: map[string]string{
+				"BBL_IAAS":                  "aws",
+				"BBL_AWS_REGION":            "some-env-region",
+				"BBL_AWS_SECRET_ACCESS_KEY": "some-secret-access-key",
+				"BBL_LB_DOMAIN":             "some-env-domain",
+			},
+		}
+		stdout = bytes.NewBuffer([]byte{})
+
+		configFile = commands.ConfigFile{
+			IAAS: "gcp",
+			GCP: commands.ConfigFileGCP{
+				ProjectID: "some-project-id",
+				Zone:      "some-zone",
+			},
+			LB: commands.ConfigFileLB{
+				Type: "cf",
+			},
+		}
+
+		command = commands.NewConfig(configFile, "/some/state/dir/bbl.yml", envGetter, stdout)
+	})
+
+	Describe("Execute", func() {
+		It("prints the effective options of up and create-lbs and where they came from", func() {
+			configFile.GCP.ServiceAccountKey = "some-service-account-key"
+			command = commands.NewConfig(configFile, "/some/state/dir/bbl.yml", envGetter, stdout)
+
+			err := command.Execute([]string{"show", "--gcp-zone", "some-other-zone"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stdout.String()).To(Equal(`bbl.yml: /some/state/dir/bbl.yml
+
+up
+  iaas                      bbl.yml                           gcp
+  name                      unset
+  aws-access-key-id         unset
+  aws-secret-access-key     env BBL_AWS_SECRET_ACCESS_KEY     <redacted>
+  aws-region                env BBL_AWS_REGION                some-env-region
+  gcp-service-account-key   bbl.yml                           <redacted>
+  gcp-project-id            bbl.yml                           some-project-id
+  gcp-zone                  flag --gcp-zone                   some-other-zone
+  gcp-region                unset
+
+create-lbs
+  type                      bbl.yml                           cf
+  cert                      unset
+  key                       unset
+  chain                     unset
+  domain                    env BBL_LB_DOMAIN                 some-env-domain
+`))
+			Expect(stdout.String()).NotTo(ContainSubstring("some-service-account-key"))
+		})
+
+		It("notes when there is no bbl.yml", func() {
+			command = commands.NewConfig(commands.ConfigFile{}, "", envGetter, stdout)
+
+			err := command.Execute([]string{"show"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stdout.String()).To(HavePrefix("bbl.yml: not found\n"))
+			Expect(stdout.String()).To(ContainSubstring("  iaas                      env BBL_IAAS                      aws\n"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when no subcommand is given", func() {
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("config requires a subcommand: show"))
+			})
+
+			It("returns an error when the subcommand is unknown", func() {
+				err := command.Execute([]string{"edit"}, storage.State{})
+				Expect(err).To(MatchError("config requires a subcommand: show"))
+			})
+
+			It("returns an error when the flags cannot be parsed", func() {
+				err := command.Execute([]string{"show", "--unknown-flag"}, storage.State{})
+				Expect(err).To(MatchError(ContainSubstring("flag provided but not defined: -unknown-flag")))
+			})
+		})
+	})
+
+	Describe("ConfigFile", func() {
+		Describe("Flags", func() {
+			It("returns the up options as flags", func() {
+				Expect(configFile.Flags("up")).To(Equal([]string{
+					"--iaas=gcp",
+					"--gcp-project-id=some-project-id",
+					"--gcp-zone=some-zone",
+				}))
+			})
+
+			It("returns the up options as flags for plan", func() {
+				Expect(configFile.Flags("plan")).To(Equal(configFile.Flags("up")))
+			})
+
+			It("returns the load balancer options as flags for create-lbs", func() {
+				Expect(configFile.Flags("create-lbs")).To(Equal([]string{"--type=cf"}))
+			})
+
+			It("returns no flags for other commands", func() {
+				Expect(configFile.Flags("destroy")).To(BeEmpty())
+			})
+		})
+	})
+})
@@ -74,10 +74,24 @@ func (c CreateLBs) Execute(args []string, state storage.State) error {
 	return nil
 }
 
+func (CreateLBs) Flags() flags.Flags {
+	return createLBsFlags(&lbConfig{})
+}
+
 func (CreateLBs) parseFlags(subcommandFlags []string) (lbConfig, error) {
+	config := lbConfig{}
+	lbFlags := createLBsFlags(&config)
+
+	if err := lbFlags.Parse(subcommandFlags); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+func createLBsFlags(config *lbConfig) flags.Flags {
 	lbFlags := flags.New("create-lbs")
 
-	config := lbConfig{}
 	lbFlags.String(&config.lbType, "type", "")
 	lbFlags.String(&config.certPath, "cert", "")
 	lbFlags.String(&config.keyPath, "key", "")
@@ -85,9 +99,8 @@ func (CreateLBs) parseFlags(subcommandFlags []string) (lbConfig, error) {
 	lbFlags.String(&config.domain, "domain", "")
 	lbFlags.Bool(&config.skipIfExists, "skip-if-exists", "", false)
 
-	if err := lbFlags.Parse(subcommandFlags); err != nil {
-		return config, err
-	}
+	lbFlags.Values("type", "cf", "concourse")
+	lbFlags.Files("cert", "key", "chain")
 
-	return config, nil
+	return lbFlags
 }
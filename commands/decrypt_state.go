@@ -0,0 +1,36 @@
+package commands
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+const (
+	DecryptStateCommand = "decrypt-state"
+)
+
+type DecryptState struct {
+	logger              logger
+	stateValidator      stateValidator
+	plaintextStateStore stateStore
+}
+
+func NewDecryptState(logger logger, stateValidator stateValidator, plaintextStateStore stateStore) DecryptState {
+	return DecryptState{
+		logger:              logger,
+		stateValidator:      stateValidator,
+		plaintextStateStore: plaintextStateStore,
+	}
+}
+
+func (d DecryptState) Execute(subcommandFlags []string, state storage.State) error {
+	err := d.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	d.logger.Step("decrypting bbl-state.json")
+	err = d.plaintextStateStore.Set(state)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
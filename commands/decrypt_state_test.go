@@ -0,0 +1,63 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DecryptState", func() {
+	var (
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+		stateStore     *fakes.StateStore
+		command        commands.DecryptState
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		stateStore = &fakes.StateStore{}
+
+		command = commands.NewDecryptState(logger, stateValidator, stateStore)
+	})
+
+	Describe("Execute", func() {
+		It("writes the state through the plaintext state store", func() {
+			state := storage.State{
+				IAAS:  "gcp",
+				EnvID: "some-env-id",
+			}
+
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
+			Expect(stateStore.SetCall.CallCount).To(Equal(1))
+			Expect(stateStore.SetCall.Receives.State).To(Equal(state))
+			Expect(logger.StepCall.Messages).To(Equal([]string{"decrypting bbl-state.json"}))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the state validator fails", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("state validator failed"))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the state cannot be written", func() {
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("failed to set state")}}
+
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("failed to set state"))
+			})
+		})
+	})
+})
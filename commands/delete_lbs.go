@@ -60,11 +60,13 @@ func (d DeleteLBs) Execute(subcommandFlags []string, state storage.State) error
 	return nil
 }
 
-func (DeleteLBs) parseFlags(subcommandFlags []string) (deleteLBsConfig, error) {
-	lbFlags := flags.New("delete-lbs")
+func (DeleteLBs) Flags() flags.Flags {
+	return deleteLBsFlags(&deleteLBsConfig{})
+}
 
+func (DeleteLBs) parseFlags(subcommandFlags []string) (deleteLBsConfig, error) {
 	config := deleteLBsConfig{}
-	lbFlags.Bool(&config.skipIfMissing, "skip-if-missing", "", false)
+	lbFlags := deleteLBsFlags(&config)
 
 	err := lbFlags.Parse(subcommandFlags)
 	if err != nil {
@@ -73,3 +75,10 @@ func (DeleteLBs) parseFlags(subcommandFlags []string) (deleteLBsConfig, error) {
 
 	return config, nil
 }
+
+func deleteLBsFlags(config *deleteLBsConfig) flags.Flags {
+	lbFlags := flags.New("delete-lbs")
+	lbFlags.Bool(&config.skipIfMissing, "skip-if-missing", "", false)
+
+	return lbFlags
+}
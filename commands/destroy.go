@@ -197,7 +197,7 @@ func (d Destroy) Execute(subcommandFlags []string, state storage.State) error {
 
 	if state.IAAS == "gcp" {
 		state.TFState, err = d.terraformExecutor.Destroy(state.GCP.ServiceAccountKey, state.EnvID, state.GCP.ProjectID, state.GCP.Zone,
-			state.GCP.Region, terraformVarsTemplate, state.TFState)
+			state.GCP.Region, terraformVarsTemplate, state.TerraformOverrides, state.TFState)
 		if err != nil {
 			if setErr := d.stateStore.Set(state); setErr != nil {
 				errorList := helpers.Errors{}
@@ -251,14 +251,14 @@ func (d Destroy) Execute(subcommandFlags []string, state storage.State) error {
 	return nil
 }
 
-func (d Destroy) parseFlags(subcommandFlags []string) (destroyConfig, error) {
-	destroyFlags := flags.New("destroy")
+func (Destroy) Flags() flags.Flags {
+	return destroyFlags(&destroyConfig{})
+}
 
+func (d Destroy) parseFlags(subcommandFlags []string) (destroyConfig, error) {
 	config := destroyConfig{}
-	destroyFlags.Bool(&config.NoConfirm, "n", "no-confirm", false)
-	destroyFlags.Bool(&config.SkipIfMissing, "", "skip-if-missing", false)
 
-	err := destroyFlags.Parse(subcommandFlags)
+	err := destroyFlags(&config).Parse(subcommandFlags)
 	if err != nil {
 		return config, err
 	}
@@ -266,6 +266,14 @@ func (d Destroy) parseFlags(subcommandFlags []string) (destroyConfig, error) {
 	return config, nil
 }
 
+func destroyFlags(config *destroyConfig) flags.Flags {
+	destroyFlagSet := flags.New("destroy")
+	destroyFlagSet.Bool(&config.NoConfirm, "n", "no-confirm", false)
+	destroyFlagSet.Bool(&config.SkipIfMissing, "", "skip-if-missing", false)
+
+	return destroyFlagSet
+}
+
 func (d Destroy) deleteBOSH(state storage.State) (storage.State, error) {
 	emptyBOSH := storage.BOSH{}
 	if reflect.DeepEqual(state.BOSH, emptyBOSH) {
@@ -537,6 +537,9 @@ var _ = Describe("Destroy", func() {
 						Region:            "some-region",
 					},
 					TFState: "some-tf-state",
+					TerraformOverrides: []storage.TerraformOverride{
+						{Name: "buckets.tf", Contents: "some-contents"},
+					},
 				})
 				Expect(err).NotTo(HaveOccurred())
 
@@ -547,6 +550,9 @@ var _ = Describe("Destroy", func() {
 				Expect(terraformExecutor.DestroyCall.Receives.Zone).To(Equal("some-zone"))
 				Expect(terraformExecutor.DestroyCall.Receives.Region).To(Equal("some-region"))
 				Expect(terraformExecutor.DestroyCall.Receives.TFState).To(Equal("some-tf-state"))
+				Expect(terraformExecutor.DestroyCall.Receives.Overrides).To(Equal([]storage.TerraformOverride{
+					{Name: "buckets.tf", Contents: "some-contents"},
+				}))
 				Expect(terraformExecutor.DestroyCall.Receives.Template).To(ContainSubstring(`variable "project_id"`))
 
 				Expect(terraformExecutor.DestroyCall.Returns.TFState).To(Equal(""))
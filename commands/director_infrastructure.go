@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/boshinit"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// directorInfrastructure reads the infrastructure configuration of an
+// existing director back from the cloudformation stack or terraform state,
+// so commands other than up can redeploy it.
+type directorInfrastructure struct {
+	credentialValidator   credentialValidator
+	infrastructureManager infrastructureManager
+	terraformOutputter    terraformOutputter
+}
+
+func newDirectorInfrastructure(credentialValidator credentialValidator, infrastructureManager infrastructureManager,
+	terraformOutputter terraformOutputter) directorInfrastructure {
+	return directorInfrastructure{
+		credentialValidator:   credentialValidator,
+		infrastructureManager: infrastructureManager,
+		terraformOutputter:    terraformOutputter,
+	}
+}
+
+func (d directorInfrastructure) configuration(state storage.State) (boshinit.InfrastructureConfiguration, error) {
+	switch state.IAAS {
+	case "aws":
+		return d.awsConfiguration(state)
+	case "gcp":
+		return d.gcpConfiguration(state)
+	default:
+		return boshinit.InfrastructureConfiguration{}, fmt.Errorf("cannot redeploy the director on iaas %q", state.IAAS)
+	}
+}
+
+func (d directorInfrastructure) awsConfiguration(state storage.State) (boshinit.InfrastructureConfiguration, error) {
+	err := d.credentialValidator.ValidateAWS()
+	if err != nil {
+		return boshinit.InfrastructureConfiguration{}, err
+	}
+
+	stack, err := d.infrastructureManager.Describe(state.Stack.Name)
+	if err != nil {
+		return boshinit.InfrastructureConfiguration{}, err
+	}
+
+	return boshinit.InfrastructureConfiguration{
+		ExternalIP: stack.Outputs["BOSHEIP"],
+		AWS: boshinit.InfrastructureConfigurationAWS{
+			AWSRegion:        state.AWS.Region,
+			SubnetID:         stack.Outputs["BOSHSubnet"],
+			AvailabilityZone: stack.Outputs["BOSHSubnetAZ"],
+			AccessKeyID:      stack.Outputs["BOSHUserAccessKey"],
+			SecretAccessKey:  stack.Outputs["BOSHUserSecretAccessKey"],
+			SecurityGroup:    stack.Outputs["BOSHSecurityGroup"],
+		},
+	}, nil
+}
+
+func (d directorInfrastructure) gcpConfiguration(state storage.State) (boshinit.InfrastructureConfiguration, error) {
+	err := d.credentialValidator.ValidateGCP()
+	if err != nil {
+		return boshinit.InfrastructureConfiguration{}, err
+	}
+
+	outputs := map[string]string{}
+	for _, name := range []string{"external_ip", "network_name", "subnetwork_name", "bosh_open_tag_name", "internal_tag_name"} {
+		output, err := d.terraformOutputter.Get(state.TFState, name)
+		if err != nil {
+			return boshinit.InfrastructureConfiguration{}, err
+		}
+		outputs[name] = output
+	}
+
+	return boshinit.InfrastructureConfiguration{
+		ExternalIP: outputs["external_ip"],
+		GCP: boshinit.InfrastructureConfigurationGCP{
+			Zone:           state.GCP.Zone,
+			NetworkName:    outputs["network_name"],
+			SubnetworkName: outputs["subnetwork_name"],
+			BOSHTag:        outputs["bosh_open_tag_name"],
+			InternalTag:    outputs["internal_tag_name"],
+			Project:        state.GCP.ProjectID,
+			JsonKey:        state.GCP.ServiceAccountKey,
+		},
+	}, nil
+}
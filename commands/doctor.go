@@ -0,0 +1,302 @@
+package commands
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	DoctorCommand = "doctor"
+
+	doctorPass = "pass"
+	doctorWarn = "warn"
+	doctorFail = "fail"
+
+	certificateExpiryWarning = 30 * 24 * time.Hour
+)
+
+var doctorNow = time.Now
+
+type Doctor struct {
+	stateValidator        stateValidator
+	credentialValidator   credentialValidator
+	infrastructureManager infrastructureManager
+	terraformOutputter    terraformOutputter
+	boshClientProvider    boshClientProvider
+	stdout                io.Writer
+}
+
+type doctorCheck struct {
+	name    string
+	status  string
+	message string
+	hint    string
+}
+
+func NewDoctor(stateValidator stateValidator, credentialValidator credentialValidator, infrastructureManager infrastructureManager,
+	terraformOutputter terraformOutputter, boshClientProvider boshClientProvider, stdout io.Writer) Doctor {
+	return Doctor{
+		stateValidator:        stateValidator,
+		credentialValidator:   credentialValidator,
+		infrastructureManager: infrastructureManager,
+		terraformOutputter:    terraformOutputter,
+		boshClientProvider:    boshClientProvider,
+		stdout:                stdout,
+	}
+}
+
+func (d Doctor) Execute(subcommandFlags []string, state storage.State) error {
+	checks := []doctorCheck{d.checkState()}
+
+	// Every other check reads the state, so there is nothing more to report
+	// without it.
+	if checks[0].status == doctorPass {
+		credentials := d.checkCredentials(state)
+		checks = append(checks, credentials)
+
+		if credentials.status == doctorPass {
+			checks = append(checks, d.checkInfrastructure(state))
+		}
+
+		checks = append(checks, d.checkDirector(state))
+		if !state.BOSH.IsEmpty() {
+			checks = append(checks, d.checkCertificate("director certificate", state.BOSH.DirectorSSLCertificate))
+			checks = append(checks, d.checkCertificate("director CA", state.BOSH.DirectorSSLCA))
+		}
+		checks = append(checks, d.checkSSHKey(state))
+	}
+
+	failures := 0
+	for _, check := range checks {
+		fmt.Fprintf(d.stdout, "[%s] %s: %s\n", check.status, check.name, check.message)
+		if check.hint != "" {
+			fmt.Fprintf(d.stdout, "       hint: %s\n", check.hint)
+		}
+
+		if check.status == doctorFail {
+			failures++
+		}
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("bbl doctor found %d failure(s)", failures)
+	}
+
+	return nil
+}
+
+func (d Doctor) checkState() doctorCheck {
+	check := doctorCheck{name: "state"}
+
+	err := d.stateValidator.Validate()
+	if err != nil {
+		check.status = doctorFail
+		check.message = err.Error()
+		check.hint = "run bbl doctor from the state directory of the environment or pass --state-dir"
+		return check
+	}
+
+	check.status = doctorPass
+	check.message = "bbl-state.json found"
+	return check
+}
+
+func (d Doctor) checkCredentials(state storage.State) doctorCheck {
+	check := doctorCheck{name: "credentials"}
+
+	var err error
+	switch state.IAAS {
+	case "aws":
+		err = d.credentialValidator.ValidateAWS()
+		check.hint = "pass --aws-access-key-id, --aws-secret-access-key and --aws-region to bbl up to store them in bbl-state.json"
+	case "gcp":
+		err = d.credentialValidator.ValidateGCP()
+		check.hint = "pass --gcp-service-account-key, --gcp-project-id, --gcp-zone and --gcp-region to bbl up to store them in bbl-state.json"
+	default:
+		check.status = doctorFail
+		check.message = fmt.Sprintf("unknown iaas %q", state.IAAS)
+		check.hint = "run bbl migrate-state or restore bbl-state.json with bbl state-rollback"
+		return check
+	}
+
+	if err != nil {
+		check.status = doctorFail
+		check.message = err.Error()
+		return check
+	}
+
+	check.status = doctorPass
+	check.message = fmt.Sprintf("%s credentials are present", strings.ToUpper(state.IAAS))
+	check.hint = ""
+	return check
+}
+
+func (d Doctor) checkInfrastructure(state storage.State) doctorCheck {
+	switch state.IAAS {
+	case "aws":
+		return d.checkStack(state)
+	default:
+		return d.checkTerraform(state)
+	}
+}
+
+func (d Doctor) checkStack(state storage.State) doctorCheck {
+	check := doctorCheck{name: "cloudformation stack"}
+
+	if state.Stack.Name == "" {
+		check.status = doctorFail
+		check.message = "bbl-state.json has no stack name"
+		check.hint = "run bbl up to create the stack"
+		return check
+	}
+
+	stack, err := d.infrastructureManager.Describe(state.Stack.Name)
+	if err != nil {
+		check.status = doctorFail
+		check.message = fmt.Sprintf("cannot describe stack %q: %s", state.Stack.Name, err)
+		check.hint = "check that the stack exists in the region and that the AWS credentials can describe it"
+		return check
+	}
+
+	check.message = fmt.Sprintf("stack %q is %s", stack.Name, stack.Status)
+
+	// A stack whose update rolled back is still in service with its previous
+	// template, while a stack whose creation rolled back can only be deleted.
+	switch {
+	case strings.HasSuffix(stack.Status, "_FAILED"), stack.Status == "ROLLBACK_COMPLETE":
+		check.status = doctorFail
+		check.hint = "inspect the stack events in the CloudFormation console, then run bbl up again"
+	case stack.Status == "UPDATE_ROLLBACK_COMPLETE":
+		check.status = doctorWarn
+		check.hint = "the last stack update was rolled back, inspect the stack events in the CloudFormation console"
+	case strings.HasSuffix(stack.Status, "_IN_PROGRESS"):
+		check.status = doctorWarn
+		check.hint = "wait for the stack operation to finish and run bbl doctor again"
+	default:
+		check.status = doctorPass
+	}
+
+	return check
+}
+
+func (d Doctor) checkTerraform(state storage.State) doctorCheck {
+	check := doctorCheck{name: "terraform state"}
+
+	if state.TFState == "" {
+		check.status = doctorFail
+		check.message = "bbl-state.json has no terraform state"
+		check.hint = "run bbl up to create the infrastructure"
+		return check
+	}
+
+	externalIP, err := d.terraformOutputter.Get(state.TFState, "external_ip")
+	if err != nil {
+		check.status = doctorFail
+		check.message = fmt.Sprintf("cannot read terraform outputs: %s", err)
+		check.hint = "check that terraform is on the PATH, or restore the terraform state with bbl state-rollback"
+		return check
+	}
+
+	check.status = doctorPass
+	check.message = fmt.Sprintf("director external ip is %s", externalIP)
+	return check
+}
+
+func (d Doctor) checkDirector(state storage.State) doctorCheck {
+	check := doctorCheck{name: "director"}
+
+	if state.BOSH.DirectorAddress == "" {
+		check.status = doctorWarn
+		check.message = "bbl-state.json has no director"
+		check.hint = "run bbl up to deploy a director"
+		return check
+	}
+
+	boshClient := d.boshClientProvider.Client(state.BOSH.DirectorAddress, state.BOSH.DirectorUsername, state.BOSH.DirectorPassword)
+	info, err := boshClient.Info()
+	if err != nil {
+		check.status = doctorFail
+		check.message = fmt.Sprintf("cannot reach the director at %s: %s", state.BOSH.DirectorAddress, err)
+		check.hint = "check that the director VM is running and that port 25555 is reachable from this machine"
+		return check
+	}
+
+	if info.User != state.BOSH.DirectorUsername {
+		check.status = doctorFail
+		check.message = fmt.Sprintf("director %q did not accept the credentials in bbl-state.json", info.Name)
+		check.hint = "run bbl rotate-director-credentials --credential director to reset the director password"
+		return check
+	}
+
+	check.status = doctorPass
+	check.message = fmt.Sprintf("director %q (%s) is reachable and accepts the credentials", info.Name, info.Version)
+	return check
+}
+
+func (d Doctor) checkCertificate(name, certificatePEM string) doctorCheck {
+	check := doctorCheck{name: name}
+
+	block, _ := pem.Decode([]byte(certificatePEM))
+	if block == nil {
+		check.status = doctorFail
+		check.message = "bbl-state.json has no PEM encoded certificate"
+		check.hint = "run bbl rotate-director-ca to issue a new certificate"
+		return check
+	}
+
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		check.status = doctorFail
+		check.message = fmt.Sprintf("cannot parse the certificate: %s", err)
+		check.hint = "run bbl rotate-director-ca to issue a new certificate"
+		return check
+	}
+
+	expiry := certificate.NotAfter.UTC().Format("2006-01-02")
+	remaining := certificate.NotAfter.Sub(doctorNow())
+
+	switch {
+	case remaining <= 0:
+		check.status = doctorFail
+		check.message = fmt.Sprintf("expired on %s", expiry)
+		check.hint = "run bbl rotate-director-ca to issue a new certificate"
+	case remaining < certificateExpiryWarning:
+		check.status = doctorWarn
+		check.message = fmt.Sprintf("expires on %s", expiry)
+		check.hint = "run bbl rotate-director-ca before the certificate expires"
+	default:
+		check.status = doctorPass
+		check.message = fmt.Sprintf("valid until %s", expiry)
+	}
+
+	return check
+}
+
+func (d Doctor) checkSSHKey(state storage.State) doctorCheck {
+	check := doctorCheck{name: "ssh key"}
+
+	if state.KeyPair.PrivateKey == "" {
+		check.status = doctorFail
+		check.message = "bbl-state.json has no ssh private key"
+		check.hint = "run bbl rotate-ssh-key to create a new key pair"
+		return check
+	}
+
+	block, _ := pem.Decode([]byte(state.KeyPair.PrivateKey))
+	if block == nil {
+		check.status = doctorFail
+		check.message = "the ssh private key is not PEM encoded"
+		check.hint = "run bbl rotate-ssh-key to create a new key pair"
+		return check
+	}
+
+	check.status = doctorPass
+	check.message = "ssh private key is present"
+	return check
+}
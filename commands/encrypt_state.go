@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	EncryptStateCommand = "encrypt-state"
+)
+
+type EncryptState struct {
+	logger               logger
+	stateValidator       stateValidator
+	encryptedStateStore  stateStore
+	passphraseConfigured bool
+}
+
+func NewEncryptState(logger logger, stateValidator stateValidator, encryptedStateStore stateStore, passphraseConfigured bool) EncryptState {
+	return EncryptState{
+		logger:               logger,
+		stateValidator:       stateValidator,
+		encryptedStateStore:  encryptedStateStore,
+		passphraseConfigured: passphraseConfigured,
+	}
+}
+
+func (e EncryptState) Execute(subcommandFlags []string, state storage.State) error {
+	err := e.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	if !e.passphraseConfigured {
+		return errors.New("a passphrase must be provided with --state-encryption-key-file or BBL_STATE_PASSPHRASE to encrypt the state")
+	}
+
+	e.logger.Step("encrypting bbl-state.json")
+	err = e.encryptedStateStore.Set(state)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
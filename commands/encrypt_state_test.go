@@ -0,0 +1,71 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EncryptState", func() {
+	var (
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+		stateStore     *fakes.StateStore
+		command        commands.EncryptState
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		stateStore = &fakes.StateStore{}
+
+		command = commands.NewEncryptState(logger, stateValidator, stateStore, true)
+	})
+
+	Describe("Execute", func() {
+		It("writes the state through the encrypted state store", func() {
+			state := storage.State{
+				IAAS:  "aws",
+				EnvID: "some-env-id",
+			}
+
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
+			Expect(stateStore.SetCall.CallCount).To(Equal(1))
+			Expect(stateStore.SetCall.Receives.State).To(Equal(state))
+			Expect(logger.StepCall.Messages).To(Equal([]string{"encrypting bbl-state.json"}))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the state validator fails", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("state validator failed"))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when no passphrase has been configured", func() {
+				command = commands.NewEncryptState(logger, stateValidator, stateStore, false)
+
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("a passphrase must be provided with --state-encryption-key-file or BBL_STATE_PASSPHRASE to encrypt the state"))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the state cannot be written", func() {
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("failed to set state")}}
+
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("failed to set state"))
+			})
+		})
+	})
+})
@@ -2,6 +2,10 @@ package commands
 
 import "os"
 
+type envGetter interface {
+	Get(name string) string
+}
+
 type EnvGetter struct{}
 
 func NewEnvGetter() EnvGetter {
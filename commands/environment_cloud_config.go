@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/cloudconfig/gcp"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type boshCloudConfigGenerator interface {
+	Generate(bosh.CloudConfigInput) (bosh.CloudConfig, error)
+}
+
+// environmentCloudConfig regenerates the cloud config that up and create-lbs
+// upload for an existing environment from the outputs of its cloudformation
+// stack or terraform state, patched with the cloud config ops files of the
+// state.
+type environmentCloudConfig struct {
+	credentialValidator       credentialValidator
+	infrastructureManager     infrastructureManager
+	availabilityZoneRetriever availabilityZoneRetriever
+	boshCloudConfigurator     boshCloudConfigurator
+	boshCloudConfigGenerator  boshCloudConfigGenerator
+	terraformOutputter        terraformOutputter
+	gcpCloudConfigGenerator   gcpCloudConfigGenerator
+	zones                     zones
+}
+
+func newEnvironmentCloudConfig(credentialValidator credentialValidator, infrastructureManager infrastructureManager,
+	availabilityZoneRetriever availabilityZoneRetriever, boshCloudConfigurator boshCloudConfigurator,
+	boshCloudConfigGenerator boshCloudConfigGenerator, terraformOutputter terraformOutputter,
+	gcpCloudConfigGenerator gcpCloudConfigGenerator, zones zones) environmentCloudConfig {
+	return environmentCloudConfig{
+		credentialValidator:       credentialValidator,
+		infrastructureManager:     infrastructureManager,
+		availabilityZoneRetriever: availabilityZoneRetriever,
+		boshCloudConfigurator:     boshCloudConfigurator,
+		boshCloudConfigGenerator:  boshCloudConfigGenerator,
+		terraformOutputter:        terraformOutputter,
+		gcpCloudConfigGenerator:   gcpCloudConfigGenerator,
+		zones:                     zones,
+	}
+}
+
+func (e environmentCloudConfig) yaml(state storage.State) ([]byte, error) {
+	var cloudConfig interface{}
+	var err error
+
+	switch state.IAAS {
+	case "aws":
+		cloudConfig, err = e.aws(state)
+	case "gcp":
+		cloudConfig, err = e.gcp(state)
+	default:
+		return nil, fmt.Errorf("cannot generate a cloud config on iaas %q", state.IAAS)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cloudConfigYAML, err := marshal(cloudConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return bosh.ApplyCloudConfigOpsFiles(cloudConfigYAML, state.CloudConfigOpsFiles)
+}
+
+func (e environmentCloudConfig) aws(state storage.State) (bosh.CloudConfig, error) {
+	err := e.credentialValidator.ValidateAWS()
+	if err != nil {
+		return bosh.CloudConfig{}, err
+	}
+
+	if state.Stack.Name == "" {
+		return bosh.CloudConfig{}, BBLNotFound
+	}
+
+	stack, err := e.infrastructureManager.Describe(state.Stack.Name)
+	if err != nil {
+		return bosh.CloudConfig{}, err
+	}
+
+	availabilityZones, err := e.availabilityZoneRetriever.Retrieve(state.AWS.Region)
+	if err != nil {
+		return bosh.CloudConfig{}, err
+	}
+
+	cloudConfigInput := e.boshCloudConfigurator.Configure(stack, availabilityZones)
+
+	return e.boshCloudConfigGenerator.Generate(cloudConfigInput)
+}
+
+func (e environmentCloudConfig) gcp(state storage.State) (gcp.CloudConfig, error) {
+	if state.TFState == "" {
+		return gcp.CloudConfig{}, BBLNotFound
+	}
+
+	names := []string{"network_name", "subnetwork_name", "internal_tag_name"}
+	switch state.LB.Type {
+	case "concourse":
+		names = append(names, "concourse_target_pool")
+	case "cf":
+		names = append(names, "router_backend_service", "ssh_proxy_target_pool", "tcp_router_target_pool", "ws_target_pool")
+	}
+
+	outputs := map[string]string{}
+	for _, name := range names {
+		output, err := e.terraformOutputter.Get(state.TFState, name)
+		if err != nil {
+			return gcp.CloudConfig{}, err
+		}
+		outputs[name] = output
+	}
+
+	return e.gcpCloudConfigGenerator.Generate(gcp.CloudConfigInput{
+		AZs:                 e.zones.Get(state.GCP.Region),
+		Tags:                []string{outputs["internal_tag_name"]},
+		NetworkName:         outputs["network_name"],
+		SubnetworkName:      outputs["subnetwork_name"],
+		ConcourseTargetPool: outputs["concourse_target_pool"],
+		CFBackends: gcp.CFBackends{
+			Router:    outputs["router_backend_service"],
+			SSHProxy:  outputs["ssh_proxy_target_pool"],
+			TCPRouter: outputs["tcp_router_target_pool"],
+			WS:        outputs["ws_target_pool"],
+		},
+	})
+}
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	EnvsCommand = "envs"
+)
+
+type environmentLister interface {
+	Environments() ([]storage.Environment, error)
+}
+
+type Envs struct {
+	environmentLister environmentLister
+	stdout            io.Writer
+}
+
+func NewEnvs(environmentLister environmentLister, stdout io.Writer) Envs {
+	return Envs{
+		environmentLister: environmentLister,
+		stdout:            stdout,
+	}
+}
+
+func (e Envs) Execute(subcommandFlags []string, state storage.State) error {
+	environments, err := e.environmentLister.Environments()
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(e.stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tIAAS\tREGION\tENV ID\tDIRECTOR ADDRESS")
+	for _, environment := range environments {
+		envState := environment.State
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", environment.Name, envState.IAAS, envRegion(envState), envState.EnvID, envState.BOSH.DirectorAddress)
+	}
+
+	return writer.Flush()
+}
+
+func envRegion(state storage.State) string {
+	switch state.IAAS {
+	case "gcp":
+		return state.GCP.Region
+	default:
+		return state.AWS.Region
+	}
+}
@@ -0,0 +1,77 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Envs", func() {
+	var (
+		environmentLister *fakes.EnvironmentLister
+		stdout            *bytes.Buffer
+		command           commands.Envs
+	)
+
+	BeforeEach(func() {
+		environmentLister = &fakes.EnvironmentLister{}
+		stdout = bytes.NewBuffer([]byte{})
+
+		command = commands.NewEnvs(environmentLister, stdout)
+	})
+
+	Describe("Execute", func() {
+		It("prints the iaas, region, env id and director address of every environment", func() {
+			environmentLister.EnvironmentsCall.Returns.Environments = []storage.Environment{
+				{
+					Name: "production",
+					State: storage.State{
+						IAAS:  "aws",
+						AWS:   storage.AWS{Region: "eu-west-1"},
+						EnvID: "production-env-id",
+						BOSH:  storage.BOSH{DirectorAddress: "https://10.0.0.6:25555"},
+					},
+				},
+				{
+					Name: "staging",
+					State: storage.State{
+						IAAS:  "gcp",
+						GCP:   storage.GCP{Region: "us-east1"},
+						EnvID: "staging-env-id",
+					},
+				},
+			}
+
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(environmentLister.EnvironmentsCall.CallCount).To(Equal(1))
+			Expect(stdout.String()).To(Equal("" +
+				"NAME        IAAS  REGION     ENV ID             DIRECTOR ADDRESS\n" +
+				"production  aws   eu-west-1  production-env-id  https://10.0.0.6:25555\n" +
+				"staging     gcp   us-east1   staging-env-id     \n"))
+		})
+
+		It("prints only the header when there are no environments", func() {
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stdout.String()).To(Equal("NAME  IAAS  REGION  ENV ID  DIRECTOR ADDRESS\n"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the environments cannot be listed", func() {
+				environmentLister.EnvironmentsCall.Returns.Error = errors.New("failed to list environments")
+
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("failed to list environments"))
+			})
+		})
+	})
+})
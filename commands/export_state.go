@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	ExportStateCommand = "export-state"
+)
+
+type ExportState struct {
+	stateValidator stateValidator
+	stdout         io.Writer
+}
+
+type exportStateConfig struct {
+	redact bool
+	output string
+}
+
+func NewExportState(stateValidator stateValidator, stdout io.Writer) ExportState {
+	return ExportState{
+		stateValidator: stateValidator,
+		stdout:         stdout,
+	}
+}
+
+func (e ExportState) Execute(subcommandFlags []string, state storage.State) error {
+	err := e.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	config, err := e.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	mode := os.FileMode(0600)
+	if config.redact {
+		state = storage.RedactState(state)
+		mode = storage.OS_READ_WRITE_MODE
+	}
+
+	contents, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if config.output == "" {
+		fmt.Fprintln(e.stdout, string(contents))
+		return nil
+	}
+
+	return ioutil.WriteFile(config.output, append(contents, '\n'), mode)
+}
+
+func (ExportState) Flags() flags.Flags {
+	return exportStateFlags(&exportStateConfig{})
+}
+
+func (ExportState) parseFlags(subcommandFlags []string) (exportStateConfig, error) {
+	config := exportStateConfig{}
+
+	err := exportStateFlags(&config).Parse(subcommandFlags)
+	if err != nil {
+		return exportStateConfig{}, err
+	}
+
+	return config, nil
+}
+
+func exportStateFlags(config *exportStateConfig) flags.Flags {
+	exportFlags := flags.New("export-state")
+	exportFlags.Bool(&config.redact, "", "redact", false)
+	exportFlags.String(&config.output, "output", "")
+
+	exportFlags.Files("output")
+
+	return exportFlags
+}
@@ -0,0 +1,98 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExportState", func() {
+	var (
+		stateValidator *fakes.StateValidator
+		stdout         *bytes.Buffer
+		command        commands.ExportState
+		state          storage.State
+	)
+
+	BeforeEach(func() {
+		stateValidator = &fakes.StateValidator{}
+		stdout = bytes.NewBuffer([]byte{})
+
+		state = storage.State{
+			Version: 2,
+			IAAS:    "gcp",
+			GCP: storage.GCP{
+				ServiceAccountKey: "some-service-account-key",
+				ProjectID:         "some-project-id",
+			},
+			EnvID: "some-env-id",
+		}
+
+		command = commands.NewExportState(stateValidator, stdout)
+	})
+
+	Describe("Execute", func() {
+		It("prints the state", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
+			Expect(stdout.String()).To(ContainSubstring(`"serviceAccountKey": "some-service-account-key"`))
+		})
+
+		It("prints the redacted state with --redact", func() {
+			err := command.Execute([]string{"--redact"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stdout.String()).To(ContainSubstring(`"serviceAccountKey": "((redacted:gcp.serviceAccountKey))"`))
+			Expect(stdout.String()).To(ContainSubstring(`"projectID": "some-project-id"`))
+			Expect(stdout.String()).NotTo(ContainSubstring("some-service-account-key"))
+		})
+
+		It("writes the export to --output", func() {
+			tempDir, err := ioutil.TempDir("", "")
+			Expect(err).NotTo(HaveOccurred())
+			output := filepath.Join(tempDir, "export.json")
+
+			err = command.Execute([]string{"--output", output}, state)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout.String()).To(BeEmpty())
+
+			contents, err := ioutil.ReadFile(output)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`"envID": "some-env-id"`))
+
+			fileInfo, err := os.Stat(output)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fileInfo.Mode()).To(Equal(os.FileMode(0600)))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the state validator fails", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("state validator failed"))
+			})
+
+			It("returns an error when the flags cannot be parsed", func() {
+				err := command.Execute([]string{"--unknown-flag"}, state)
+				Expect(err).To(MatchError(ContainSubstring("flag provided but not defined: -unknown-flag")))
+			})
+
+			It("returns an error when the export cannot be written", func() {
+				err := command.Execute([]string{"--output", "/some/missing/dir/export.json"}, state)
+				Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+			})
+		})
+	})
+})
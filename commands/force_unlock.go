@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	ForceUnlockCommand = "force-unlock"
+)
+
+type stateUnlocker interface {
+	ForceUnlock() (storage.LockInfo, error)
+}
+
+type ForceUnlock struct {
+	logger        logger
+	stateUnlocker stateUnlocker
+}
+
+func NewForceUnlock(logger logger, stateUnlocker stateUnlocker) ForceUnlock {
+	return ForceUnlock{
+		logger:        logger,
+		stateUnlocker: stateUnlocker,
+	}
+}
+
+func (f ForceUnlock) Execute(subcommandFlags []string, state storage.State) error {
+	lockInfo, err := f.stateUnlocker.ForceUnlock()
+	if err != nil {
+		return err
+	}
+
+	f.logger.Step("removed lock held by %s running %q since %s", lockInfo.Holder, lockInfo.Command, lockInfo.CreatedAt.Format(time.RFC3339))
+
+	return nil
+}
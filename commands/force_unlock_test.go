@@ -0,0 +1,55 @@
+package commands_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ForceUnlock", func() {
+	var (
+		logger      *fakes.Logger
+		stateLocker *fakes.StateLocker
+		command     commands.ForceUnlock
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateLocker = &fakes.StateLocker{}
+
+		command = commands.NewForceUnlock(logger, stateLocker)
+	})
+
+	Describe("Execute", func() {
+		It("removes the lock and reports who held it", func() {
+			stateLocker.ForceUnlockCall.Returns.LockInfo = storage.LockInfo{
+				ID:        "some-lock-id",
+				Holder:    "some-user@some-host (pid 123)",
+				Command:   "up",
+				CreatedAt: time.Date(2017, time.January, 2, 3, 4, 5, 0, time.UTC),
+			}
+
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateLocker.ForceUnlockCall.CallCount).To(Equal(1))
+			Expect(logger.StepCall.Messages).To(Equal([]string{`removed lock held by some-user@some-host (pid 123) running "up" since 2017-01-02T03:04:05Z`}))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the lock cannot be removed", func() {
+				stateLocker.ForceUnlockCall.Returns.Error = errors.New("failed to unlock")
+
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("failed to unlock"))
+				Expect(logger.StepCall.CallCount).To(Equal(0))
+			})
+		})
+	})
+})
@@ -88,7 +88,8 @@ func (c GCPCreateLBs) Execute(config GCPCreateLBsConfig, state storage.State) er
 
 	templateWithLB := strings.Join([]string{terraformVarsTemplate, terraformBOSHDirectorTemplate, lbTemplate}, "\n")
 	tfState, err := c.terraformExecutor.Apply(state.GCP.ServiceAccountKey, state.EnvID, state.GCP.ProjectID, state.GCP.Zone,
-		state.GCP.Region, string(cert), string(key), config.Domain, templateWithLB, state.TFState)
+		state.GCP.Region, string(cert), string(key), config.Domain, templateWithLB,
+		state.TerraformOverrides, state.TFState)
 	switch err.(type) {
 	case terraform.TerraformApplyError:
 		taError := err.(terraform.TerraformApplyError)
@@ -178,6 +179,11 @@ func (c GCPCreateLBs) Execute(config GCPCreateLBsConfig, state storage.State) er
 		return err
 	}
 
+	manifestYAML, err = bosh.ApplyCloudConfigOpsFiles(manifestYAML, state.CloudConfigOpsFiles)
+	if err != nil {
+		return err
+	}
+
 	c.logger.Step("applying cloud config")
 	if err := boshClient.UpdateCloudConfig(manifestYAML); err != nil {
 		return err
@@ -688,6 +688,9 @@ var _ = Describe("GCPCreateLBs", func() {
 							Zone:              "some-zone",
 							Region:            "some-region",
 						},
+						TerraformOverrides: []storage.TerraformOverride{
+							{Name: "buckets.tf", Contents: "some-contents"},
+						},
 					})
 					Expect(err).NotTo(HaveOccurred())
 
@@ -700,6 +703,9 @@ var _ = Describe("GCPCreateLBs", func() {
 					Expect(terraformExecutor.ApplyCall.Receives.Zone).To(Equal("some-zone"))
 					Expect(terraformExecutor.ApplyCall.Receives.Region).To(Equal("some-region"))
 					Expect(terraformExecutor.ApplyCall.Receives.TFState).To(Equal("some-prev-tf-state"))
+					Expect(terraformExecutor.ApplyCall.Receives.Overrides).To(Equal([]storage.TerraformOverride{
+						{Name: "buckets.tf", Contents: "some-contents"},
+					}))
 					Expect(terraformExecutor.ApplyCall.Receives.Template).To(Equal(expectedConcourseTemplate))
 				})
 			})
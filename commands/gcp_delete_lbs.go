@@ -3,6 +3,7 @@ package commands
 import (
 	"strings"
 
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
 	"github.com/cloudfoundry/bosh-bootloader/cloudconfig/gcp"
 	"github.com/cloudfoundry/bosh-bootloader/helpers"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
@@ -65,6 +66,11 @@ func (g GCPDeleteLBs) Execute(state storage.State) error {
 		return err
 	}
 
+	cloudConfigYaml, err = bosh.ApplyCloudConfigOpsFiles(cloudConfigYaml, state.CloudConfigOpsFiles)
+	if err != nil {
+		return err
+	}
+
 	g.logger.Step("applying cloud config")
 	err = boshClient.UpdateCloudConfig(cloudConfigYaml)
 	if err != nil {
@@ -75,7 +81,7 @@ func (g GCPDeleteLBs) Execute(state storage.State) error {
 
 	g.logger.Step("generating terraform template")
 	tfState, err := g.terraformExecutor.Apply(state.GCP.ServiceAccountKey, state.EnvID, state.GCP.ProjectID,
-		state.GCP.Zone, state.GCP.Region, "", "", "", template, state.TFState)
+		state.GCP.Zone, state.GCP.Region, "", "", "", template, state.TerraformOverrides, state.TFState)
 
 	switch err.(type) {
 	case terraform.TerraformApplyError:
@@ -147,6 +147,9 @@ var _ = Describe("GCPDeleteLBs", func() {
 					ProjectID:         projectID,
 				},
 				TFState: tfState,
+				TerraformOverrides: []storage.TerraformOverride{
+					{Name: "buckets.tf", Contents: "some-contents"},
+				},
 			})
 			Expect(err).NotTo(HaveOccurred())
 
@@ -158,6 +161,9 @@ var _ = Describe("GCPDeleteLBs", func() {
 			Expect(terraformExecutor.ApplyCall.Receives.Region).To(Equal(region))
 			Expect(terraformExecutor.ApplyCall.Receives.Template).To(Equal(expectedTerraformTemplate))
 			Expect(terraformExecutor.ApplyCall.Receives.TFState).To(Equal(tfState))
+			Expect(terraformExecutor.ApplyCall.Receives.Overrides).To(Equal([]storage.TerraformOverride{
+				{Name: "buckets.tf", Contents: "some-contents"},
+			}))
 
 			Expect(logger.StepCall.Messages).To(ContainSequence([]string{
 				"generating terraform template", "finished applying terraform template",
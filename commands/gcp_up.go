@@ -9,6 +9,7 @@ import (
 
 	yaml "gopkg.in/yaml.v2"
 
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
 	"github.com/cloudfoundry/bosh-bootloader/boshinit"
 	"github.com/cloudfoundry/bosh-bootloader/cloudconfig/gcp"
 	"github.com/cloudfoundry/bosh-bootloader/helpers"
@@ -31,6 +32,7 @@ type GCPUp struct {
 	cloudConfigGenerator gcpCloudConfigGenerator
 	terraformOutputter   terraformOutputter
 	terraformExecutor    terraformExecutor
+	overridesLoader      terraformOverridesLoader
 	zones                zones
 }
 
@@ -39,6 +41,7 @@ type GCPUpConfig struct {
 	ProjectID             string
 	Zone                  string
 	Region                string
+	DryRun                bool
 }
 
 type gcpCloudConfigGenerator interface {
@@ -58,8 +61,16 @@ type gcpProvider interface {
 }
 
 type terraformExecutor interface {
-	Apply(credentials, envID, projectID, zone, region, certPath, keyPath, domain, template, tfState string) (string, error)
-	Destroy(serviceAccountKey, envID, projectID, zone, region, template, tfState string) (string, error)
+	Apply(credentials, envID, projectID, zone, region, certPath, keyPath, domain, template string, overrides []storage.TerraformOverride,
+		tfState string) (string, error)
+	Plan(credentials, envID, projectID, zone, region, certPath, keyPath, domain, template string, overrides []storage.TerraformOverride,
+		tfState string) (terraform.Plan, error)
+	Destroy(serviceAccountKey, envID, projectID, zone, region, template string, overrides []storage.TerraformOverride,
+		tfState string) (string, error)
+}
+
+type terraformOverridesLoader interface {
+	Load() ([]storage.TerraformOverride, bool, error)
 }
 
 type terraformOutputter interface {
@@ -72,7 +83,7 @@ type zones interface {
 
 func NewGCPUp(stateStore stateStore, keyPairUpdater keyPairUpdater, gcpProvider gcpProvider, terraformExecutor terraformExecutor, boshDeployer boshDeployer,
 	stringGenerator stringGenerator, logger logger, boshClientProvider boshClientProvider, cloudConfigGenerator gcpCloudConfigGenerator,
-	terraformOutputter terraformOutputter, zones zones) GCPUp {
+	terraformOutputter terraformOutputter, overridesLoader terraformOverridesLoader, zones zones) GCPUp {
 	return GCPUp{
 		stateStore:           stateStore,
 		keyPairUpdater:       keyPairUpdater,
@@ -84,6 +95,7 @@ func NewGCPUp(stateStore stateStore, keyPairUpdater keyPairUpdater, gcpProvider
 		boshClientProvider:   boshClientProvider,
 		cloudConfigGenerator: cloudConfigGenerator,
 		terraformOutputter:   terraformOutputter,
+		overridesLoader:      overridesLoader,
 		zones:                zones,
 	}
 }
@@ -108,6 +120,20 @@ func (u GCPUp) Execute(upConfig GCPUpConfig, state storage.State) error {
 		return err
 	}
 
+	// The override files of the environment replace the ones kept in the
+	// state, which the other commands that run terraform apply again.
+	overrides, found, err := u.overridesLoader.Load()
+	if err != nil {
+		return err
+	}
+	if found {
+		state.TerraformOverrides = overrides
+	}
+
+	if upConfig.DryRun {
+		return u.plan(state)
+	}
+
 	if err := u.stateStore.Set(state); err != nil {
 		return err
 	}
@@ -127,22 +153,12 @@ func (u GCPUp) Execute(upConfig GCPUpConfig, state storage.State) error {
 		}
 	}
 
-	var template string
 	zones := u.zones.Get(state.GCP.Region)
-	switch state.LB.Type {
-	case "concourse":
-		template = strings.Join([]string{terraformVarsTemplate, terraformBOSHDirectorTemplate, terraformConcourseLBTemplate}, "\n")
-	case "cf":
-		terraformCFLBBackendService := generateBackendServiceTerraform(len(zones))
-		instanceGroups := generateInstanceGroups(zones)
-		template = strings.Join([]string{terraformVarsTemplate, terraformBOSHDirectorTemplate, terraformCFLBTemplate, instanceGroups, terraformCFLBBackendService}, "\n")
-	default:
-		template = strings.Join([]string{terraformVarsTemplate, terraformBOSHDirectorTemplate}, "\n")
-	}
+	template := terraformTemplate(state.LB.Type, zones)
 
 	tfState, err := u.terraformExecutor.Apply(state.GCP.ServiceAccountKey,
 		state.EnvID, state.GCP.ProjectID, state.GCP.Zone, state.GCP.Region, state.LB.Cert, state.LB.Key, state.LB.Domain,
-		template, state.TFState,
+		template, state.TerraformOverrides, state.TFState,
 	)
 	switch err.(type) {
 	case terraform.TerraformApplyError:
@@ -253,6 +269,11 @@ func (u GCPUp) Execute(upConfig GCPUpConfig, state storage.State) error {
 		return err
 	}
 
+	manifestYAML, err = bosh.ApplyCloudConfigOpsFiles(manifestYAML, state.CloudConfigOpsFiles)
+	if err != nil {
+		return err
+	}
+
 	u.logger.Step("applying cloud config")
 	if err := boshClient.UpdateCloudConfig(manifestYAML); err != nil {
 		return err
@@ -261,6 +282,43 @@ func (u GCPUp) Execute(upConfig GCPUpConfig, state storage.State) error {
 	return nil
 }
 
+func (u GCPUp) plan(state storage.State) error {
+	if err := u.gcpProvider.SetConfig(state.GCP.ServiceAccountKey, state.GCP.ProjectID, state.GCP.Zone); err != nil {
+		return err
+	}
+
+	template := terraformTemplate(state.LB.Type, u.zones.Get(state.GCP.Region))
+
+	plan, err := u.terraformExecutor.Plan(state.GCP.ServiceAccountKey,
+		state.EnvID, state.GCP.ProjectID, state.GCP.Zone, state.GCP.Region, state.LB.Cert, state.LB.Key, state.LB.Domain,
+		template, state.TerraformOverrides, state.TFState,
+	)
+	if err != nil {
+		return err
+	}
+
+	u.logger.Step("planned changes to terraform infrastructure")
+	if plan.Output != "" {
+		u.logger.Println(plan.Output)
+	}
+	u.logger.Println(planSummary(plan.Add, plan.Change, plan.Destroy))
+
+	return nil
+}
+
+func terraformTemplate(lbType string, zones []string) string {
+	switch lbType {
+	case "concourse":
+		return strings.Join([]string{terraformVarsTemplate, terraformBOSHDirectorTemplate, terraformConcourseLBTemplate}, "\n")
+	case "cf":
+		terraformCFLBBackendService := generateBackendServiceTerraform(len(zones))
+		instanceGroups := generateInstanceGroups(zones)
+		return strings.Join([]string{terraformVarsTemplate, terraformBOSHDirectorTemplate, terraformCFLBTemplate, instanceGroups, terraformCFLBBackendService}, "\n")
+	default:
+		return strings.Join([]string{terraformVarsTemplate, terraformBOSHDirectorTemplate}, "\n")
+	}
+}
+
 func (u GCPUp) validateState(state storage.State) error {
 	switch {
 	case state.GCP.ServiceAccountKey == "":
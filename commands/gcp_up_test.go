@@ -27,6 +27,7 @@ var _ = Describe("gcp up", func() {
 		gcpClientProvider       *fakes.GCPClientProvider
 		terraformExecutor       *fakes.TerraformExecutor
 		terraformOutputter      *fakes.TerraformOutputter
+		overridesLoader         *fakes.TerraformOverridesLoader
 		boshDeployer            *fakes.BOSHDeployer
 		stringGenerator         *fakes.StringGenerator
 		boshClientProvider      *fakes.BOSHClientProvider
@@ -46,6 +47,7 @@ var _ = Describe("gcp up", func() {
 		keyPairUpdater = &fakes.GCPKeyPairUpdater{}
 		gcpClientProvider = &fakes.GCPClientProvider{}
 		terraformExecutor = &fakes.TerraformExecutor{}
+		overridesLoader = &fakes.TerraformOverridesLoader{}
 		zones = &fakes.Zones{}
 		terraformExecutor.ApplyCall.Returns.TFState = "some-tf-state"
 		stringGenerator = &fakes.StringGenerator{}
@@ -110,7 +112,7 @@ var _ = Describe("gcp up", func() {
 		}
 
 		gcpUp = commands.NewGCPUp(stateStore, keyPairUpdater, gcpClientProvider, terraformExecutor, boshDeployer,
-			stringGenerator, logger, boshClientProvider, gcpCloudConfigGenerator, terraformOutputter, zones)
+			stringGenerator, logger, boshClientProvider, gcpCloudConfigGenerator, terraformOutputter, overridesLoader, zones)
 
 		tempFile, err := ioutil.TempFile("", "gcpServiceAccountKey")
 		Expect(err).NotTo(HaveOccurred())
@@ -199,6 +201,69 @@ var _ = Describe("gcp up", func() {
 				Expect(stateStore.SetCall.Receives.State.TFState).To(Equal("some-tf-state"))
 			})
 
+			Context("terraform overrides", func() {
+				var state storage.State
+
+				BeforeEach(func() {
+					state = storage.State{
+						IAAS: "gcp",
+						GCP: storage.GCP{
+							ServiceAccountKey: serviceAccountKey,
+							ProjectID:         "some-project-id",
+							Zone:              "some-zone",
+							Region:            "us-west1",
+						},
+						EnvID: "some-env-id",
+						TerraformOverrides: []storage.TerraformOverride{
+							{Name: "some-old-override.tf", Contents: "some-old-contents"},
+						},
+					}
+				})
+
+				It("applies the override files of the environment and keeps them in the state", func() {
+					overrides := []storage.TerraformOverride{
+						{Name: "buckets.tf", Contents: "some-contents"},
+					}
+					overridesLoader.LoadCall.Returns.Overrides = overrides
+					overridesLoader.LoadCall.Returns.Found = true
+
+					err := gcpUp.Execute(commands.GCPUpConfig{}, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(overridesLoader.LoadCall.CallCount).To(Equal(1))
+					Expect(terraformExecutor.ApplyCall.Receives.Overrides).To(Equal(overrides))
+					Expect(stateStore.SetCall.Receives.State.TerraformOverrides).To(Equal(overrides))
+				})
+
+				It("removes the overrides kept in the state when the overrides directory is empty", func() {
+					overridesLoader.LoadCall.Returns.Overrides = []storage.TerraformOverride{}
+					overridesLoader.LoadCall.Returns.Found = true
+
+					err := gcpUp.Execute(commands.GCPUpConfig{}, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(terraformExecutor.ApplyCall.Receives.Overrides).To(BeEmpty())
+					Expect(stateStore.SetCall.Receives.State.TerraformOverrides).To(BeEmpty())
+				})
+
+				It("applies the overrides kept in the state when the overrides directory does not exist", func() {
+					err := gcpUp.Execute(commands.GCPUpConfig{}, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(terraformExecutor.ApplyCall.Receives.Overrides).To(Equal(state.TerraformOverrides))
+					Expect(stateStore.SetCall.Receives.State.TerraformOverrides).To(Equal(state.TerraformOverrides))
+				})
+
+				It("returns an error when the override files cannot be loaded", func() {
+					overridesLoader.LoadCall.Returns.Error = errors.New("failed to load overrides")
+
+					err := gcpUp.Execute(commands.GCPUpConfig{}, state)
+					Expect(err).To(MatchError("failed to load overrides"))
+					Expect(stateStore.SetCall.CallCount).To(Equal(0))
+					Expect(terraformExecutor.ApplyCall.CallCount).To(Equal(0))
+				})
+			})
+
 			It("saves the tf state even if the applier fails", func() {
 				expectedError := terraform.NewTerraformApplyError("some-tf-state", errors.New("failed to apply"))
 				terraformExecutor.ApplyCall.Returns.Error = expectedError
@@ -434,6 +499,114 @@ var _ = Describe("gcp up", func() {
 		})
 	})
 
+	Context("dry run", func() {
+		var lines []string
+
+		BeforeEach(func() {
+			lines = []string{}
+			logger.PrintlnCall.Stub = func(message string) {
+				lines = append(lines, message)
+			}
+
+			terraformExecutor.PlanCall.Returns.Plan = terraform.Plan{
+				Add:     1,
+				Change:  0,
+				Destroy: 0,
+				Output:  "+ google_compute_network.bbl-network",
+			}
+		})
+
+		It("prints the terraform plan without changing anything", func() {
+			err := gcpUp.Execute(commands.GCPUpConfig{
+				ServiceAccountKeyPath: serviceAccountKeyPath,
+				ProjectID:             "some-project-id",
+				Zone:                  "some-zone",
+				Region:                "us-west1",
+				DryRun:                true,
+			}, storage.State{
+				EnvID:   "some-env-id",
+				TFState: "some-tf-state",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(gcpClientProvider.SetConfigCall.Receives.ServiceAccountKey).To(Equal(serviceAccountKey))
+			Expect(terraformExecutor.PlanCall.CallCount).To(Equal(1))
+			Expect(terraformExecutor.PlanCall.Receives.Credentials).To(Equal(serviceAccountKey))
+			Expect(terraformExecutor.PlanCall.Receives.EnvID).To(Equal("some-env-id"))
+			Expect(terraformExecutor.PlanCall.Receives.ProjectID).To(Equal("some-project-id"))
+			Expect(terraformExecutor.PlanCall.Receives.Zone).To(Equal("some-zone"))
+			Expect(terraformExecutor.PlanCall.Receives.Region).To(Equal("us-west1"))
+			Expect(terraformExecutor.PlanCall.Receives.Template).To(Equal(expectedTerraformTemplate))
+			Expect(terraformExecutor.PlanCall.Receives.TFState).To(Equal("some-tf-state"))
+			Expect(overridesLoader.LoadCall.CallCount).To(Equal(1))
+
+			Expect(logger.StepCall.Messages).To(Equal([]string{"planned changes to terraform infrastructure"}))
+			Expect(lines).To(Equal([]string{
+				"+ google_compute_network.bbl-network",
+				"Plan: 1 to add, 0 to change, 0 to destroy",
+			}))
+
+			Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			Expect(keyPairUpdater.UpdateCall.CallCount).To(Equal(0))
+			Expect(terraformExecutor.ApplyCall.CallCount).To(Equal(0))
+			Expect(boshDeployer.DeployCall.Receives.Input).To(Equal(boshinit.DeployInput{}))
+		})
+
+		It("plans with the override files of the environment", func() {
+			overrides := []storage.TerraformOverride{
+				{Name: "buckets.tf", Contents: "some-contents"},
+			}
+			overridesLoader.LoadCall.Returns.Overrides = overrides
+			overridesLoader.LoadCall.Returns.Found = true
+
+			err := gcpUp.Execute(commands.GCPUpConfig{DryRun: true}, storage.State{
+				IAAS: "gcp",
+				GCP: storage.GCP{
+					ServiceAccountKey: serviceAccountKey,
+					ProjectID:         "some-project-id",
+					Zone:              "some-zone",
+					Region:            "us-west1",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(terraformExecutor.PlanCall.Receives.Overrides).To(Equal(overrides))
+			Expect(stateStore.SetCall.CallCount).To(Equal(0))
+		})
+
+		It("reports when there are no changes", func() {
+			terraformExecutor.PlanCall.Returns.Plan = terraform.Plan{}
+
+			err := gcpUp.Execute(commands.GCPUpConfig{DryRun: true}, storage.State{
+				IAAS: "gcp",
+				GCP: storage.GCP{
+					ServiceAccountKey: serviceAccountKey,
+					ProjectID:         "some-project-id",
+					Zone:              "some-zone",
+					Region:            "us-west1",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(lines).To(Equal([]string{"No changes, the infrastructure is up to date"}))
+		})
+
+		It("returns an error when terraform plan fails", func() {
+			terraformExecutor.PlanCall.Returns.Error = errors.New("failed to plan")
+
+			err := gcpUp.Execute(commands.GCPUpConfig{DryRun: true}, storage.State{
+				IAAS: "gcp",
+				GCP: storage.GCP{
+					ServiceAccountKey: serviceAccountKey,
+					ProjectID:         "some-project-id",
+					Zone:              "some-zone",
+					Region:            "us-west1",
+				},
+			})
+			Expect(err).To(MatchError("failed to plan"))
+		})
+	})
+
 	Context("cloud config", func() {
 		It("generates and uploads a cloud config", func() {
 			zones.GetCall.Returns.Zones = []string{"zone-1", "zone-2", "zone-3"}
@@ -465,6 +638,32 @@ var _ = Describe("gcp up", func() {
 			Expect(boshClient.UpdateCloudConfigCall.CallCount).To(Equal(1))
 		})
 
+		It("applies the cloud config ops files of the state before uploading it", func() {
+			gcpCloudConfigGenerator.GenerateCall.Returns.CloudConfig = gcp.CloudConfig{
+				AZs: []gcp.AZ{
+					{Name: "z1", CloudProperties: gcp.AZCloudProperties{Zone: "zone-1"}},
+				},
+			}
+
+			err := gcpUp.Execute(commands.GCPUpConfig{
+				ServiceAccountKeyPath: serviceAccountKeyPath,
+				ProjectID:             "some-project-id",
+				Zone:                  "some-zone",
+				Region:                "some-region",
+			}, storage.State{
+				CloudConfigOpsFiles: []storage.OpsFile{
+					{Path: "ops.yml", Contents: "- type: replace\n  path: /azs/name=z1/cloud_properties/zone\n  value: zone-2\n"},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(boshClient.UpdateCloudConfigCall.Receives.Yaml)).To(Equal(`azs:
+- cloud_properties:
+    zone: zone-2
+  name: z1
+`))
+		})
+
 		Context("failure cases", func() {
 			It("returns an error when the cloud config fails to be generated", func() {
 				gcpCloudConfigGenerator.GenerateCall.Returns.Error = errors.New("failed to generate cloud config")
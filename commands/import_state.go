@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	ImportStateCommand = "import-state"
+)
+
+type ImportState struct {
+	logger     logger
+	stateStore stateStore
+	stdin      io.Reader
+	stdout     io.Writer
+}
+
+type importStateConfig struct {
+	file       string
+	secretsDir string
+}
+
+func NewImportState(logger logger, stateStore stateStore, stdin io.Reader, stdout io.Writer) ImportState {
+	return ImportState{
+		logger:     logger,
+		stateStore: stateStore,
+		stdin:      stdin,
+		stdout:     stdout,
+	}
+}
+
+func (i ImportState) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := i.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(state, storage.State{}) {
+		return errors.New("bbl-state.json already exists, import-state only creates a new state")
+	}
+
+	contents, err := ioutil.ReadFile(config.file)
+	if err != nil {
+		return err
+	}
+
+	var imported storage.State
+	err = json.Unmarshal(contents, &imported)
+	if err != nil {
+		return fmt.Errorf("%s is not a bbl state export: %s", config.file, err)
+	}
+
+	stdin := bufio.NewReader(i.stdin)
+	secrets := map[string]string{}
+	for _, path := range storage.RedactedPaths(imported) {
+		secrets[path], err = i.readSecret(stdin, config.secretsDir, path)
+		if err != nil {
+			return err
+		}
+	}
+
+	imported, err = storage.HydrateState(imported, secrets)
+	if err != nil {
+		return err
+	}
+
+	i.logger.Step("importing bbl-state.json from %s", config.file)
+	return i.stateStore.Set(imported)
+}
+
+func (i ImportState) readSecret(stdin *bufio.Reader, secretsDir, path string) (string, error) {
+	if secretsDir != "" {
+		contents, err := ioutil.ReadFile(filepath.Join(secretsDir, path))
+		switch {
+		case err == nil:
+			return strings.TrimRight(string(contents), "\r\n"), nil
+		case !os.IsNotExist(err):
+			return "", err
+		}
+	}
+
+	fmt.Fprintf(i.stdout, "%s: ", path)
+	secret, err := stdin.ReadString('\n')
+	secret = strings.TrimRight(secret, "\r\n")
+	if secret == "" {
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		return "", fmt.Errorf("missing secret for %s, enter it when prompted or write it to a file named %s in --secrets-dir", path, path)
+	}
+
+	return secret, nil
+}
+
+func (ImportState) Flags() flags.Flags {
+	return importStateFlags(&importStateConfig{})
+}
+
+func (ImportState) parseFlags(subcommandFlags []string) (importStateConfig, error) {
+	config := importStateConfig{}
+
+	err := importStateFlags(&config).Parse(subcommandFlags)
+	if err != nil {
+		return importStateConfig{}, err
+	}
+
+	if config.file == "" {
+		return importStateConfig{}, errors.New("--file must be set to a file written by bbl export-state")
+	}
+
+	return config, nil
+}
+
+func importStateFlags(config *importStateConfig) flags.Flags {
+	importFlags := flags.New("import-state")
+	importFlags.String(&config.file, "file", "")
+	importFlags.String(&config.secretsDir, "secrets-dir", "")
+
+	importFlags.Files("file")
+	importFlags.Directories("secrets-dir")
+
+	return importFlags
+}
@@ -0,0 +1,165 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ImportState", func() {
+	var (
+		logger     *fakes.Logger
+		stateStore *fakes.StateStore
+		stdin      *bytes.Buffer
+		stdout     *bytes.Buffer
+		command    commands.ImportState
+
+		tempDir    string
+		exportFile string
+		secretsDir string
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateStore = &fakes.StateStore{}
+		stdin = bytes.NewBuffer([]byte{})
+		stdout = bytes.NewBuffer([]byte{})
+
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		exportFile = filepath.Join(tempDir, "export.json")
+		err = ioutil.WriteFile(exportFile, []byte(`{
+			"version": 2,
+			"iaas": "aws",
+			"aws": {
+				"accessKeyId": "((redacted:aws.accessKeyId))",
+				"secretAccessKey": "((redacted:aws.secretAccessKey))",
+				"region": "some-region"
+			},
+			"envID": "some-env-id"
+		}`), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		secretsDir = filepath.Join(tempDir, "secrets")
+		err = os.Mkdir(secretsDir, os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		command = commands.NewImportState(logger, stateStore, stdin, stdout)
+	})
+
+	Describe("Execute", func() {
+		It("reads the redacted secrets from the secrets directory", func() {
+			err := ioutil.WriteFile(filepath.Join(secretsDir, "aws.accessKeyId"), []byte("some-access-key-id\n"), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+			err = ioutil.WriteFile(filepath.Join(secretsDir, "aws.secretAccessKey"), []byte("some-secret-access-key"), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = command.Execute([]string{"--file", exportFile, "--secrets-dir", secretsDir}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateStore.SetCall.CallCount).To(Equal(1))
+			Expect(stateStore.SetCall.Receives.State).To(Equal(storage.State{
+				Version: 2,
+				IAAS:    "aws",
+				AWS: storage.AWS{
+					AccessKeyID:     "some-access-key-id",
+					SecretAccessKey: "some-secret-access-key",
+					Region:          "some-region",
+				},
+				EnvID: "some-env-id",
+			}))
+			Expect(stdout.String()).To(BeEmpty())
+			Expect(logger.StepCall.Messages).To(Equal([]string{"importing bbl-state.json from " + exportFile}))
+		})
+
+		It("prompts for the secrets that are not in the secrets directory", func() {
+			err := ioutil.WriteFile(filepath.Join(secretsDir, "aws.accessKeyId"), []byte("some-access-key-id"), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+			stdin.WriteString("some-secret-access-key\n")
+
+			err = command.Execute([]string{"--file", exportFile, "--secrets-dir", secretsDir}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stdout.String()).To(Equal("aws.secretAccessKey: "))
+			Expect(stateStore.SetCall.Receives.State.AWS.SecretAccessKey).To(Equal("some-secret-access-key"))
+		})
+
+		It("prompts for every secret without a secrets directory", func() {
+			stdin.WriteString("some-access-key-id\nsome-secret-access-key")
+
+			err := command.Execute([]string{"--file", exportFile}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stdout.String()).To(Equal("aws.accessKeyId: aws.secretAccessKey: "))
+			Expect(stateStore.SetCall.Receives.State.AWS.AccessKeyID).To(Equal("some-access-key-id"))
+			Expect(stateStore.SetCall.Receives.State.AWS.SecretAccessKey).To(Equal("some-secret-access-key"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when --file is missing", func() {
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("--file must be set to a file written by bbl export-state"))
+			})
+
+			It("returns an error when the flags cannot be parsed", func() {
+				err := command.Execute([]string{"--unknown-flag"}, storage.State{})
+				Expect(err).To(MatchError(ContainSubstring("flag provided but not defined: -unknown-flag")))
+			})
+
+			It("returns an error when a state already exists", func() {
+				err := command.Execute([]string{"--file", exportFile}, storage.State{EnvID: "some-env-id"})
+				Expect(err).To(MatchError("bbl-state.json already exists, import-state only creates a new state"))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the export cannot be read", func() {
+				err := command.Execute([]string{"--file", filepath.Join(tempDir, "missing.json")}, storage.State{})
+				Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+			})
+
+			It("returns an error when the export is not a state", func() {
+				err := ioutil.WriteFile(exportFile, []byte("%%%"), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = command.Execute([]string{"--file", exportFile}, storage.State{})
+				Expect(err).To(MatchError(ContainSubstring("is not a bbl state export")))
+			})
+
+			It("returns an error when a secret is not provided", func() {
+				stdin.WriteString("some-access-key-id\n\n")
+
+				err := command.Execute([]string{"--file", exportFile, "--secrets-dir", secretsDir}, storage.State{})
+				Expect(err).To(MatchError("missing secret for aws.secretAccessKey, enter it when prompted or write it to a file named aws.secretAccessKey in --secrets-dir"))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when a secret file cannot be read", func() {
+				err := os.Mkdir(filepath.Join(secretsDir, "aws.accessKeyId"), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = command.Execute([]string{"--file", exportFile, "--secrets-dir", secretsDir}, storage.State{})
+				Expect(err).To(MatchError(ContainSubstring("is a directory")))
+			})
+
+			It("returns an error when the state cannot be stored", func() {
+				stdin.WriteString(strings.Repeat("some-secret\n", 2))
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("failed to set state")}}
+
+				err := command.Execute([]string{"--file", exportFile}, storage.State{})
+				Expect(err).To(MatchError("failed to set state"))
+			})
+		})
+	})
+})
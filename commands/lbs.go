@@ -17,15 +17,65 @@ type LBs struct {
 	infrastructureManager infrastructureManager
 	stateValidator        stateValidator
 	terraformOutputter    terraformOutputter
+	output                string
 	stdout                io.Writer
 }
 
-func NewLBs(credentialValidator credentialValidator, stateValidator stateValidator, infrastructureManager infrastructureManager, terraformOutputter terraformOutputter, stdout io.Writer) LBs {
+type lbsOutput struct {
+	IAAS    string                  `json:"iaas" yaml:"iaas"`
+	Type    string                  `json:"type" yaml:"type"`
+	LBs     map[string]loadBalancer `json:"lbs" yaml:"lbs"`
+	Outputs map[string]string       `json:"outputs" yaml:"outputs"`
+}
+
+type loadBalancer struct {
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	URL  string `json:"url,omitempty" yaml:"url,omitempty"`
+	IP   string `json:"ip,omitempty" yaml:"ip,omitempty"`
+
+	key   string
+	label string
+}
+
+type lbOutputNames struct {
+	key   string
+	label string
+	name  string
+	url   string
+	ip    string
+}
+
+var (
+	awsLBOutputNames = map[string][]lbOutputNames{
+		"cf": {
+			{key: "cfRouter", label: "CF Router LB", name: "CFRouterLoadBalancer", url: "CFRouterLoadBalancerURL"},
+			{key: "cfSSHProxy", label: "CF SSH Proxy LB", name: "CFSSHProxyLoadBalancer", url: "CFSSHProxyLoadBalancerURL"},
+		},
+		"concourse": {
+			{key: "concourse", label: "Concourse LB", name: "ConcourseLoadBalancer", url: "ConcourseLoadBalancerURL"},
+		},
+	}
+
+	gcpLBOutputNames = map[string][]lbOutputNames{
+		"cf": {
+			{key: "cfRouter", label: "CF Router LB", ip: "router_lb_ip"},
+			{key: "cfSSHProxy", label: "CF SSH Proxy LB", ip: "ssh_proxy_lb_ip"},
+			{key: "cfTCPRouter", label: "CF TCP Router LB", ip: "tcp_router_lb_ip"},
+			{key: "cfWebSocket", label: "CF WebSocket LB", ip: "ws_lb_ip"},
+		},
+		"concourse": {
+			{key: "concourse", label: "Concourse LB", ip: "concourse_lb_ip"},
+		},
+	}
+)
+
+func NewLBs(credentialValidator credentialValidator, stateValidator stateValidator, infrastructureManager infrastructureManager, terraformOutputter terraformOutputter, output string, stdout io.Writer) LBs {
 	return LBs{
 		credentialValidator:   credentialValidator,
 		infrastructureManager: infrastructureManager,
 		stateValidator:        stateValidator,
 		terraformOutputter:    terraformOutputter,
+		output:                output,
 		stdout:                stdout,
 	}
 }
@@ -36,64 +86,109 @@ func (c LBs) Execute(subcommandFlags []string, state storage.State) error {
 		return err
 	}
 
+	var (
+		lbType  string
+		lbs     []loadBalancer
+		outputs map[string]string
+	)
+
 	switch state.IAAS {
 	case "aws":
-		err = c.credentialValidator.ValidateAWS()
-		if err != nil {
-			return err
+		lbType = state.Stack.LBType
+		lbs, outputs, err = c.awsLBs(state)
+	case "gcp":
+		lbType = state.LB.Type
+		lbs, outputs, err = c.gcpLBs(state)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.output == JSONOutput || c.output == YAMLOutput {
+		return c.printStructured(lbsOutput{
+			IAAS:    state.IAAS,
+			Type:    lbType,
+			Outputs: outputs,
+		}, lbs)
+	}
+
+	for _, lb := range lbs {
+		switch state.IAAS {
+		case "aws":
+			fmt.Fprintf(c.stdout, "%s: %s [%s]\n", lb.label, lb.Name, lb.URL)
+		case "gcp":
+			fmt.Fprintf(c.stdout, "%s: %s\n", lb.label, lb.IP)
 		}
+	}
+
+	return nil
+}
 
-		stack, err := c.infrastructureManager.Describe(state.Stack.Name)
+func (c LBs) awsLBs(state storage.State) ([]loadBalancer, map[string]string, error) {
+	err := c.credentialValidator.ValidateAWS()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stack, err := c.infrastructureManager.Describe(state.Stack.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	outputNames, ok := awsLBOutputNames[state.Stack.LBType]
+	if !ok {
+		return nil, nil, errors.New("no lbs found")
+	}
+
+	lbs := []loadBalancer{}
+	for _, names := range outputNames {
+		lbs = append(lbs, loadBalancer{
+			key:   names.key,
+			label: names.label,
+			Name:  stack.Outputs[names.name],
+			URL:   stack.Outputs[names.url],
+		})
+	}
+
+	return lbs, stack.Outputs, nil
+}
+
+func (c LBs) gcpLBs(state storage.State) ([]loadBalancer, map[string]string, error) {
+	outputNames, ok := gcpLBOutputNames[state.LB.Type]
+	if !ok {
+		return nil, nil, errors.New("no lbs found")
+	}
+
+	lbs := []loadBalancer{}
+	outputs := map[string]string{}
+	for _, names := range outputNames {
+		ip, err := c.terraformOutputter.Get(state.TFState, names.ip)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 
-		switch state.Stack.LBType {
-		case "cf":
-			fmt.Fprintf(c.stdout, "CF Router LB: %s [%s]\n", stack.Outputs["CFRouterLoadBalancer"], stack.Outputs["CFRouterLoadBalancerURL"])
-			fmt.Fprintf(c.stdout, "CF SSH Proxy LB: %s [%s]\n", stack.Outputs["CFSSHProxyLoadBalancer"], stack.Outputs["CFSSHProxyLoadBalancerURL"])
-		case "concourse":
-			fmt.Fprintf(c.stdout, "Concourse LB: %s [%s]\n", stack.Outputs["ConcourseLoadBalancer"], stack.Outputs["ConcourseLoadBalancerURL"])
-		default:
-			return errors.New("no lbs found")
-		}
-	case "gcp":
-		switch state.LB.Type {
-		case "cf":
-			routerLB, err := c.terraformOutputter.Get(state.TFState, "router_lb_ip")
-			if err != nil {
-				return err
-			}
-
-			sshProxyLB, err := c.terraformOutputter.Get(state.TFState, "ssh_proxy_lb_ip")
-			if err != nil {
-				return err
-			}
-
-			tcpRouterLB, err := c.terraformOutputter.Get(state.TFState, "tcp_router_lb_ip")
-			if err != nil {
-				return err
-			}
-
-			webSocketLB, err := c.terraformOutputter.Get(state.TFState, "ws_lb_ip")
-			if err != nil {
-				return err
-			}
-
-			fmt.Fprintf(c.stdout, "CF Router LB: %s\n", routerLB)
-			fmt.Fprintf(c.stdout, "CF SSH Proxy LB: %s\n", sshProxyLB)
-			fmt.Fprintf(c.stdout, "CF TCP Router LB: %s\n", tcpRouterLB)
-			fmt.Fprintf(c.stdout, "CF WebSocket LB: %s\n", webSocketLB)
-		case "concourse":
-			concourseLB, err := c.terraformOutputter.Get(state.TFState, "concourse_lb_ip")
-			if err != nil {
-				return err
-			}
-			fmt.Fprintf(c.stdout, "Concourse LB: %s\n", concourseLB)
-		default:
-			return errors.New("no lbs found")
-		}
+		outputs[names.ip] = ip
+		lbs = append(lbs, loadBalancer{
+			key:   names.key,
+			label: names.label,
+			IP:    ip,
+		})
+	}
+
+	return lbs, outputs, nil
+}
+
+func (c LBs) printStructured(output lbsOutput, lbs []loadBalancer) error {
+	output.LBs = map[string]loadBalancer{}
+	for _, lb := range lbs {
+		output.LBs[lb.key] = lb
+	}
+
+	contents, err := marshalOutput(c.output, output)
+	if err != nil {
+		return err
 	}
 
+	fmt.Fprintln(c.stdout, contents)
 	return nil
 }
@@ -31,7 +31,7 @@ var _ = Describe("LBs", func() {
 		terraformOutputter = &fakes.TerraformOutputter{}
 		stdout = bytes.NewBuffer([]byte{})
 
-		lbsCommand = commands.NewLBs(credentialValidator, stateValidator, infrastructureManager, terraformOutputter, stdout)
+		lbsCommand = commands.NewLBs(credentialValidator, stateValidator, infrastructureManager, terraformOutputter, commands.TextOutput, stdout)
 	})
 
 	Describe("Execute", func() {
@@ -93,6 +93,43 @@ var _ = Describe("LBs", func() {
 				Expect(stdout.String()).To(ContainSubstring("Concourse LB: some-lb-name [http://some.lb.url]"))
 			})
 
+			It("prints the lbs and every stack output as json with --output json", func() {
+				infrastructureManager.DescribeCall.Returns.Stack = cloudformation.Stack{
+					Name: "some-stack-name",
+					Outputs: map[string]string{
+						"CFRouterLoadBalancer":      "some-lb-name",
+						"CFRouterLoadBalancerURL":   "http://some.lb.url",
+						"CFSSHProxyLoadBalancer":    "some-other-lb-name",
+						"CFSSHProxyLoadBalancerURL": "http://some.other.lb.url",
+						"VPCID":                     "some-vpc-id",
+					},
+				}
+				incomingState.Stack = storage.Stack{
+					LBType: "cf",
+					Name:   "some-stack-name",
+				}
+
+				lbsCommand = commands.NewLBs(credentialValidator, stateValidator, infrastructureManager, terraformOutputter, commands.JSONOutput, stdout)
+				err := lbsCommand.Execute([]string{}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stdout.String()).To(MatchJSON(`{
+					"iaas": "aws",
+					"type": "cf",
+					"lbs": {
+						"cfRouter": {"name": "some-lb-name", "url": "http://some.lb.url"},
+						"cfSSHProxy": {"name": "some-other-lb-name", "url": "http://some.other.lb.url"}
+					},
+					"outputs": {
+						"CFRouterLoadBalancer": "some-lb-name",
+						"CFRouterLoadBalancerURL": "http://some.lb.url",
+						"CFSSHProxyLoadBalancer": "some-other-lb-name",
+						"CFSSHProxyLoadBalancerURL": "http://some.other.lb.url",
+						"VPCID": "some-vpc-id"
+					}
+				}`))
+			})
+
 			It("returns error when lb type is not cf or concourse", func() {
 				incomingState.Stack = storage.Stack{
 					LBType: "",
@@ -173,6 +210,25 @@ var _ = Describe("LBs", func() {
 				Expect(stdout.String()).To(ContainSubstring("Concourse LB: some-concourse-lb-ip"))
 			})
 
+			It("prints the lbs and their terraform outputs as yaml with --output yaml", func() {
+				incomingState.LB = storage.LB{
+					Type: "concourse",
+				}
+
+				lbsCommand = commands.NewLBs(credentialValidator, stateValidator, infrastructureManager, terraformOutputter, commands.YAMLOutput, stdout)
+				err := lbsCommand.Execute([]string{}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stdout.String()).To(Equal(`iaas: gcp
+type: concourse
+lbs:
+  concourse:
+    ip: some-concourse-lb-ip
+outputs:
+  concourse_lb_ip: some-concourse-lb-ip
+`))
+			})
+
 			Context("failure cases", func() {
 				It("returns an error when terraform outputter fails to return router_lb_ip", func() {
 					terraformOutputter.GetCall.Stub = func(output string) (string, error) {
@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	MigrateStateCommand = "migrate-state"
+)
+
+type stateMigrator interface {
+	PlanMigration() (storage.MigrationPlan, error)
+	Migrate() (storage.MigrationPlan, error)
+}
+
+type MigrateState struct {
+	logger         logger
+	stateValidator stateValidator
+	stateMigrator  stateMigrator
+	stdout         io.Writer
+}
+
+func NewMigrateState(logger logger, stateValidator stateValidator, stateMigrator stateMigrator, stdout io.Writer) MigrateState {
+	return MigrateState{
+		logger:         logger,
+		stateValidator: stateValidator,
+		stateMigrator:  stateMigrator,
+		stdout:         stdout,
+	}
+}
+
+func (m MigrateState) Execute(subcommandFlags []string, state storage.State) error {
+	err := m.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	var dryRun bool
+	err = migrateStateFlags(&dryRun).Parse(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		plan, err := m.stateMigrator.PlanMigration()
+		if err != nil {
+			return err
+		}
+
+		if plan.Empty() {
+			fmt.Fprintf(m.stdout, "bbl-state.json is already at version %d\n", plan.ToVersion)
+			return nil
+		}
+
+		fmt.Fprintf(m.stdout, "bbl-state.json would be migrated from version %d to version %d:\n", plan.FromVersion, plan.ToVersion)
+		for _, description := range plan.Migrations {
+			fmt.Fprintf(m.stdout, "  %s\n", description)
+		}
+		fmt.Fprintln(m.stdout)
+		for _, change := range plan.Changes {
+			fmt.Fprintln(m.stdout, formatStateChange(change))
+		}
+
+		return nil
+	}
+
+	plan, err := m.stateMigrator.Migrate()
+	if err != nil {
+		return err
+	}
+
+	if plan.Empty() {
+		m.logger.Println(fmt.Sprintf("bbl-state.json is already at version %d", plan.ToVersion))
+		return nil
+	}
+
+	for _, description := range plan.Migrations {
+		m.logger.Step("migrated bbl-state.json %s", description)
+	}
+
+	return nil
+}
+
+func formatStateChange(change storage.StateChange) string {
+	switch {
+	case change.Added():
+		return fmt.Sprintf("+ %s: %s", change.Path, formatStateValue(change.Current))
+	case change.Removed():
+		return fmt.Sprintf("- %s: %s", change.Path, formatStateValue(change.Previous))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", change.Path, formatStateValue(change.Previous), formatStateValue(change.Current))
+	}
+}
+
+func formatStateValue(value interface{}) string {
+	contents, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return string(contents)
+}
+
+func (MigrateState) Flags() flags.Flags {
+	var dryRun bool
+	return migrateStateFlags(&dryRun)
+}
+
+// MigrateStateDryRun reports whether migrate-state is run with --dry-run, in
+// which case it only reads the state.
+func MigrateStateDryRun(subcommandFlags []string) bool {
+	var dryRun bool
+	err := migrateStateFlags(&dryRun).Parse(subcommandFlags)
+
+	return err == nil && dryRun
+}
+
+func migrateStateFlags(dryRun *bool) flags.Flags {
+	migrateFlags := flags.New("migrate-state")
+	migrateFlags.Bool(dryRun, "", "dry-run", false)
+
+	return migrateFlags
+}
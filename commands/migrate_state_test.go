@@ -0,0 +1,138 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MigrateState", func() {
+	var (
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+		stateMigrator  *fakes.StateMigrator
+		stdout         *bytes.Buffer
+		command        commands.MigrateState
+
+		plan storage.MigrationPlan
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		stateMigrator = &fakes.StateMigrator{}
+		stdout = bytes.NewBuffer([]byte{})
+
+		plan = storage.MigrationPlan{
+			FromVersion: 1,
+			ToVersion:   2,
+			Migrations:  []string{"1 -> 2: default iaas to aws"},
+			Changes: []storage.StateChange{
+				{Path: "bosh.directorName", Previous: "some-director"},
+				{Path: "iaas", Current: "aws"},
+				{Path: "version", Previous: 1, Current: 2},
+			},
+		}
+
+		command = commands.NewMigrateState(logger, stateValidator, stateMigrator, stdout)
+	})
+
+	Describe("Execute", func() {
+		It("migrates the state", func() {
+			stateMigrator.MigrateCall.Returns.Plan = plan
+
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
+			Expect(stateMigrator.MigrateCall.CallCount).To(Equal(1))
+			Expect(stateMigrator.PlanMigrationCall.CallCount).To(Equal(0))
+			Expect(logger.StepCall.Messages).To(Equal([]string{"migrated bbl-state.json 1 -> 2: default iaas to aws"}))
+		})
+
+		It("reports when the state is already current", func() {
+			stateMigrator.MigrateCall.Returns.Plan = storage.MigrationPlan{FromVersion: 2, ToVersion: 2}
+
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal("bbl-state.json is already at version 2"))
+			Expect(logger.StepCall.CallCount).To(Equal(0))
+		})
+
+		Context("when --dry-run is passed", func() {
+			It("prints the changes without migrating the state", func() {
+				stateMigrator.PlanMigrationCall.Returns.Plan = plan
+
+				err := command.Execute([]string{"--dry-run"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stateMigrator.PlanMigrationCall.CallCount).To(Equal(1))
+				Expect(stateMigrator.MigrateCall.CallCount).To(Equal(0))
+				Expect(stdout.String()).To(Equal(`bbl-state.json would be migrated from version 1 to version 2:
+  1 -> 2: default iaas to aws
+
+- bosh.directorName: "some-director"
++ iaas: "aws"
+~ version: 1 -> 2
+`))
+			})
+
+			It("prints that the state is already current", func() {
+				stateMigrator.PlanMigrationCall.Returns.Plan = storage.MigrationPlan{FromVersion: 2, ToVersion: 2}
+
+				err := command.Execute([]string{"--dry-run"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stdout.String()).To(Equal("bbl-state.json is already at version 2\n"))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the state validator fails", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("state validator failed"))
+				Expect(stateMigrator.MigrateCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the flags cannot be parsed", func() {
+				err := command.Execute([]string{"--unknown-flag"}, storage.State{})
+				Expect(err).To(MatchError(ContainSubstring("flag provided but not defined: -unknown-flag")))
+			})
+
+			It("returns an error when the migration cannot be planned", func() {
+				stateMigrator.PlanMigrationCall.Returns.Error = errors.New("failed to plan")
+
+				err := command.Execute([]string{"--dry-run"}, storage.State{})
+				Expect(err).To(MatchError("failed to plan"))
+			})
+
+			It("returns an error when the migration fails", func() {
+				stateMigrator.MigrateCall.Returns.Error = errors.New("failed to migrate")
+
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("failed to migrate"))
+			})
+		})
+	})
+
+	Describe("MigrateStateDryRun", func() {
+		It("reports whether --dry-run is given", func() {
+			Expect(commands.MigrateStateDryRun([]string{"--dry-run"})).To(BeTrue())
+			Expect(commands.MigrateStateDryRun([]string{"--dry-run=false"})).To(BeFalse())
+			Expect(commands.MigrateStateDryRun([]string{})).To(BeFalse())
+		})
+
+		It("reports false when the flags cannot be parsed", func() {
+			Expect(commands.MigrateStateDryRun([]string{"--dry-run", "--unknown-flag"})).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	TextOutput = "text"
+	JSONOutput = "json"
+	YAMLOutput = "yaml"
+)
+
+func ValidateOutput(output string) error {
+	switch output {
+	case TextOutput, JSONOutput, YAMLOutput:
+		return nil
+	default:
+		return fmt.Errorf("--output must be one of text, json or yaml, got %q", output)
+	}
+}
+
+func marshalOutput(output string, value interface{}) (string, error) {
+	var (
+		contents []byte
+		err      error
+	)
+
+	switch output {
+	case JSONOutput:
+		contents, err = json.MarshalIndent(value, "", "  ")
+	case YAMLOutput:
+		contents, err = yaml.Marshal(value)
+	default:
+		return "", fmt.Errorf("cannot marshal %s output", output)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(contents), "\n"), nil
+}
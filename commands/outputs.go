@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	OutputsCommand = "outputs"
+)
+
+type terraformOutputsGetter interface {
+	All(tfState string) (map[string]interface{}, error)
+}
+
+type Outputs struct {
+	credentialValidator   credentialValidator
+	stateValidator        stateValidator
+	infrastructureManager infrastructureManager
+	terraformOutputter    terraformOutputsGetter
+	output                string
+	stdout                io.Writer
+}
+
+func NewOutputs(credentialValidator credentialValidator, stateValidator stateValidator, infrastructureManager infrastructureManager,
+	terraformOutputter terraformOutputsGetter, output string, stdout io.Writer) Outputs {
+	return Outputs{
+		credentialValidator:   credentialValidator,
+		stateValidator:        stateValidator,
+		infrastructureManager: infrastructureManager,
+		terraformOutputter:    terraformOutputter,
+		output:                output,
+		stdout:                stdout,
+	}
+}
+
+func (o Outputs) Execute(subcommandFlags []string, state storage.State) error {
+	err := o.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	var outputs map[string]interface{}
+	switch state.IAAS {
+	case "aws":
+		outputs, err = o.awsOutputs(state)
+	case "gcp":
+		outputs, err = o.gcpOutputs(state)
+	default:
+		return fmt.Errorf("cannot print the outputs of iaas %q", state.IAAS)
+	}
+	if err != nil {
+		return err
+	}
+
+	if o.output == JSONOutput || o.output == YAMLOutput {
+		contents, err := marshalOutput(o.output, outputs)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(o.stdout, contents)
+		return nil
+	}
+
+	names := []string{}
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, ok := outputs[name].(string)
+		if !ok {
+			contents, err := json.Marshal(outputs[name])
+			if err != nil {
+				return err
+			}
+			value = string(contents)
+		}
+
+		fmt.Fprintf(o.stdout, "%s: %s\n", name, value)
+	}
+
+	return nil
+}
+
+func (o Outputs) awsOutputs(state storage.State) (map[string]interface{}, error) {
+	err := o.credentialValidator.ValidateAWS()
+	if err != nil {
+		return nil, err
+	}
+
+	if state.Stack.Name == "" {
+		return nil, BBLNotFound
+	}
+
+	stack, err := o.infrastructureManager.Describe(state.Stack.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := map[string]interface{}{}
+	for name, value := range stack.Outputs {
+		outputs[name] = value
+	}
+
+	return outputs, nil
+}
+
+func (o Outputs) gcpOutputs(state storage.State) (map[string]interface{}, error) {
+	if state.TFState == "" {
+		return nil, BBLNotFound
+	}
+
+	return o.terraformOutputter.All(state.TFState)
+}
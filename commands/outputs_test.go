@@ -0,0 +1,163 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/aws/cloudformation"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Outputs", func() {
+	var (
+		credentialValidator   *fakes.CredentialValidator
+		stateValidator        *fakes.StateValidator
+		infrastructureManager *fakes.InfrastructureManager
+		terraformOutputter    *fakes.TerraformOutputter
+		stdout                *bytes.Buffer
+		command               commands.Outputs
+	)
+
+	BeforeEach(func() {
+		credentialValidator = &fakes.CredentialValidator{}
+		stateValidator = &fakes.StateValidator{}
+		infrastructureManager = &fakes.InfrastructureManager{}
+		terraformOutputter = &fakes.TerraformOutputter{}
+		stdout = bytes.NewBuffer([]byte{})
+
+		command = commands.NewOutputs(credentialValidator, stateValidator, infrastructureManager, terraformOutputter, commands.TextOutput, stdout)
+	})
+
+	Describe("Execute", func() {
+		Context("on gcp", func() {
+			var state storage.State
+
+			BeforeEach(func() {
+				state = storage.State{
+					IAAS:    "gcp",
+					TFState: "some-tf-state",
+				}
+
+				terraformOutputter.AllCall.Returns.Outputs = map[string]interface{}{
+					"network_name": "some-network",
+					"bucket_names": []interface{}{"some-bucket", "some-other-bucket"},
+				}
+			})
+
+			It("prints the outputs of the terraform state sorted by name", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
+				Expect(terraformOutputter.AllCall.Receives.TFState).To(Equal("some-tf-state"))
+				Expect(stdout.String()).To(Equal(`bucket_names: ["some-bucket","some-other-bucket"]
+network_name: some-network
+`))
+			})
+
+			It("prints the outputs as json when --output json is given", func() {
+				command = commands.NewOutputs(credentialValidator, stateValidator, infrastructureManager, terraformOutputter, commands.JSONOutput, stdout)
+
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stdout.String()).To(MatchJSON(`{
+					"bucket_names": ["some-bucket", "some-other-bucket"],
+					"network_name": "some-network"
+				}`))
+			})
+
+			Context("failure cases", func() {
+				It("returns an error when there is no terraform state", func() {
+					state.TFState = ""
+
+					err := command.Execute([]string{}, state)
+					Expect(err).To(MatchError(commands.BBLNotFound))
+				})
+
+				It("returns an error when the outputs cannot be read", func() {
+					terraformOutputter.AllCall.Returns.Error = errors.New("failed to read outputs")
+
+					err := command.Execute([]string{}, state)
+					Expect(err).To(MatchError("failed to read outputs"))
+				})
+			})
+		})
+
+		Context("on aws", func() {
+			var state storage.State
+
+			BeforeEach(func() {
+				state = storage.State{
+					IAAS: "aws",
+					Stack: storage.Stack{
+						Name: "some-stack-name",
+					},
+				}
+
+				infrastructureManager.DescribeCall.Returns.Stack = cloudformation.Stack{
+					Name: "some-stack-name",
+					Outputs: map[string]string{
+						"VPCID":             "some-vpc-id",
+						"BOSHEIP":           "some-eip",
+						"InternalSubnet1AZ": "some-az",
+					},
+				}
+			})
+
+			It("prints the outputs of the stack sorted by name", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(credentialValidator.ValidateAWSCall.CallCount).To(Equal(1))
+				Expect(infrastructureManager.DescribeCall.Receives.StackName).To(Equal("some-stack-name"))
+				Expect(stdout.String()).To(Equal(`BOSHEIP: some-eip
+InternalSubnet1AZ: some-az
+VPCID: some-vpc-id
+`))
+			})
+
+			Context("failure cases", func() {
+				It("returns an error when the aws credentials are missing", func() {
+					credentialValidator.ValidateAWSCall.Returns.Error = errors.New("aws credentials missing")
+
+					err := command.Execute([]string{}, state)
+					Expect(err).To(MatchError("aws credentials missing"))
+				})
+
+				It("returns an error when there is no stack", func() {
+					state.Stack.Name = ""
+
+					err := command.Execute([]string{}, state)
+					Expect(err).To(MatchError(commands.BBLNotFound))
+				})
+
+				It("returns an error when the stack cannot be described", func() {
+					infrastructureManager.DescribeCall.Returns.Error = errors.New("failed to describe stack")
+
+					err := command.Execute([]string{}, state)
+					Expect(err).To(MatchError("failed to describe stack"))
+				})
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the state is not valid", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("state not found")
+
+				err := command.Execute([]string{}, storage.State{IAAS: "gcp", TFState: "some-tf-state"})
+				Expect(err).To(MatchError("state not found"))
+			})
+
+			It("returns an error when the iaas is unknown", func() {
+				err := command.Execute([]string{}, storage.State{IAAS: "some-iaas"})
+				Expect(err).To(MatchError(`cannot print the outputs of iaas "some-iaas"`))
+			})
+		})
+	})
+})
@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	PlanCommand = "plan"
+)
+
+type Plan struct {
+	up Up
+}
+
+func NewPlan(up Up) Plan {
+	return Plan{
+		up: up,
+	}
+}
+
+func (p Plan) Flags() flags.Flags {
+	return p.up.Flags()
+}
+
+func (p Plan) Execute(subcommandFlags []string, state storage.State) error {
+	return p.up.Execute(append([]string{"--dry-run"}, subcommandFlags...), state)
+}
@@ -0,0 +1,46 @@
+package commands_test
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Plan", func() {
+	var (
+		command commands.Plan
+
+		fakeAWSUp          *fakes.AWSUp
+		fakeGCPUp          *fakes.GCPUp
+		fakeEnvIDGenerator *fakes.EnvIDGenerator
+	)
+
+	BeforeEach(func() {
+		fakeAWSUp = &fakes.AWSUp{Name: "aws"}
+		fakeGCPUp = &fakes.GCPUp{Name: "gcp"}
+		fakeEnvIDGenerator = &fakes.EnvIDGenerator{}
+
+		command = commands.NewPlan(commands.NewUp(fakeAWSUp, fakeGCPUp, fakeEnvIDGenerator))
+	})
+
+	Describe("Execute", func() {
+		It("runs up as a dry run with the given flags", func() {
+			err := command.Execute([]string{"--aws-region", "some-region"}, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAWSUp.ExecuteCall.CallCount).To(Equal(1))
+			Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig).To(Equal(commands.AWSUpConfig{
+				Region: "some-region",
+				DryRun: true,
+			}))
+		})
+
+		It("returns an error when up fails", func() {
+			err := command.Execute([]string{"--unknown-flag"}, storage.State{IAAS: "aws"})
+			Expect(err).To(MatchError(ContainSubstring("flag provided but not defined: -unknown-flag")))
+		})
+	})
+})
@@ -0,0 +1,204 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	PrintEnvCommand = "print-env"
+
+	directorCACertFileName = "director-ca-cert.pem"
+	sshKeyFileName         = "ssh-key"
+)
+
+type PrintEnv struct {
+	stateValidator stateValidator
+	dir            string
+	stdout         io.Writer
+}
+
+type printEnvConfig struct {
+	shell string
+	json  bool
+}
+
+type envVar struct {
+	name  string
+	value string
+}
+
+func NewPrintEnv(stateValidator stateValidator, dir string, stdout io.Writer) PrintEnv {
+	return PrintEnv{
+		stateValidator: stateValidator,
+		dir:            dir,
+		stdout:         stdout,
+	}
+}
+
+func (p PrintEnv) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := p.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	err = p.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	if state.BOSH.DirectorAddress == "" {
+		return fmt.Errorf("Could not retrieve %s, please make sure you are targeting the proper state dir.", DirectorAddressPropertyName)
+	}
+
+	vars, err := p.envVars(state)
+	if err != nil {
+		return err
+	}
+
+	if config.json {
+		return p.printJSON(vars)
+	}
+
+	for _, v := range vars {
+		switch config.shell {
+		case "fish":
+			fmt.Fprintf(p.stdout, "set -gx %s %s;\n", v.name, fishQuote(v.value))
+		case "powershell":
+			fmt.Fprintf(p.stdout, "$env:%s=%s\n", v.name, powershellQuote(v.value))
+		default:
+			fmt.Fprintf(p.stdout, "export %s=%s\n", v.name, bashQuote(v.value))
+		}
+	}
+
+	return nil
+}
+
+func (p PrintEnv) envVars(state storage.State) ([]envVar, error) {
+	name := "bbl-" + state.EnvID + "-env"
+	if state.EnvID == "" {
+		name = "bbl-env"
+	}
+
+	// The files are overwritten on every run, so that printing the variables
+	// again does not leave another copy of the ssh key behind.
+	dir, err := filepath.Abs(filepath.Join(p.dir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	// MkdirAll keeps the mode of an existing directory.
+	err = os.Chmod(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := []envVar{
+		{"BOSH_ENVIRONMENT", state.BOSH.DirectorAddress},
+		{"BOSH_CLIENT", state.BOSH.DirectorUsername},
+		{"BOSH_CLIENT_SECRET", state.BOSH.DirectorPassword},
+	}
+
+	if state.BOSH.DirectorSSLCA != "" {
+		path := filepath.Join(dir, directorCACertFileName)
+		err = writePrivateFile(path, state.BOSH.DirectorSSLCABundle())
+		if err != nil {
+			return nil, err
+		}
+		vars = append(vars, envVar{"BOSH_CA_CERT", path})
+	}
+
+	if state.KeyPair.PrivateKey != "" {
+		path := filepath.Join(dir, sshKeyFileName)
+		err = writePrivateFile(path, state.KeyPair.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		vars = append(vars, envVar{"BOSH_GW_PRIVATE_KEY", path})
+	}
+
+	return vars, nil
+}
+
+// writePrivateFile writes a file only the current user can read, also when
+// it already exists with looser permissions.
+func writePrivateFile(path, contents string) error {
+	err := ioutil.WriteFile(path, []byte(contents), 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Chmod(path, 0600)
+}
+
+func (p PrintEnv) printJSON(vars []envVar) error {
+	values := map[string]string{}
+	for _, v := range vars {
+		values[v.name] = v.value
+	}
+
+	contents, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(p.stdout, string(contents))
+	return nil
+}
+
+func (PrintEnv) Flags() flags.Flags {
+	return printEnvFlags(&printEnvConfig{})
+}
+
+func (PrintEnv) parseFlags(subcommandFlags []string) (printEnvConfig, error) {
+	config := printEnvConfig{}
+
+	err := printEnvFlags(&config).Parse(subcommandFlags)
+	if err != nil {
+		return printEnvConfig{}, err
+	}
+
+	switch config.shell {
+	case "bash", "fish", "powershell":
+	default:
+		return printEnvConfig{}, fmt.Errorf("--shell must be one of bash, fish or powershell, got %q", config.shell)
+	}
+
+	return config, nil
+}
+
+func printEnvFlags(config *printEnvConfig) flags.Flags {
+	envFlags := flags.New("print-env")
+	envFlags.String(&config.shell, "shell", "bash")
+	envFlags.Bool(&config.json, "", "json", false)
+
+	envFlags.Values("shell", "bash", "fish", "powershell")
+
+	return envFlags
+}
+
+func bashQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
+
+func fishQuote(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	return "'" + strings.Replace(value, "'", `\'`, -1) + "'"
+}
+
+func powershellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", "''", -1) + "'"
+}
@@ -0,0 +1,208 @@
+package commands_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PrintEnv", func() {
+	var (
+		stateValidator *fakes.StateValidator
+		stdout         *bytes.Buffer
+		tempDir        string
+		state          storage.State
+		command        commands.PrintEnv
+	)
+
+	envDir := func() string {
+		return filepath.Join(tempDir, "bbl-some-env-id-env")
+	}
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		stateValidator = &fakes.StateValidator{}
+		stdout = bytes.NewBuffer([]byte{})
+
+		state = storage.State{
+			EnvID: "some-env-id",
+			KeyPair: storage.KeyPair{
+				PrivateKey: "some-private-key",
+			},
+			BOSH: storage.BOSH{
+				DirectorAddress:  "https://10.0.0.6:25555",
+				DirectorUsername: "some-director-username",
+				DirectorPassword: "some-director-'password'",
+				DirectorSSLCA:    "some-director-ca-cert",
+			},
+		}
+
+		command = commands.NewPrintEnv(stateValidator, tempDir, stdout)
+	})
+
+	Describe("Execute", func() {
+		It("writes the director ca cert and ssh key to files and prints bash exports", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
+
+			caCertPath := filepath.Join(envDir(), "director-ca-cert.pem")
+			sshKeyPath := filepath.Join(envDir(), "ssh-key")
+			Expect(stdout.String()).To(Equal(`export BOSH_ENVIRONMENT='https://10.0.0.6:25555'
+export BOSH_CLIENT='some-director-username'
+export BOSH_CLIENT_SECRET='some-director-'\''password'\'''
+export BOSH_CA_CERT='` + caCertPath + `'
+export BOSH_GW_PRIVATE_KEY='` + sshKeyPath + `'
+`))
+
+			caCert, err := ioutil.ReadFile(caCertPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(caCert)).To(Equal("some-director-ca-cert"))
+
+			sshKey, err := ioutil.ReadFile(sshKeyPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(sshKey)).To(Equal("some-private-key"))
+
+			info, err := os.Stat(sshKeyPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode()).To(Equal(os.FileMode(0600)))
+
+			info, err = os.Lstat(envDir())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode()).To(Equal(os.ModeDir | 0700))
+		})
+
+		It("overwrites the files in the same directory every time", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.Chmod(envDir(), 0755)).To(Succeed())
+			Expect(os.Chmod(filepath.Join(envDir(), "ssh-key"), 0644)).To(Succeed())
+
+			state.KeyPair.PrivateKey = "some-new-private-key"
+			err = command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			dirs, err := filepath.Glob(filepath.Join(tempDir, "bbl-*"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dirs).To(Equal([]string{envDir()}))
+
+			sshKey, err := ioutil.ReadFile(filepath.Join(envDir(), "ssh-key"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(sshKey)).To(Equal("some-new-private-key"))
+
+			info, err := os.Stat(filepath.Join(envDir(), "ssh-key"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode()).To(Equal(os.FileMode(0600)))
+
+			info, err = os.Stat(envDir())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode()).To(Equal(os.ModeDir | 0700))
+		})
+
+		It("prints fish variables with --shell fish", func() {
+			err := command.Execute([]string{"--shell", "fish"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stdout.String()).To(HavePrefix(`set -gx BOSH_ENVIRONMENT 'https://10.0.0.6:25555';
+set -gx BOSH_CLIENT 'some-director-username';
+set -gx BOSH_CLIENT_SECRET 'some-director-\'password\'';
+`))
+		})
+
+		It("prints powershell variables with --shell powershell", func() {
+			err := command.Execute([]string{"--shell", "powershell"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stdout.String()).To(HavePrefix(`$env:BOSH_ENVIRONMENT='https://10.0.0.6:25555'
+$env:BOSH_CLIENT='some-director-username'
+$env:BOSH_CLIENT_SECRET='some-director-''password'''
+`))
+		})
+
+		It("prints a json object with --json", func() {
+			err := command.Execute([]string{"--json"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			var vars map[string]string
+			Expect(json.Unmarshal(stdout.Bytes(), &vars)).To(Succeed())
+			Expect(vars).To(Equal(map[string]string{
+				"BOSH_ENVIRONMENT":    "https://10.0.0.6:25555",
+				"BOSH_CLIENT":         "some-director-username",
+				"BOSH_CLIENT_SECRET":  "some-director-'password'",
+				"BOSH_CA_CERT":        filepath.Join(envDir(), "director-ca-cert.pem"),
+				"BOSH_GW_PRIVATE_KEY": filepath.Join(envDir(), "ssh-key"),
+			}))
+		})
+
+		It("writes both CAs while the director CA is being rotated", func() {
+			state.BOSH.DirectorSSLRotationPhase = "trust"
+			state.BOSH.DirectorSSLRotationCA = "some-new-director-ca-cert"
+
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			caCert, err := ioutil.ReadFile(filepath.Join(envDir(), "director-ca-cert.pem"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(caCert)).To(Equal("some-director-ca-cert\nsome-new-director-ca-cert"))
+		})
+
+		It("leaves out the ca cert and ssh key when the state has none", func() {
+			state.BOSH.DirectorSSLCA = ""
+			state.KeyPair.PrivateKey = ""
+
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stdout.String()).NotTo(ContainSubstring("BOSH_CA_CERT"))
+			Expect(stdout.String()).NotTo(ContainSubstring("BOSH_GW_PRIVATE_KEY"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the state validator fails", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("state validator failed"))
+			})
+
+			It("returns an error when the state has no director", func() {
+				state.BOSH = storage.BOSH{}
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("Could not retrieve director address, please make sure you are targeting the proper state dir."))
+			})
+
+			It("returns an error when the shell is not supported", func() {
+				err := command.Execute([]string{"--shell", "tcsh"}, state)
+				Expect(err).To(MatchError(`--shell must be one of bash, fish or powershell, got "tcsh"`))
+			})
+
+			It("returns an error when the files cannot be written", func() {
+				command = commands.NewPrintEnv(stateValidator, "/dev/null", stdout)
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("returns an error when the flags cannot be parsed", func() {
+				err := command.Execute([]string{"--unknown-flag"}, state)
+				Expect(err).To(MatchError(ContainSubstring("flag provided but not defined: -unknown-flag")))
+			})
+		})
+	})
+})
@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/boshinit"
+	"github.com/cloudfoundry/bosh-bootloader/ssl"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	RotateDirectorCACommand = "rotate-director-ca"
+)
+
+type sslKeyPairGenerator interface {
+	Generate(caCommonName, commonName string) (ssl.KeyPair, error)
+}
+
+// RotateDirectorCA rotates the director CA in three phases, one per run, so
+// that BOSH clients can pick up the CA bundle printed by bbl director-ca-cert
+// before the director switches its certificate:
+//
+//  1. trust:  the director trusts the current and a new CA
+//  2. switch: the director serves a certificate signed by the new CA
+//  3. the director only trusts the new CA
+type RotateDirectorCA struct {
+	logger                 logger
+	stateValidator         stateValidator
+	stateStore             stateStore
+	stringGenerator        stringGenerator
+	boshDeployer           boshDeployer
+	sslKeyPairGenerator    sslKeyPairGenerator
+	directorInfrastructure directorInfrastructure
+}
+
+func NewRotateDirectorCA(logger logger, stateValidator stateValidator, stateStore stateStore, stringGenerator stringGenerator,
+	boshDeployer boshDeployer, sslKeyPairGenerator sslKeyPairGenerator, credentialValidator credentialValidator,
+	infrastructureManager infrastructureManager, terraformOutputter terraformOutputter) RotateDirectorCA {
+	return RotateDirectorCA{
+		logger:                 logger,
+		stateValidator:         stateValidator,
+		stateStore:             stateStore,
+		stringGenerator:        stringGenerator,
+		boshDeployer:           boshDeployer,
+		sslKeyPairGenerator:    sslKeyPairGenerator,
+		directorInfrastructure: newDirectorInfrastructure(credentialValidator, infrastructureManager, terraformOutputter),
+	}
+}
+
+func (r RotateDirectorCA) Execute(subcommandFlags []string, state storage.State) error {
+	err := r.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	if state.BOSH.IsEmpty() {
+		return BBLNotFound
+	}
+
+	infrastructureConfiguration, err := r.directorInfrastructure.configuration(state)
+	if err != nil {
+		return err
+	}
+
+	rotatedState := state
+	var next string
+
+	switch state.BOSH.DirectorSSLRotationPhase {
+	case "":
+		keyPair, err := r.sslKeyPairGenerator.Generate(boshinit.BOSH_BOOTLOADER_COMMON_NAME, infrastructureConfiguration.ExternalIP)
+		if err != nil {
+			return err
+		}
+
+		rotatedState.BOSH.DirectorSSLRotationPhase = storage.DirectorSSLRotationTrust
+		rotatedState.BOSH.DirectorSSLRotationCA = string(keyPair.CA)
+		rotatedState.BOSH.DirectorSSLRotationCertificate = string(keyPair.Certificate)
+		rotatedState.BOSH.DirectorSSLRotationPrivateKey = string(keyPair.PrivateKey)
+
+		r.logger.Step("redeploying the director trusting the current and the new director CA")
+		next = "add the CA bundle printed by bbl director-ca-cert to the BOSH clients, then run bbl rotate-director-ca again to switch the director certificate"
+	case storage.DirectorSSLRotationTrust:
+		rotatedState.BOSH.DirectorSSLRotationPhase = storage.DirectorSSLRotationSwitch
+		rotatedState.BOSH.DirectorSSLCA = state.BOSH.DirectorSSLRotationCA
+		rotatedState.BOSH.DirectorSSLCertificate = state.BOSH.DirectorSSLRotationCertificate
+		rotatedState.BOSH.DirectorSSLPrivateKey = state.BOSH.DirectorSSLRotationPrivateKey
+		rotatedState.BOSH.DirectorSSLRotationCA = state.BOSH.DirectorSSLCA
+		rotatedState.BOSH.DirectorSSLRotationCertificate = ""
+		rotatedState.BOSH.DirectorSSLRotationPrivateKey = ""
+
+		r.logger.Step("redeploying the director with a certificate signed by the new director CA")
+		next = "run bbl rotate-director-ca again to stop trusting the previous director CA"
+	case storage.DirectorSSLRotationSwitch:
+		rotatedState.BOSH.DirectorSSLRotationPhase = ""
+		rotatedState.BOSH.DirectorSSLRotationCA = ""
+
+		r.logger.Step("redeploying the director without the previous director CA")
+		next = "the director CA has been rotated, bbl director-ca-cert now prints only the new CA"
+	default:
+		return fmt.Errorf("unknown director CA rotation phase %q", state.BOSH.DirectorSSLRotationPhase)
+	}
+
+	deployInput, err := boshinit.NewDeployInput(rotatedState, infrastructureConfiguration, r.stringGenerator, rotatedState.EnvID, rotatedState.IAAS)
+	if err != nil {
+		return err
+	}
+
+	deployOutput, err := r.boshDeployer.Deploy(deployInput)
+	if err != nil {
+		return fmt.Errorf("failed to redeploy the director, the director CA rotation did not advance: %s", err)
+	}
+
+	rotatedState.BOSH.State = deployOutput.BOSHInitState
+	rotatedState.BOSH.Manifest = deployOutput.BOSHInitManifest
+
+	err = r.stateStore.Set(rotatedState)
+	if err != nil {
+		return err
+	}
+
+	r.logger.Println(next)
+
+	return nil
+}
@@ -0,0 +1,228 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/aws/cloudformation"
+	"github.com/cloudfoundry/bosh-bootloader/boshinit"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/ssl"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RotateDirectorCA", func() {
+	var (
+		logger                *fakes.Logger
+		stateValidator        *fakes.StateValidator
+		stateStore            *fakes.StateStore
+		stringGenerator       *fakes.StringGenerator
+		boshDeployer          *fakes.BOSHDeployer
+		sslKeyPairGenerator   *fakes.SSLKeyPairGenerator
+		credentialValidator   *fakes.CredentialValidator
+		infrastructureManager *fakes.InfrastructureManager
+		terraformOutputter    *fakes.TerraformOutputter
+		state                 storage.State
+		command               commands.RotateDirectorCA
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		stateStore = &fakes.StateStore{}
+		stringGenerator = &fakes.StringGenerator{}
+		boshDeployer = &fakes.BOSHDeployer{}
+		sslKeyPairGenerator = &fakes.SSLKeyPairGenerator{}
+		credentialValidator = &fakes.CredentialValidator{}
+		infrastructureManager = &fakes.InfrastructureManager{}
+		terraformOutputter = &fakes.TerraformOutputter{}
+
+		sslKeyPairGenerator.GenerateCall.Returns.KeyPair = ssl.KeyPair{
+			CA:          []byte("some-new-ca\n"),
+			Certificate: []byte("some-new-certificate"),
+			PrivateKey:  []byte("some-new-private-key"),
+		}
+
+		boshDeployer.DeployCall.Returns.Output = boshinit.DeployOutput{
+			BOSHInitState:    boshinit.State{"some-key": "some-new-value"},
+			BOSHInitManifest: "some-new-manifest",
+		}
+
+		infrastructureManager.DescribeCall.Returns.Stack = cloudformation.Stack{
+			Outputs: map[string]string{
+				"BOSHEIP": "some-external-ip",
+			},
+		}
+
+		state = storage.State{
+			IAAS:  "aws",
+			EnvID: "some-env-id",
+			Stack: storage.Stack{
+				Name: "some-stack-name",
+			},
+			BOSH: storage.BOSH{
+				DirectorName:           "some-director",
+				DirectorUsername:       "some-director-username",
+				DirectorPassword:       "some-director-password",
+				DirectorSSLCA:          "some-ca\n",
+				DirectorSSLCertificate: "some-certificate",
+				DirectorSSLPrivateKey:  "some-private-key",
+				State:                  boshinit.State{"some-key": "some-value"},
+				Manifest:               "some-manifest",
+			},
+		}
+
+		command = commands.NewRotateDirectorCA(logger, stateValidator, stateStore, stringGenerator, boshDeployer,
+			sslKeyPairGenerator, credentialValidator, infrastructureManager, terraformOutputter)
+	})
+
+	Describe("Execute", func() {
+		Context("when no rotation is in progress", func() {
+			It("generates a new CA and redeploys the director trusting both CAs", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
+				Expect(sslKeyPairGenerator.GenerateCall.Receives.CACommonName).To(Equal("BOSH Bootloader"))
+				Expect(sslKeyPairGenerator.GenerateCall.Receives.CertCommonName).To(Equal("some-external-ip"))
+
+				deployInput := boshDeployer.DeployCall.Receives.Input
+				Expect(deployInput.SSLKeyPair).To(Equal(ssl.KeyPair{
+					Certificate: []byte("some-certificate"),
+					PrivateKey:  []byte("some-private-key"),
+				}))
+				Expect(deployInput.TrustedCerts).To(Equal("some-ca\nsome-new-ca\n"))
+
+				savedBOSH := stateStore.SetCall.Receives.State.BOSH
+				Expect(savedBOSH.DirectorSSLRotationPhase).To(Equal("trust"))
+				Expect(savedBOSH.DirectorSSLCA).To(Equal("some-ca\n"))
+				Expect(savedBOSH.DirectorSSLCertificate).To(Equal("some-certificate"))
+				Expect(savedBOSH.DirectorSSLRotationCA).To(Equal("some-new-ca\n"))
+				Expect(savedBOSH.DirectorSSLRotationCertificate).To(Equal("some-new-certificate"))
+				Expect(savedBOSH.DirectorSSLRotationPrivateKey).To(Equal("some-new-private-key"))
+				Expect(savedBOSH.State).To(Equal(map[string]interface{}{"some-key": "some-new-value"}))
+				Expect(savedBOSH.Manifest).To(Equal("some-new-manifest"))
+
+				Expect(logger.StepCall.Messages).To(Equal([]string{"redeploying the director trusting the current and the new director CA"}))
+				Expect(logger.PrintlnCall.Receives.Message).To(Equal("add the CA bundle printed by bbl director-ca-cert to the BOSH clients, then run bbl rotate-director-ca again to switch the director certificate"))
+			})
+
+			It("returns an error when the key pair cannot be generated", func() {
+				sslKeyPairGenerator.GenerateCall.Returns.Error = errors.New("failed to generate key pair")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to generate key pair"))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when the director trusts both CAs", func() {
+			BeforeEach(func() {
+				state.BOSH.DirectorSSLRotationPhase = "trust"
+				state.BOSH.DirectorSSLRotationCA = "some-new-ca\n"
+				state.BOSH.DirectorSSLRotationCertificate = "some-new-certificate"
+				state.BOSH.DirectorSSLRotationPrivateKey = "some-new-private-key"
+			})
+
+			It("redeploys the director serving the new certificate", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(sslKeyPairGenerator.GenerateCall.CallCount).To(Equal(0))
+
+				deployInput := boshDeployer.DeployCall.Receives.Input
+				Expect(deployInput.SSLKeyPair).To(Equal(ssl.KeyPair{
+					Certificate: []byte("some-new-certificate"),
+					PrivateKey:  []byte("some-new-private-key"),
+				}))
+				Expect(deployInput.TrustedCerts).To(Equal("some-new-ca\nsome-ca\n"))
+
+				savedBOSH := stateStore.SetCall.Receives.State.BOSH
+				Expect(savedBOSH.DirectorSSLRotationPhase).To(Equal("switch"))
+				Expect(savedBOSH.DirectorSSLCA).To(Equal("some-new-ca\n"))
+				Expect(savedBOSH.DirectorSSLCertificate).To(Equal("some-new-certificate"))
+				Expect(savedBOSH.DirectorSSLPrivateKey).To(Equal("some-new-private-key"))
+				Expect(savedBOSH.DirectorSSLRotationCA).To(Equal("some-ca\n"))
+				Expect(savedBOSH.DirectorSSLRotationCertificate).To(BeEmpty())
+				Expect(savedBOSH.DirectorSSLRotationPrivateKey).To(BeEmpty())
+
+				Expect(logger.StepCall.Messages).To(Equal([]string{"redeploying the director with a certificate signed by the new director CA"}))
+			})
+		})
+
+		Context("when the director serves the new certificate", func() {
+			BeforeEach(func() {
+				state.BOSH.DirectorSSLRotationPhase = "switch"
+				state.BOSH.DirectorSSLCA = "some-new-ca\n"
+				state.BOSH.DirectorSSLCertificate = "some-new-certificate"
+				state.BOSH.DirectorSSLPrivateKey = "some-new-private-key"
+				state.BOSH.DirectorSSLRotationCA = "some-ca\n"
+			})
+
+			It("redeploys the director without the previous CA and finishes the rotation", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				deployInput := boshDeployer.DeployCall.Receives.Input
+				Expect(deployInput.SSLKeyPair.Certificate).To(Equal([]byte("some-new-certificate")))
+				Expect(deployInput.TrustedCerts).To(BeEmpty())
+
+				savedBOSH := stateStore.SetCall.Receives.State.BOSH
+				Expect(savedBOSH.DirectorSSLRotationPhase).To(BeEmpty())
+				Expect(savedBOSH.DirectorSSLRotationCA).To(BeEmpty())
+				Expect(savedBOSH.DirectorSSLCABundle()).To(Equal("some-new-ca\n"))
+
+				Expect(logger.StepCall.Messages).To(Equal([]string{"redeploying the director without the previous director CA"}))
+				Expect(logger.PrintlnCall.Receives.Message).To(Equal("the director CA has been rotated, bbl director-ca-cert now prints only the new CA"))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the state validator fails", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("state validator failed"))
+			})
+
+			It("returns an error when there is no director", func() {
+				state.BOSH = storage.BOSH{}
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError(commands.BBLNotFound))
+			})
+
+			It("returns an error when the rotation phase is unknown", func() {
+				state.BOSH.DirectorSSLRotationPhase = "some-phase"
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError(`unknown director CA rotation phase "some-phase"`))
+			})
+
+			It("returns an error when the infrastructure cannot be described", func() {
+				infrastructureManager.DescribeCall.Returns.Error = errors.New("failed to describe stack")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to describe stack"))
+			})
+
+			It("does not advance the rotation when the deploy fails", func() {
+				boshDeployer.DeployCall.Returns.Error = errors.New("failed to deploy")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to redeploy the director, the director CA rotation did not advance: failed to deploy"))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the state cannot be saved", func() {
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("failed to set state")}}
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to set state"))
+			})
+		})
+	})
+})
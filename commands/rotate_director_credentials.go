@@ -0,0 +1,220 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/boshinit"
+	"github.com/cloudfoundry/bosh-bootloader/boshinit/manifests"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	RotateDirectorCredentialsCommand = "rotate-director-credentials"
+
+	allDirectorCredentials = "all"
+)
+
+type directorCredential struct {
+	name   string
+	key    string
+	prefix string
+}
+
+// directorCredentials lists the passwords that can be rotated, keyed into
+// BOSH.Credentials with the prefixes the manifest builders generate them with.
+// The director password lives in BOSH.DirectorPassword and has no key.
+var directorCredentials = []directorCredential{
+	{name: "director", prefix: boshinit.PASSWORD_PREFIX},
+	{name: "mbus", key: "mbusPassword", prefix: manifests.MBUS_PASSWORD_PREFIX},
+	{name: "nats", key: "natsPassword", prefix: "nats-"},
+	{name: "postgres", key: "postgresPassword", prefix: "postgres-"},
+	{name: "registry", key: "registryPassword", prefix: "registry-"},
+	{name: "blobstore-director", key: "blobstoreDirectorPassword", prefix: "blobstore-director-"},
+	{name: "blobstore-agent", key: "blobstoreAgentPassword", prefix: "blobstore-agent-"},
+	{name: "hm", key: "hmPassword", prefix: "hm-"},
+}
+
+type RotateDirectorCredentials struct {
+	logger                 logger
+	stateValidator         stateValidator
+	stateStore             stateStore
+	stringGenerator        stringGenerator
+	boshDeployer           boshDeployer
+	boshClientProvider     boshClientProvider
+	directorInfrastructure directorInfrastructure
+}
+
+func NewRotateDirectorCredentials(logger logger, stateValidator stateValidator, stateStore stateStore,
+	stringGenerator stringGenerator, boshDeployer boshDeployer, boshClientProvider boshClientProvider,
+	credentialValidator credentialValidator, infrastructureManager infrastructureManager,
+	terraformOutputter terraformOutputter) RotateDirectorCredentials {
+	return RotateDirectorCredentials{
+		logger:                 logger,
+		stateValidator:         stateValidator,
+		stateStore:             stateStore,
+		stringGenerator:        stringGenerator,
+		boshDeployer:           boshDeployer,
+		boshClientProvider:     boshClientProvider,
+		directorInfrastructure: newDirectorInfrastructure(credentialValidator, infrastructureManager, terraformOutputter),
+	}
+}
+
+func (r RotateDirectorCredentials) Execute(subcommandFlags []string, state storage.State) error {
+	credentials, err := r.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	err = r.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	if state.BOSH.IsEmpty() {
+		return BBLNotFound
+	}
+
+	infrastructureConfiguration, err := r.directorInfrastructure.configuration(state)
+	if err != nil {
+		return err
+	}
+
+	// The rotated credentials are only written once the redeployed director
+	// accepts them. Returning before that leaves the credentials in
+	// bbl-state.json as they were, which rolls the rotation back.
+	rotatedState, err := r.rotate(state, credentials)
+	if err != nil {
+		return err
+	}
+
+	deployInput, err := boshinit.NewDeployInput(rotatedState, infrastructureConfiguration, r.stringGenerator, rotatedState.EnvID, rotatedState.IAAS)
+	if err != nil {
+		return err
+	}
+
+	r.logger.Step("redeploying the director with rotated credentials")
+	deployOutput, err := r.boshDeployer.Deploy(deployInput)
+	if err != nil {
+		return fmt.Errorf("failed to redeploy the director, the previous credentials were kept: %s", err)
+	}
+
+	// The redeploy replaced the director VM, so the new bosh-init state is
+	// saved right away. The rotated credentials are only saved once the
+	// director accepts them.
+	deployedState := state
+	deployedState.BOSH.State = deployOutput.BOSHInitState
+	err = r.stateStore.Set(deployedState)
+	if err != nil {
+		return err
+	}
+
+	r.logger.Step("verifying the rotated credentials")
+	boshClient := r.boshClientProvider.Client(rotatedState.BOSH.DirectorAddress, rotatedState.BOSH.DirectorUsername,
+		rotatedState.BOSH.DirectorPassword)
+
+	info, err := boshClient.Info()
+	if err != nil {
+		return fmt.Errorf("failed to verify the rotated credentials, the previous credentials were kept, redeploy the director with bbl up: %s", err)
+	}
+
+	if info.User != rotatedState.BOSH.DirectorUsername {
+		return fmt.Errorf("director %q did not accept the rotated credentials, the previous credentials were kept, redeploy the director with bbl up", info.Name)
+	}
+
+	rotatedState.BOSH.State = deployOutput.BOSHInitState
+	rotatedState.BOSH.Manifest = deployOutput.BOSHInitManifest
+	err = r.stateStore.Set(rotatedState)
+	if err != nil {
+		return err
+	}
+
+	r.logger.Println(fmt.Sprintf("rotated %s", strings.Join(credentialNames(credentials), ", ")))
+
+	return nil
+}
+
+// rotate returns a copy of the state with new passwords for the selected
+// credentials, leaving the credentials map of the given state untouched.
+func (r RotateDirectorCredentials) rotate(state storage.State, credentials []directorCredential) (storage.State, error) {
+	rotated := map[string]string{}
+	for key, value := range state.BOSH.Credentials {
+		rotated[key] = value
+	}
+
+	for _, credential := range credentials {
+		password, err := r.stringGenerator.Generate(credential.prefix, manifests.PASSWORD_LENGTH)
+		if err != nil {
+			return storage.State{}, err
+		}
+
+		if credential.key == "" {
+			state.BOSH.DirectorPassword = password
+			continue
+		}
+		rotated[credential.key] = password
+	}
+
+	state.BOSH.Credentials = rotated
+
+	return state, nil
+}
+
+func (RotateDirectorCredentials) Flags() flags.Flags {
+	var selected string
+	return rotateDirectorCredentialsFlags(&selected)
+}
+
+func (RotateDirectorCredentials) parseFlags(subcommandFlags []string) ([]directorCredential, error) {
+	var selected string
+
+	err := rotateDirectorCredentialsFlags(&selected).Parse(subcommandFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	if selected == allDirectorCredentials {
+		return directorCredentials, nil
+	}
+
+	credentials := []directorCredential{}
+	for _, name := range strings.Split(selected, ",") {
+		credential, ok := findDirectorCredential(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("--credential must be %s or a comma separated list of %s, got %q",
+				allDirectorCredentials, strings.Join(credentialNames(directorCredentials), ", "), name)
+		}
+		credentials = append(credentials, credential)
+	}
+
+	return credentials, nil
+}
+
+func rotateDirectorCredentialsFlags(selected *string) flags.Flags {
+	rotateFlags := flags.New(RotateDirectorCredentialsCommand)
+	rotateFlags.String(selected, "credential", allDirectorCredentials)
+
+	rotateFlags.Values("credential", append([]string{allDirectorCredentials}, credentialNames(directorCredentials)...)...)
+
+	return rotateFlags
+}
+
+func findDirectorCredential(name string) (directorCredential, bool) {
+	for _, credential := range directorCredentials {
+		if credential.name == name {
+			return credential, true
+		}
+	}
+
+	return directorCredential{}, false
+}
+
+func credentialNames(credentials []directorCredential) []string {
+	names := []string{}
+	for _, credential := range credentials {
+		names = append(names, credential.name)
+	}
+
+	return names
+}
@@ -0,0 +1,323 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/aws/cloudformation"
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/boshinit"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RotateDirectorCredentials", func() {
+	var (
+		logger                *fakes.Logger
+		stateValidator        *fakes.StateValidator
+		stateStore            *fakes.StateStore
+		stringGenerator       *fakes.StringGenerator
+		boshDeployer          *fakes.BOSHDeployer
+		boshClientProvider    *fakes.BOSHClientProvider
+		boshClient            *fakes.BOSHClient
+		credentialValidator   *fakes.CredentialValidator
+		infrastructureManager *fakes.InfrastructureManager
+		terraformOutputter    *fakes.TerraformOutputter
+		state                 storage.State
+		command               commands.RotateDirectorCredentials
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		stateStore = &fakes.StateStore{}
+		stringGenerator = &fakes.StringGenerator{}
+		boshDeployer = &fakes.BOSHDeployer{}
+		boshClientProvider = &fakes.BOSHClientProvider{}
+		boshClient = &fakes.BOSHClient{}
+		credentialValidator = &fakes.CredentialValidator{}
+		infrastructureManager = &fakes.InfrastructureManager{}
+		terraformOutputter = &fakes.TerraformOutputter{}
+
+		boshClientProvider.ClientCall.Returns.Client = boshClient
+		boshClient.InfoCall.Returns.Info = bosh.Info{Name: "some-director", User: "some-director-username"}
+
+		stringGenerator.GenerateCall.Stub = func(prefix string, length int) (string, error) {
+			return prefix + "rotated", nil
+		}
+
+		boshDeployer.DeployCall.Returns.Output = boshinit.DeployOutput{
+			BOSHInitState:    boshinit.State{"some-key": "some-new-value"},
+			BOSHInitManifest: "some-new-manifest",
+		}
+
+		infrastructureManager.DescribeCall.Returns.Stack = cloudformation.Stack{
+			Outputs: map[string]string{
+				"BOSHEIP":                 "some-external-ip",
+				"BOSHSubnet":              "some-subnet",
+				"BOSHSubnetAZ":            "some-az",
+				"BOSHUserAccessKey":       "some-access-key",
+				"BOSHUserSecretAccessKey": "some-secret-access-key",
+				"BOSHSecurityGroup":       "some-security-group",
+			},
+		}
+
+		state = storage.State{
+			IAAS:  "aws",
+			EnvID: "some-env-id",
+			AWS: storage.AWS{
+				Region: "some-region",
+			},
+			Stack: storage.Stack{
+				Name: "some-stack-name",
+			},
+			BOSH: storage.BOSH{
+				DirectorName:     "some-director",
+				DirectorAddress:  "some-director-address",
+				DirectorUsername: "some-director-username",
+				DirectorPassword: "some-director-password",
+				State:            boshinit.State{"some-key": "some-value"},
+				Manifest:         "some-manifest",
+				Credentials: map[string]string{
+					"natsUsername": "some-nats-username",
+					"natsPassword": "some-nats-password",
+					"hmPassword":   "some-hm-password",
+				},
+			},
+		}
+
+		command = commands.NewRotateDirectorCredentials(logger, stateValidator, stateStore, stringGenerator, boshDeployer,
+			boshClientProvider, credentialValidator, infrastructureManager, terraformOutputter)
+	})
+
+	Describe("Execute", func() {
+		It("rotates every password, redeploys the director and saves the state", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
+			Expect(credentialValidator.ValidateAWSCall.CallCount).To(Equal(1))
+			Expect(infrastructureManager.DescribeCall.Receives.StackName).To(Equal("some-stack-name"))
+
+			Expect(stringGenerator.GenerateCall.Receives.Prefixes).To(Equal([]string{
+				"p-", "mbus-", "nats-", "postgres-", "registry-", "blobstore-director-", "blobstore-agent-", "hm-",
+			}))
+
+			deployInput := boshDeployer.DeployCall.Receives.Input
+			Expect(deployInput.IAAS).To(Equal("aws"))
+			Expect(deployInput.DirectorUsername).To(Equal("some-director-username"))
+			Expect(deployInput.DirectorPassword).To(Equal("p-rotated"))
+			Expect(deployInput.State).To(Equal(boshinit.State{"some-key": "some-value"}))
+			Expect(deployInput.Credentials).To(Equal(map[string]string{
+				"natsUsername":              "some-nats-username",
+				"mbusPassword":              "mbus-rotated",
+				"natsPassword":              "nats-rotated",
+				"postgresPassword":          "postgres-rotated",
+				"registryPassword":          "registry-rotated",
+				"blobstoreDirectorPassword": "blobstore-director-rotated",
+				"blobstoreAgentPassword":    "blobstore-agent-rotated",
+				"hmPassword":                "hm-rotated",
+			}))
+			Expect(deployInput.InfrastructureConfiguration).To(Equal(boshinit.InfrastructureConfiguration{
+				ExternalIP: "some-external-ip",
+				AWS: boshinit.InfrastructureConfigurationAWS{
+					AWSRegion:        "some-region",
+					SubnetID:         "some-subnet",
+					AvailabilityZone: "some-az",
+					AccessKeyID:      "some-access-key",
+					SecretAccessKey:  "some-secret-access-key",
+					SecurityGroup:    "some-security-group",
+				},
+			}))
+
+			Expect(boshClientProvider.ClientCall.Receives.DirectorAddress).To(Equal("some-director-address"))
+			Expect(boshClientProvider.ClientCall.Receives.DirectorUsername).To(Equal("some-director-username"))
+			Expect(boshClientProvider.ClientCall.Receives.DirectorPassword).To(Equal("p-rotated"))
+			Expect(boshClient.InfoCall.CallCount).To(Equal(1))
+
+			Expect(stateStore.SetCall.CallCount).To(Equal(2))
+			savedState := stateStore.SetCall.Receives.State
+			Expect(savedState.BOSH.DirectorPassword).To(Equal("p-rotated"))
+			Expect(savedState.BOSH.Credentials).To(Equal(deployInput.Credentials))
+			Expect(savedState.BOSH.State).To(Equal(map[string]interface{}{"some-key": "some-new-value"}))
+			Expect(savedState.BOSH.Manifest).To(Equal("some-new-manifest"))
+
+			Expect(logger.StepCall.Messages).To(Equal([]string{
+				"redeploying the director with rotated credentials",
+				"verifying the rotated credentials",
+			}))
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal("rotated director, mbus, nats, postgres, registry, blobstore-director, blobstore-agent, hm"))
+		})
+
+		It("rotates only the credentials selected with --credential", func() {
+			err := command.Execute([]string{"--credential", "nats, hm"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stringGenerator.GenerateCall.Receives.Prefixes).To(Equal([]string{"nats-", "hm-"}))
+
+			savedState := stateStore.SetCall.Receives.State
+			Expect(savedState.BOSH.DirectorPassword).To(Equal("some-director-password"))
+			Expect(savedState.BOSH.Credentials).To(Equal(map[string]string{
+				"natsUsername": "some-nats-username",
+				"natsPassword": "nats-rotated",
+				"hmPassword":   "hm-rotated",
+			}))
+		})
+
+		It("does not change the credentials of the given state", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(state.BOSH.Credentials["natsPassword"]).To(Equal("some-nats-password"))
+		})
+
+		Context("when the iaas is gcp", func() {
+			BeforeEach(func() {
+				state.IAAS = "gcp"
+				state.TFState = "some-tf-state"
+				state.GCP = storage.GCP{
+					Zone:              "some-zone",
+					ProjectID:         "some-project-id",
+					ServiceAccountKey: "some-service-account-key",
+				}
+
+				terraformOutputter.GetCall.Stub = func(output string) (string, error) {
+					return "some-" + output, nil
+				}
+			})
+
+			It("redeploys the director with the terraform outputs", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(credentialValidator.ValidateGCPCall.CallCount).To(Equal(1))
+				Expect(terraformOutputter.GetCall.Receives.TFState).To(Equal("some-tf-state"))
+
+				deployInput := boshDeployer.DeployCall.Receives.Input
+				Expect(deployInput.IAAS).To(Equal("gcp"))
+				Expect(deployInput.InfrastructureConfiguration).To(Equal(boshinit.InfrastructureConfiguration{
+					ExternalIP: "some-external_ip",
+					GCP: boshinit.InfrastructureConfigurationGCP{
+						Zone:           "some-zone",
+						NetworkName:    "some-network_name",
+						SubnetworkName: "some-subnetwork_name",
+						BOSHTag:        "some-bosh_open_tag_name",
+						InternalTag:    "some-internal_tag_name",
+						Project:        "some-project-id",
+						JsonKey:        "some-service-account-key",
+					},
+				}))
+			})
+
+			It("returns an error when the terraform output cannot be read", func() {
+				terraformOutputter.GetCall.Stub = nil
+				terraformOutputter.GetCall.Returns.Error = errors.New("failed to get output")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to get output"))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the state validator fails", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("state validator failed"))
+			})
+
+			It("returns an error when there is no director", func() {
+				state.BOSH = storage.BOSH{}
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError(commands.BBLNotFound))
+			})
+
+			It("returns an error when the credential is unknown", func() {
+				err := command.Execute([]string{"--credential", "nats,uaa"}, state)
+				Expect(err).To(MatchError(`--credential must be all or a comma separated list of director, mbus, nats, postgres, registry, blobstore-director, blobstore-agent, hm, got "uaa"`))
+			})
+
+			It("returns an error when the flags cannot be parsed", func() {
+				err := command.Execute([]string{"--unknown-flag"}, state)
+				Expect(err).To(MatchError(ContainSubstring("flag provided but not defined: -unknown-flag")))
+			})
+
+			It("returns an error when the aws credentials are invalid", func() {
+				credentialValidator.ValidateAWSCall.Returns.Error = errors.New("invalid aws credentials")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("invalid aws credentials"))
+			})
+
+			It("returns an error when the stack cannot be described", func() {
+				infrastructureManager.DescribeCall.Returns.Error = errors.New("failed to describe stack")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to describe stack"))
+			})
+
+			It("returns an error when a password cannot be generated", func() {
+				stringGenerator.GenerateCall.Stub = nil
+				stringGenerator.GenerateCall.Returns.Error = errors.New("failed to generate string")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to generate string"))
+				Expect(boshDeployer.DeployCall.Receives.Input).To(Equal(boshinit.DeployInput{}))
+			})
+
+			It("keeps the previous credentials when the deploy fails", func() {
+				boshDeployer.DeployCall.Returns.Error = errors.New("failed to deploy")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to redeploy the director, the previous credentials were kept: failed to deploy"))
+				Expect(boshClient.InfoCall.CallCount).To(Equal(0))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+
+			It("keeps the previous credentials and asks for a redeploy when the director cannot be reached", func() {
+				boshClient.InfoCall.Returns.Error = errors.New("connection refused")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to verify the rotated credentials, the previous credentials were kept, redeploy the director with bbl up: connection refused"))
+
+				Expect(stateStore.SetCall.CallCount).To(Equal(1))
+				savedState := stateStore.SetCall.Receives.State
+				Expect(savedState.BOSH.DirectorPassword).To(Equal("some-director-password"))
+				Expect(savedState.BOSH.Credentials).To(Equal(state.BOSH.Credentials))
+				Expect(savedState.BOSH.Manifest).To(Equal(state.BOSH.Manifest))
+				Expect(savedState.BOSH.State).To(Equal(map[string]interface{}{"some-key": "some-new-value"}))
+			})
+
+			It("keeps the previous credentials and asks for a redeploy when the director does not accept them", func() {
+				boshClient.InfoCall.Returns.Info = bosh.Info{Name: "some-director"}
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError(`director "some-director" did not accept the rotated credentials, the previous credentials were kept, redeploy the director with bbl up`))
+
+				Expect(stateStore.SetCall.CallCount).To(Equal(1))
+				Expect(stateStore.SetCall.Receives.State.BOSH.DirectorPassword).To(Equal("some-director-password"))
+			})
+
+			It("returns an error when the redeployed director state cannot be saved", func() {
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("failed to set state")}}
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to set state"))
+				Expect(boshClient.InfoCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the rotated credentials cannot be saved", func() {
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{}, {Error: errors.New("failed to set state")}}
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to set state"))
+				Expect(boshClient.InfoCall.CallCount).To(Equal(1))
+			})
+		})
+	})
+})
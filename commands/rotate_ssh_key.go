@@ -0,0 +1,185 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/aws/ec2"
+	"github.com/cloudfoundry/bosh-bootloader/boshinit"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	RotateSSHKeyCommand = "rotate-ssh-key"
+)
+
+type RotateSSHKey struct {
+	logger                    logger
+	stateValidator            stateValidator
+	stateStore                stateStore
+	stringGenerator           stringGenerator
+	boshDeployer              boshDeployer
+	keyPairSynchronizer       keyPairSynchronizer
+	awsKeyPairDeleter         awsKeyPairDeleter
+	gcpKeyPairUpdater         keyPairUpdater
+	gcpKeyPairDeleter         gcpKeyPairDeleter
+	guidGenerator             guidGenerator
+	infrastructureManager     infrastructureManager
+	availabilityZoneRetriever availabilityZoneRetriever
+	certificateDescriber      certificateDescriber
+	directorInfrastructure    directorInfrastructure
+}
+
+func NewRotateSSHKey(logger logger, stateValidator stateValidator, stateStore stateStore, stringGenerator stringGenerator,
+	boshDeployer boshDeployer, keyPairSynchronizer keyPairSynchronizer, awsKeyPairDeleter awsKeyPairDeleter,
+	gcpKeyPairUpdater keyPairUpdater, gcpKeyPairDeleter gcpKeyPairDeleter, guidGenerator guidGenerator,
+	credentialValidator credentialValidator, infrastructureManager infrastructureManager,
+	availabilityZoneRetriever availabilityZoneRetriever, certificateDescriber certificateDescriber,
+	terraformOutputter terraformOutputter) RotateSSHKey {
+	return RotateSSHKey{
+		logger:                    logger,
+		stateValidator:            stateValidator,
+		stateStore:                stateStore,
+		stringGenerator:           stringGenerator,
+		boshDeployer:              boshDeployer,
+		keyPairSynchronizer:       keyPairSynchronizer,
+		awsKeyPairDeleter:         awsKeyPairDeleter,
+		gcpKeyPairUpdater:         gcpKeyPairUpdater,
+		gcpKeyPairDeleter:         gcpKeyPairDeleter,
+		guidGenerator:             guidGenerator,
+		infrastructureManager:     infrastructureManager,
+		availabilityZoneRetriever: availabilityZoneRetriever,
+		certificateDescriber:      certificateDescriber,
+		directorInfrastructure:    newDirectorInfrastructure(credentialValidator, infrastructureManager, terraformOutputter),
+	}
+}
+
+func (r RotateSSHKey) Execute(subcommandFlags []string, state storage.State) error {
+	err := r.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	if state.BOSH.IsEmpty() {
+		return BBLNotFound
+	}
+
+	infrastructureConfiguration, err := r.directorInfrastructure.configuration(state)
+	if err != nil {
+		return err
+	}
+
+	keyPair, err := r.createKeyPair(state)
+	if err != nil {
+		return err
+	}
+
+	rotatedState := state
+	rotatedState.KeyPair = keyPair
+
+	deployInput, err := boshinit.NewDeployInput(rotatedState, infrastructureConfiguration, r.stringGenerator, rotatedState.EnvID, rotatedState.IAAS)
+	if err != nil {
+		return r.deleteKeyPair(rotatedState, err)
+	}
+
+	r.logger.Step("redeploying the director with the new ssh key")
+	deployOutput, err := r.boshDeployer.Deploy(deployInput)
+	if err != nil {
+		return r.deleteKeyPair(rotatedState, fmt.Errorf("failed to redeploy the director, the previous ssh key was kept: %s", err))
+	}
+
+	rotatedState.BOSH.State = deployOutput.BOSHInitState
+	rotatedState.BOSH.Manifest = deployOutput.BOSHInitManifest
+
+	err = r.stateStore.Set(rotatedState)
+	if err != nil {
+		return err
+	}
+
+	// The NAT instance of the stack is launched with the key pair too, so it
+	// has to stop using the previous key pair before that is deleted.
+	if rotatedState.IAAS == "aws" {
+		r.logger.Step("updating the stack with the new ssh key")
+		err = r.updateStack(rotatedState)
+		if err != nil {
+			return fmt.Errorf("failed to update the stack with the new ssh key, the previous ssh key was kept: %s", err)
+		}
+	}
+
+	r.logger.Step("removing the previous ssh key")
+	return r.deleteKeyPair(state, nil)
+}
+
+// createKeyPair registers a new key with the iaas. On AWS the key pair gets a
+// new name, since the previous key pair stays in use until the director has
+// been redeployed.
+func (r RotateSSHKey) createKeyPair(state storage.State) (storage.KeyPair, error) {
+	switch state.IAAS {
+	case "aws":
+		guid, err := r.guidGenerator.Generate()
+		if err != nil {
+			return storage.KeyPair{}, err
+		}
+
+		keyPair, err := r.keyPairSynchronizer.Sync(ec2.KeyPair{
+			Name: fmt.Sprintf("keypair-%s-%s", state.EnvID, guid),
+		})
+		if err != nil {
+			return storage.KeyPair{}, err
+		}
+
+		return storage.KeyPair{
+			Name:       keyPair.Name,
+			PrivateKey: keyPair.PrivateKey,
+			PublicKey:  keyPair.PublicKey,
+		}, nil
+	case "gcp":
+		return r.gcpKeyPairUpdater.Update()
+	default:
+		return storage.KeyPair{}, fmt.Errorf("cannot rotate the ssh key on iaas %q", state.IAAS)
+	}
+}
+
+func (r RotateSSHKey) updateStack(state storage.State) error {
+	availabilityZones, err := r.availabilityZoneRetriever.Retrieve(state.AWS.Region)
+	if err != nil {
+		return err
+	}
+
+	var certificateARN string
+	if lbExists(state.Stack.LBType) {
+		certificate, err := r.certificateDescriber.Describe(state.Stack.CertificateName)
+		if err != nil {
+			return err
+		}
+		certificateARN = certificate.ARN
+	}
+
+	_, err = r.infrastructureManager.Update(state.KeyPair.Name, len(availabilityZones), state.Stack.Name, state.Stack.LBType,
+		certificateARN, state.EnvID, state.CloudFormationFragments)
+	return err
+}
+
+// deleteKeyPair removes the key pair of the given state from the iaas and
+// returns the error that caused the removal, if any, along with its own.
+func (r RotateSSHKey) deleteKeyPair(state storage.State, cause error) error {
+	var err error
+	switch state.IAAS {
+	case "aws":
+		err = r.awsKeyPairDeleter.Delete(state.KeyPair.Name)
+	case "gcp":
+		err = r.gcpKeyPairDeleter.Delete(state.KeyPair.PublicKey)
+	}
+
+	switch {
+	case err == nil:
+		return cause
+	case cause == nil:
+		return err
+	default:
+		errorList := helpers.Errors{}
+		errorList.Add(cause)
+		errorList.Add(err)
+		return errorList
+	}
+}
@@ -0,0 +1,312 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/aws/cloudformation"
+	"github.com/cloudfoundry/bosh-bootloader/aws/ec2"
+	"github.com/cloudfoundry/bosh-bootloader/aws/iam"
+	"github.com/cloudfoundry/bosh-bootloader/boshinit"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RotateSSHKey", func() {
+	var (
+		logger                    *fakes.Logger
+		stateValidator            *fakes.StateValidator
+		stateStore                *fakes.StateStore
+		stringGenerator           *fakes.StringGenerator
+		boshDeployer              *fakes.BOSHDeployer
+		keyPairSynchronizer       *fakes.KeyPairSynchronizer
+		awsKeyPairDeleter         *fakes.AWSKeyPairDeleter
+		gcpKeyPairUpdater         *fakes.GCPKeyPairUpdater
+		gcpKeyPairDeleter         *fakes.GCPKeyPairDeleter
+		guidGenerator             *fakes.GuidGenerator
+		credentialValidator       *fakes.CredentialValidator
+		infrastructureManager     *fakes.InfrastructureManager
+		availabilityZoneRetriever *fakes.AvailabilityZoneRetriever
+		certificateDescriber      *fakes.CertificateDescriber
+		terraformOutputter        *fakes.TerraformOutputter
+		state                     storage.State
+		command                   commands.RotateSSHKey
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		stateStore = &fakes.StateStore{}
+		stringGenerator = &fakes.StringGenerator{}
+		boshDeployer = &fakes.BOSHDeployer{}
+		keyPairSynchronizer = &fakes.KeyPairSynchronizer{}
+		awsKeyPairDeleter = &fakes.AWSKeyPairDeleter{}
+		gcpKeyPairUpdater = &fakes.GCPKeyPairUpdater{}
+		gcpKeyPairDeleter = &fakes.GCPKeyPairDeleter{}
+		guidGenerator = &fakes.GuidGenerator{}
+		credentialValidator = &fakes.CredentialValidator{}
+		infrastructureManager = &fakes.InfrastructureManager{}
+		availabilityZoneRetriever = &fakes.AvailabilityZoneRetriever{}
+		certificateDescriber = &fakes.CertificateDescriber{}
+		terraformOutputter = &fakes.TerraformOutputter{}
+
+		guidGenerator.GenerateCall.Returns.Output = "some-guid"
+		keyPairSynchronizer.SyncCall.Returns.KeyPair = ec2.KeyPair{
+			Name:       "keypair-some-env-id-some-guid",
+			PrivateKey: "some-new-private-key",
+		}
+
+		boshDeployer.DeployCall.Returns.Output = boshinit.DeployOutput{
+			BOSHInitState:    boshinit.State{"some-key": "some-new-value"},
+			BOSHInitManifest: "some-new-manifest",
+		}
+
+		infrastructureManager.DescribeCall.Returns.Stack = cloudformation.Stack{
+			Outputs: map[string]string{
+				"BOSHEIP": "some-external-ip",
+			},
+		}
+
+		state = storage.State{
+			IAAS:  "aws",
+			EnvID: "some-env-id",
+			AWS: storage.AWS{
+				Region: "some-region",
+			},
+			Stack: storage.Stack{
+				Name: "some-stack-name",
+			},
+			KeyPair: storage.KeyPair{
+				Name:       "keypair-some-env-id",
+				PrivateKey: "some-private-key",
+				PublicKey:  "some-public-key",
+			},
+			BOSH: storage.BOSH{
+				DirectorName:     "some-director",
+				DirectorUsername: "some-director-username",
+				DirectorPassword: "some-director-password",
+				State:            boshinit.State{"some-key": "some-value"},
+				Manifest:         "some-manifest",
+			},
+		}
+
+		command = commands.NewRotateSSHKey(logger, stateValidator, stateStore, stringGenerator, boshDeployer,
+			keyPairSynchronizer, awsKeyPairDeleter, gcpKeyPairUpdater, gcpKeyPairDeleter, guidGenerator,
+			credentialValidator, infrastructureManager, availabilityZoneRetriever, certificateDescriber, terraformOutputter)
+	})
+
+	Describe("Execute", func() {
+		Context("when the iaas is aws", func() {
+			BeforeEach(func() {
+				availabilityZoneRetriever.RetrieveCall.Returns.AZs = []string{"some-az-1", "some-az-2"}
+			})
+
+			It("creates a new key pair, redeploys the director and deletes the previous key pair", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
+				Expect(credentialValidator.ValidateAWSCall.CallCount).To(Equal(1))
+				Expect(keyPairSynchronizer.SyncCall.Receives.KeyPair).To(Equal(ec2.KeyPair{
+					Name: "keypair-some-env-id-some-guid",
+				}))
+
+				deployInput := boshDeployer.DeployCall.Receives.Input
+				Expect(deployInput.EC2KeyPair).To(Equal(ec2.KeyPair{
+					Name:       "keypair-some-env-id-some-guid",
+					PrivateKey: "some-new-private-key",
+				}))
+				Expect(deployInput.State).To(Equal(boshinit.State{"some-key": "some-value"}))
+				Expect(deployInput.InfrastructureConfiguration.ExternalIP).To(Equal("some-external-ip"))
+
+				Expect(stateStore.SetCall.CallCount).To(Equal(1))
+				savedState := stateStore.SetCall.Receives.State
+				Expect(savedState.KeyPair).To(Equal(storage.KeyPair{
+					Name:       "keypair-some-env-id-some-guid",
+					PrivateKey: "some-new-private-key",
+				}))
+				Expect(savedState.BOSH.State).To(Equal(map[string]interface{}{"some-key": "some-new-value"}))
+				Expect(savedState.BOSH.Manifest).To(Equal("some-new-manifest"))
+
+				Expect(awsKeyPairDeleter.DeleteCall.CallCount).To(Equal(1))
+				Expect(awsKeyPairDeleter.DeleteCall.Receives.Name).To(Equal("keypair-some-env-id"))
+
+				Expect(logger.StepCall.Messages).To(Equal([]string{
+					"redeploying the director with the new ssh key",
+					"updating the stack with the new ssh key",
+					"removing the previous ssh key",
+				}))
+			})
+
+			It("updates the stack with the new key pair before the previous key pair is deleted", func() {
+				state.Stack.LBType = "concourse"
+				state.Stack.CertificateName = "some-certificate-name"
+				state.CloudFormationFragments = []storage.CloudFormationFragment{
+					{Name: "database.yml", Contents: "some-contents"},
+				}
+				certificateDescriber.DescribeCall.Returns.Certificate = iam.Certificate{ARN: "some-certificate-arn"}
+
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(availabilityZoneRetriever.RetrieveCall.Receives.Region).To(Equal("some-region"))
+				Expect(certificateDescriber.DescribeCall.Receives.CertificateName).To(Equal("some-certificate-name"))
+
+				Expect(infrastructureManager.UpdateCall.CallCount).To(Equal(1))
+				Expect(infrastructureManager.UpdateCall.Receives.KeyPairName).To(Equal("keypair-some-env-id-some-guid"))
+				Expect(infrastructureManager.UpdateCall.Receives.NumberOfAvailabilityZones).To(Equal(2))
+				Expect(infrastructureManager.UpdateCall.Receives.StackName).To(Equal("some-stack-name"))
+				Expect(infrastructureManager.UpdateCall.Receives.LBType).To(Equal("concourse"))
+				Expect(infrastructureManager.UpdateCall.Receives.LBCertificateARN).To(Equal("some-certificate-arn"))
+				Expect(infrastructureManager.UpdateCall.Receives.EnvID).To(Equal("some-env-id"))
+				Expect(infrastructureManager.UpdateCall.Receives.Fragments).To(Equal(state.CloudFormationFragments))
+			})
+
+			It("keeps the previous key pair when the stack cannot be updated", func() {
+				infrastructureManager.UpdateCall.Returns.Error = errors.New("failed to update stack")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to update the stack with the new ssh key, the previous ssh key was kept: failed to update stack"))
+
+				Expect(stateStore.SetCall.Receives.State.KeyPair.Name).To(Equal("keypair-some-env-id-some-guid"))
+				Expect(awsKeyPairDeleter.DeleteCall.CallCount).To(Equal(0))
+			})
+
+			It("keeps the previous key pair when the availability zones cannot be retrieved", func() {
+				availabilityZoneRetriever.RetrieveCall.Returns.Error = errors.New("failed to retrieve azs")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to update the stack with the new ssh key, the previous ssh key was kept: failed to retrieve azs"))
+				Expect(infrastructureManager.UpdateCall.CallCount).To(Equal(0))
+				Expect(awsKeyPairDeleter.DeleteCall.CallCount).To(Equal(0))
+			})
+
+			It("deletes the new key pair and keeps the previous one when the deploy fails", func() {
+				boshDeployer.DeployCall.Returns.Error = errors.New("failed to deploy")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to redeploy the director, the previous ssh key was kept: failed to deploy"))
+
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+				Expect(awsKeyPairDeleter.DeleteCall.CallCount).To(Equal(1))
+				Expect(awsKeyPairDeleter.DeleteCall.Receives.Name).To(Equal("keypair-some-env-id-some-guid"))
+			})
+
+			It("returns both errors when the new key pair cannot be deleted after the deploy fails", func() {
+				boshDeployer.DeployCall.Returns.Error = errors.New("failed to deploy")
+				awsKeyPairDeleter.DeleteCall.Returns.Error = errors.New("failed to delete keypair")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("the following errors occurred:\nfailed to redeploy the director, the previous ssh key was kept: failed to deploy,\nfailed to delete keypair"))
+			})
+
+			It("returns an error when the guid cannot be generated", func() {
+				guidGenerator.GenerateCall.Returns.Error = errors.New("failed to generate guid")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to generate guid"))
+			})
+
+			It("returns an error when the key pair cannot be created", func() {
+				keyPairSynchronizer.SyncCall.Returns.Error = errors.New("failed to create keypair")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to create keypair"))
+				Expect(boshDeployer.DeployCall.Receives.Input).To(Equal(boshinit.DeployInput{}))
+			})
+
+			It("returns an error when the aws credentials are invalid", func() {
+				credentialValidator.ValidateAWSCall.Returns.Error = errors.New("invalid aws credentials")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("invalid aws credentials"))
+				Expect(keyPairSynchronizer.SyncCall.Receives.KeyPair).To(Equal(ec2.KeyPair{}))
+			})
+		})
+
+		Context("when the iaas is gcp", func() {
+			BeforeEach(func() {
+				state.IAAS = "gcp"
+				state.KeyPair = storage.KeyPair{
+					PrivateKey: "some-private-key",
+					PublicKey:  "some-public-key",
+				}
+
+				gcpKeyPairUpdater.UpdateCall.Returns.KeyPair = storage.KeyPair{
+					PrivateKey: "some-new-private-key",
+					PublicKey:  "some-new-public-key",
+				}
+			})
+
+			It("adds a new key to the project metadata, redeploys the director and removes the previous key", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(credentialValidator.ValidateGCPCall.CallCount).To(Equal(1))
+				Expect(gcpKeyPairUpdater.UpdateCall.CallCount).To(Equal(1))
+				Expect(boshDeployer.DeployCall.Receives.Input.EC2KeyPair.PrivateKey).To(Equal("some-new-private-key"))
+
+				Expect(stateStore.SetCall.Receives.State.KeyPair).To(Equal(storage.KeyPair{
+					PrivateKey: "some-new-private-key",
+					PublicKey:  "some-new-public-key",
+				}))
+
+				Expect(gcpKeyPairDeleter.DeleteCall.CallCount).To(Equal(1))
+				Expect(gcpKeyPairDeleter.DeleteCall.Receives.PublicKey).To(Equal("some-public-key"))
+				Expect(infrastructureManager.UpdateCall.CallCount).To(Equal(0))
+			})
+
+			It("removes the new key and keeps the previous one when the deploy fails", func() {
+				boshDeployer.DeployCall.Returns.Error = errors.New("failed to deploy")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to redeploy the director, the previous ssh key was kept: failed to deploy"))
+
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+				Expect(gcpKeyPairDeleter.DeleteCall.Receives.PublicKey).To(Equal("some-new-public-key"))
+			})
+
+			It("returns an error when the project metadata cannot be updated", func() {
+				gcpKeyPairUpdater.UpdateCall.Returns.Error = errors.New("failed to update project metadata")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to update project metadata"))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the state validator fails", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("state validator failed"))
+			})
+
+			It("returns an error when there is no director", func() {
+				state.BOSH = storage.BOSH{}
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError(commands.BBLNotFound))
+			})
+
+			It("returns an error and keeps the previous key pair when the state cannot be saved", func() {
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("failed to set state")}}
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to set state"))
+				Expect(awsKeyPairDeleter.DeleteCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the previous key pair cannot be deleted", func() {
+				awsKeyPairDeleter.DeleteCall.Returns.Error = errors.New("failed to delete keypair")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to delete keypair"))
+				Expect(stateStore.SetCall.CallCount).To(Equal(1))
+			})
+		})
+	})
+})
@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	SSHConfigCommand = "ssh-config"
+
+	directorSSHUser = "vcap"
+	directorSSHPort = "22"
+)
+
+type SSHConfig struct {
+	stateValidator stateValidator
+	stateDir       string
+	stdout         io.Writer
+}
+
+type sshConfigConfig struct {
+	host      string
+	proxyJump string
+	appendTo  string
+}
+
+func NewSSHConfig(stateValidator stateValidator, stateDir string, stdout io.Writer) SSHConfig {
+	return SSHConfig{
+		stateValidator: stateValidator,
+		stateDir:       stateDir,
+		stdout:         stdout,
+	}
+}
+
+func (s SSHConfig) Execute(subcommandFlags []string, state storage.State) error {
+	config, err := s.parseFlags(subcommandFlags, state)
+	if err != nil {
+		return err
+	}
+
+	err = s.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	if state.KeyPair.PrivateKey == "" {
+		return fmt.Errorf("Could not retrieve %s, please make sure you are targeting the proper state dir.", SSHKeyPropertyName)
+	}
+
+	directorIP, err := directorHost(state.BOSH.DirectorAddress)
+	if err != nil {
+		return err
+	}
+
+	keyPath, err := filepath.Abs(filepath.Join(s.stateDir, fmt.Sprintf("bbl-%s-ssh-key", state.EnvID)))
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(keyPath), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(keyPath, []byte(state.KeyPair.PrivateKey), 0600)
+	if err != nil {
+		return err
+	}
+
+	// WriteFile keeps the mode of an existing file, so a key written by an
+	// older bbl with looser permissions is tightened here.
+	err = os.Chmod(keyPath, 0600)
+	if err != nil {
+		return err
+	}
+
+	stanza := hostStanza(config, directorIP, keyPath)
+	if config.appendTo == "" {
+		fmt.Fprint(s.stdout, stanza)
+		return nil
+	}
+
+	return appendHostStanza(config.appendTo, config.host, stanza)
+}
+
+func (SSHConfig) Flags() flags.Flags {
+	return sshConfigFlags(&sshConfigConfig{})
+}
+
+func (SSHConfig) parseFlags(subcommandFlags []string, state storage.State) (sshConfigConfig, error) {
+	config := sshConfigConfig{}
+
+	err := sshConfigFlags(&config).Parse(subcommandFlags)
+	if err != nil {
+		return sshConfigConfig{}, err
+	}
+
+	if config.host == "" {
+		config.host = "bbl-" + state.EnvID
+	}
+
+	if strings.ContainsAny(config.host, " \t*?!") {
+		return sshConfigConfig{}, fmt.Errorf("--host must be a single host name without patterns, got %q", config.host)
+	}
+
+	return config, nil
+}
+
+func sshConfigFlags(config *sshConfigConfig) flags.Flags {
+	sshFlags := flags.New("ssh-config")
+	sshFlags.String(&config.host, "host", "")
+	sshFlags.String(&config.proxyJump, "proxy-jump", "")
+	sshFlags.String(&config.appendTo, "append-to", "")
+
+	sshFlags.Files("append-to")
+
+	return sshFlags
+}
+
+func directorHost(directorAddress string) (string, error) {
+	if directorAddress == "" {
+		return "", fmt.Errorf("Could not retrieve %s, please make sure you are targeting the proper state dir.", DirectorAddressPropertyName)
+	}
+
+	address, err := url.Parse(directorAddress)
+	if err != nil || address.Host == "" {
+		return "", fmt.Errorf("director address %q is not a URL", directorAddress)
+	}
+
+	host, _, err := net.SplitHostPort(address.Host)
+	if err != nil {
+		return address.Host, nil
+	}
+
+	return host, nil
+}
+
+func hostStanza(config sshConfigConfig, directorIP, keyPath string) string {
+	stanza := bytes.NewBuffer([]byte{})
+
+	fmt.Fprintf(stanza, "Host %s\n", config.host)
+	fmt.Fprintf(stanza, "  HostName %s\n", directorIP)
+	fmt.Fprintf(stanza, "  User %s\n", directorSSHUser)
+	fmt.Fprintf(stanza, "  Port %s\n", directorSSHPort)
+	fmt.Fprintf(stanza, "  IdentityFile %s\n", keyPath)
+	fmt.Fprintf(stanza, "  IdentitiesOnly yes\n")
+	if config.proxyJump != "" {
+		fmt.Fprintf(stanza, "  ProxyJump %s\n", config.proxyJump)
+	}
+
+	return stanza.String()
+}
+
+// appendHostStanza adds the stanza to the ssh config file between markers
+// naming the host, replacing the stanza an earlier run wrote for that host.
+func appendHostStanza(path, host, stanza string) error {
+	begin := fmt.Sprintf("# BEGIN bbl %s\n", host)
+	end := fmt.Sprintf("# END bbl %s\n", host)
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	existing := string(contents)
+	if start := strings.Index(existing, begin); start != -1 {
+		if stop := strings.Index(existing[start:], end); stop != -1 {
+			existing = existing[:start] + existing[start+stop+len(end):]
+		}
+	}
+
+	if existing != "" && !strings.HasSuffix(existing, "\n") {
+		existing += "\n"
+	}
+
+	return ioutil.WriteFile(path, []byte(existing+begin+stanza+end), 0600)
+}
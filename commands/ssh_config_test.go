@@ -0,0 +1,198 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SSHConfig", func() {
+	var (
+		stateValidator *fakes.StateValidator
+		stdout         *bytes.Buffer
+		stateDir       string
+		keyPath        string
+		state          storage.State
+		command        commands.SSHConfig
+	)
+
+	BeforeEach(func() {
+		var err error
+		stateDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		stateValidator = &fakes.StateValidator{}
+		stdout = bytes.NewBuffer([]byte{})
+		keyPath = filepath.Join(stateDir, "bbl-some-env-id-ssh-key")
+
+		state = storage.State{
+			EnvID: "some-env-id",
+			KeyPair: storage.KeyPair{
+				PrivateKey: "some-private-key",
+			},
+			BOSH: storage.BOSH{
+				DirectorAddress: "https://203.0.113.10:25555",
+			},
+		}
+
+		command = commands.NewSSHConfig(stateValidator, stateDir, stdout)
+	})
+
+	Describe("Execute", func() {
+		It("writes the ssh key to the state dir and prints a host stanza for the director", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
+
+			Expect(stdout.String()).To(Equal(`Host bbl-some-env-id
+  HostName 203.0.113.10
+  User vcap
+  Port 22
+  IdentityFile ` + keyPath + `
+  IdentitiesOnly yes
+`))
+
+			sshKey, err := ioutil.ReadFile(keyPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(sshKey)).To(Equal("some-private-key"))
+
+			info, err := os.Stat(keyPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode()).To(Equal(os.FileMode(0600)))
+		})
+
+		It("tightens the permissions of an existing key file", func() {
+			err := ioutil.WriteFile(keyPath, []byte("some-old-private-key"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			info, err := os.Stat(keyPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode()).To(Equal(os.FileMode(0600)))
+		})
+
+		It("uses the host alias and jump host from the flags", func() {
+			err := command.Execute([]string{"--host", "director", "--proxy-jump", "ubuntu@bastion.example.com"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stdout.String()).To(HavePrefix("Host director\n"))
+			Expect(stdout.String()).To(HaveSuffix("  ProxyJump ubuntu@bastion.example.com\n"))
+		})
+
+		Context("when --append-to is provided", func() {
+			var sshConfigPath string
+
+			BeforeEach(func() {
+				sshConfigPath = filepath.Join(stateDir, "config")
+				err := ioutil.WriteFile(sshConfigPath, []byte("Host github.com\n  User git"), 0600)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("adds the stanza to the file between markers instead of printing it", func() {
+				err := command.Execute([]string{"--append-to", sshConfigPath}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stdout.String()).To(BeEmpty())
+
+				contents, err := ioutil.ReadFile(sshConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(Equal(`Host github.com
+  User git
+# BEGIN bbl bbl-some-env-id
+Host bbl-some-env-id
+  HostName 203.0.113.10
+  User vcap
+  Port 22
+  IdentityFile ` + keyPath + `
+  IdentitiesOnly yes
+# END bbl bbl-some-env-id
+`))
+			})
+
+			It("replaces the stanza written by an earlier run", func() {
+				err := command.Execute([]string{"--append-to", sshConfigPath}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				state.BOSH.DirectorAddress = "https://203.0.113.20:25555"
+				err = command.Execute([]string{"--append-to", sshConfigPath}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := ioutil.ReadFile(sshConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(HavePrefix("Host github.com\n  User git\n# BEGIN bbl bbl-some-env-id\n"))
+				Expect(string(contents)).To(ContainSubstring("HostName 203.0.113.20"))
+				Expect(string(contents)).NotTo(ContainSubstring("HostName 203.0.113.10"))
+			})
+
+			It("creates the file when it does not exist", func() {
+				newConfigPath := filepath.Join(stateDir, "new-config")
+
+				err := command.Execute([]string{"--append-to", newConfigPath}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := ioutil.ReadFile(newConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(HavePrefix("# BEGIN bbl bbl-some-env-id\n"))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the state validator fails", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("state validator failed"))
+			})
+
+			It("returns an error when the state has no ssh key", func() {
+				state.KeyPair = storage.KeyPair{}
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("Could not retrieve ssh key, please make sure you are targeting the proper state dir."))
+			})
+
+			It("returns an error when the state has no director", func() {
+				state.BOSH = storage.BOSH{}
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("Could not retrieve director address, please make sure you are targeting the proper state dir."))
+			})
+
+			It("returns an error when the director address is not a url", func() {
+				state.BOSH.DirectorAddress = "203.0.113.10"
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError(`director address "203.0.113.10" is not a URL`))
+			})
+
+			It("returns an error when the host alias is a pattern", func() {
+				err := command.Execute([]string{"--host", "bbl-*"}, state)
+				Expect(err).To(MatchError(`--host must be a single host name without patterns, got "bbl-*"`))
+			})
+
+			It("returns an error when the key cannot be written", func() {
+				command = commands.NewSSHConfig(stateValidator, "/dev/null", stdout)
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("returns an error when the flags cannot be parsed", func() {
+				err := command.Execute([]string{"--unknown-flag"}, state)
+				Expect(err).To(MatchError(ContainSubstring("flag provided but not defined: -unknown-flag")))
+			})
+		})
+	})
+})
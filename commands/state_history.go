@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	StateHistoryCommand = "state-history"
+)
+
+type stateHistory interface {
+	History() ([]storage.HistoryEntry, error)
+}
+
+type StateHistory struct {
+	stateValidator stateValidator
+	stateHistory   stateHistory
+	stdout         io.Writer
+}
+
+func NewStateHistory(stateValidator stateValidator, stateHistory stateHistory, stdout io.Writer) StateHistory {
+	return StateHistory{
+		stateValidator: stateValidator,
+		stateHistory:   stateHistory,
+		stdout:         stdout,
+	}
+}
+
+func (s StateHistory) Execute(subcommandFlags []string, state storage.State) error {
+	err := s.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	entries, err := s.stateHistory.History()
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(s.stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(writer, "VERSION\tTIMESTAMP\tCOMMAND\tCHANGED")
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\n", entry.Version, entry.Timestamp.Format(time.RFC3339), entry.Command, strings.Join(entry.ChangedFields, ", "))
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,77 @@
+package commands_test
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StateHistory", func() {
+	var (
+		stateValidator *fakes.StateValidator
+		stateHistory   *fakes.StateHistory
+		stdout         *bytes.Buffer
+		command        commands.StateHistory
+	)
+
+	BeforeEach(func() {
+		stateValidator = &fakes.StateValidator{}
+		stateHistory = &fakes.StateHistory{}
+		stdout = bytes.NewBuffer([]byte{})
+
+		command = commands.NewStateHistory(stateValidator, stateHistory, stdout)
+	})
+
+	Describe("Execute", func() {
+		It("lists the versions in the state history, newest first", func() {
+			stateHistory.HistoryCall.Returns.Entries = []storage.HistoryEntry{
+				{
+					Version:       1,
+					Timestamp:     time.Date(2017, time.January, 2, 3, 4, 5, 0, time.UTC),
+					Command:       "up",
+					ChangedFields: []string{"bosh", "iaas", "tfState"},
+				},
+				{
+					Version:       2,
+					Timestamp:     time.Date(2017, time.January, 3, 3, 4, 5, 0, time.UTC),
+					Command:       "create-lbs",
+					ChangedFields: []string{"lb"},
+				},
+			}
+
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
+			Expect(stateHistory.HistoryCall.CallCount).To(Equal(1))
+			Expect(stdout.String()).To(Equal(`VERSION  TIMESTAMP             COMMAND     CHANGED
+2        2017-01-03T03:04:05Z  create-lbs  lb
+1        2017-01-02T03:04:05Z  up          bosh, iaas, tfState
+`))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the state validator fails", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("state validator failed"))
+				Expect(stateHistory.HistoryCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the history cannot be read", func() {
+				stateHistory.HistoryCall.Returns.Error = errors.New("failed to read history")
+
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("failed to read history"))
+			})
+		})
+	})
+})
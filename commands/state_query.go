@@ -27,16 +27,23 @@ const (
 type StateQuery struct {
 	logger         logger
 	stateValidator stateValidator
+	output         string
 	propertyName   string
 	getProperty    getPropertyFunc
 }
 
 type getPropertyFunc func(storage.State) string
 
-func NewStateQuery(logger logger, stateValidator stateValidator, propertyName string, getProperty getPropertyFunc) StateQuery {
+type stateQueryOutput struct {
+	Property string `json:"property" yaml:"property"`
+	Value    string `json:"value" yaml:"value"`
+}
+
+func NewStateQuery(logger logger, stateValidator stateValidator, output string, propertyName string, getProperty getPropertyFunc) StateQuery {
 	return StateQuery{
 		logger:         logger,
 		stateValidator: stateValidator,
+		output:         output,
 		propertyName:   propertyName,
 		getProperty:    getProperty,
 	}
@@ -53,6 +60,17 @@ func (s StateQuery) Execute(subcommandFlags []string, state storage.State) error
 		return fmt.Errorf("Could not retrieve %s, please make sure you are targeting the proper state dir.", s.propertyName)
 	}
 
+	if s.output == JSONOutput || s.output == YAMLOutput {
+		contents, err := marshalOutput(s.output, stateQueryOutput{
+			Property: s.propertyName,
+			Value:    propertyValue,
+		})
+		if err != nil {
+			return err
+		}
+		propertyValue = contents
+	}
+
 	s.logger.Println(propertyValue)
 	return nil
 }
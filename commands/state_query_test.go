@@ -26,7 +26,7 @@ var _ = Describe("StateQuery", func() {
 
 	Describe("Execute", func() {
 		It("prints out the director address", func() {
-			command := commands.NewStateQuery(fakeLogger, fakeStateValidator, "director address", func(state storage.State) string {
+			command := commands.NewStateQuery(fakeLogger, fakeStateValidator, commands.TextOutput, "director address", func(state storage.State) string {
 				return state.BOSH.DirectorAddress
 			})
 
@@ -42,9 +42,42 @@ var _ = Describe("StateQuery", func() {
 			Expect(fakeLogger.PrintlnCall.Receives.Message).To(Equal("some-director-address"))
 		})
 
+		It("prints the property name and value as json with --output json", func() {
+			command := commands.NewStateQuery(fakeLogger, fakeStateValidator, commands.JSONOutput, "director address", func(state storage.State) string {
+				return state.BOSH.DirectorAddress
+			})
+
+			err := command.Execute([]string{}, storage.State{
+				BOSH: storage.BOSH{
+					DirectorAddress: "some-director-address",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeLogger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+				"property": "director address",
+				"value": "some-director-address"
+			}`))
+		})
+
+		It("prints the property name and value as yaml with --output yaml", func() {
+			command := commands.NewStateQuery(fakeLogger, fakeStateValidator, commands.YAMLOutput, "director address", func(state storage.State) string {
+				return state.BOSH.DirectorAddress
+			})
+
+			err := command.Execute([]string{}, storage.State{
+				BOSH: storage.BOSH{
+					DirectorAddress: "some-director-address",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeLogger.PrintlnCall.Receives.Message).To(Equal("property: director address\nvalue: some-director-address"))
+		})
+
 		It("returns an error when the state validator fails", func() {
 			fakeStateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
-			command := commands.NewStateQuery(fakeLogger, fakeStateValidator, "", func(state storage.State) string {
+			command := commands.NewStateQuery(fakeLogger, fakeStateValidator, commands.TextOutput, "", func(state storage.State) string {
 				return ""
 			})
 
@@ -57,7 +90,7 @@ var _ = Describe("StateQuery", func() {
 
 		It("returns an error when the state value is empty", func() {
 			propertyName := fmt.Sprintf("%s-%d", "some-name", rand.Int())
-			command := commands.NewStateQuery(fakeLogger, fakeStateValidator, propertyName, func(state storage.State) string {
+			command := commands.NewStateQuery(fakeLogger, fakeStateValidator, commands.TextOutput, propertyName, func(state storage.State) string {
 				return ""
 			})
 			err := command.Execute([]string{}, storage.State{
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	StateRollbackCommand = "state-rollback"
+)
+
+type stateRollbacker interface {
+	Rollback(version int) (storage.State, error)
+}
+
+type StateRollback struct {
+	logger          logger
+	stateValidator  stateValidator
+	stateRollbacker stateRollbacker
+}
+
+func NewStateRollback(logger logger, stateValidator stateValidator, stateRollbacker stateRollbacker) StateRollback {
+	return StateRollback{
+		logger:          logger,
+		stateValidator:  stateValidator,
+		stateRollbacker: stateRollbacker,
+	}
+}
+
+func (s StateRollback) Execute(subcommandFlags []string, state storage.State) error {
+	err := s.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	version, err := s.parseFlags(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Step("rolling back bbl-state.json to version %d", version)
+	_, err = s.stateRollbacker.Rollback(version)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (StateRollback) Flags() flags.Flags {
+	var version int
+	return stateRollbackFlags(&version)
+}
+
+func (StateRollback) parseFlags(subcommandFlags []string) (int, error) {
+	var version int
+
+	err := stateRollbackFlags(&version).Parse(subcommandFlags)
+	if err != nil {
+		return 0, err
+	}
+
+	if version <= 0 {
+		return 0, errors.New("--to must be set to a version listed by bbl state-history")
+	}
+
+	return version, nil
+}
+
+func stateRollbackFlags(version *int) flags.Flags {
+	rollbackFlags := flags.New("state-rollback")
+	rollbackFlags.Int(version, "to", 0)
+
+	return rollbackFlags
+}
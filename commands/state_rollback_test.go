@@ -0,0 +1,69 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StateRollback", func() {
+	var (
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+		stateHistory   *fakes.StateHistory
+		command        commands.StateRollback
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		stateHistory = &fakes.StateHistory{}
+
+		command = commands.NewStateRollback(logger, stateValidator, stateHistory)
+	})
+
+	Describe("Execute", func() {
+		It("restores the requested version", func() {
+			err := command.Execute([]string{"--to", "3"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
+			Expect(stateHistory.RollbackCall.CallCount).To(Equal(1))
+			Expect(stateHistory.RollbackCall.Receives.Version).To(Equal(3))
+			Expect(logger.StepCall.Messages).To(Equal([]string{"rolling back bbl-state.json to version 3"}))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the state validator fails", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+				err := command.Execute([]string{"--to", "3"}, storage.State{})
+				Expect(err).To(MatchError("state validator failed"))
+				Expect(stateHistory.RollbackCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the version is missing", func() {
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("--to must be set to a version listed by bbl state-history"))
+				Expect(stateHistory.RollbackCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the flags cannot be parsed", func() {
+				err := command.Execute([]string{"--to", "latest"}, storage.State{})
+				Expect(err).To(MatchError(ContainSubstring(`invalid value "latest" for flag -to`)))
+			})
+
+			It("returns an error when the rollback fails", func() {
+				stateHistory.RollbackCall.Returns.Error = errors.New("failed to roll back")
+
+				err := command.Execute([]string{"--to", "3"}, storage.State{})
+				Expect(err).To(MatchError("failed to roll back"))
+			})
+		})
+	})
+})
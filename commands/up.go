@@ -3,15 +3,16 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
 
 	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/patch"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
 
 type Up struct {
 	awsUp          awsUp
 	gcpUp          gcpUp
-	envGetter      envGetter
 	envIDGenerator envIDGenerator
 }
 
@@ -23,32 +24,31 @@ type gcpUp interface {
 	Execute(gcpUpConfig GCPUpConfig, state storage.State) error
 }
 
-type envGetter interface {
-	Get(name string) string
-}
-
 type envIDGenerator interface {
 	Generate() (string, error)
 }
 
 type upConfig struct {
-	awsAccessKeyID       string
-	awsSecretAccessKey   string
-	awsRegion            string
-	gcpServiceAccountKey string
-	gcpProjectID         string
-	gcpZone              string
-	gcpRegion            string
-	iaas                 string
-	name                 string
+	awsAccessKeyID        string
+	awsSecretAccessKey    string
+	awsRegion             string
+	gcpServiceAccountKey  string
+	gcpProjectID          string
+	gcpZone               string
+	gcpRegion             string
+	iaas                  string
+	name                  string
+	dryRun                bool
+	directorOpsFiles      []string
+	noDirectorOpsFiles    bool
+	cloudConfigOpsFiles   []string
+	noCloudConfigOpsFiles bool
 }
 
-func NewUp(awsUp awsUp, gcpUp gcpUp, envGetter envGetter,
-	envIDGenerator envIDGenerator) Up {
+func NewUp(awsUp awsUp, gcpUp gcpUp, envIDGenerator envIDGenerator) Up {
 	return Up{
 		awsUp:          awsUp,
 		gcpUp:          gcpUp,
-		envGetter:      envGetter,
 		envIDGenerator: envIDGenerator,
 	}
 }
@@ -91,12 +91,43 @@ func (u Up) Execute(args []string, state storage.State) error {
 		}
 	}
 
+	// Ops files given to up replace the ones kept in the state, which every
+	// later deploy of the director or upload of the cloud config applies again.
+	if config.noDirectorOpsFiles {
+		if len(config.directorOpsFiles) > 0 {
+			return errors.New("--director-ops-file and --no-director-ops-files cannot be given together")
+		}
+		state.DirectorOpsFiles = nil
+	}
+
+	if len(config.directorOpsFiles) > 0 {
+		state.DirectorOpsFiles, err = readOpsFiles(config.directorOpsFiles)
+		if err != nil {
+			return err
+		}
+	}
+
+	if config.noCloudConfigOpsFiles {
+		if len(config.cloudConfigOpsFiles) > 0 {
+			return errors.New("--cloud-config-ops-file and --no-cloud-config-ops-files cannot be given together")
+		}
+		state.CloudConfigOpsFiles = nil
+	}
+
+	if len(config.cloudConfigOpsFiles) > 0 {
+		state.CloudConfigOpsFiles, err = readOpsFiles(config.cloudConfigOpsFiles)
+		if err != nil {
+			return err
+		}
+	}
+
 	switch desiredIAAS {
 	case "aws":
 		err = u.awsUp.Execute(AWSUpConfig{
 			AccessKeyID:     config.awsAccessKeyID,
 			SecretAccessKey: config.awsSecretAccessKey,
 			Region:          config.awsRegion,
+			DryRun:          config.dryRun,
 		}, state)
 	case "gcp":
 		err = u.gcpUp.Execute(GCPUpConfig{
@@ -104,6 +135,7 @@ func (u Up) Execute(args []string, state storage.State) error {
 			ProjectID:             config.gcpProjectID,
 			Zone:                  config.gcpZone,
 			Region:                config.gcpRegion,
+			DryRun:                config.dryRun,
 		}, state)
 	default:
 		return fmt.Errorf("%q is an invalid iaas type, supported values are: [gcp, aws]", desiredIAAS)
@@ -116,28 +148,62 @@ func (u Up) Execute(args []string, state storage.State) error {
 	return nil
 }
 
+func (Up) Flags() flags.Flags {
+	return upFlags(&upConfig{})
+}
+
 func (u Up) parseArgs(args []string) (upConfig, error) {
 	var config upConfig
 
-	upFlags := flags.New("up")
+	err := upFlags(&config).Parse(args)
+	if err != nil {
+		return upConfig{}, err
+	}
+
+	return config, nil
+}
 
-	upFlags.String(&config.iaas, "iaas", u.envGetter.Get("BBL_IAAS"))
+func upFlags(config *upConfig) flags.Flags {
+	upFlagSet := flags.New("up")
 
-	upFlags.String(&config.awsAccessKeyID, "aws-access-key-id", u.envGetter.Get("BBL_AWS_ACCESS_KEY_ID"))
-	upFlags.String(&config.awsSecretAccessKey, "aws-secret-access-key", u.envGetter.Get("BBL_AWS_SECRET_ACCESS_KEY"))
-	upFlags.String(&config.awsRegion, "aws-region", u.envGetter.Get("BBL_AWS_REGION"))
+	upFlagSet.String(&config.iaas, "iaas", "")
 
-	upFlags.String(&config.gcpServiceAccountKey, "gcp-service-account-key", u.envGetter.Get("BBL_GCP_SERVICE_ACCOUNT_KEY"))
-	upFlags.String(&config.gcpProjectID, "gcp-project-id", u.envGetter.Get("BBL_GCP_PROJECT_ID"))
-	upFlags.String(&config.gcpZone, "gcp-zone", u.envGetter.Get("BBL_GCP_ZONE"))
-	upFlags.String(&config.gcpRegion, "gcp-region", u.envGetter.Get("BBL_GCP_REGION"))
+	upFlagSet.String(&config.awsAccessKeyID, "aws-access-key-id", "")
+	upFlagSet.String(&config.awsSecretAccessKey, "aws-secret-access-key", "")
+	upFlagSet.String(&config.awsRegion, "aws-region", "")
 
-	upFlags.String(&config.name, "name", "")
+	upFlagSet.String(&config.gcpServiceAccountKey, "gcp-service-account-key", "")
+	upFlagSet.String(&config.gcpProjectID, "gcp-project-id", "")
+	upFlagSet.String(&config.gcpZone, "gcp-zone", "")
+	upFlagSet.String(&config.gcpRegion, "gcp-region", "")
 
-	err := upFlags.Parse(args)
-	if err != nil {
-		return upConfig{}, err
+	upFlagSet.String(&config.name, "name", "")
+	upFlagSet.Bool(&config.dryRun, "", "dry-run", false)
+	upFlagSet.StringSlice(&config.directorOpsFiles, "director-ops-file")
+	upFlagSet.Bool(&config.noDirectorOpsFiles, "", "no-director-ops-files", false)
+	upFlagSet.StringSlice(&config.cloudConfigOpsFiles, "cloud-config-ops-file")
+	upFlagSet.Bool(&config.noCloudConfigOpsFiles, "", "no-cloud-config-ops-files", false)
+
+	upFlagSet.Values("iaas", "aws", "gcp")
+	upFlagSet.Files("director-ops-file", "cloud-config-ops-file")
+
+	return upFlagSet
+}
+
+func readOpsFiles(paths []string) ([]storage.OpsFile, error) {
+	opsFiles := []storage.OpsFile{}
+	for _, path := range paths {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := patch.Parse(contents); err != nil {
+			return nil, fmt.Errorf("ops file %s: %s", path, err)
+		}
+
+		opsFiles = append(opsFiles, storage.OpsFile{Path: path, Contents: string(contents)})
 	}
 
-	return config, nil
+	return opsFiles, nil
 }
@@ -2,6 +2,9 @@ package commands_test
 
 import (
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 
 	"github.com/cloudfoundry/bosh-bootloader/commands"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
@@ -18,30 +21,43 @@ var _ = Describe("Up", func() {
 
 		fakeAWSUp          *fakes.AWSUp
 		fakeGCPUp          *fakes.GCPUp
-		fakeEnvGetter      *fakes.EnvGetter
 		fakeEnvIDGenerator *fakes.EnvIDGenerator
 		state              storage.State
+		envNames           []string
 	)
 
+	setEnv := func(values map[string]string) {
+		for name, value := range values {
+			os.Setenv(name, value)
+			envNames = append(envNames, name)
+		}
+	}
+
 	BeforeEach(func() {
 		fakeAWSUp = &fakes.AWSUp{Name: "aws"}
 		fakeGCPUp = &fakes.GCPUp{Name: "gcp"}
-		fakeEnvGetter = &fakes.EnvGetter{}
 
 		fakeEnvIDGenerator = &fakes.EnvIDGenerator{}
 		fakeEnvIDGenerator.GenerateCall.Returns.EnvID = "bbl-lake-time:stamp"
 
-		command = commands.NewUp(fakeAWSUp, fakeGCPUp, fakeEnvGetter, fakeEnvIDGenerator)
+		command = commands.NewUp(fakeAWSUp, fakeGCPUp, fakeEnvIDGenerator)
+	})
+
+	AfterEach(func() {
+		for _, name := range envNames {
+			os.Unsetenv(name)
+		}
+		envNames = nil
 	})
 
 	Describe("Execute", func() {
 		Context("when aws args are provided through environment variables", func() {
 			BeforeEach(func() {
-				fakeEnvGetter.Values = map[string]string{
+				setEnv(map[string]string{
 					"BBL_AWS_ACCESS_KEY_ID":     "access-key-id-from-env",
 					"BBL_AWS_SECRET_ACCESS_KEY": "secret-access-key-from-env",
 					"BBL_AWS_REGION":            "region-from-env",
-				}
+				})
 			})
 
 			It("uses the aws args provided by environment variables", func() {
@@ -168,12 +184,12 @@ var _ = Describe("Up", func() {
 
 		Context("when gcp args are provided through environment variables", func() {
 			BeforeEach(func() {
-				fakeEnvGetter.Values = map[string]string{
+				setEnv(map[string]string{
 					"BBL_GCP_SERVICE_ACCOUNT_KEY": "some-service-account-key-env",
 					"BBL_GCP_PROJECT_ID":          "some-project-id-env",
 					"BBL_GCP_ZONE":                "some-zone-env",
 					"BBL_GCP_REGION":              "some-region-env",
-				}
+				})
 			})
 
 			It("uses the gcp args provided by environment variables", func() {
@@ -246,9 +262,9 @@ var _ = Describe("Up", func() {
 
 		Context("when state does not contain an iaas", func() {
 			It("uses the iaas from the env var", func() {
-				fakeEnvGetter.Values = map[string]string{
+				setEnv(map[string]string{
 					"BBL_IAAS": "gcp",
-				}
+				})
 				err := command.Execute([]string{
 					"--gcp-service-account-key", "some-service-account-key",
 					"--gcp-project-id", "some-project-id",
@@ -261,9 +277,9 @@ var _ = Describe("Up", func() {
 			})
 
 			It("uses the iaas from the args over the env var", func() {
-				fakeEnvGetter.Values = map[string]string{
+				setEnv(map[string]string{
 					"BBL_IAAS": "aws",
-				}
+				})
 				err := command.Execute([]string{
 					"--iaas", "gcp",
 					"--gcp-service-account-key", "some-service-account-key",
@@ -300,12 +316,12 @@ var _ = Describe("Up", func() {
 				})
 
 				It("executes the GCP up with gcp details from env vars", func() {
-					fakeEnvGetter.Values = map[string]string{
+					setEnv(map[string]string{
 						"BBL_GCP_SERVICE_ACCOUNT_KEY": "some-service-account-key",
 						"BBL_GCP_PROJECT_ID":          "some-project-id",
 						"BBL_GCP_ZONE":                "some-zone",
 						"BBL_GCP_REGION":              "some-region",
-					}
+					})
 					err := command.Execute([]string{
 						"--iaas", "gcp",
 					}, storage.State{})
@@ -422,6 +438,165 @@ var _ = Describe("Up", func() {
 				})
 			})
 
+			Context("when --dry-run is provided", func() {
+				It("passes it to the AWS up", func() {
+					err := command.Execute([]string{"--dry-run"}, storage.State{IAAS: "aws"})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeAWSUp.ExecuteCall.Receives.AWSUpConfig.DryRun).To(BeTrue())
+				})
+
+				It("passes it to the GCP up", func() {
+					err := command.Execute([]string{"--dry-run"}, storage.State{IAAS: "gcp"})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeGCPUp.ExecuteCall.Receives.GCPUpConfig.DryRun).To(BeTrue())
+				})
+			})
+
+			Context("when --director-ops-file is provided", func() {
+				var opsFilePath string
+
+				BeforeEach(func() {
+					tempDir, err := ioutil.TempDir("", "")
+					Expect(err).NotTo(HaveOccurred())
+
+					opsFilePath = filepath.Join(tempDir, "ops.yml")
+					err = ioutil.WriteFile(opsFilePath, []byte("- type: remove\n  path: /some-key\n"), os.ModePerm)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("keeps the ops files in the state passed to up", func() {
+					err := command.Execute([]string{"--director-ops-file", opsFilePath}, storage.State{IAAS: "gcp"})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeGCPUp.ExecuteCall.Receives.State.DirectorOpsFiles).To(Equal([]storage.OpsFile{
+						{Path: opsFilePath, Contents: "- type: remove\n  path: /some-key\n"},
+					}))
+				})
+
+				It("replaces the ops files already in the state", func() {
+					err := command.Execute([]string{"--director-ops-file", opsFilePath}, storage.State{
+						IAAS: "aws",
+						DirectorOpsFiles: []storage.OpsFile{
+							{Path: "some-old-ops-file", Contents: "some-contents"},
+						},
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeAWSUp.ExecuteCall.Receives.State.DirectorOpsFiles).To(Equal([]storage.OpsFile{
+						{Path: opsFilePath, Contents: "- type: remove\n  path: /some-key\n"},
+					}))
+				})
+
+				It("keeps the ops files already in the state when the flag is not provided", func() {
+					opsFiles := []storage.OpsFile{
+						{Path: "some-old-ops-file", Contents: "some-contents"},
+					}
+
+					err := command.Execute([]string{}, storage.State{IAAS: "aws", DirectorOpsFiles: opsFiles})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeAWSUp.ExecuteCall.Receives.State.DirectorOpsFiles).To(Equal(opsFiles))
+				})
+
+				It("removes the ops files already in the state when --no-director-ops-files is provided", func() {
+					err := command.Execute([]string{"--no-director-ops-files"}, storage.State{
+						IAAS: "aws",
+						DirectorOpsFiles: []storage.OpsFile{
+							{Path: "some-old-ops-file", Contents: "some-contents"},
+						},
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeAWSUp.ExecuteCall.Receives.State.DirectorOpsFiles).To(BeEmpty())
+				})
+
+				Context("failure cases", func() {
+					It("returns an error when the ops file does not exist", func() {
+						err := command.Execute([]string{"--director-ops-file", "/some/missing/ops.yml"}, storage.State{IAAS: "aws"})
+						Expect(err).To(MatchError(ContainSubstring("/some/missing/ops.yml")))
+						Expect(fakeAWSUp.ExecuteCall.CallCount).To(Equal(0))
+					})
+
+					It("returns an error when --no-director-ops-files is provided with ops files", func() {
+						err := command.Execute([]string{"--director-ops-file", opsFilePath, "--no-director-ops-files"}, storage.State{IAAS: "aws"})
+						Expect(err).To(MatchError("--director-ops-file and --no-director-ops-files cannot be given together"))
+						Expect(fakeAWSUp.ExecuteCall.CallCount).To(Equal(0))
+					})
+
+					It("returns an error when the ops file is not valid", func() {
+						err := ioutil.WriteFile(opsFilePath, []byte("- type: some-type\n  path: /some-key\n"), os.ModePerm)
+						Expect(err).NotTo(HaveOccurred())
+
+						err = command.Execute([]string{"--director-ops-file", opsFilePath}, storage.State{IAAS: "aws"})
+						Expect(err).To(MatchError(`ops file ` + opsFilePath + `: operation 1 (some-type /some-key): unknown type "some-type", expected "replace" or "remove"`))
+						Expect(fakeAWSUp.ExecuteCall.CallCount).To(Equal(0))
+					})
+				})
+			})
+
+			Context("when --cloud-config-ops-file is provided", func() {
+				var opsFilePath string
+
+				BeforeEach(func() {
+					tempDir, err := ioutil.TempDir("", "")
+					Expect(err).NotTo(HaveOccurred())
+
+					opsFilePath = filepath.Join(tempDir, "ops.yml")
+					err = ioutil.WriteFile(opsFilePath, []byte("- type: remove\n  path: /some-key\n"), os.ModePerm)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("replaces the cloud config ops files in the state passed to up", func() {
+					err := command.Execute([]string{"--cloud-config-ops-file", opsFilePath}, storage.State{
+						IAAS: "gcp",
+						CloudConfigOpsFiles: []storage.OpsFile{
+							{Path: "some-old-ops-file", Contents: "some-contents"},
+						},
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeGCPUp.ExecuteCall.Receives.State.CloudConfigOpsFiles).To(Equal([]storage.OpsFile{
+						{Path: opsFilePath, Contents: "- type: remove\n  path: /some-key\n"},
+					}))
+					Expect(fakeGCPUp.ExecuteCall.Receives.State.DirectorOpsFiles).To(BeEmpty())
+				})
+
+				It("removes the cloud config ops files in the state when --no-cloud-config-ops-files is provided", func() {
+					directorOpsFiles := []storage.OpsFile{
+						{Path: "some-director-ops-file", Contents: "some-contents"},
+					}
+
+					err := command.Execute([]string{"--no-cloud-config-ops-files"}, storage.State{
+						IAAS:             "gcp",
+						DirectorOpsFiles: directorOpsFiles,
+						CloudConfigOpsFiles: []storage.OpsFile{
+							{Path: "some-old-ops-file", Contents: "some-contents"},
+						},
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeGCPUp.ExecuteCall.Receives.State.CloudConfigOpsFiles).To(BeEmpty())
+					Expect(fakeGCPUp.ExecuteCall.Receives.State.DirectorOpsFiles).To(Equal(directorOpsFiles))
+				})
+
+				It("returns an error when --no-cloud-config-ops-files is provided with ops files", func() {
+					err := command.Execute([]string{"--cloud-config-ops-file", opsFilePath, "--no-cloud-config-ops-files"}, storage.State{IAAS: "aws"})
+					Expect(err).To(MatchError("--cloud-config-ops-file and --no-cloud-config-ops-files cannot be given together"))
+					Expect(fakeAWSUp.ExecuteCall.CallCount).To(Equal(0))
+				})
+
+				It("returns an error when the ops file is not valid", func() {
+					err := ioutil.WriteFile(opsFilePath, []byte("some-invalid-ops"), os.ModePerm)
+					Expect(err).NotTo(HaveOccurred())
+
+					err = command.Execute([]string{"--cloud-config-ops-file", opsFilePath}, storage.State{IAAS: "aws"})
+					Expect(err).To(MatchError(ContainSubstring("ops file " + opsFilePath + ": ops file is not a YAML list of operations")))
+					Expect(fakeAWSUp.ExecuteCall.CallCount).To(Equal(0))
+				})
+			})
+
 			Context("when iaas specified is different than the iaas in state", func() {
 				It("returns an error when the iaas is provided via args", func() {
 					err := command.Execute([]string{"--iaas", "aws"}, storage.State{IAAS: "gcp"})
@@ -429,9 +604,9 @@ var _ = Describe("Up", func() {
 				})
 
 				It("returns an error when the iaas is provided via env vars", func() {
-					fakeEnvGetter.Values = map[string]string{
+					setEnv(map[string]string{
 						"BBL_IAAS": "aws",
-					}
+					})
 					err := command.Execute([]string{}, storage.State{IAAS: "gcp"})
 					Expect(err).To(MatchError("The iaas type cannot be changed for an existing environment. The current iaas type is gcp."))
 				})
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	UpdateCloudConfigCommand = "update-cloud-config"
+)
+
+type UpdateCloudConfig struct {
+	logger                 logger
+	stateValidator         stateValidator
+	boshClientProvider     boshClientProvider
+	environmentCloudConfig environmentCloudConfig
+}
+
+func NewUpdateCloudConfig(logger logger, stateValidator stateValidator, boshClientProvider boshClientProvider,
+	credentialValidator credentialValidator, infrastructureManager infrastructureManager,
+	availabilityZoneRetriever availabilityZoneRetriever, boshCloudConfigurator boshCloudConfigurator,
+	boshCloudConfigGenerator boshCloudConfigGenerator, terraformOutputter terraformOutputter,
+	gcpCloudConfigGenerator gcpCloudConfigGenerator, zones zones) UpdateCloudConfig {
+	return UpdateCloudConfig{
+		logger:             logger,
+		stateValidator:     stateValidator,
+		boshClientProvider: boshClientProvider,
+		environmentCloudConfig: newEnvironmentCloudConfig(credentialValidator, infrastructureManager, availabilityZoneRetriever,
+			boshCloudConfigurator, boshCloudConfigGenerator, terraformOutputter, gcpCloudConfigGenerator, zones),
+	}
+}
+
+func (u UpdateCloudConfig) Execute(subcommandFlags []string, state storage.State) error {
+	err := u.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	if state.BOSH.IsEmpty() {
+		return BBLNotFound
+	}
+
+	u.logger.Step("generating cloud config")
+	cloudConfig, err := u.environmentCloudConfig.yaml(state)
+	if err != nil {
+		return err
+	}
+
+	u.logger.Step("applying cloud config")
+	boshClient := u.boshClientProvider.Client(state.BOSH.DirectorAddress, state.BOSH.DirectorUsername,
+		state.BOSH.DirectorPassword)
+
+	return boshClient.UpdateCloudConfig(cloudConfig)
+}
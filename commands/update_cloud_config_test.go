@@ -0,0 +1,127 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/cloudconfig/gcp"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UpdateCloudConfig", func() {
+	var (
+		logger                  *fakes.Logger
+		stateValidator          *fakes.StateValidator
+		boshClientProvider      *fakes.BOSHClientProvider
+		boshClient              *fakes.BOSHClient
+		credentialValidator     *fakes.CredentialValidator
+		infrastructureManager   *fakes.InfrastructureManager
+		terraformOutputter      *fakes.TerraformOutputter
+		gcpCloudConfigGenerator *fakes.GCPCloudConfigGenerator
+		zones                   *fakes.Zones
+		state                   storage.State
+		command                 commands.UpdateCloudConfig
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		boshClientProvider = &fakes.BOSHClientProvider{}
+		boshClient = &fakes.BOSHClient{}
+		credentialValidator = &fakes.CredentialValidator{}
+		infrastructureManager = &fakes.InfrastructureManager{}
+		terraformOutputter = &fakes.TerraformOutputter{}
+		gcpCloudConfigGenerator = &fakes.GCPCloudConfigGenerator{}
+		zones = &fakes.Zones{}
+
+		boshClientProvider.ClientCall.Returns.Client = boshClient
+		zones.GetCall.Returns.Zones = []string{"some-zone-1"}
+		gcpCloudConfigGenerator.GenerateCall.Returns.CloudConfig = gcp.CloudConfig{
+			AZs: []gcp.AZ{
+				{Name: "z1", CloudProperties: gcp.AZCloudProperties{Zone: "some-zone-1"}},
+			},
+		}
+
+		state = storage.State{
+			IAAS: "gcp",
+			GCP: storage.GCP{
+				Region: "some-region",
+			},
+			TFState: "some-tf-state",
+			BOSH: storage.BOSH{
+				DirectorAddress:  "some-director-address",
+				DirectorUsername: "some-director-username",
+				DirectorPassword: "some-director-password",
+			},
+		}
+
+		command = commands.NewUpdateCloudConfig(logger, stateValidator, boshClientProvider, credentialValidator, infrastructureManager,
+			&fakes.AvailabilityZoneRetriever{}, &fakes.BoshCloudConfigurator{}, &fakes.CloudConfigGenerator{}, terraformOutputter,
+			gcpCloudConfigGenerator, zones)
+	})
+
+	Describe("Execute", func() {
+		It("uploads the regenerated cloud config to the director", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
+			Expect(boshClientProvider.ClientCall.Receives.DirectorAddress).To(Equal("some-director-address"))
+			Expect(boshClientProvider.ClientCall.Receives.DirectorUsername).To(Equal("some-director-username"))
+			Expect(boshClientProvider.ClientCall.Receives.DirectorPassword).To(Equal("some-director-password"))
+
+			Expect(boshClient.UpdateCloudConfigCall.CallCount).To(Equal(1))
+			Expect(string(boshClient.UpdateCloudConfigCall.Receives.Yaml)).To(Equal(`azs:
+- name: z1
+  cloud_properties:
+    zone: some-zone-1
+`))
+
+			Expect(logger.StepCall.Messages).To(Equal([]string{"generating cloud config", "applying cloud config"}))
+		})
+
+		It("does not touch the infrastructure or the director deployment", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(infrastructureManager.UpdateCall.CallCount).To(Equal(0))
+			Expect(infrastructureManager.CreateCall.CallCount).To(Equal(0))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the state is not valid", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("state not found")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("state not found"))
+			})
+
+			It("returns an error when there is no director", func() {
+				state.BOSH = storage.BOSH{}
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError(commands.BBLNotFound))
+				Expect(boshClient.UpdateCloudConfigCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the cloud config cannot be generated", func() {
+				gcpCloudConfigGenerator.GenerateCall.Returns.Error = errors.New("failed to generate cloud config")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to generate cloud config"))
+				Expect(boshClient.UpdateCloudConfigCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the director rejects the cloud config", func() {
+				boshClient.UpdateCloudConfigCall.Returns.Error = errors.New("failed to update cloud config")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("failed to update cloud config"))
+			})
+		})
+	})
+})
@@ -92,20 +92,31 @@ func (c UpdateLBs) Execute(subcommandFlags []string, state storage.State) error
 	return nil
 }
 
+func (UpdateLBs) Flags() flags.Flags {
+	return updateLBsFlags(&updateLBConfig{})
+}
+
 func (UpdateLBs) parseFlags(subcommandFlags []string) (updateLBConfig, error) {
+	config := updateLBConfig{}
+
+	err := updateLBsFlags(&config).Parse(subcommandFlags)
+	if err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+func updateLBsFlags(config *updateLBConfig) flags.Flags {
 	lbFlags := flags.New("update-lbs")
 
-	config := updateLBConfig{}
 	lbFlags.String(&config.certPath, "cert", "")
 	lbFlags.String(&config.keyPath, "key", "")
 	lbFlags.String(&config.chainPath, "chain", "")
 	lbFlags.String(&config.domain, "domain", "")
 	lbFlags.Bool(&config.skipIfMissing, "skip-if-missing", "", false)
 
-	err := lbFlags.Parse(subcommandFlags)
-	if err != nil {
-		return config, err
-	}
+	lbFlags.Files("cert", "key", "chain")
 
-	return config, nil
+	return lbFlags
 }
@@ -5,6 +5,7 @@ import (
 	"io"
 	"strings"
 
+	"github.com/cloudfoundry/bosh-bootloader/flags"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
 
@@ -16,8 +17,16 @@ Usage:
   bbl [GLOBAL OPTIONS] %s [OPTIONS]
 
 Global Options:
-  --help      [-h]       Print usage
-  --state-dir            Directory containing bbl-state.json
+  --help      [-h]             Print usage
+  --debug     [-d]             Print debug output
+  --env                        Name of an environment kept under envs/NAME in the state directory (Defaults to bbl-state.json in the state directory itself)
+  --lock-timeout               How long to wait for another command to release the lock on bbl-state.json, e.g. 5m (Defaults to 0)
+  --output                     Output format of the query commands and lbs: text, json or yaml (Defaults to text)
+  --config                     Path to a bbl.yml with options for up and create-lbs (Defaults to bbl.yml in the state directory)
+  --state-dir                  Directory containing bbl-state.json
+  --state-backend              Object store holding bbl-state.json, e.g. s3://bucket/prefix or gs://bucket/prefix (Defaults to --state-dir)
+  --state-encryption-key-file  File containing the passphrase for an encrypted bbl-state.json (Defaults to environment variable BBL_STATE_PASSPHRASE)
+  --vars-store                 YAML file holding generated credentials, which bbl-state.json then only references
 %s
 `
 	CommandUsage = `
@@ -27,21 +36,43 @@ Global Options:
 
 const GlobalUsage = `
 Commands:
-  bosh-ca-cert           Prints BOSH director CA certificate
-  create-lbs             Attaches load balancer(s)
-  delete-lbs             Deletes attached load balancer(s)
-  destroy                Tears down BOSH director infrastructure
-  director-address       Prints BOSH director address
-  director-username      Prints BOSH director username
-  director-password      Prints BOSH director password
-  director-ca-cert       Prints BOSH director CA certificate
-  env-id                 Prints environment ID
-  help                   Prints usage
-  lbs                    Prints attached load balancer(s)
-  ssh-key                Prints SSH private key
-  up                     Deploys BOSH director on AWS
-  update-lbs             Updates load balancer(s)
-  version                Prints version
+  bosh-ca-cert                 Prints BOSH director CA certificate
+  cloud-config                 Prints the cloud config bbl uploads to the director
+  completion                   Prints a shell completion script for bash, zsh or fish
+  config                       Prints the options of up and create-lbs and where they came from
+  create-lbs                   Attaches load balancer(s)
+  decrypt-state                Decrypts bbl-state.json
+  delete-lbs                   Deletes attached load balancer(s)
+  destroy                      Tears down BOSH director infrastructure
+  director-address             Prints BOSH director address
+  director-username            Prints BOSH director username
+  director-password            Prints BOSH director password
+  director-ca-cert             Prints BOSH director CA certificate
+  doctor                       Checks the health of the environment
+  encrypt-state                Encrypts bbl-state.json
+  env-id                       Prints environment ID
+  envs                         Lists the environments in the state directory
+  export-state                 Prints a copy of bbl-state.json
+  force-unlock                 Removes the lock on bbl-state.json
+  help                         Prints usage
+  import-state                 Creates bbl-state.json from an export
+  lbs                          Prints attached load balancer(s)
+  migrate-state                Migrates bbl-state.json to the current state version
+  outputs                      Prints the outputs of the stack or terraform state
+  plan                         Prints the infrastructure changes bbl up would make
+  print-env                    Prints BOSH CLI environment variables for the director
+  rotate-director-credentials  Regenerates director and internal passwords and redeploys the director
+  rotate-director-ca           Rotates the director CA and certificate in three phases
+  rotate-ssh-key               Replaces the SSH key pair and redeploys the director
+  ssh-config                   Prints an OpenSSH Host stanza for the director
+  ssh-key                      Prints SSH private key
+  state-history                Lists versions of bbl-state.json
+  state-rollback               Restores a version of bbl-state.json
+  up                           Deploys BOSH director on AWS
+  update-cloud-config          Regenerates and uploads the cloud config
+  update-lbs                   Updates load balancer(s)
+  validate-state               Reports problems in bbl-state.json
+  version                      Prints version
 
   Use "bbl [command] --help" for more information about a command.`
 
@@ -59,12 +90,30 @@ func (u Usage) Execute(subcommandFlags []string, state storage.State) error {
 }
 
 func (u Usage) Print() {
-	content := fmt.Sprintf(UsageHeader, "COMMAND", GlobalUsage)
+	content := fmt.Sprintf(withEnvNames("global", UsageHeader), "COMMAND", GlobalUsage)
 	fmt.Fprint(u.stdout, strings.TrimLeft(content, "\n"))
 }
 
 func (u Usage) PrintCommandUsage(command, message string) {
-	commandUsage := fmt.Sprintf(CommandUsage, command, message)
-	content := fmt.Sprintf(UsageHeader, command, commandUsage)
+	commandUsage := fmt.Sprintf(CommandUsage, command, withEnvNames(command, message))
+	content := fmt.Sprintf(withEnvNames("global", UsageHeader), command, commandUsage)
 	fmt.Fprint(u.stdout, strings.TrimLeft(content, "\n"))
 }
+
+// withEnvNames appends the environment variable of each flag listed in usage,
+// as read by the flag set named set, to the line of the flag.
+func withEnvNames(set, usage string) string {
+	lines := strings.Split(usage, "\n")
+	for i, line := range lines {
+		matches := usageFlagPattern.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+
+		if envName := flags.EnvName(set, matches[1]); envName != "" {
+			lines[i] = fmt.Sprintf("%s [$%s]", line, envName)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 
 	. "github.com/onsi/ginkgo"
@@ -31,25 +32,55 @@ Usage:
   bbl [GLOBAL OPTIONS] COMMAND [OPTIONS]
 
 Global Options:
-  --help      [-h]       Print usage
-  --state-dir            Directory containing bbl-state.json
+  --help      [-h]             Print usage
+  --debug     [-d]             Print debug output [$BBL_DEBUG]
+  --env                        Name of an environment kept under envs/NAME in the state directory (Defaults to bbl-state.json in the state directory itself) [$BBL_ENV]
+  --lock-timeout               How long to wait for another command to release the lock on bbl-state.json, e.g. 5m (Defaults to 0) [$BBL_LOCK_TIMEOUT]
+  --output                     Output format of the query commands and lbs: text, json or yaml (Defaults to text) [$BBL_OUTPUT]
+  --config                     Path to a bbl.yml with options for up and create-lbs (Defaults to bbl.yml in the state directory) [$BBL_CONFIG]
+  --state-dir                  Directory containing bbl-state.json [$BBL_STATE_DIR]
+  --state-backend              Object store holding bbl-state.json, e.g. s3://bucket/prefix or gs://bucket/prefix (Defaults to --state-dir) [$BBL_STATE_BACKEND]
+  --state-encryption-key-file  File containing the passphrase for an encrypted bbl-state.json (Defaults to environment variable BBL_STATE_PASSPHRASE) [$BBL_STATE_ENCRYPTION_KEY_FILE]
+  --vars-store                 YAML file holding generated credentials, which bbl-state.json then only references [$BBL_VARS_STORE]
 
 Commands:
-  bosh-ca-cert           Prints BOSH director CA certificate
-  create-lbs             Attaches load balancer(s)
-  delete-lbs             Deletes attached load balancer(s)
-  destroy                Tears down BOSH director infrastructure
-  director-address       Prints BOSH director address
-  director-username      Prints BOSH director username
-  director-password      Prints BOSH director password
-  director-ca-cert       Prints BOSH director CA certificate
-  env-id                 Prints environment ID
-  help                   Prints usage
-  lbs                    Prints attached load balancer(s)
-  ssh-key                Prints SSH private key
-  up                     Deploys BOSH director on AWS
-  update-lbs             Updates load balancer(s)
-  version                Prints version
+  bosh-ca-cert                 Prints BOSH director CA certificate
+  cloud-config                 Prints the cloud config bbl uploads to the director
+  completion                   Prints a shell completion script for bash, zsh or fish
+  config                       Prints the options of up and create-lbs and where they came from
+  create-lbs                   Attaches load balancer(s)
+  decrypt-state                Decrypts bbl-state.json
+  delete-lbs                   Deletes attached load balancer(s)
+  destroy                      Tears down BOSH director infrastructure
+  director-address             Prints BOSH director address
+  director-username            Prints BOSH director username
+  director-password            Prints BOSH director password
+  director-ca-cert             Prints BOSH director CA certificate
+  doctor                       Checks the health of the environment
+  encrypt-state                Encrypts bbl-state.json
+  env-id                       Prints environment ID
+  envs                         Lists the environments in the state directory
+  export-state                 Prints a copy of bbl-state.json
+  force-unlock                 Removes the lock on bbl-state.json
+  help                         Prints usage
+  import-state                 Creates bbl-state.json from an export
+  lbs                          Prints attached load balancer(s)
+  migrate-state                Migrates bbl-state.json to the current state version
+  outputs                      Prints the outputs of the stack or terraform state
+  plan                         Prints the infrastructure changes bbl up would make
+  print-env                    Prints BOSH CLI environment variables for the director
+  rotate-director-credentials  Regenerates director and internal passwords and redeploys the director
+  rotate-director-ca           Rotates the director CA and certificate in three phases
+  rotate-ssh-key               Replaces the SSH key pair and redeploys the director
+  ssh-config                   Prints an OpenSSH Host stanza for the director
+  ssh-key                      Prints SSH private key
+  state-history                Lists versions of bbl-state.json
+  state-rollback               Restores a version of bbl-state.json
+  up                           Deploys BOSH director on AWS
+  update-cloud-config          Regenerates and uploads the cloud config
+  update-lbs                   Updates load balancer(s)
+  validate-state               Reports problems in bbl-state.json
+  version                      Prints version
 
   Use "bbl [command] --help" for more information about a command.
 `, "\n")))
@@ -63,12 +94,62 @@ Commands:
   bbl [GLOBAL OPTIONS] my-command [OPTIONS]
 
 Global Options:
-  --help      [-h]       Print usage
-  --state-dir            Directory containing bbl-state.json
+  --help      [-h]             Print usage
+  --debug     [-d]             Print debug output [$BBL_DEBUG]
+  --env                        Name of an environment kept under envs/NAME in the state directory (Defaults to bbl-state.json in the state directory itself) [$BBL_ENV]
+  --lock-timeout               How long to wait for another command to release the lock on bbl-state.json, e.g. 5m (Defaults to 0) [$BBL_LOCK_TIMEOUT]
+  --output                     Output format of the query commands and lbs: text, json or yaml (Defaults to text) [$BBL_OUTPUT]
+  --config                     Path to a bbl.yml with options for up and create-lbs (Defaults to bbl.yml in the state directory) [$BBL_CONFIG]
+  --state-dir                  Directory containing bbl-state.json [$BBL_STATE_DIR]
+  --state-backend              Object store holding bbl-state.json, e.g. s3://bucket/prefix or gs://bucket/prefix (Defaults to --state-dir) [$BBL_STATE_BACKEND]
+  --state-encryption-key-file  File containing the passphrase for an encrypted bbl-state.json (Defaults to environment variable BBL_STATE_PASSPHRASE) [$BBL_STATE_ENCRYPTION_KEY_FILE]
+  --vars-store                 YAML file holding generated credentials, which bbl-state.json then only references [$BBL_VARS_STORE]
 
 [my-command command options]
   some message
 `, "\n")))
 		})
+
+		It("lists the environment variable of each flag of the command", func() {
+			usage.PrintCommandUsage("create-lbs", commands.CreateLBsCommandUsage)
+			Expect(stdout.String()).To(HaveSuffix(`
+[create-lbs command options]
+  Attaches load balancer(s) with a certificate, key, and optional chain
+
+  --type              Load balancer(s) type. Valid options: "concourse" or "cf" [$BBL_LB_TYPE]
+  [--cert]            Path to SSL certificate (required when type="cf") [$BBL_LB_CERT]
+  [--key]             Path to SSL certificate key (required when type="cf") [$BBL_LB_KEY]
+  [--chain]           Path to SSL certificate chain (optional) [$BBL_LB_CHAIN]
+  [--domain]          Creates a nameserver with a zone for given domain [$BBL_LB_DOMAIN]
+  [--skip-if-exists]  Skips creating load balancer(s) if it is already attached (optional) [$BBL_CREATE_LBS_SKIP_IF_EXISTS]
+`))
+		})
+
+		It("names an environment variable for every flag of every command", func() {
+			definers := map[string]interface{ Flags() flags.Flags }{
+				commands.UpCommand:                        commands.Up{},
+				commands.CreateLBsCommand:                 commands.CreateLBs{},
+				commands.UpdateLBsCommand:                 commands.UpdateLBs{},
+				commands.DeleteLBsCommand:                 commands.DeleteLBs{},
+				commands.DestroyCommand:                   commands.Destroy{},
+				commands.ExportStateCommand:               commands.ExportState{},
+				commands.ImportStateCommand:               commands.ImportState{},
+				commands.MigrateStateCommand:              commands.MigrateState{},
+				commands.ValidateStateCommand:             commands.ValidateState{},
+				commands.StateRollbackCommand:             commands.StateRollback{},
+				commands.PrintEnvCommand:                  commands.PrintEnv{},
+				commands.SSHConfigCommand:                 commands.SSHConfig{},
+				commands.RotateDirectorCredentialsCommand: commands.RotateDirectorCredentials{},
+			}
+
+			for command, definer := range definers {
+				set := definer.Flags()
+				Expect(set.Name()).To(Equal(command))
+
+				set.VisitAll(func(flag flags.Flag) {
+					Expect(flags.EnvName(set.Name(), flag.Name)).To(HavePrefix("BBL_"), command+" --"+flag.Name)
+				})
+			}
+		})
 	})
 })
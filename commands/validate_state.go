@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	ValidateStateCommand = "validate-state"
+)
+
+type stateProblemFinder interface {
+	Validate() ([]storage.StateProblem, error)
+}
+
+type ValidateState struct {
+	stateValidator     stateValidator
+	stateProblemFinder stateProblemFinder
+	stdout             io.Writer
+}
+
+func NewValidateState(stateValidator stateValidator, stateProblemFinder stateProblemFinder, stdout io.Writer) ValidateState {
+	return ValidateState{
+		stateValidator:     stateValidator,
+		stateProblemFinder: stateProblemFinder,
+		stdout:             stdout,
+	}
+}
+
+func (v ValidateState) Execute(subcommandFlags []string, state storage.State) error {
+	var printSchema bool
+	err := validateStateFlags(&printSchema).Parse(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if printSchema {
+		schema, err := storage.StateSchemaJSON()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(v.stdout, string(schema))
+		return nil
+	}
+
+	err = v.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	problems, err := v.stateProblemFinder.Validate()
+	if err != nil {
+		return err
+	}
+
+	if len(problems) == 0 {
+		fmt.Fprintln(v.stdout, "bbl-state.json is valid")
+		return nil
+	}
+
+	for _, problem := range problems {
+		fmt.Fprintln(v.stdout, problem.String())
+	}
+
+	return fmt.Errorf("bbl-state.json has %d problem(s)", len(problems))
+}
+
+func (ValidateState) Flags() flags.Flags {
+	var printSchema bool
+	return validateStateFlags(&printSchema)
+}
+
+func validateStateFlags(printSchema *bool) flags.Flags {
+	validateFlags := flags.New("validate-state")
+	validateFlags.Bool(printSchema, "", "schema", false)
+
+	return validateFlags
+}
@@ -0,0 +1,90 @@
+package commands_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ValidateState", func() {
+	var (
+		stateValidator     *fakes.StateValidator
+		stateProblemFinder *fakes.StateProblemFinder
+		stdout             *bytes.Buffer
+		command            commands.ValidateState
+	)
+
+	BeforeEach(func() {
+		stateValidator = &fakes.StateValidator{}
+		stateProblemFinder = &fakes.StateProblemFinder{}
+		stdout = bytes.NewBuffer([]byte{})
+
+		command = commands.NewValidateState(stateValidator, stateProblemFinder, stdout)
+	})
+
+	Describe("Execute", func() {
+		It("reports a valid state", func() {
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
+			Expect(stateProblemFinder.ValidateCall.CallCount).To(Equal(1))
+			Expect(stdout.String()).To(Equal("bbl-state.json is valid\n"))
+		})
+
+		It("prints every problem and returns an error", func() {
+			stateProblemFinder.ValidateCall.Returns.Problems = []storage.StateProblem{
+				{Path: "gcp.projectID", Message: "must be set"},
+				{Path: "lb.cert", Message: "must be set"},
+			}
+
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).To(MatchError("bbl-state.json has 2 problem(s)"))
+
+			Expect(stdout.String()).To(Equal("gcp.projectID: must be set\nlb.cert: must be set\n"))
+		})
+
+		Context("when --schema is passed", func() {
+			It("prints the state schema without validating the state", func() {
+				err := command.Execute([]string{"--schema"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stateValidator.ValidateCall.CallCount).To(Equal(0))
+				Expect(stateProblemFinder.ValidateCall.CallCount).To(Equal(0))
+
+				var schema map[string]interface{}
+				Expect(json.Unmarshal(stdout.Bytes(), &schema)).To(Succeed())
+				Expect(schema).To(HaveKeyWithValue("title", "bbl-state.json"))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the state validator fails", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("state validator failed"))
+				Expect(stateProblemFinder.ValidateCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the state cannot be read", func() {
+				stateProblemFinder.ValidateCall.Returns.Error = errors.New("failed to read state")
+
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("failed to read state"))
+			})
+
+			It("returns an error when the flags cannot be parsed", func() {
+				err := command.Execute([]string{"--unknown-flag"}, storage.State{})
+				Expect(err).To(MatchError(ContainSubstring("flag provided but not defined: -unknown-flag")))
+			})
+		})
+	})
+})
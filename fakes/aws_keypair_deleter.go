@@ -2,7 +2,8 @@ package fakes
 
 type AWSKeyPairDeleter struct {
 	DeleteCall struct {
-		Receives struct {
+		CallCount int
+		Receives  struct {
 			Name string
 		}
 		Returns struct {
@@ -12,6 +13,7 @@ type AWSKeyPairDeleter struct {
 }
 
 func (d *AWSKeyPairDeleter) Delete(name string) error {
+	d.DeleteCall.CallCount++
 	d.DeleteCall.Receives.Name = name
 
 	return d.DeleteCall.Returns.Error
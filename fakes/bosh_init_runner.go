@@ -4,7 +4,8 @@ import "github.com/cloudfoundry/bosh-bootloader/boshinit"
 
 type BOSHInitCommandRunner struct {
 	ExecuteCall struct {
-		Receives struct {
+		CallCount int
+		Receives  struct {
 			Manifest   []byte
 			PrivateKey string
 			State      boshinit.State
@@ -17,6 +18,7 @@ type BOSHInitCommandRunner struct {
 }
 
 func (r *BOSHInitCommandRunner) Execute(manifest []byte, privateKey string, state boshinit.State) (boshinit.State, error) {
+	r.ExecuteCall.CallCount++
 	r.ExecuteCall.Receives.Manifest = manifest
 	r.ExecuteCall.Receives.PrivateKey = privateKey
 	r.ExecuteCall.Receives.State = state
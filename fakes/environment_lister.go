@@ -0,0 +1,19 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type EnvironmentLister struct {
+	EnvironmentsCall struct {
+		CallCount int
+		Returns   struct {
+			Environments []storage.Environment
+			Error        error
+		}
+	}
+}
+
+func (e *EnvironmentLister) Environments() ([]storage.Environment, error) {
+	e.EnvironmentsCall.CallCount++
+
+	return e.EnvironmentsCall.Returns.Environments, e.EnvironmentsCall.Returns.Error
+}
@@ -0,0 +1,20 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type FragmentLoader struct {
+	LoadCall struct {
+		CallCount int
+		Returns   struct {
+			Fragments []storage.CloudFormationFragment
+			Found     bool
+			Error     error
+		}
+	}
+}
+
+func (f *FragmentLoader) Load() ([]storage.CloudFormationFragment, bool, error) {
+	f.LoadCall.CallCount++
+
+	return f.LoadCall.Returns.Fragments, f.LoadCall.Returns.Found, f.LoadCall.Returns.Error
+}
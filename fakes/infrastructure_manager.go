@@ -1,6 +1,9 @@
 package fakes
 
-import "github.com/cloudfoundry/bosh-bootloader/aws/cloudformation"
+import (
+	"github.com/cloudfoundry/bosh-bootloader/aws/cloudformation"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
 
 type InfrastructureManager struct {
 	CreateCall struct {
@@ -13,6 +16,7 @@ type InfrastructureManager struct {
 			LBCertificateARN          string
 			NumberOfAvailabilityZones int
 			EnvID                     string
+			Fragments                 []storage.CloudFormationFragment
 		}
 		Returns struct {
 			Stack cloudformation.Stack
@@ -29,6 +33,7 @@ type InfrastructureManager struct {
 			LBType                    string
 			LBCertificateARN          string
 			EnvID                     string
+			Fragments                 []storage.CloudFormationFragment
 		}
 		Returns struct {
 			Stack cloudformation.Stack
@@ -36,6 +41,23 @@ type InfrastructureManager struct {
 		}
 	}
 
+	PlanCall struct {
+		CallCount int
+		Receives  struct {
+			KeyPairName               string
+			NumberOfAvailabilityZones int
+			StackName                 string
+			LBType                    string
+			LBCertificateARN          string
+			EnvID                     string
+			Fragments                 []storage.CloudFormationFragment
+		}
+		Returns struct {
+			Changes []cloudformation.StackChange
+			Error   error
+		}
+	}
+
 	ExistsCall struct {
 		Receives struct {
 			StackName string
@@ -57,7 +79,8 @@ type InfrastructureManager struct {
 	}
 
 	DescribeCall struct {
-		Receives struct {
+		CallCount int
+		Receives  struct {
 			StackName string
 		}
 		Returns struct {
@@ -67,7 +90,7 @@ type InfrastructureManager struct {
 	}
 }
 
-func (m *InfrastructureManager) Create(keyPairName string, numberOfAZs int, stackName, lbType, lbCertificateARN, envID string) (cloudformation.Stack, error) {
+func (m *InfrastructureManager) Create(keyPairName string, numberOfAZs int, stackName, lbType, lbCertificateARN, envID string, fragments []storage.CloudFormationFragment) (cloudformation.Stack, error) {
 	m.CreateCall.CallCount++
 	m.CreateCall.Receives.StackName = stackName
 	m.CreateCall.Receives.LBType = lbType
@@ -75,6 +98,7 @@ func (m *InfrastructureManager) Create(keyPairName string, numberOfAZs int, stac
 	m.CreateCall.Receives.KeyPairName = keyPairName
 	m.CreateCall.Receives.NumberOfAvailabilityZones = numberOfAZs
 	m.CreateCall.Receives.EnvID = envID
+	m.CreateCall.Receives.Fragments = fragments
 
 	if m.CreateCall.Stub != nil {
 		return m.CreateCall.Stub(keyPairName, numberOfAZs, stackName, lbType, envID)
@@ -83,7 +107,7 @@ func (m *InfrastructureManager) Create(keyPairName string, numberOfAZs int, stac
 	return m.CreateCall.Returns.Stack, m.CreateCall.Returns.Error
 }
 
-func (m *InfrastructureManager) Update(keyPairName string, numberOfAZs int, stackName, lbType, lbCertificateARN, envID string) (cloudformation.Stack, error) {
+func (m *InfrastructureManager) Update(keyPairName string, numberOfAZs int, stackName, lbType, lbCertificateARN, envID string, fragments []storage.CloudFormationFragment) (cloudformation.Stack, error) {
 	m.UpdateCall.CallCount++
 	m.UpdateCall.Receives.KeyPairName = keyPairName
 	m.UpdateCall.Receives.NumberOfAvailabilityZones = numberOfAZs
@@ -91,9 +115,22 @@ func (m *InfrastructureManager) Update(keyPairName string, numberOfAZs int, stac
 	m.UpdateCall.Receives.LBType = lbType
 	m.UpdateCall.Receives.LBCertificateARN = lbCertificateARN
 	m.UpdateCall.Receives.EnvID = envID
+	m.UpdateCall.Receives.Fragments = fragments
 	return m.UpdateCall.Returns.Stack, m.UpdateCall.Returns.Error
 }
 
+func (m *InfrastructureManager) Plan(keyPairName string, numberOfAZs int, stackName, lbType, lbCertificateARN, envID string, fragments []storage.CloudFormationFragment) ([]cloudformation.StackChange, error) {
+	m.PlanCall.CallCount++
+	m.PlanCall.Receives.KeyPairName = keyPairName
+	m.PlanCall.Receives.NumberOfAvailabilityZones = numberOfAZs
+	m.PlanCall.Receives.StackName = stackName
+	m.PlanCall.Receives.LBType = lbType
+	m.PlanCall.Receives.LBCertificateARN = lbCertificateARN
+	m.PlanCall.Receives.EnvID = envID
+	m.PlanCall.Receives.Fragments = fragments
+	return m.PlanCall.Returns.Changes, m.PlanCall.Returns.Error
+}
+
 func (m *InfrastructureManager) Exists(stackName string) (bool, error) {
 	m.ExistsCall.Receives.StackName = stackName
 
@@ -108,6 +145,7 @@ func (m *InfrastructureManager) Delete(stackName string) error {
 }
 
 func (m *InfrastructureManager) Describe(stackName string) (cloudformation.Stack, error) {
+	m.DescribeCall.CallCount++
 	m.DescribeCall.Receives.StackName = stackName
 
 	return m.DescribeCall.Returns.Stack, m.DescribeCall.Returns.Error
@@ -61,6 +61,20 @@ type StackManager struct {
 		}
 	}
 
+	ChangeSetCall struct {
+		CallCount int
+		Receives  struct {
+			StackName     string
+			Template      templates.Template
+			Tags          cloudformation.Tags
+			SleepInterval time.Duration
+		}
+		Returns struct {
+			Changes []cloudformation.StackChange
+			Error   error
+		}
+	}
+
 	GetPhysicalIDForResourceCall struct {
 		Receives struct {
 			StackName         string
@@ -119,3 +133,13 @@ func (m *StackManager) GetPhysicalIDForResource(stackName string, logicalResourc
 
 	return m.GetPhysicalIDForResourceCall.Returns.PhysicalResourceID, m.GetPhysicalIDForResourceCall.Returns.Error
 }
+
+func (m *StackManager) ChangeSet(stackName string, template templates.Template, tags cloudformation.Tags, sleepInterval time.Duration) ([]cloudformation.StackChange, error) {
+	m.ChangeSetCall.CallCount++
+	m.ChangeSetCall.Receives.StackName = stackName
+	m.ChangeSetCall.Receives.Template = template
+	m.ChangeSetCall.Receives.Tags = tags
+	m.ChangeSetCall.Receives.SleepInterval = sleepInterval
+
+	return m.ChangeSetCall.Returns.Changes, m.ChangeSetCall.Returns.Error
+}
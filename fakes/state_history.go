@@ -0,0 +1,37 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type StateHistory struct {
+	HistoryCall struct {
+		CallCount int
+		Returns   struct {
+			Entries []storage.HistoryEntry
+			Error   error
+		}
+	}
+
+	RollbackCall struct {
+		CallCount int
+		Receives  struct {
+			Version int
+		}
+		Returns struct {
+			State storage.State
+			Error error
+		}
+	}
+}
+
+func (s *StateHistory) History() ([]storage.HistoryEntry, error) {
+	s.HistoryCall.CallCount++
+
+	return s.HistoryCall.Returns.Entries, s.HistoryCall.Returns.Error
+}
+
+func (s *StateHistory) Rollback(version int) (storage.State, error) {
+	s.RollbackCall.CallCount++
+	s.RollbackCall.Receives.Version = version
+
+	return s.RollbackCall.Returns.State, s.RollbackCall.Returns.Error
+}
@@ -0,0 +1,33 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type StateMigrator struct {
+	PlanMigrationCall struct {
+		CallCount int
+		Returns   struct {
+			Plan  storage.MigrationPlan
+			Error error
+		}
+	}
+
+	MigrateCall struct {
+		CallCount int
+		Returns   struct {
+			Plan  storage.MigrationPlan
+			Error error
+		}
+	}
+}
+
+func (s *StateMigrator) PlanMigration() (storage.MigrationPlan, error) {
+	s.PlanMigrationCall.CallCount++
+
+	return s.PlanMigrationCall.Returns.Plan, s.PlanMigrationCall.Returns.Error
+}
+
+func (s *StateMigrator) Migrate() (storage.MigrationPlan, error) {
+	s.MigrateCall.CallCount++
+
+	return s.MigrateCall.Returns.Plan, s.MigrateCall.Returns.Error
+}
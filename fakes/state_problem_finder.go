@@ -0,0 +1,19 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type StateProblemFinder struct {
+	ValidateCall struct {
+		CallCount int
+		Returns   struct {
+			Problems []storage.StateProblem
+			Error    error
+		}
+	}
+}
+
+func (s *StateProblemFinder) Validate() ([]storage.StateProblem, error) {
+	s.ValidateCall.CallCount++
+
+	return s.ValidateCall.Returns.Problems, s.ValidateCall.Returns.Error
+}
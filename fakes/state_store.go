@@ -36,3 +36,9 @@ func (s *StateStore) Set(state storage.State) error {
 	}
 	return s.SetCall.Returns[s.SetCall.CallCount-1].Error
 }
+
+func (s *StateStore) Get() (storage.State, error) {
+	s.GetCall.CallCount++
+
+	return s.GetCall.Returns.State, s.GetCall.Returns.Error
+}
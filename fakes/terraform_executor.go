@@ -1,5 +1,10 @@
 package fakes
 
+import (
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
+)
+
 type TerraformExecutor struct {
 	ApplyCall struct {
 		CallCount int
@@ -13,6 +18,7 @@ type TerraformExecutor struct {
 			Key         string
 			Domain      string
 			Template    string
+			Overrides   []storage.TerraformOverride
 			TFState     string
 		}
 		Returns struct {
@@ -20,6 +26,26 @@ type TerraformExecutor struct {
 			Error   error
 		}
 	}
+	PlanCall struct {
+		CallCount int
+		Receives  struct {
+			Credentials string
+			EnvID       string
+			ProjectID   string
+			Zone        string
+			Region      string
+			Cert        string
+			Key         string
+			Domain      string
+			Template    string
+			Overrides   []storage.TerraformOverride
+			TFState     string
+		}
+		Returns struct {
+			Plan  terraform.Plan
+			Error error
+		}
+	}
 	DestroyCall struct {
 		CallCount int
 		Receives  struct {
@@ -29,6 +55,7 @@ type TerraformExecutor struct {
 			Zone        string
 			Region      string
 			Template    string
+			Overrides   []storage.TerraformOverride
 			TFState     string
 		}
 		Returns struct {
@@ -38,7 +65,8 @@ type TerraformExecutor struct {
 	}
 }
 
-func (t *TerraformExecutor) Apply(credentials, envID, projectID, zone, region, cert, key, domain, template, tfState string) (string, error) {
+func (t *TerraformExecutor) Apply(credentials, envID, projectID, zone, region, cert, key, domain, template string,
+	overrides []storage.TerraformOverride, tfState string) (string, error) {
 	t.ApplyCall.CallCount++
 	t.ApplyCall.Receives.Credentials = credentials
 	t.ApplyCall.Receives.EnvID = envID
@@ -49,11 +77,30 @@ func (t *TerraformExecutor) Apply(credentials, envID, projectID, zone, region, c
 	t.ApplyCall.Receives.Key = key
 	t.ApplyCall.Receives.Domain = domain
 	t.ApplyCall.Receives.Template = template
+	t.ApplyCall.Receives.Overrides = overrides
 	t.ApplyCall.Receives.TFState = tfState
 	return t.ApplyCall.Returns.TFState, t.ApplyCall.Returns.Error
 }
 
-func (t *TerraformExecutor) Destroy(credentials, envID, projectID, zone, region, template, tfState string) (string, error) {
+func (t *TerraformExecutor) Plan(credentials, envID, projectID, zone, region, cert, key, domain, template string,
+	overrides []storage.TerraformOverride, tfState string) (terraform.Plan, error) {
+	t.PlanCall.CallCount++
+	t.PlanCall.Receives.Credentials = credentials
+	t.PlanCall.Receives.EnvID = envID
+	t.PlanCall.Receives.ProjectID = projectID
+	t.PlanCall.Receives.Zone = zone
+	t.PlanCall.Receives.Region = region
+	t.PlanCall.Receives.Cert = cert
+	t.PlanCall.Receives.Key = key
+	t.PlanCall.Receives.Domain = domain
+	t.PlanCall.Receives.Template = template
+	t.PlanCall.Receives.Overrides = overrides
+	t.PlanCall.Receives.TFState = tfState
+	return t.PlanCall.Returns.Plan, t.PlanCall.Returns.Error
+}
+
+func (t *TerraformExecutor) Destroy(credentials, envID, projectID, zone, region, template string, overrides []storage.TerraformOverride,
+	tfState string) (string, error) {
 	t.DestroyCall.CallCount++
 	t.DestroyCall.Receives.Credentials = credentials
 	t.DestroyCall.Receives.EnvID = envID
@@ -61,6 +108,7 @@ func (t *TerraformExecutor) Destroy(credentials, envID, projectID, zone, region,
 	t.DestroyCall.Receives.Zone = zone
 	t.DestroyCall.Receives.Region = region
 	t.DestroyCall.Receives.Template = template
+	t.DestroyCall.Receives.Overrides = overrides
 	t.DestroyCall.Receives.TFState = tfState
 	return t.DestroyCall.Returns.TFState, t.DestroyCall.Returns.Error
 }
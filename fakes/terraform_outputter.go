@@ -13,6 +13,17 @@ type TerraformOutputter struct {
 			Error  error
 		}
 	}
+
+	AllCall struct {
+		CallCount int
+		Receives  struct {
+			TFState string
+		}
+		Returns struct {
+			Outputs map[string]interface{}
+			Error   error
+		}
+	}
 }
 
 func (t *TerraformOutputter) Get(tfState, outputName string) (string, error) {
@@ -26,3 +37,10 @@ func (t *TerraformOutputter) Get(tfState, outputName string) (string, error) {
 
 	return t.GetCall.Returns.Output, t.GetCall.Returns.Error
 }
+
+func (t *TerraformOutputter) All(tfState string) (map[string]interface{}, error) {
+	t.AllCall.CallCount++
+	t.AllCall.Receives.TFState = tfState
+
+	return t.AllCall.Returns.Outputs, t.AllCall.Returns.Error
+}
@@ -0,0 +1,20 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type TerraformOverridesLoader struct {
+	LoadCall struct {
+		CallCount int
+		Returns   struct {
+			Overrides []storage.TerraformOverride
+			Found     bool
+			Error     error
+		}
+	}
+}
+
+func (l *TerraformOverridesLoader) Load() ([]storage.TerraformOverride, bool, error) {
+	l.LoadCall.CallCount++
+
+	return l.LoadCall.Returns.Overrides, l.LoadCall.Returns.Found, l.LoadCall.Returns.Error
+}
@@ -0,0 +1,9 @@
+package flags
+
+func SetEnvNames(set string, names map[string]string) {
+	envNames[set] = names
+}
+
+func ResetEnvNames(set string) {
+	delete(envNames, set)
+}
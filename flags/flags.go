@@ -2,11 +2,115 @@ package flags
 
 import (
 	"flag"
+	"fmt"
 	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
 )
 
+// envNames lists the environment variable of every flag, keyed by flag set.
+// Flags that only one command takes are prefixed with the command, so that a
+// variable exported for one command is never picked up by another. Flags
+// that are not listed, and flag sets such as config show, can only be given
+// on the command line.
+var envNames = map[string]map[string]string{
+	"global": {
+		"endpoint-override":         "BBL_ENDPOINT_OVERRIDE",
+		"state-dir":                 "BBL_STATE_DIR",
+		"config":                    "BBL_CONFIG",
+		"state-backend":             "BBL_STATE_BACKEND",
+		"state-encryption-key-file": "BBL_STATE_ENCRYPTION_KEY_FILE",
+		"lock-timeout":              "BBL_LOCK_TIMEOUT",
+		"vars-store":                "BBL_VARS_STORE",
+		"env":                       "BBL_ENV",
+		"output":                    "BBL_OUTPUT",
+		"debug":                     "BBL_DEBUG",
+	},
+	"up": {
+		"iaas":                      "BBL_IAAS",
+		"aws-access-key-id":         "BBL_AWS_ACCESS_KEY_ID",
+		"aws-secret-access-key":     "BBL_AWS_SECRET_ACCESS_KEY",
+		"aws-region":                "BBL_AWS_REGION",
+		"gcp-service-account-key":   "BBL_GCP_SERVICE_ACCOUNT_KEY",
+		"gcp-project-id":            "BBL_GCP_PROJECT_ID",
+		"gcp-zone":                  "BBL_GCP_ZONE",
+		"gcp-region":                "BBL_GCP_REGION",
+		"name":                      "BBL_UP_NAME",
+		"dry-run":                   "BBL_UP_DRY_RUN",
+		"director-ops-file":         "BBL_UP_DIRECTOR_OPS_FILE",
+		"no-director-ops-files":     "BBL_UP_NO_DIRECTOR_OPS_FILES",
+		"cloud-config-ops-file":     "BBL_UP_CLOUD_CONFIG_OPS_FILE",
+		"no-cloud-config-ops-files": "BBL_UP_NO_CLOUD_CONFIG_OPS_FILES",
+	},
+	"create-lbs": {
+		"type":           "BBL_LB_TYPE",
+		"cert":           "BBL_LB_CERT",
+		"key":            "BBL_LB_KEY",
+		"chain":          "BBL_LB_CHAIN",
+		"domain":         "BBL_LB_DOMAIN",
+		"skip-if-exists": "BBL_CREATE_LBS_SKIP_IF_EXISTS",
+	},
+	"update-lbs": {
+		"cert":            "BBL_LB_CERT",
+		"key":             "BBL_LB_KEY",
+		"chain":           "BBL_LB_CHAIN",
+		"domain":          "BBL_LB_DOMAIN",
+		"skip-if-missing": "BBL_UPDATE_LBS_SKIP_IF_MISSING",
+	},
+	"delete-lbs": {
+		"skip-if-missing": "BBL_DELETE_LBS_SKIP_IF_MISSING",
+	},
+	"destroy": {
+		"no-confirm":      "BBL_DESTROY_NO_CONFIRM",
+		"skip-if-missing": "BBL_DESTROY_SKIP_IF_MISSING",
+	},
+	"export-state": {
+		"output": "BBL_EXPORT_OUTPUT",
+		"redact": "BBL_EXPORT_REDACT",
+	},
+	"import-state": {
+		"file":        "BBL_IMPORT_FILE",
+		"secrets-dir": "BBL_IMPORT_SECRETS_DIR",
+	},
+	"migrate-state": {
+		"dry-run": "BBL_MIGRATE_STATE_DRY_RUN",
+	},
+	"validate-state": {
+		"schema": "BBL_VALIDATE_STATE_SCHEMA",
+	},
+	"state-rollback": {
+		"to": "BBL_STATE_ROLLBACK_TO",
+	},
+	"print-env": {
+		"shell": "BBL_PRINT_ENV_SHELL",
+		"json":  "BBL_PRINT_ENV_JSON",
+	},
+	"ssh-config": {
+		"host":       "BBL_SSH_CONFIG_HOST",
+		"proxy-jump": "BBL_SSH_CONFIG_PROXY_JUMP",
+		"append-to":  "BBL_SSH_CONFIG_APPEND_TO",
+	},
+	"rotate-director-credentials": {
+		"credential": "BBL_ROTATE_DIRECTOR_CREDENTIALS_CREDENTIAL",
+	},
+}
+
 type Flags struct {
-	set *flag.FlagSet
+	set   *flag.FlagSet
+	flags map[string]*Flag
+}
+
+// Flag describes a registered flag and the values it takes, so that shell
+// completion can be generated from the flags a command defines.
+type Flag struct {
+	Name        string
+	Short       string
+	Switch      bool
+	Values      []string
+	Files       bool
+	Directories bool
 }
 
 func New(name string) Flags {
@@ -15,23 +119,160 @@ func New(name string) Flags {
 	set.SetOutput(ioutil.Discard)
 
 	return Flags{
-		set: set,
+		set:   set,
+		flags: map[string]*Flag{},
 	}
 }
 
+// EnvName returns the environment variable that sets the flag name of the flag
+// set, e.g. BBL_STATE_DIR for --state-dir, or "" when the flag has none.
+func EnvName(set, name string) string {
+	return envNames[set][name]
+}
+
 func (f Flags) Bool(v *bool, short, long string, value bool) {
-	f.set.BoolVar(v, long, value, "")
-	f.set.BoolVar(v, short, value, "")
+	for _, name := range []string{long, short} {
+		if name != "" {
+			f.set.BoolVar(v, name, value, "")
+		}
+	}
+
+	if long == "" {
+		short, long = "", short
+	}
+	f.flags[long] = &Flag{Name: long, Short: short, Switch: true}
 }
 
 func (f Flags) String(v *string, name string, value string) {
 	f.set.StringVar(v, name, value, "")
+	f.flags[name] = &Flag{Name: name}
 }
 
+// StringSlice registers a flag that can be given several times, collecting its
+// values in order.
+func (f Flags) StringSlice(v *[]string, name string) {
+	f.set.Var(&stringSlice{values: v}, name, "")
+	f.flags[name] = &Flag{Name: name}
+}
+
+func (f Flags) Int(v *int, name string, value int) {
+	f.set.IntVar(v, name, value, "")
+	f.flags[name] = &Flag{Name: name}
+}
+
+func (f Flags) Duration(v *time.Duration, name string, value time.Duration) {
+	f.set.DurationVar(v, name, value, "")
+	f.flags[name] = &Flag{Name: name}
+}
+
+// Values records the values a registered flag takes.
+func (f Flags) Values(name string, values ...string) {
+	if fl, ok := f.flags[name]; ok {
+		fl.Values = values
+	}
+}
+
+// Files records that the registered flags take a path to a file.
+func (f Flags) Files(names ...string) {
+	for _, name := range names {
+		if fl, ok := f.flags[name]; ok {
+			fl.Files = true
+		}
+	}
+}
+
+// Directories records that the registered flags take a path to a directory.
+func (f Flags) Directories(names ...string) {
+	for _, name := range names {
+		if fl, ok := f.flags[name]; ok {
+			fl.Directories = true
+		}
+	}
+}
+
+// Name returns the name of the flag set.
+func (f Flags) Name() string {
+	return f.set.Name()
+}
+
+// VisitAll calls fn for each registered flag in lexicographical order of the
+// long names, visiting a flag and its short name once.
+func (f Flags) VisitAll(fn func(Flag)) {
+	names := []string{}
+	for name := range f.flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fn(*f.flags[name])
+	}
+}
+
+// Parse sets the flags from their environment variables and then from args,
+// so that the command line wins. The environment variable of a repeatable
+// flag takes a comma separated list of values.
 func (f Flags) Parse(args []string) error {
+	var err error
+	f.set.VisitAll(func(fl *flag.Flag) {
+		envName := EnvName(f.set.Name(), fl.Name)
+		if err != nil || envName == "" {
+			return
+		}
+
+		envValue := os.Getenv(envName)
+		if envValue == "" {
+			return
+		}
+
+		values := []string{envValue}
+		if _, ok := fl.Value.(*stringSlice); ok {
+			values = strings.Split(envValue, ",")
+		}
+
+		for _, value := range values {
+			if setErr := f.set.Set(fl.Name, strings.TrimSpace(value)); setErr != nil {
+				err = fmt.Errorf("invalid value %q for environment variable %s: %v", envValue, envName, setErr)
+				return
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	// The command line replaces rather than adds to the values of repeatable
+	// flags taken from the environment.
+	f.set.VisitAll(func(fl *flag.Flag) {
+		if slice, ok := fl.Value.(*stringSlice); ok {
+			slice.set = false
+		}
+	})
+
 	return f.set.Parse(args)
 }
 
 func (f Flags) Args() []string {
 	return f.set.Args()
 }
+
+type stringSlice struct {
+	values *[]string
+	set    bool
+}
+
+func (s *stringSlice) String() string {
+	if s.values == nil {
+		return ""
+	}
+	return strings.Join(*s.values, ",")
+}
+
+func (s *stringSlice) Set(value string) error {
+	if !s.set {
+		*s.values = nil
+		s.set = true
+	}
+	*s.values = append(*s.values, value)
+	return nil
+}
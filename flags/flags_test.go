@@ -1,6 +1,9 @@
 package flags_test
 
 import (
+	"os"
+	"time"
+
 	"github.com/cloudfoundry/bosh-bootloader/flags"
 
 	. "github.com/onsi/ginkgo"
@@ -12,12 +15,20 @@ var _ = Describe("Flags", func() {
 		f         flags.Flags
 		boolVal   bool
 		stringVal string
+		intVal    int
+		durVal    time.Duration
+		sliceVal  []string
 	)
 
 	BeforeEach(func() {
+		sliceVal = nil
+
 		f = flags.New("test")
 		f.Bool(&boolVal, "b", "bool", false)
 		f.String(&stringVal, "string", "")
+		f.Int(&intVal, "int", 0)
+		f.Duration(&durVal, "duration", 0)
+		f.StringSlice(&sliceVal, "slice")
 	})
 
 	Describe("Parse", func() {
@@ -33,6 +44,18 @@ var _ = Describe("Flags", func() {
 				Expect(err).NotTo(HaveOccurred())
 				Expect(boolVal).To(BeTrue())
 			})
+
+			It("can register several flags that only have a long name", func() {
+				var first, second bool
+				set := flags.New("test")
+				set.Bool(&first, "", "first", false)
+				set.Bool(&second, "", "second", false)
+
+				err := set.Parse([]string{"--second"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(first).To(BeFalse())
+				Expect(second).To(BeTrue())
+			})
 		})
 
 		Context("String flags", func() {
@@ -42,6 +65,187 @@ var _ = Describe("Flags", func() {
 				Expect(stringVal).To(Equal("string_value"))
 			})
 		})
+
+		Context("Int flags", func() {
+			It("can parse int fields from flags", func() {
+				err := f.Parse([]string{"--int", "42"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(intVal).To(Equal(42))
+			})
+		})
+
+		Context("Duration flags", func() {
+			It("can parse duration fields from flags", func() {
+				err := f.Parse([]string{"--duration", "5m"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(durVal).To(Equal(5 * time.Minute))
+			})
+
+			It("returns an error when the duration is invalid", func() {
+				err := f.Parse([]string{"--duration", "five minutes"})
+				Expect(err).To(MatchError(ContainSubstring(`invalid value "five minutes" for flag -duration`)))
+			})
+		})
+
+		Context("StringSlice flags", func() {
+			It("collects every value of a repeated flag in order", func() {
+				err := f.Parse([]string{"--slice", "first", "--slice=second"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sliceVal).To(Equal([]string{"first", "second"}))
+			})
+		})
+	})
+
+	Describe("environment variables", func() {
+		BeforeEach(func() {
+			flags.SetEnvNames("test", map[string]string{
+				"bool":     "BBL_BOOL",
+				"string":   "BBL_STRING",
+				"int":      "BBL_INT",
+				"duration": "BBL_DURATION",
+				"slice":    "BBL_SLICE",
+			})
+
+			os.Setenv("BBL_BOOL", "true")
+			os.Setenv("BBL_STRING", "string-from-env")
+			os.Setenv("BBL_INT", "7")
+			os.Setenv("BBL_DURATION", "2m")
+			os.Setenv("BBL_SLICE", "slice-from-env")
+		})
+
+		AfterEach(func() {
+			flags.ResetEnvNames("test")
+
+			os.Unsetenv("BBL_BOOL")
+			os.Unsetenv("BBL_STRING")
+			os.Unsetenv("BBL_INT")
+			os.Unsetenv("BBL_DURATION")
+			os.Unsetenv("BBL_SLICE")
+		})
+
+		It("sets flags from their BBL_ environment variables", func() {
+			err := f.Parse([]string{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(boolVal).To(BeTrue())
+			Expect(stringVal).To(Equal("string-from-env"))
+			Expect(intVal).To(Equal(7))
+			Expect(durVal).To(Equal(2 * time.Minute))
+			Expect(sliceVal).To(Equal([]string{"slice-from-env"}))
+		})
+
+		It("lets the command line win over the environment", func() {
+			err := f.Parse([]string{"--string", "string-from-args", "--int=9", "-b=false", "--slice", "slice-from-args"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sliceVal).To(Equal([]string{"slice-from-args"}))
+			Expect(boolVal).To(BeFalse())
+			Expect(stringVal).To(Equal("string-from-args"))
+			Expect(intVal).To(Equal(9))
+		})
+
+		It("sets a repeatable flag to every value of a comma separated environment variable", func() {
+			os.Setenv("BBL_SLICE", "a.yml, b.yml")
+
+			err := f.Parse([]string{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sliceVal).To(Equal([]string{"a.yml", "b.yml"}))
+		})
+
+		It("replaces the values of a repeatable flag from the environment with every value on the command line", func() {
+			err := f.Parse([]string{"--slice", "first-from-args", "--slice", "second-from-args"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sliceVal).To(Equal([]string{"first-from-args", "second-from-args"}))
+		})
+
+		It("returns an error when an environment variable has an invalid value", func() {
+			os.Setenv("BBL_INT", "seven")
+
+			err := f.Parse([]string{})
+			Expect(err).To(MatchError(ContainSubstring(`invalid value "seven" for environment variable BBL_INT`)))
+		})
+
+		It("does not read the environment for flag sets that only take the command line", func() {
+			showFlags := flags.New("config show")
+			showFlags.String(&stringVal, "string", "")
+
+			err := showFlags.Parse([]string{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stringVal).To(BeEmpty())
+		})
+
+		It("does not read the environment for flags without a listed variable", func() {
+			flags.SetEnvNames("test", map[string]string{})
+
+			err := f.Parse([]string{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stringVal).To(BeEmpty())
+		})
+	})
+
+	Describe("EnvName", func() {
+		It("returns the listed environment variable of the flag", func() {
+			Expect(flags.EnvName("global", "state-dir")).To(Equal("BBL_STATE_DIR"))
+			Expect(flags.EnvName("up", "aws-access-key-id")).To(Equal("BBL_AWS_ACCESS_KEY_ID"))
+		})
+
+		It("prefixes flags that only one command takes with the command", func() {
+			Expect(flags.EnvName("create-lbs", "type")).To(Equal("BBL_LB_TYPE"))
+			Expect(flags.EnvName("create-lbs", "key")).To(Equal("BBL_LB_KEY"))
+			Expect(flags.EnvName("export-state", "output")).To(Equal("BBL_EXPORT_OUTPUT"))
+			Expect(flags.EnvName("global", "output")).To(Equal("BBL_OUTPUT"))
+			Expect(flags.EnvName("up", "name")).To(Equal("BBL_UP_NAME"))
+			Expect(flags.EnvName("up", "director-ops-file")).To(Equal("BBL_UP_DIRECTOR_OPS_FILE"))
+			Expect(flags.EnvName("import-state", "file")).To(Equal("BBL_IMPORT_FILE"))
+			Expect(flags.EnvName("ssh-config", "host")).To(Equal("BBL_SSH_CONFIG_HOST"))
+			Expect(flags.EnvName("state-rollback", "to")).To(Equal("BBL_STATE_ROLLBACK_TO"))
+			Expect(flags.EnvName("rotate-director-credentials", "credential")).To(Equal("BBL_ROTATE_DIRECTOR_CREDENTIALS_CREDENTIAL"))
+		})
+
+		It("returns nothing for short flags and flags that only take the command line", func() {
+			Expect(flags.EnvName("global", "d")).To(BeEmpty())
+			Expect(flags.EnvName("global", "help")).To(BeEmpty())
+			Expect(flags.EnvName("global", "version")).To(BeEmpty())
+			Expect(flags.EnvName("config show", "iaas")).To(BeEmpty())
+			Expect(flags.EnvName("some-command", "some-flag")).To(BeEmpty())
+		})
+	})
+
+	Describe("VisitAll", func() {
+		It("visits every registered flag once, in order of the long names", func() {
+			f.Files("string")
+			f.Directories("slice")
+			f.Values("int", "1", "2")
+
+			visited := []flags.Flag{}
+			f.VisitAll(func(fl flags.Flag) {
+				visited = append(visited, fl)
+			})
+
+			Expect(visited).To(Equal([]flags.Flag{
+				{Name: "bool", Short: "b", Switch: true},
+				{Name: "duration"},
+				{Name: "int", Values: []string{"1", "2"}},
+				{Name: "slice", Directories: true},
+				{Name: "string", Files: true},
+			}))
+		})
+
+		It("visits a switch without a short name under its long name", func() {
+			var skip bool
+			set := flags.New("test")
+			set.Bool(&skip, "skip", "", false)
+
+			visited := []flags.Flag{}
+			set.VisitAll(func(fl flags.Flag) {
+				visited = append(visited, fl)
+			})
+			Expect(visited).To(Equal([]flags.Flag{{Name: "skip", Switch: true}}))
+		})
+	})
+
+	Describe("Name", func() {
+		It("returns the name of the flag set", func() {
+			Expect(f.Name()).To(Equal("test"))
+		})
 	})
 
 	Describe("Args", func() {
@@ -0,0 +1,302 @@
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	ReplaceOp = "replace"
+	RemoveOp  = "remove"
+)
+
+// Op is an operation of an ops file in the format of go-patch: it replaces or
+// removes the value at a path such as /jobs/name=bosh/properties/director/max_threads?.
+type Op struct {
+	Type  string      `yaml:"type"`
+	Path  string      `yaml:"path"`
+	Value interface{} `yaml:"value,omitempty"`
+}
+
+type OpError struct {
+	Index int
+	Op    Op
+	Err   error
+}
+
+func (e OpError) Error() string {
+	return fmt.Sprintf("operation %d (%s %s): %s", e.Index+1, e.Op.Type, e.Op.Path, e.Err)
+}
+
+type token struct {
+	raw        string
+	key        string
+	index      int
+	isIndex    bool
+	isAppend   bool
+	matchKey   string
+	matchValue string
+	optional   bool
+}
+
+func (t token) isMatch() bool {
+	return t.matchKey != ""
+}
+
+// Parse reads the operations of an ops file and checks that their types and
+// paths are valid.
+func Parse(contents []byte) ([]Op, error) {
+	var ops []Op
+	if err := yaml.Unmarshal(contents, &ops); err != nil {
+		return nil, fmt.Errorf("ops file is not a YAML list of operations: %s", err)
+	}
+
+	for i, op := range ops {
+		if op.Type != ReplaceOp && op.Type != RemoveOp {
+			return nil, OpError{Index: i, Op: op, Err: fmt.Errorf("unknown type %q, expected %q or %q", op.Type, ReplaceOp, RemoveOp)}
+		}
+
+		if _, err := parsePath(op.Path); err != nil {
+			return nil, OpError{Index: i, Op: op, Err: err}
+		}
+	}
+
+	return ops, nil
+}
+
+// Apply applies the operations in order to the YAML document and returns the
+// patched document.
+func Apply(document []byte, ops []Op) ([]byte, error) {
+	var node interface{}
+	if err := yaml.Unmarshal(document, &node); err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		tokens, err := parsePath(op.Path)
+		if err != nil {
+			return nil, OpError{Index: i, Op: op, Err: err}
+		}
+
+		node, err = op.apply(node, tokens, 0)
+		if err != nil {
+			return nil, OpError{Index: i, Op: op, Err: err}
+		}
+	}
+
+	return yaml.Marshal(node)
+}
+
+func parsePath(path string) ([]token, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("path %q must start with /", path)
+	}
+
+	if path == "/" {
+		return []token{}, nil
+	}
+
+	var tokens []token
+	optional := false
+	for _, raw := range strings.Split(path[1:], "/") {
+		t := token{raw: raw}
+
+		if strings.HasSuffix(raw, "?") {
+			optional = true
+			raw = strings.TrimSuffix(raw, "?")
+		}
+		// Everything after an optional token is optional too, so that a whole
+		// missing branch can be created.
+		t.optional = optional
+
+		raw = strings.Replace(strings.Replace(raw, "~1", "/", -1), "~0", "~", -1)
+		if raw == "" {
+			return nil, fmt.Errorf("path %q has an empty segment", path)
+		}
+
+		if index, err := strconv.Atoi(raw); err == nil {
+			t.index = index
+			t.isIndex = true
+		} else if raw == "-" {
+			t.isAppend = true
+		} else if parts := strings.SplitN(raw, "=", 2); len(parts) == 2 {
+			t.matchKey = parts[0]
+			t.matchValue = parts[1]
+		} else {
+			t.key = raw
+		}
+
+		tokens = append(tokens, t)
+	}
+
+	for i, t := range tokens {
+		if t.isAppend && i != len(tokens)-1 {
+			return nil, fmt.Errorf("path %q can only use - as its last segment", path)
+		}
+	}
+
+	return tokens, nil
+}
+
+func tokensPath(tokens []token) string {
+	raws := []string{}
+	for _, t := range tokens {
+		raws = append(raws, t.raw)
+	}
+	return "/" + strings.Join(raws, "/")
+}
+
+func (o Op) apply(node interface{}, tokens []token, i int) (interface{}, error) {
+	if len(tokens) == 0 {
+		if o.Type == RemoveOp {
+			return nil, errors.New("cannot remove the whole document")
+		}
+		return o.Value, nil
+	}
+
+	t := tokens[i]
+	path := tokensPath(tokens[:i+1])
+	last := i == len(tokens)-1
+
+	if t.isIndex || t.isAppend || t.isMatch() {
+		array, ok := node.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected to find an array at path %q but found %s", tokensPath(tokens[:i]), describe(node))
+		}
+		return o.applyArray(array, tokens, i, path, last)
+	}
+
+	m, ok := node.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected to find a map at path %q but found %s", tokensPath(tokens[:i]), describe(node))
+	}
+
+	child, found := m[t.key]
+	if !found {
+		switch {
+		case !t.optional:
+			return nil, fmt.Errorf("expected to find a map key %q for path %q (found map keys: %s)", t.key, path, mapKeys(m))
+		case o.Type == RemoveOp:
+			return m, nil
+		case !last:
+			child = emptyContainer(tokens[i+1])
+		}
+	}
+
+	if last {
+		if o.Type == RemoveOp {
+			delete(m, t.key)
+		} else {
+			m[t.key] = o.Value
+		}
+		return m, nil
+	}
+
+	child, err := o.apply(child, tokens, i+1)
+	if err != nil {
+		return nil, err
+	}
+	m[t.key] = child
+
+	return m, nil
+}
+
+func (o Op) applyArray(array []interface{}, tokens []token, i int, path string, last bool) (interface{}, error) {
+	t := tokens[i]
+
+	var position int
+	switch {
+	case t.isAppend:
+		if o.Type == RemoveOp {
+			return nil, fmt.Errorf("cannot remove with - in path %q", path)
+		}
+		return append(array, o.Value), nil
+	case t.isIndex:
+		position = t.index
+		if position < 0 || position >= len(array) {
+			return nil, fmt.Errorf("expected to find array index %d for path %q but found an array of length %d", position, path, len(array))
+		}
+	default:
+		matches := []int{}
+		for j, item := range array {
+			if itemMap, ok := item.(map[interface{}]interface{}); ok && fmt.Sprint(itemMap[t.matchKey]) == t.matchValue {
+				matches = append(matches, j)
+			}
+		}
+
+		switch {
+		case len(matches) == 1:
+			position = matches[0]
+		case len(matches) == 0 && t.optional:
+			if o.Type == RemoveOp {
+				return array, nil
+			}
+			if last {
+				return append(array, o.Value), nil
+			}
+
+			item := map[interface{}]interface{}{t.matchKey: t.matchValue}
+			child, err := o.apply(item, tokens, i+1)
+			if err != nil {
+				return nil, err
+			}
+			return append(array, child), nil
+		default:
+			return nil, fmt.Errorf("expected to find exactly one array item with %s=%s for path %q but found %d", t.matchKey, t.matchValue, path, len(matches))
+		}
+	}
+
+	if last {
+		if o.Type == RemoveOp {
+			return append(array[:position:position], array[position+1:]...), nil
+		}
+		array[position] = o.Value
+		return array, nil
+	}
+
+	child, err := o.apply(array[position], tokens, i+1)
+	if err != nil {
+		return nil, err
+	}
+	array[position] = child
+
+	return array, nil
+}
+
+func emptyContainer(next token) interface{} {
+	if next.isIndex || next.isAppend || next.isMatch() {
+		return []interface{}{}
+	}
+	return map[interface{}]interface{}{}
+}
+
+func mapKeys(m map[interface{}]interface{}) string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, strconv.Quote(fmt.Sprint(key)))
+	}
+	sort.Strings(keys)
+
+	if len(keys) == 0 {
+		return "none"
+	}
+	return strings.Join(keys, ", ")
+}
+
+func describe(node interface{}) string {
+	switch node.(type) {
+	case nil:
+		return "nothing"
+	case map[interface{}]interface{}:
+		return "a map"
+	case []interface{}:
+		return "an array"
+	default:
+		return fmt.Sprintf("%q", fmt.Sprint(node))
+	}
+}
@@ -0,0 +1,173 @@
+package patch_test
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/patch"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Patch", func() {
+	var document []byte
+
+	BeforeEach(func() {
+		document = []byte(`name: bosh
+jobs:
+- name: bosh
+  properties:
+    director:
+      max_threads: 10
+- name: other
+resource_pools:
+- name: vms
+  cloud_properties:
+    instance_type: m3.xlarge
+`)
+	})
+
+	apply := func(opsFile string) (string, error) {
+		ops, err := patch.Parse([]byte(opsFile))
+		Expect(err).NotTo(HaveOccurred())
+
+		patched, err := patch.Apply(document, ops)
+		return string(patched), err
+	}
+
+	Describe("Parse", func() {
+		It("reads the operations of an ops file", func() {
+			ops, err := patch.Parse([]byte(`
+- type: replace
+  path: /name
+  value: some-name
+- type: remove
+  path: /jobs/name=other
+`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ops).To(Equal([]patch.Op{
+				{Type: "replace", Path: "/name", Value: "some-name"},
+				{Type: "remove", Path: "/jobs/name=other"},
+			}))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the ops file is not a list", func() {
+				_, err := patch.Parse([]byte("type: replace"))
+				Expect(err).To(MatchError(ContainSubstring("ops file is not a YAML list of operations")))
+			})
+
+			It("returns an error when an operation has an unknown type", func() {
+				_, err := patch.Parse([]byte("- {type: test, path: /name}"))
+				Expect(err).To(MatchError(`operation 1 (test /name): unknown type "test", expected "replace" or "remove"`))
+			})
+
+			It("returns an error when a path does not start with /", func() {
+				_, err := patch.Parse([]byte("- {type: remove, path: /name}\n- {type: remove, path: name}"))
+				Expect(err).To(MatchError(`operation 2 (remove name): path "name" must start with /`))
+			})
+
+			It("returns an error when - is not the last segment", func() {
+				_, err := patch.Parse([]byte("- {type: replace, path: /jobs/-/name, value: x}"))
+				Expect(err).To(MatchError(ContainSubstring(`path "/jobs/-/name" can only use - as its last segment`)))
+			})
+		})
+	})
+
+	Describe("Apply", func() {
+		It("replaces values found by map keys, array indexes and array item matches", func() {
+			patched, err := apply(`
+- type: replace
+  path: /name
+  value: my-bosh
+- type: replace
+  path: /jobs/name=bosh/properties/director/max_threads
+  value: 32
+- type: replace
+  path: /resource_pools/0/cloud_properties/instance_type
+  value: m4.large
+`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(patched).To(ContainSubstring("name: my-bosh\n"))
+			Expect(patched).To(ContainSubstring("max_threads: 32\n"))
+			Expect(patched).To(ContainSubstring("instance_type: m4.large\n"))
+		})
+
+		It("appends to arrays with -", func() {
+			patched, err := apply(`
+- type: replace
+  path: /jobs/-
+  value: {name: added}
+`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(patched).To(ContainSubstring("- name: other\n- name: added\n"))
+		})
+
+		It("creates missing optional paths", func() {
+			patched, err := apply(`
+- type: replace
+  path: /jobs/name=bosh/properties/director/enable_snapshots?
+  value: true
+- type: replace
+  path: /tags?/env
+  value: staging
+- type: replace
+  path: /variables?/name=some-variable/type
+  value: password
+`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(patched).To(ContainSubstring("enable_snapshots: true\n"))
+			Expect(patched).To(ContainSubstring("tags:\n  env: staging\n"))
+			Expect(patched).To(ContainSubstring("variables:\n- name: some-variable\n  type: password\n"))
+		})
+
+		It("removes values", func() {
+			patched, err := apply(`
+- type: remove
+  path: /jobs/name=other
+- type: remove
+  path: /resource_pools/0/cloud_properties
+- type: remove
+  path: /missing?
+`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(patched).NotTo(ContainSubstring("other"))
+			Expect(patched).NotTo(ContainSubstring("instance_type"))
+			Expect(patched).To(ContainSubstring("- name: bosh\n"))
+		})
+
+		It("unescapes ~1 and ~0 in path segments", func() {
+			document = []byte("a/b: 1\nc~d: 2\n")
+
+			patched, err := apply(`
+- {type: replace, path: /a~1b, value: 3}
+- {type: replace, path: /c~0d, value: 4}
+`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(patched).To(Equal("a/b: 3\nc~d: 4\n"))
+		})
+
+		Context("when a path does not match the document", func() {
+			It("names the missing map key and the keys that exist", func() {
+				_, err := apply("- {type: replace, path: /jobs/name=bosh/properties/hm/resurrector, value: true}")
+				Expect(err).To(MatchError(`operation 1 (replace /jobs/name=bosh/properties/hm/resurrector): expected to find a map key "hm" for path "/jobs/name=bosh/properties/hm" (found map keys: "director")`))
+			})
+
+			It("reports array items that are not found or ambiguous", func() {
+				_, err := apply("- {type: remove, path: /jobs/name=missing}")
+				Expect(err).To(MatchError(`operation 1 (remove /jobs/name=missing): expected to find exactly one array item with name=missing for path "/jobs/name=missing" but found 0`))
+			})
+
+			It("reports array indexes that are out of range", func() {
+				_, err := apply("- {type: replace, path: /resource_pools/3/name, value: x}")
+				Expect(err).To(MatchError(`operation 1 (replace /resource_pools/3/name): expected to find array index 3 for path "/resource_pools/3" but found an array of length 1`))
+			})
+
+			It("reports values of the wrong kind", func() {
+				_, err := apply("- {type: replace, path: /name/first, value: x}")
+				Expect(err).To(MatchError(`operation 1 (replace /name/first): expected to find a map at path "/name" but found "bosh"`))
+
+				_, err = apply("- {type: replace, path: /name/0, value: x}")
+				Expect(err).To(MatchError(`operation 1 (replace /name/0): expected to find an array at path "/name" but found "bosh"`))
+			})
+		})
+	})
+})
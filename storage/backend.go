@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Backend interface {
+	Read(name string) ([]byte, error)
+	Write(name string, contents []byte) error
+	Create(name string, contents []byte) error
+	Delete(name string) error
+	List(dir string) ([]string, error)
+	String() string
+}
+
+type LocalBackend struct {
+	dir string
+}
+
+func NewLocalBackend(dir string) LocalBackend {
+	return LocalBackend{
+		dir: dir,
+	}
+}
+
+func (b LocalBackend) Read(name string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(b.dir, name))
+}
+
+func (b LocalBackend) Write(name string, contents []byte) error {
+	_, err := os.Stat(b.dir)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(b.dir, name)
+	err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	err = writeAndClose(file, contents)
+	if err != nil {
+		return err
+	}
+
+	return rename(file.Name(), path)
+}
+
+func (b LocalBackend) Create(name string, contents []byte) error {
+	_, err := os.Stat(b.dir)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(b.dir, name)
+	err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, OS_READ_WRITE_MODE)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(contents)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func writeAndClose(file *os.File, contents []byte) error {
+	_, err := file.Write(contents)
+	if err == nil {
+		err = file.Chmod(OS_READ_WRITE_MODE)
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+
+	closeErr := file.Close()
+	if err != nil {
+		return err
+	}
+
+	return closeErr
+}
+
+func (b LocalBackend) Delete(name string) error {
+	_, err := os.Stat(b.dir)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(filepath.Join(b.dir, name))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (b LocalBackend) List(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(filepath.Join(b.dir, dir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	names := []string{}
+	for _, file := range files {
+		if file.IsDir() {
+			names = append(names, file.Name())
+		}
+	}
+
+	return names, nil
+}
+
+func (b LocalBackend) String() string {
+	return b.dir
+}
+
+func NewBackend(stateDir, stateBackend string) (Backend, error) {
+	if stateBackend == "" {
+		return NewLocalBackend(stateDir), nil
+	}
+
+	backendURL, err := url.Parse(stateBackend)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state backend %q: %s", stateBackend, err)
+	}
+
+	if backendURL.Host == "" {
+		return nil, fmt.Errorf("invalid state backend %q: missing bucket name", stateBackend)
+	}
+
+	bucket := backendURL.Host
+	prefix := strings.Trim(backendURL.Path, "/")
+	query := backendURL.Query()
+
+	switch backendURL.Scheme {
+	case "s3":
+		return NewS3BackendFromEnvironment(bucket, prefix, query.Get("region"), query.Get("endpoint"))
+	case "gs", "gcs":
+		return NewGCSBackendFromEnvironment(bucket, prefix, query.Get("endpoint"))
+	default:
+		return nil, fmt.Errorf("unsupported state backend %q: scheme must be one of s3 or gs", stateBackend)
+	}
+}
+
+func objectKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+
+	return prefix + "/" + name
+}
+
+func objectURL(scheme, bucket, prefix string) string {
+	return fmt.Sprintf("%s://%s", scheme, strings.TrimSuffix(objectKey(bucket, prefix), "/"))
+}
@@ -0,0 +1,154 @@
+package storage_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Backend", func() {
+	Describe("LocalBackend", func() {
+		var (
+			backend storage.LocalBackend
+			tempDir string
+		)
+
+		BeforeEach(func() {
+			var err error
+			tempDir, err = ioutil.TempDir("", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			backend = storage.NewLocalBackend(tempDir)
+		})
+
+		It("writes, reads and deletes files in the directory", func() {
+			err := backend.Write("some-file", []byte("some-contents"))
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := ioutil.ReadFile(filepath.Join(tempDir, "some-file"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("some-contents"))
+
+			contents, err = backend.Read("some-file")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("some-contents"))
+
+			err = backend.Delete("some-file")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = os.Stat(filepath.Join(tempDir, "some-file"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("writes files atomically without leaving temporary files behind", func() {
+			err := backend.Write("some-file", []byte("some-contents"))
+			Expect(err).NotTo(HaveOccurred())
+
+			err = backend.Write("some-file", []byte("some-other-contents"))
+			Expect(err).NotTo(HaveOccurred())
+
+			files, err := ioutil.ReadDir(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(HaveLen(1))
+			Expect(files[0].Name()).To(Equal("some-file"))
+			Expect(files[0].Mode()).To(Equal(os.FileMode(0644)))
+		})
+
+		It("writes files in nested directories", func() {
+			err := backend.Write("some-dir/some-file", []byte("some-contents"))
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := backend.Read("some-dir/some-file")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("some-contents"))
+		})
+
+		It("creates files that do not exist yet", func() {
+			err := backend.Create("some-file", []byte("some-contents"))
+			Expect(err).NotTo(HaveOccurred())
+
+			err = backend.Create("some-file", []byte("some-other-contents"))
+			Expect(os.IsExist(err)).To(BeTrue())
+
+			contents, err := backend.Read("some-file")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("some-contents"))
+		})
+
+		It("creates files in nested directories", func() {
+			err := backend.Create("some-dir/some-file", []byte("some-contents"))
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := backend.Read("some-dir/some-file")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("some-contents"))
+		})
+
+		It("lists the directories in a directory", func() {
+			Expect(backend.Write("some-dir/first/some-file", []byte("some-contents"))).To(Succeed())
+			Expect(backend.Write("some-dir/second/some-file", []byte("some-contents"))).To(Succeed())
+			Expect(backend.Write("some-dir/some-file", []byte("some-contents"))).To(Succeed())
+
+			names, err := backend.List("some-dir")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names).To(Equal([]string{"first", "second"}))
+		})
+
+		It("lists nothing in a missing directory", func() {
+			names, err := backend.List("missing-dir")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names).To(BeEmpty())
+		})
+
+		It("returns a not exist error when reading a missing file", func() {
+			_, err := backend.Read("missing-file")
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("ignores deleting a missing file", func() {
+			err := backend.Delete("missing-file")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("describes itself as the directory", func() {
+			Expect(backend.String()).To(Equal(tempDir))
+		})
+	})
+
+	Describe("NewBackend", func() {
+		It("returns a local backend when no state backend is specified", func() {
+			backend, err := storage.NewBackend("some-state-dir", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backend).To(Equal(storage.NewLocalBackend("some-state-dir")))
+		})
+
+		It("returns an s3 backend for s3 urls", func() {
+			backend, err := storage.NewBackend("some-state-dir", "s3://some-bucket/some/prefix/?region=some-region&endpoint=http://127.0.0.1:9000")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backend).To(BeAssignableToTypeOf(storage.S3Backend{}))
+			Expect(backend.String()).To(Equal("s3://some-bucket/some/prefix"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the url cannot be parsed", func() {
+				_, err := storage.NewBackend("some-state-dir", "%%%")
+				Expect(err).To(MatchError(ContainSubstring(`invalid state backend "%%%"`)))
+			})
+
+			It("returns an error when the bucket is missing", func() {
+				_, err := storage.NewBackend("some-state-dir", "s3:///some-prefix")
+				Expect(err).To(MatchError(`invalid state backend "s3:///some-prefix": missing bucket name`))
+			})
+
+			It("returns an error when the scheme is unsupported", func() {
+				_, err := storage.NewBackend("some-state-dir", "ftp://some-bucket")
+				Expect(err).To(MatchError(`unsupported state backend "ftp://some-bucket": scheme must be one of s3 or gs`))
+			})
+		})
+	})
+})
@@ -1,6 +1,14 @@
 package storage
 
-import "reflect"
+import (
+	"reflect"
+	"strings"
+)
+
+const (
+	DirectorSSLRotationTrust  = "trust"
+	DirectorSSLRotationSwitch = "switch"
+)
 
 type BOSH struct {
 	DirectorName           string                 `json:"directorName"`
@@ -13,8 +21,28 @@ type BOSH struct {
 	Credentials            map[string]string      `json:"credentials"`
 	State                  map[string]interface{} `json:"state"`
 	Manifest               string                 `json:"manifest"`
+
+	// The DirectorSSLRotation fields are only set while bbl rotate-director-ca
+	// is in progress. In the trust phase they hold the new CA and the
+	// certificate the director switches to next, in the switch phase the
+	// director serves the new certificate and DirectorSSLRotationCA holds the
+	// previous CA.
+	DirectorSSLRotationPhase       string `json:"directorSSLRotationPhase,omitempty"`
+	DirectorSSLRotationCA          string `json:"directorSSLRotationCA,omitempty"`
+	DirectorSSLRotationCertificate string `json:"directorSSLRotationCertificate,omitempty"`
+	DirectorSSLRotationPrivateKey  string `json:"directorSSLRotationPrivateKey,omitempty"`
 }
 
 func (b BOSH) IsEmpty() bool {
 	return reflect.DeepEqual(b, BOSH{})
 }
+
+// DirectorSSLCABundle returns the director CA, followed by the other CA the
+// director trusts while its CA is being rotated.
+func (b BOSH) DirectorSSLCABundle() string {
+	if b.DirectorSSLRotationCA == "" {
+		return b.DirectorSSLCA
+	}
+
+	return strings.TrimSuffix(b.DirectorSSLCA, "\n") + "\n" + b.DirectorSSLRotationCA
+}
@@ -27,4 +27,23 @@ var _ = Describe("BOSH", func() {
 			Expect(bosh.IsEmpty()).To(BeFalse())
 		})
 	})
+	Describe("DirectorSSLCABundle", func() {
+		It("returns the director CA", func() {
+			bosh := storage.BOSH{
+				DirectorSSLCA: "some-ca\n",
+			}
+
+			Expect(bosh.DirectorSSLCABundle()).To(Equal("some-ca\n"))
+		})
+
+		It("appends the other CA while the director CA is being rotated", func() {
+			bosh := storage.BOSH{
+				DirectorSSLCA:            "some-ca\n",
+				DirectorSSLRotationPhase: storage.DirectorSSLRotationTrust,
+				DirectorSSLRotationCA:    "some-new-ca\n",
+			}
+
+			Expect(bosh.DirectorSSLCABundle()).To(Equal("some-ca\nsome-new-ca\n"))
+		})
+	})
 })
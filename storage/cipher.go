@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	encryptionVersion   = 1
+	encryptionCipher    = "aes-256-gcm"
+	encryptionKDF       = "scrypt"
+	encryptionKeyLength = 32
+	encryptionSaltSize  = 32
+
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+var (
+	StateEncryptedError      = errors.New("bbl-state.json is encrypted, please provide a passphrase with --state-encryption-key-file or BBL_STATE_PASSPHRASE")
+	StateDecryptionError     = errors.New("bbl-state.json could not be decrypted, please check that the correct passphrase was provided")
+	encryptionAdditionalData = []byte("bbl-state")
+)
+
+var randReader io.Reader = rand.Reader
+
+type encryptionParameters struct {
+	Version int    `json:"version"`
+	Cipher  string `json:"cipher"`
+	KDF     string `json:"kdf"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+}
+
+type encryptedState struct {
+	Encryption *encryptionParameters `json:"encryption"`
+	Ciphertext []byte                `json:"ciphertext"`
+}
+
+type Cipher struct {
+	passphrase []byte
+	salt       []byte
+	key        []byte
+}
+
+func NewCipher(passphrase []byte) *Cipher {
+	return &Cipher{
+		passphrase: passphrase,
+	}
+}
+
+func (c *Cipher) Seal(plaintext []byte) ([]byte, error) {
+	if c.key == nil {
+		salt := make([]byte, encryptionSaltSize)
+		if _, err := io.ReadFull(randReader, salt); err != nil {
+			return nil, err
+		}
+
+		key, err := c.deriveKey(salt)
+		if err != nil {
+			return nil, err
+		}
+
+		c.salt = salt
+		c.key = key
+	}
+
+	aead, err := newAEAD(c.key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(randReader, nonce); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(encryptedState{
+		Encryption: &encryptionParameters{
+			Version: encryptionVersion,
+			Cipher:  encryptionCipher,
+			KDF:     encryptionKDF,
+			Salt:    c.salt,
+			Nonce:   nonce,
+		},
+		Ciphertext: aead.Seal(nil, nonce, plaintext, encryptionAdditionalData),
+	})
+}
+
+func (c *Cipher) Open(sealed []byte) ([]byte, error) {
+	var envelope encryptedState
+	if err := json.Unmarshal(sealed, &envelope); err != nil {
+		return nil, err
+	}
+
+	if envelope.Encryption == nil {
+		return nil, errors.New("bbl-state.json is not encrypted")
+	}
+
+	parameters := envelope.Encryption
+	if parameters.Version != encryptionVersion || parameters.Cipher != encryptionCipher || parameters.KDF != encryptionKDF {
+		return nil, fmt.Errorf("unsupported state encryption: version %d, cipher %q, kdf %q", parameters.Version, parameters.Cipher, parameters.KDF)
+	}
+
+	key := c.key
+	if key == nil || !bytes.Equal(c.salt, parameters.Salt) {
+		var err error
+		key, err = c.deriveKey(parameters.Salt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(parameters.Nonce) != aead.NonceSize() {
+		return nil, StateDecryptionError
+	}
+
+	plaintext, err := aead.Open(nil, parameters.Nonce, envelope.Ciphertext, encryptionAdditionalData)
+	if err != nil {
+		return nil, StateDecryptionError
+	}
+
+	c.salt = parameters.Salt
+	c.key = key
+
+	return plaintext, nil
+}
+
+func (c *Cipher) deriveKey(salt []byte) ([]byte, error) {
+	if len(c.passphrase) == 0 {
+		return nil, errors.New("state encryption passphrase must not be empty")
+	}
+
+	return scrypt.Key(c.passphrase, salt, scryptN, scryptR, scryptP, encryptionKeyLength)
+}
+
+func IsEncrypted(contents []byte) bool {
+	var envelope struct {
+		Encryption *json.RawMessage `json:"encryption"`
+	}
+
+	if err := json.Unmarshal(contents, &envelope); err != nil {
+		return false
+	}
+
+	return envelope.Encryption != nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
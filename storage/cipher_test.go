@@ -0,0 +1,109 @@
+package storage_test
+
+import (
+	"encoding/json"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cipher", func() {
+	var stateCipher *storage.Cipher
+
+	BeforeEach(func() {
+		stateCipher = storage.NewCipher([]byte("some-passphrase"))
+	})
+
+	Describe("Seal", func() {
+		It("returns an envelope that does not contain the plaintext", func() {
+			sealed, err := stateCipher.Seal([]byte(`{"iaas": "some-secret-iaas"}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(sealed)).NotTo(ContainSubstring("some-secret-iaas"))
+			Expect(storage.IsEncrypted(sealed)).To(BeTrue())
+
+			var envelope map[string]interface{}
+			err = json.Unmarshal(sealed, &envelope)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(envelope["encryption"]).To(HaveKeyWithValue("version", BeEquivalentTo(1)))
+			Expect(envelope["encryption"]).To(HaveKeyWithValue("cipher", "aes-256-gcm"))
+			Expect(envelope["encryption"]).To(HaveKeyWithValue("kdf", "scrypt"))
+		})
+
+		It("uses a fresh nonce for every seal", func() {
+			first, err := stateCipher.Seal([]byte("some-plaintext"))
+			Expect(err).NotTo(HaveOccurred())
+
+			second, err := stateCipher.Seal([]byte("some-plaintext"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(first).NotTo(Equal(second))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the passphrase is empty", func() {
+				_, err := storage.NewCipher([]byte{}).Seal([]byte("some-plaintext"))
+				Expect(err).To(MatchError("state encryption passphrase must not be empty"))
+			})
+		})
+	})
+
+	Describe("Open", func() {
+		It("returns the sealed plaintext", func() {
+			sealed, err := stateCipher.Seal([]byte("some-plaintext"))
+			Expect(err).NotTo(HaveOccurred())
+
+			plaintext, err := storage.NewCipher([]byte("some-passphrase")).Open(sealed)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(plaintext)).To(Equal("some-plaintext"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the passphrase is wrong", func() {
+				sealed, err := stateCipher.Seal([]byte("some-plaintext"))
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = storage.NewCipher([]byte("some-other-passphrase")).Open(sealed)
+				Expect(err).To(Equal(storage.StateDecryptionError))
+			})
+
+			It("returns an error when the ciphertext has been tampered with", func() {
+				sealed, err := stateCipher.Seal([]byte("some-plaintext"))
+				Expect(err).NotTo(HaveOccurred())
+
+				var envelope map[string]interface{}
+				err = json.Unmarshal(sealed, &envelope)
+				Expect(err).NotTo(HaveOccurred())
+
+				envelope["ciphertext"] = "dGFtcGVyZWQtY2lwaGVydGV4dA=="
+				tampered, err := json.Marshal(envelope)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = stateCipher.Open(tampered)
+				Expect(err).To(Equal(storage.StateDecryptionError))
+			})
+
+			It("returns an error when the contents are not encrypted", func() {
+				_, err := stateCipher.Open([]byte(`{"version": 2}`))
+				Expect(err).To(MatchError("bbl-state.json is not encrypted"))
+			})
+
+			It("returns an error when the encryption is unsupported", func() {
+				_, err := stateCipher.Open([]byte(`{"encryption": {"version": 2, "cipher": "rot13", "kdf": "none"}}`))
+				Expect(err).To(MatchError(`unsupported state encryption: version 2, cipher "rot13", kdf "none"`))
+			})
+		})
+	})
+
+	Describe("IsEncrypted", func() {
+		It("returns false for plaintext state", func() {
+			Expect(storage.IsEncrypted([]byte(`{"version": 2, "iaas": "aws"}`))).To(BeFalse())
+		})
+
+		It("returns false for invalid json", func() {
+			Expect(storage.IsEncrypted([]byte(`%%%%`))).To(BeFalse())
+		})
+	})
+})
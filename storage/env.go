@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const EnvsDir = "envs"
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+type Environment struct {
+	Name  string
+	State State
+}
+
+type envBackend struct {
+	backend Backend
+	env     string
+}
+
+func ValidateEnvName(env string) error {
+	if !envNamePattern.MatchString(env) {
+		return fmt.Errorf("invalid environment name %q, use only letters, digits, '.', '_' and '-'", env)
+	}
+
+	return nil
+}
+
+func NewEnvBackend(backend Backend, env string) Backend {
+	if env == "" {
+		return backend
+	}
+
+	return envBackend{
+		backend: backend,
+		env:     env,
+	}
+}
+
+// EnvDir is the local directory of an environment in the state dir, where
+// bbl keeps files that tools other than bbl read, such as the SSH key.
+func EnvDir(stateDir, env string) string {
+	if env == "" {
+		return stateDir
+	}
+
+	return filepath.Join(stateDir, EnvsDir, env)
+}
+
+func (b envBackend) Read(name string) ([]byte, error) {
+	return b.backend.Read(b.path(name))
+}
+
+func (b envBackend) Write(name string, contents []byte) error {
+	return b.backend.Write(b.path(name), contents)
+}
+
+func (b envBackend) Create(name string, contents []byte) error {
+	return b.backend.Create(b.path(name), contents)
+}
+
+func (b envBackend) Delete(name string) error {
+	return b.backend.Delete(b.path(name))
+}
+
+func (b envBackend) List(dir string) ([]string, error) {
+	return b.backend.List(b.path(dir))
+}
+
+func (b envBackend) String() string {
+	return strings.TrimSuffix(b.backend.String(), "/") + "/" + path.Join(EnvsDir, b.env)
+}
+
+func (b envBackend) path(name string) string {
+	return path.Join(EnvsDir, b.env, name)
+}
+
+type Workspace struct {
+	backend Backend
+	cipher  *Cipher
+}
+
+func NewWorkspace(backend Backend, passphrase []byte) Workspace {
+	workspace := Workspace{
+		backend: backend,
+	}
+
+	if passphrase != nil {
+		workspace.cipher = NewCipher(passphrase)
+	}
+
+	return workspace
+}
+
+func (w Workspace) Environments() ([]Environment, error) {
+	names, err := w.backend.List(EnvsDir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	environments := []Environment{}
+	for _, name := range names {
+		if ValidateEnvName(name) != nil {
+			continue
+		}
+
+		backend := NewEnvBackend(w.backend, name)
+		contents, err := backend.Read(StateFileName)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		state, err := decodeState(contents, w.cipher)
+		if err != nil {
+			return nil, fmt.Errorf("environment %s: %s", name, err)
+		}
+
+		environments = append(environments, Environment{
+			Name:  name,
+			State: state,
+		})
+	}
+
+	return environments, nil
+}
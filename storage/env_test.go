@@ -0,0 +1,123 @@
+package storage_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Environments", func() {
+	var tempDir string
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("ValidateEnvName", func() {
+		It("accepts letters, digits, dots, underscores and dashes", func() {
+			Expect(storage.ValidateEnvName("staging-2.eu_west")).To(Succeed())
+		})
+
+		It("rejects names that would escape the envs directory", func() {
+			for _, name := range []string{"", "..", "some/env", ".hidden", "some env"} {
+				Expect(storage.ValidateEnvName(name)).To(MatchError(ContainSubstring("invalid environment name")), name)
+			}
+		})
+	})
+
+	Describe("EnvDir", func() {
+		It("returns the state dir when no environment is given", func() {
+			Expect(storage.EnvDir(tempDir, "")).To(Equal(tempDir))
+		})
+
+		It("returns the directory of the environment under envs/NAME", func() {
+			Expect(storage.EnvDir(tempDir, "staging")).To(Equal(filepath.Join(tempDir, "envs", "staging")))
+		})
+	})
+
+	Describe("NewEnvBackend", func() {
+		It("returns the backend itself when no environment is given", func() {
+			backend := storage.NewLocalBackend(tempDir)
+			Expect(storage.NewEnvBackend(backend, "")).To(Equal(backend))
+		})
+
+		It("keeps the state, lock and history of an environment under envs/NAME", func() {
+			backend := storage.NewEnvBackend(storage.NewLocalBackend(tempDir), "staging")
+			Expect(backend.String()).To(Equal(filepath.Join(tempDir, "envs", "staging")))
+
+			store := storage.NewBackendStore(backend, nil)
+			err := store.Set(storage.State{IAAS: "aws", EnvID: "some-env-id"})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = os.Stat(filepath.Join(tempDir, "envs", "staging", "bbl-state.json"))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = os.Stat(filepath.Join(tempDir, "bbl-state.json"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+
+			_, err = storage.NewLocker(backend, "some-holder").Lock("up", 0)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = os.Stat(filepath.Join(tempDir, "envs", "staging", "bbl-state.lock"))
+			Expect(err).NotTo(HaveOccurred())
+
+			state, err := store.Get()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.EnvID).To(Equal("some-env-id"))
+		})
+	})
+
+	Describe("Workspace", func() {
+		var backend storage.LocalBackend
+
+		BeforeEach(func() {
+			backend = storage.NewLocalBackend(tempDir)
+		})
+
+		It("lists the environments with a state, sorted by name", func() {
+			Expect(storage.NewBackendStore(storage.NewEnvBackend(backend, "staging"), nil).Set(storage.State{
+				IAAS:  "gcp",
+				GCP:   storage.GCP{Region: "us-east1"},
+				EnvID: "staging-env-id",
+			})).To(Succeed())
+			Expect(storage.NewBackendStore(storage.NewEnvBackend(backend, "production"), nil).Set(storage.State{
+				IAAS:  "aws",
+				AWS:   storage.AWS{Region: "eu-west-1"},
+				EnvID: "production-env-id",
+			})).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(tempDir, "envs", "empty"), os.ModePerm)).To(Succeed())
+
+			environments, err := storage.NewWorkspace(backend, nil).Environments()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(environments).To(HaveLen(2))
+			Expect(environments[0].Name).To(Equal("production"))
+			Expect(environments[0].State.EnvID).To(Equal("production-env-id"))
+			Expect(environments[1].Name).To(Equal("staging"))
+			Expect(environments[1].State.GCP.Region).To(Equal("us-east1"))
+		})
+
+		It("lists nothing when the state directory has no environments", func() {
+			environments, err := storage.NewWorkspace(backend, nil).Environments()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(environments).To(BeEmpty())
+		})
+
+		It("decrypts encrypted environments with the passphrase", func() {
+			Expect(storage.NewBackendStore(storage.NewEnvBackend(backend, "staging"), []byte("some-passphrase")).Set(storage.State{
+				EnvID: "staging-env-id",
+			})).To(Succeed())
+
+			_, err := storage.NewWorkspace(backend, nil).Environments()
+			Expect(err).To(MatchError(ContainSubstring("environment staging:")))
+
+			environments, err := storage.NewWorkspace(backend, []byte("some-passphrase")).Environments()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(environments[0].State.EnvID).To(Equal("staging-env-id"))
+		})
+	})
+})
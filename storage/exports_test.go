@@ -3,6 +3,7 @@ package storage
 import (
 	"io"
 	"os"
+	"time"
 )
 
 func SetEncode(f func(io.Writer, interface{}) error) {
@@ -20,3 +21,48 @@ func SetRename(f func(string, string) error) {
 func ResetRename() {
 	rename = os.Rename
 }
+
+func SetLockRetryInterval(interval time.Duration) {
+	lockRetryInterval = interval
+}
+
+func ResetLockRetryInterval() {
+	lockRetryInterval = 2 * time.Second
+}
+
+func SetLockNow(f func() time.Time) {
+	lockNow = f
+}
+
+func ResetLockNow() {
+	lockNow = time.Now
+}
+
+func SetLockSleep(f func(time.Duration)) {
+	lockSleep = f
+}
+
+func ResetLockSleep() {
+	lockSleep = time.Sleep
+}
+
+func SetHistoryNow(f func() time.Time) {
+	historyNow = f
+}
+
+func ResetHistoryNow() {
+	historyNow = time.Now
+}
+
+func MigrateV1ToV2(state map[string]interface{}) error {
+	return migrateV1ToV2(state)
+}
+
+func MigrationVersions() []int {
+	versions := []int{}
+	for _, m := range migrations {
+		versions = append(versions, m.from)
+	}
+
+	return versions
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	GCSBasePath            = "https://storage.googleapis.com"
+	GoogleStorageReadWrite = "https://www.googleapis.com/auth/devstorage.read_write"
+)
+
+type GCSBackend struct {
+	client   *http.Client
+	basePath string
+	bucket   string
+	prefix   string
+}
+
+func NewGCSBackend(client *http.Client, basePath, bucket, prefix string) GCSBackend {
+	return GCSBackend{
+		client:   client,
+		basePath: strings.TrimSuffix(basePath, "/"),
+		bucket:   bucket,
+		prefix:   prefix,
+	}
+}
+
+func NewGCSBackendFromEnvironment(bucket, prefix, endpoint string) (GCSBackend, error) {
+	client, err := google.DefaultClient(context.Background(), GoogleStorageReadWrite)
+	if err != nil {
+		return GCSBackend{}, err
+	}
+
+	basePath := GCSBasePath
+	if endpoint != "" {
+		basePath = endpoint
+	}
+
+	return NewGCSBackend(client, basePath, bucket, prefix), nil
+}
+
+func (b GCSBackend) Read(name string) ([]byte, error) {
+	response, err := b.client.Get(fmt.Sprintf("%s/storage/v1/b/%s/o/%s?alt=media", b.basePath, url.PathEscape(b.bucket), url.PathEscape(objectKey(b.prefix, name))))
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	contents, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		return contents, nil
+	case http.StatusNotFound:
+		return nil, &os.PathError{Op: "read", Path: b.objectURL(name), Err: os.ErrNotExist}
+	default:
+		return nil, gcsError("read", b.objectURL(name), response.StatusCode, contents)
+	}
+}
+
+func (b GCSBackend) Write(name string, contents []byte) error {
+	return b.upload(name, contents, "")
+}
+
+func (b GCSBackend) Create(name string, contents []byte) error {
+	return b.upload(name, contents, "&ifGenerationMatch=0")
+}
+
+func (b GCSBackend) upload(name string, contents []byte, preconditions string) error {
+	uploadURL := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s%s", b.basePath, url.PathEscape(b.bucket), url.QueryEscape(objectKey(b.prefix, name)), preconditions)
+	response, err := b.client.Post(uploadURL, "application/json", bytes.NewReader(contents))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusPreconditionFailed:
+		return &os.PathError{Op: "create", Path: b.objectURL(name), Err: os.ErrExist}
+	default:
+		body, _ := ioutil.ReadAll(response.Body)
+		return gcsError("write", b.objectURL(name), response.StatusCode, body)
+	}
+}
+
+func (b GCSBackend) Delete(name string) error {
+	request, err := http.NewRequest("DELETE", fmt.Sprintf("%s/storage/v1/b/%s/o/%s", b.basePath, url.PathEscape(b.bucket), url.PathEscape(objectKey(b.prefix, name))), nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := b.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		body, _ := ioutil.ReadAll(response.Body)
+		return gcsError("delete", b.objectURL(name), response.StatusCode, body)
+	}
+}
+
+func (b GCSBackend) List(dir string) ([]string, error) {
+	prefix := objectKey(b.prefix, dir) + "/"
+
+	names := []string{}
+	pageToken := ""
+	for {
+		listURL := fmt.Sprintf("%s/storage/v1/b/%s/o?delimiter=%%2F&prefix=%s", b.basePath, url.PathEscape(b.bucket), url.QueryEscape(prefix))
+		if pageToken != "" {
+			listURL += "&pageToken=" + url.QueryEscape(pageToken)
+		}
+
+		response, err := b.client.Get(listURL)
+		if err != nil {
+			return nil, err
+		}
+
+		contents, err := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if response.StatusCode != http.StatusOK {
+			return nil, gcsError("list", b.objectURL(dir), response.StatusCode, contents)
+		}
+
+		var objects struct {
+			Prefixes      []string `json:"prefixes"`
+			NextPageToken string   `json:"nextPageToken"`
+		}
+		err = json.Unmarshal(contents, &objects)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, objectPrefix := range objects.Prefixes {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(objectPrefix, prefix), "/"))
+		}
+
+		if objects.NextPageToken == "" {
+			return names, nil
+		}
+		pageToken = objects.NextPageToken
+	}
+}
+
+func (b GCSBackend) String() string {
+	return objectURL("gs", b.bucket, b.prefix)
+}
+
+func (b GCSBackend) objectURL(name string) string {
+	return objectURL("gs", b.bucket, objectKey(b.prefix, name))
+}
+
+func gcsError(operation, object string, statusCode int, body []byte) error {
+	return fmt.Errorf("failed to %s %s: %d %s: %s", operation, object, statusCode, http.StatusText(statusCode), strings.TrimSpace(string(body)))
+}
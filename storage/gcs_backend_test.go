@@ -0,0 +1,107 @@
+package storage_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"github.com/cloudfoundry/bosh-bootloader/bbl/objectstorebackend"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GCSBackend", func() {
+	var (
+		objectStore *objectstorebackend.Backend
+		server      *httptest.Server
+		backend     storage.GCSBackend
+	)
+
+	BeforeEach(func() {
+		objectStore = objectstorebackend.New()
+		server = objectStore.Start()
+
+		backend = storage.NewGCSBackend(http.DefaultClient, server.URL, "some-bucket", "some/prefix")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("writes objects under the prefix", func() {
+		err := backend.Write("bbl-state.json", []byte("some-contents"))
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, ok := objectStore.Get("some-bucket", "some/prefix/bbl-state.json")
+		Expect(ok).To(BeTrue())
+		Expect(string(contents)).To(Equal("some-contents"))
+	})
+
+	It("creates objects that do not exist yet", func() {
+		err := backend.Create("bbl-state.lock", []byte("some-contents"))
+		Expect(err).NotTo(HaveOccurred())
+
+		err = backend.Create("bbl-state.lock", []byte("some-other-contents"))
+		Expect(os.IsExist(err)).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring("gs://some-bucket/some/prefix/bbl-state.lock")))
+
+		contents, ok := objectStore.Get("some-bucket", "some/prefix/bbl-state.lock")
+		Expect(ok).To(BeTrue())
+		Expect(string(contents)).To(Equal("some-contents"))
+	})
+
+	It("reads objects under the prefix", func() {
+		objectStore.Set("some-bucket", "some/prefix/bbl-state.json", []byte("some-contents"))
+
+		contents, err := backend.Read("bbl-state.json")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("some-contents"))
+	})
+
+	It("deletes objects under the prefix", func() {
+		objectStore.Set("some-bucket", "some/prefix/bbl-state.json", []byte("some-contents"))
+
+		err := backend.Delete("bbl-state.json")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, ok := objectStore.Get("some-bucket", "some/prefix/bbl-state.json")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("ignores deleting a missing object", func() {
+		err := backend.Delete("bbl-state.json")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("lists the directories under the prefix", func() {
+		objectStore.Set("some-bucket", "some/prefix/envs/first/bbl-state.json", []byte("some-contents"))
+		objectStore.Set("some-bucket", "some/prefix/envs/second/bbl-state.json", []byte("some-contents"))
+		objectStore.Set("some-bucket", "some/prefix/envs/some-file", []byte("some-contents"))
+		objectStore.Set("some-bucket", "other/prefix/envs/third/bbl-state.json", []byte("some-contents"))
+
+		names, err := backend.List("envs")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names).To(Equal([]string{"first", "second"}))
+	})
+
+	It("returns a not exist error when reading a missing object", func() {
+		_, err := backend.Read("bbl-state.json")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring("gs://some-bucket/some/prefix/bbl-state.json")))
+	})
+
+	It("describes itself as a gs url", func() {
+		Expect(backend.String()).To(Equal("gs://some-bucket/some/prefix"))
+	})
+
+	Context("failure cases", func() {
+		It("returns an error when the object store rejects the request", func() {
+			backend = storage.NewGCSBackend(http.DefaultClient, server.URL+"/some-unknown-path", "some-bucket", "some/prefix")
+
+			err := backend.Write("bbl-state.json", []byte("some-contents"))
+			Expect(err).To(MatchError(ContainSubstring("failed to write gs://some-bucket/some/prefix/bbl-state.json: 405 Method Not Allowed")))
+		})
+	})
+})
@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	HistoryDirName     = "bbl-state-history"
+	DefaultHistorySize = 20
+
+	historyIndexName = HistoryDirName + "/index.json"
+
+	// HistoryBaselineCommand is the command of the version that keeps the state
+	// as it was before the history recorded anything.
+	HistoryBaselineCommand = "(existing state)"
+)
+
+var historyNow = time.Now
+
+type HistoryEntry struct {
+	Version       int       `json:"version"`
+	Timestamp     time.Time `json:"timestamp"`
+	Command       string    `json:"command"`
+	ChangedFields []string  `json:"changedFields"`
+	Invocation    string    `json:"invocation"`
+}
+
+type history struct {
+	backend    Backend
+	cipher     *Cipher
+	size       int
+	command    string
+	invocation string
+}
+
+func (h history) entries() ([]HistoryEntry, error) {
+	contents, err := h.backend.Read(historyIndexName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []HistoryEntry{}, nil
+		}
+		return nil, err
+	}
+
+	var entries []HistoryEntry
+	err = json.Unmarshal(contents, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("bbl-state.json history index is corrupt: %s", err)
+	}
+
+	return entries, nil
+}
+
+func (h history) snapshot(version int) ([]byte, error) {
+	contents, err := h.backend.Read(historySnapshotName(version))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("bbl-state.json version %d is not in the history", version)
+		}
+		return nil, err
+	}
+
+	return contents, nil
+}
+
+func (h history) record(previousContents []byte, previous, current State, contents []byte) error {
+	entries, err := h.entries()
+	if err != nil {
+		return err
+	}
+
+	// When the state is encrypted for the first time, the versions kept so
+	// far are sealed too, so that no secret stays readable in the history.
+	if h.cipher != nil && len(previousContents) > 0 && !IsEncrypted(previousContents) {
+		previousContents, err = h.cipher.Seal(previousContents)
+		if err != nil {
+			return err
+		}
+
+		err = h.seal(entries)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The first recorded change also keeps the state it replaces, so that it
+	// can be rolled back to.
+	if len(entries) == 0 && previousContents != nil {
+		baseline := HistoryEntry{
+			Version:       1,
+			Timestamp:     historyNow().UTC(),
+			Command:       HistoryBaselineCommand,
+			ChangedFields: []string{},
+		}
+
+		err = h.backend.Write(historySnapshotName(baseline.Version), previousContents)
+		if err != nil {
+			return err
+		}
+
+		entries = append(entries, baseline)
+	}
+
+	changedFields := changedFields(previous, current)
+
+	var entry HistoryEntry
+	if len(entries) > 0 && entries[len(entries)-1].Invocation == h.invocation {
+		entry = entries[len(entries)-1]
+		entries = entries[:len(entries)-1]
+		changedFields = mergeFields(entry.ChangedFields, changedFields)
+	} else {
+		entry = HistoryEntry{
+			Version:    1,
+			Command:    h.command,
+			Invocation: h.invocation,
+		}
+		if len(entries) > 0 {
+			entry.Version = entries[len(entries)-1].Version + 1
+		}
+	}
+
+	entry.Timestamp = historyNow().UTC()
+	entry.ChangedFields = changedFields
+
+	err = h.backend.Write(historySnapshotName(entry.Version), contents)
+	if err != nil {
+		return err
+	}
+
+	entries = append(entries, entry)
+	for len(entries) > h.size {
+		err = h.backend.Delete(historySnapshotName(entries[0].Version))
+		if err != nil {
+			return err
+		}
+		entries = entries[1:]
+	}
+
+	index, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	return h.backend.Write(historyIndexName, index)
+}
+
+// seal encrypts the snapshots of the entries that are not encrypted yet.
+func (h history) seal(entries []HistoryEntry) error {
+	for _, entry := range entries {
+		contents, err := h.snapshot(entry.Version)
+		if err != nil {
+			return err
+		}
+
+		if len(contents) == 0 || IsEncrypted(contents) {
+			continue
+		}
+
+		contents, err = h.cipher.Seal(contents)
+		if err != nil {
+			return err
+		}
+
+		err = h.backend.Write(historySnapshotName(entry.Version), contents)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func historySnapshotName(version int) string {
+	return fmt.Sprintf("%s/%d.json", HistoryDirName, version)
+}
+
+func changedFields(previous, current State) []string {
+	fields := []string{}
+
+	previousValue := reflect.ValueOf(previous)
+	currentValue := reflect.ValueOf(current)
+	stateType := currentValue.Type()
+
+	for i := 0; i < stateType.NumField(); i++ {
+		name := strings.Split(stateType.Field(i).Tag.Get("json"), ",")[0]
+		if name == "version" || name == "bblVersion" {
+			continue
+		}
+
+		if !reflect.DeepEqual(previousValue.Field(i).Interface(), currentValue.Field(i).Interface()) {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+func mergeFields(first, second []string) []string {
+	fields := map[string]bool{}
+	for _, field := range append(first, second...) {
+		fields[field] = true
+	}
+
+	merged := []string{}
+	for field := range fields {
+		merged = append(merged, field)
+	}
+	sort.Strings(merged)
+
+	return merged
+}
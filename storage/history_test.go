@@ -0,0 +1,223 @@
+package storage_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("History", func() {
+	var (
+		tempDir string
+		now     time.Time
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		now = time.Date(2017, time.January, 2, 3, 4, 5, 0, time.UTC)
+		storage.SetHistoryNow(func() time.Time {
+			now = now.Add(time.Minute)
+			return now
+		})
+	})
+
+	AfterEach(func() {
+		storage.ResetHistoryNow()
+	})
+
+	It("records one version per command with the changed top-level fields", func() {
+		upStore := storage.NewStore(tempDir).ForCommand("up")
+		Expect(upStore.Set(storage.State{IAAS: "gcp"})).To(Succeed())
+		Expect(upStore.Set(storage.State{IAAS: "gcp", TFState: "some-tf-state"})).To(Succeed())
+
+		lbsStore := storage.NewStore(tempDir).ForCommand("create-lbs")
+		Expect(lbsStore.Set(storage.State{IAAS: "gcp", TFState: "some-tf-state", LB: storage.LB{Type: "cf"}})).To(Succeed())
+
+		entries, err := lbsStore.History()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+
+		Expect(entries[0].Version).To(Equal(1))
+		Expect(entries[0].Command).To(Equal("up"))
+		Expect(entries[0].Timestamp).To(Equal(time.Date(2017, time.January, 2, 3, 6, 5, 0, time.UTC)))
+		Expect(entries[0].ChangedFields).To(Equal([]string{"iaas", "tfState"}))
+
+		Expect(entries[1].Version).To(Equal(2))
+		Expect(entries[1].Command).To(Equal("create-lbs"))
+		Expect(entries[1].ChangedFields).To(Equal([]string{"lb"}))
+	})
+
+	It("keeps only the most recent versions", func() {
+		for i := 0; i < storage.DefaultHistorySize+2; i++ {
+			store := storage.NewStore(tempDir).ForCommand("up")
+			Expect(store.Set(storage.State{EnvID: string(rune('a' + i))})).To(Succeed())
+		}
+
+		entries, err := storage.NewStore(tempDir).History()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(storage.DefaultHistorySize))
+		Expect(entries[0].Version).To(Equal(3))
+
+		_, err = os.Stat(filepath.Join(tempDir, "bbl-state-history", "2.json"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+
+		_, err = os.Stat(filepath.Join(tempDir, "bbl-state-history", "3.json"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("keeps the state that existed before the first recorded change", func() {
+		err := ioutil.WriteFile(filepath.Join(tempDir, "bbl-state.json"), []byte(`{"version": 2, "iaas": "gcp", "envID": "some-env-id"}`), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		store := storage.NewStore(tempDir).ForCommand("up")
+		Expect(store.Set(storage.State{IAAS: "gcp", EnvID: "some-other-env-id"})).To(Succeed())
+
+		entries, err := store.History()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Version).To(Equal(1))
+		Expect(entries[0].Command).To(Equal(storage.HistoryBaselineCommand))
+		Expect(entries[1].Version).To(Equal(2))
+		Expect(entries[1].ChangedFields).To(Equal([]string{"envID"}))
+
+		state, err := storage.NewStore(tempDir).Rollback(1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state.EnvID).To(Equal("some-env-id"))
+	})
+
+	It("records the deletion of the state", func() {
+		Expect(storage.NewStore(tempDir).ForCommand("up").Set(storage.State{IAAS: "gcp", EnvID: "some-env-id"})).To(Succeed())
+		Expect(storage.NewStore(tempDir).ForCommand("destroy").Set(storage.State{})).To(Succeed())
+
+		entries, err := storage.NewStore(tempDir).History()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[1].Command).To(Equal("destroy"))
+		Expect(entries[1].ChangedFields).To(Equal([]string{"envID", "iaas"}))
+
+		_, err = os.Stat(filepath.Join(tempDir, "bbl-state.json"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+
+		state, err := storage.NewStore(tempDir).ForCommand("state-rollback").Rollback(1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state.EnvID).To(Equal("some-env-id"))
+
+		state, err = storage.NewStore(tempDir).ForCommand("state-rollback").Rollback(2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state).To(Equal(storage.State{}))
+
+		_, err = os.Stat(filepath.Join(tempDir, "bbl-state.json"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("does not write the state when the history cannot be recorded", func() {
+		Expect(storage.NewStore(tempDir).ForCommand("up").Set(storage.State{EnvID: "some-env-id"})).To(Succeed())
+
+		err := ioutil.WriteFile(filepath.Join(tempDir, "bbl-state-history", "index.json"), []byte("%%%"), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = storage.NewStore(tempDir).ForCommand("up").Set(storage.State{EnvID: "some-other-env-id"})
+		Expect(err).To(MatchError(ContainSubstring("bbl-state.json history index is corrupt")))
+
+		state, err := storage.GetState(tempDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state.EnvID).To(Equal("some-env-id"))
+	})
+
+	It("encrypts the versions kept so far when the state is encrypted", func() {
+		err := ioutil.WriteFile(filepath.Join(tempDir, "bbl-state.json"), []byte(`{"version": 2, "iaas": "gcp", "envID": "some-env-id", "tfState": "some-secret-tf-state"}`), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		store := storage.NewStore(tempDir).ForCommand("up")
+		Expect(store.Set(storage.State{IAAS: "gcp", EnvID: "some-env-id", TFState: "some-other-secret-tf-state"})).To(Succeed())
+
+		encryptedStore := storage.NewEncryptedStore(tempDir, []byte("some-passphrase")).ForCommand("encrypt-state")
+		Expect(encryptedStore.Set(storage.State{IAAS: "gcp", EnvID: "some-env-id", TFState: "some-other-secret-tf-state"})).To(Succeed())
+
+		snapshots, err := filepath.Glob(filepath.Join(tempDir, "bbl-state-history", "[0-9]*.json"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshots).To(HaveLen(3))
+
+		for _, snapshot := range snapshots {
+			contents, err := ioutil.ReadFile(snapshot)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(storage.IsEncrypted(contents)).To(BeTrue(), snapshot)
+			Expect(string(contents)).NotTo(ContainSubstring("secret"))
+		}
+
+		state, err := storage.NewEncryptedStore(tempDir, []byte("some-passphrase")).Rollback(1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state.TFState).To(Equal("some-secret-tf-state"))
+	})
+
+	It("returns an empty history when nothing has been recorded", func() {
+		entries, err := storage.NewStore(tempDir).History()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+
+	Describe("Rollback", func() {
+		It("restores a previous version and records the rollback", func() {
+			Expect(storage.NewStore(tempDir).ForCommand("up").Set(storage.State{IAAS: "gcp", EnvID: "some-env-id"})).To(Succeed())
+			Expect(storage.NewStore(tempDir).ForCommand("destroy").Set(storage.State{IAAS: "gcp"})).To(Succeed())
+
+			store := storage.NewStore(tempDir).ForCommand("state-rollback")
+			state, err := store.Rollback(1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.EnvID).To(Equal("some-env-id"))
+
+			currentState, err := storage.GetState(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(currentState.EnvID).To(Equal("some-env-id"))
+
+			entries, err := store.History()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(3))
+			Expect(entries[2].Command).To(Equal("state-rollback"))
+			Expect(entries[2].ChangedFields).To(Equal([]string{"envID"}))
+		})
+
+		It("restores encrypted versions with the passphrase", func() {
+			Expect(storage.NewEncryptedStore(tempDir, []byte("some-passphrase")).Set(storage.State{EnvID: "some-env-id"})).To(Succeed())
+			Expect(storage.NewEncryptedStore(tempDir, []byte("some-passphrase")).Set(storage.State{EnvID: "some-other-env-id"})).To(Succeed())
+
+			state, err := storage.NewEncryptedStore(tempDir, []byte("some-passphrase")).Rollback(1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.EnvID).To(Equal("some-env-id"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the version is not in the history", func() {
+				_, err := storage.NewStore(tempDir).Rollback(7)
+				Expect(err).To(MatchError("bbl-state.json version 7 is not in the history"))
+			})
+
+			It("returns an error when an encrypted version is restored without a passphrase", func() {
+				Expect(storage.NewEncryptedStore(tempDir, []byte("some-passphrase")).Set(storage.State{EnvID: "some-env-id"})).To(Succeed())
+
+				_, err := storage.NewStore(tempDir).Rollback(1)
+				Expect(err).To(Equal(storage.StateEncryptedError))
+			})
+
+			It("returns an error when the history index is corrupt", func() {
+				err := os.MkdirAll(filepath.Join(tempDir, "bbl-state-history"), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = ioutil.WriteFile(filepath.Join(tempDir, "bbl-state-history", "index.json"), []byte("%%%"), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = storage.NewStore(tempDir).History()
+				Expect(err).To(MatchError(ContainSubstring("bbl-state.json history index is corrupt")))
+			})
+		})
+	})
+})
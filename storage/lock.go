@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"time"
+)
+
+const LockFileName = "bbl-state.lock"
+
+var (
+	StateNotLockedError = errors.New("bbl-state.json is not locked")
+
+	lockRetryInterval = 2 * time.Second
+	lockNow           = time.Now
+	lockSleep         = time.Sleep
+)
+
+type LockInfo struct {
+	ID        string    `json:"id"`
+	Holder    string    `json:"holder"`
+	Command   string    `json:"command"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type StateLockedError struct {
+	Info LockInfo
+}
+
+func (e StateLockedError) Error() string {
+	return fmt.Sprintf("bbl-state.json is locked by %s running %q since %s, wait for it to finish, retry with --lock-timeout or remove the lock with bbl force-unlock",
+		e.Info.Holder, e.Info.Command, e.Info.CreatedAt.Format(time.RFC3339))
+}
+
+type Locker struct {
+	backend Backend
+	holder  string
+}
+
+func NewLocker(backend Backend, holder string) Locker {
+	return Locker{
+		backend: backend,
+		holder:  holder,
+	}
+}
+
+func (l Locker) Lock(command string, timeout time.Duration) (LockInfo, error) {
+	id := make([]byte, 16)
+	if _, err := io.ReadFull(randReader, id); err != nil {
+		return LockInfo{}, err
+	}
+
+	deadline := lockNow().Add(timeout)
+	for {
+		info := LockInfo{
+			ID:        hex.EncodeToString(id),
+			Holder:    l.holder,
+			Command:   command,
+			CreatedAt: lockNow().UTC(),
+		}
+
+		contents, err := json.Marshal(info)
+		if err != nil {
+			return LockInfo{}, err
+		}
+
+		err = l.backend.Create(LockFileName, contents)
+		if err == nil {
+			return info, nil
+		}
+
+		if !os.IsExist(err) {
+			return LockInfo{}, err
+		}
+
+		current, err := l.current()
+		switch {
+		case os.IsNotExist(err):
+			continue
+		case err != nil:
+			return LockInfo{}, err
+		}
+
+		if !lockNow().Before(deadline) {
+			return LockInfo{}, StateLockedError{Info: current}
+		}
+
+		lockSleep(lockRetryInterval)
+	}
+}
+
+func (l Locker) Unlock(info LockInfo) error {
+	current, err := l.current()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if current.ID != info.ID {
+		return fmt.Errorf("bbl-state.json lock was taken over by %s running %q since %s", current.Holder, current.Command, current.CreatedAt.Format(time.RFC3339))
+	}
+
+	return l.backend.Delete(LockFileName)
+}
+
+func (l Locker) ForceUnlock() (LockInfo, error) {
+	current, err := l.current()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return LockInfo{}, StateNotLockedError
+		}
+		return LockInfo{}, err
+	}
+
+	err = l.backend.Delete(LockFileName)
+	if err != nil {
+		return LockInfo{}, err
+	}
+
+	return current, nil
+}
+
+func (l Locker) current() (LockInfo, error) {
+	contents, err := l.backend.Read(LockFileName)
+	if err != nil {
+		return LockInfo{}, err
+	}
+
+	var info LockInfo
+	err = json.Unmarshal(contents, &info)
+	if err != nil {
+		return LockInfo{}, fmt.Errorf("bbl-state.json lock is corrupt, remove it with bbl force-unlock: %s", err)
+	}
+
+	return info, nil
+}
+
+func LockHolder() string {
+	username := "unknown"
+	if currentUser, err := user.Current(); err == nil {
+		username = currentUser.Username
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return fmt.Sprintf("%s@%s (pid %d)", username, hostname, os.Getpid())
+}
@@ -0,0 +1,184 @@
+package storage_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Locker", func() {
+	var (
+		tempDir string
+		backend storage.Backend
+		locker  storage.Locker
+		now     time.Time
+		sleeps  []time.Duration
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		backend = storage.NewLocalBackend(tempDir)
+		locker = storage.NewLocker(backend, "some-user@some-host (pid 123)")
+
+		now = time.Date(2017, time.January, 2, 3, 4, 5, 0, time.UTC)
+		sleeps = []time.Duration{}
+		storage.SetLockNow(func() time.Time {
+			return now
+		})
+		storage.SetLockSleep(func(interval time.Duration) {
+			sleeps = append(sleeps, interval)
+			now = now.Add(interval)
+		})
+		storage.SetLockRetryInterval(10 * time.Second)
+	})
+
+	AfterEach(func() {
+		storage.ResetLockNow()
+		storage.ResetLockSleep()
+		storage.ResetLockRetryInterval()
+	})
+
+	Describe("Lock", func() {
+		It("writes a lock file identifying the holder", func() {
+			lockInfo, err := locker.Lock("up", 0)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(lockInfo.ID).NotTo(BeEmpty())
+			Expect(lockInfo.Holder).To(Equal("some-user@some-host (pid 123)"))
+			Expect(lockInfo.Command).To(Equal("up"))
+			Expect(lockInfo.CreatedAt).To(Equal(now))
+
+			contents, err := ioutil.ReadFile(filepath.Join(tempDir, "bbl-state.lock"))
+			Expect(err).NotTo(HaveOccurred())
+
+			var storedLockInfo storage.LockInfo
+			err = json.Unmarshal(contents, &storedLockInfo)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(storedLockInfo).To(Equal(lockInfo))
+		})
+
+		It("waits for the lock to be released until the timeout", func() {
+			heldLockInfo, err := locker.Lock("create-lbs", 0)
+			Expect(err).NotTo(HaveOccurred())
+
+			storage.SetLockSleep(func(interval time.Duration) {
+				sleeps = append(sleeps, interval)
+				now = now.Add(interval)
+				Expect(locker.Unlock(heldLockInfo)).To(Succeed())
+			})
+
+			lockInfo, err := locker.Lock("up", time.Minute)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lockInfo.Command).To(Equal("up"))
+			Expect(sleeps).To(Equal([]time.Duration{10 * time.Second}))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error describing the holder when the state is locked", func() {
+				_, err := locker.Lock("create-lbs", 0)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = storage.NewLocker(backend, "some-other-user").Lock("up", 0)
+				Expect(err).To(MatchError(`bbl-state.json is locked by some-user@some-host (pid 123) running "create-lbs" since 2017-01-02T03:04:05Z, wait for it to finish, retry with --lock-timeout or remove the lock with bbl force-unlock`))
+				Expect(err).To(BeAssignableToTypeOf(storage.StateLockedError{}))
+				Expect(sleeps).To(BeEmpty())
+			})
+
+			It("gives up when the timeout expires", func() {
+				_, err := locker.Lock("create-lbs", 0)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = locker.Lock("up", 25*time.Second)
+				Expect(err).To(BeAssignableToTypeOf(storage.StateLockedError{}))
+				Expect(sleeps).To(Equal([]time.Duration{10 * time.Second, 10 * time.Second, 10 * time.Second}))
+			})
+
+			It("returns an error when the lock file cannot be created", func() {
+				locker = storage.NewLocker(storage.NewLocalBackend("/some/missing/dir"), "some-holder")
+
+				_, err := locker.Lock("up", 0)
+				Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+			})
+
+			It("returns an error when the existing lock is corrupt", func() {
+				err := ioutil.WriteFile(filepath.Join(tempDir, "bbl-state.lock"), []byte("%%%"), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = locker.Lock("up", 0)
+				Expect(err).To(MatchError(ContainSubstring("bbl-state.json lock is corrupt, remove it with bbl force-unlock")))
+			})
+		})
+	})
+
+	Describe("Unlock", func() {
+		It("removes the lock file", func() {
+			lockInfo, err := locker.Lock("up", 0)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = locker.Unlock(lockInfo)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = os.Stat(filepath.Join(tempDir, "bbl-state.lock"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("does nothing when the lock has already been removed", func() {
+			err := locker.Unlock(storage.LockInfo{ID: "some-lock-id"})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("failure cases", func() {
+			It("leaves a lock taken over by someone else in place", func() {
+				_, err := locker.Lock("up", 0)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = locker.Unlock(storage.LockInfo{ID: "some-other-lock-id"})
+				Expect(err).To(MatchError(`bbl-state.json lock was taken over by some-user@some-host (pid 123) running "up" since 2017-01-02T03:04:05Z`))
+
+				_, err = os.Stat(filepath.Join(tempDir, "bbl-state.lock"))
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("ForceUnlock", func() {
+		It("removes the lock and returns its holder", func() {
+			lockInfo, err := locker.Lock("up", 0)
+			Expect(err).NotTo(HaveOccurred())
+
+			removedLockInfo, err := locker.ForceUnlock()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(removedLockInfo).To(Equal(lockInfo))
+
+			_, err = os.Stat(filepath.Join(tempDir, "bbl-state.lock"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the state is not locked", func() {
+				_, err := locker.ForceUnlock()
+				Expect(err).To(Equal(storage.StateNotLockedError))
+			})
+		})
+	})
+
+	Describe("LockHolder", func() {
+		It("identifies the user, host and process", func() {
+			hostname, err := os.Hostname()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(storage.LockHolder()).To(ContainSubstring("@" + hostname))
+			Expect(storage.LockHolder()).To(HaveSuffix("(pid %d)", os.Getpid()))
+		})
+	})
+})
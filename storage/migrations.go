@@ -0,0 +1,323 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const CurrentStateVersion = 2
+
+var BBLVersion string
+
+type migration struct {
+	from        int
+	description string
+	migrate     func(state map[string]interface{}) error
+}
+
+var migrations = []migration{
+	{
+		from:        1,
+		description: "default iaas to aws",
+		migrate:     migrateV1ToV2,
+	},
+}
+
+type StateTooNewError struct {
+	StateVersion   int
+	WriterVersion  string
+	RunningVersion string
+}
+
+func (e StateTooNewError) Error() string {
+	writer := e.WriterVersion
+	if writer == "" {
+		writer = "an unknown version"
+	}
+
+	running := e.RunningVersion
+	if running == "" {
+		running = "unknown"
+	}
+
+	return fmt.Sprintf("bbl-state.json version %d was written by bbl %s, which is newer than this bbl (%s, supports state version %d), please upgrade bbl",
+		e.StateVersion, writer, running, CurrentStateVersion)
+}
+
+type StateChange struct {
+	Path     string
+	Previous interface{}
+	Current  interface{}
+}
+
+func (c StateChange) Added() bool {
+	return c.Previous == nil
+}
+
+func (c StateChange) Removed() bool {
+	return c.Current == nil
+}
+
+type MigrationPlan struct {
+	FromVersion int
+	ToVersion   int
+	Migrations  []string
+	Changes     []StateChange
+}
+
+func (p MigrationPlan) Empty() bool {
+	return len(p.Migrations) == 0
+}
+
+func (s Store) PlanMigration() (MigrationPlan, error) {
+	plan, _, err := s.planMigration()
+	return plan, err
+}
+
+func (s Store) Migrate() (MigrationPlan, error) {
+	plan, state, err := s.planMigration()
+	if err != nil {
+		return MigrationPlan{}, err
+	}
+
+	if plan.Empty() {
+		return plan, nil
+	}
+
+	err = s.Set(state)
+	if err != nil {
+		return MigrationPlan{}, err
+	}
+
+	return plan, nil
+}
+
+func (s Store) planMigration() (MigrationPlan, State, error) {
+	contents, err := s.backend.Read(StateFileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return MigrationPlan{FromVersion: CurrentStateVersion, ToVersion: CurrentStateVersion}, State{}, nil
+		}
+		return MigrationPlan{}, State{}, err
+	}
+
+	contents, err = openState(contents, s.cipher)
+	if err != nil {
+		return MigrationPlan{}, State{}, err
+	}
+
+	previous, err := unmarshalRawState(contents)
+	if err != nil {
+		return MigrationPlan{}, State{}, err
+	}
+
+	current, err := unmarshalRawState(contents)
+	if err != nil {
+		return MigrationPlan{}, State{}, err
+	}
+
+	plan := MigrationPlan{FromVersion: rawStateVersion(previous)}
+	plan.Migrations, err = migrateRawState(current)
+	if err != nil {
+		return MigrationPlan{}, State{}, err
+	}
+	plan.ToVersion = rawStateVersion(current)
+	plan.Changes = diffRawState("", previous, current)
+
+	state, err := rawStateToState(current)
+	if err != nil {
+		return MigrationPlan{}, State{}, err
+	}
+
+	return plan, state, nil
+}
+
+func migrateRawState(state map[string]interface{}) ([]string, error) {
+	version := rawStateVersion(state)
+	writer, _ := state["bblVersion"].(string)
+
+	if version > CurrentStateVersion || isNewerBBLVersion(writer, BBLVersion) {
+		return nil, StateTooNewError{
+			StateVersion:   version,
+			WriterVersion:  writer,
+			RunningVersion: BBLVersion,
+		}
+	}
+
+	applied := []string{}
+	if version == 0 {
+		return applied, nil
+	}
+
+	for _, m := range migrations {
+		if m.from != version {
+			continue
+		}
+
+		err := m.migrate(state)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate bbl-state.json from version %d to %d: %s", m.from, m.from+1, err)
+		}
+
+		version = m.from + 1
+		state["version"] = version
+		applied = append(applied, fmt.Sprintf("%d -> %d: %s", m.from, version, m.description))
+	}
+
+	return applied, nil
+}
+
+func migrateV1ToV2(state map[string]interface{}) error {
+	state["iaas"] = "aws"
+	return nil
+}
+
+func unmarshalRawState(contents []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.UseNumber()
+
+	state := map[string]interface{}{}
+	err := decoder.Decode(&state)
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+func rawStateToState(raw map[string]interface{}) (State, error) {
+	contents, err := json.Marshal(raw)
+	if err != nil {
+		return State{}, err
+	}
+
+	state := State{}
+	err = json.Unmarshal(contents, &state)
+	if err != nil {
+		return State{}, err
+	}
+
+	return state, nil
+}
+
+func rawStateVersion(state map[string]interface{}) int {
+	switch version := state["version"].(type) {
+	case int:
+		return version
+	case json.Number:
+		v, err := version.Int64()
+		if err != nil {
+			return 0
+		}
+		return int(v)
+	default:
+		return 0
+	}
+}
+
+func diffRawState(prefix string, previous, current map[string]interface{}) []StateChange {
+	keys := map[string]bool{}
+	for key := range previous {
+		keys[key] = true
+	}
+	for key := range current {
+		keys[key] = true
+	}
+
+	sortedKeys := []string{}
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	changes := []StateChange{}
+	for _, key := range sortedKeys {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		previousValue, currentValue := normalizeRawValue(previous[key]), normalizeRawValue(current[key])
+		previousMap, previousIsMap := previousValue.(map[string]interface{})
+		currentMap, currentIsMap := currentValue.(map[string]interface{})
+
+		switch {
+		case previousIsMap && currentIsMap:
+			changes = append(changes, diffRawState(path, previousMap, currentMap)...)
+		case !reflect.DeepEqual(previousValue, currentValue):
+			changes = append(changes, StateChange{
+				Path:     path,
+				Previous: previousValue,
+				Current:  currentValue,
+			})
+		}
+	}
+
+	return changes
+}
+
+func normalizeRawValue(value interface{}) interface{} {
+	number, ok := value.(json.Number)
+	if !ok {
+		return value
+	}
+
+	if i, err := number.Int64(); err == nil {
+		return int(i)
+	}
+
+	if f, err := number.Float64(); err == nil {
+		return f
+	}
+
+	return value
+}
+
+func isNewerBBLVersion(writer, running string) bool {
+	writerParts, ok := parseBBLVersion(writer)
+	if !ok {
+		return false
+	}
+
+	runningParts, ok := parseBBLVersion(running)
+	if !ok {
+		return false
+	}
+
+	for i := range writerParts {
+		if writerParts[i] != runningParts[i] {
+			return writerParts[i] > runningParts[i]
+		}
+	}
+
+	return false
+}
+
+func parseBBLVersion(version string) ([3]int, bool) {
+	var parts [3]int
+
+	version = strings.TrimPrefix(version, "v")
+	version = strings.SplitN(version, "-", 2)[0]
+	version = strings.SplitN(version, "+", 2)[0]
+
+	fields := strings.Split(version, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+
+	for i, field := range fields {
+		part, err := strconv.Atoi(field)
+		if err != nil || part < 0 {
+			return parts, false
+		}
+		parts[i] = part
+	}
+
+	return parts, true
+}
@@ -0,0 +1,178 @@
+package storage_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Migrations", func() {
+	var (
+		tempDir string
+		store   storage.Store
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		store = storage.NewStore(tempDir).ForCommand("migrate-state")
+	})
+
+	AfterEach(func() {
+		storage.BBLVersion = ""
+	})
+
+	writeState := func(contents string) {
+		err := ioutil.WriteFile(filepath.Join(tempDir, storage.StateFileName), []byte(contents), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("registers one migration per state version in order up to the current version", func() {
+		versions := storage.MigrationVersions()
+		for i, version := range versions {
+			Expect(version).To(Equal(i + 1))
+		}
+		Expect(versions[len(versions)-1]).To(Equal(storage.CurrentStateVersion - 1))
+	})
+
+	Describe("MigrateV1ToV2", func() {
+		It("defaults the iaas to aws", func() {
+			state := map[string]interface{}{"envID": "some-env-id"}
+
+			err := storage.MigrateV1ToV2(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(state).To(Equal(map[string]interface{}{
+				"envID": "some-env-id",
+				"iaas":  "aws",
+			}))
+		})
+	})
+
+	Describe("PlanMigration", func() {
+		It("returns the migrations and the changes without writing the state", func() {
+			writeState(`{"version": 1, "envID": "some-env-id", "bosh": {"directorName": "some-director"}}`)
+
+			plan, err := store.PlanMigration()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(plan).To(Equal(storage.MigrationPlan{
+				FromVersion: 1,
+				ToVersion:   2,
+				Migrations:  []string{"1 -> 2: default iaas to aws"},
+				Changes: []storage.StateChange{
+					{Path: "iaas", Current: "aws"},
+					{Path: "version", Previous: 1, Current: 2},
+				},
+			}))
+
+			contents, err := ioutil.ReadFile(filepath.Join(tempDir, storage.StateFileName))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contents).To(MatchJSON(`{"version": 1, "envID": "some-env-id", "bosh": {"directorName": "some-director"}}`))
+		})
+
+		It("returns an empty plan when the state is current", func() {
+			writeState(`{"version": 2, "iaas": "gcp"}`)
+
+			plan, err := store.PlanMigration()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Empty()).To(BeTrue())
+			Expect(plan.ToVersion).To(Equal(2))
+			Expect(plan.Changes).To(BeEmpty())
+		})
+
+		It("returns an empty plan when there is no state", func() {
+			plan, err := store.PlanMigration()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Empty()).To(BeTrue())
+			Expect(plan.ToVersion).To(Equal(storage.CurrentStateVersion))
+		})
+
+		It("plans the migration of an encrypted state", func() {
+			encryptedStore := storage.NewEncryptedStore(tempDir, []byte("some-passphrase"))
+			contents, err := storage.NewCipher([]byte("some-passphrase")).Seal([]byte(`{"version": 1}`))
+			Expect(err).NotTo(HaveOccurred())
+			writeState(string(contents))
+
+			plan, err := encryptedStore.PlanMigration()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Migrations).To(HaveLen(1))
+		})
+	})
+
+	Describe("Migrate", func() {
+		It("writes the migrated state and records the writer's bbl version", func() {
+			storage.BBLVersion = "1.2.3"
+			writeState(`{"version": 1, "envID": "some-env-id"}`)
+
+			plan, err := store.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Migrations).To(Equal([]string{"1 -> 2: default iaas to aws"}))
+
+			state, err := storage.GetState(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.Version).To(Equal(2))
+			Expect(state.IAAS).To(Equal("aws"))
+			Expect(state.EnvID).To(Equal("some-env-id"))
+			Expect(state.BBLVersion).To(Equal("1.2.3"))
+		})
+
+		It("does not write the state when it is current", func() {
+			writeState(`{"version": 2, "iaas": "gcp"}`)
+
+			_, err := store.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+
+			entries, err := store.History()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+	})
+
+	Context("when the state was written by a newer bbl", func() {
+		It("refuses a state version this bbl does not know", func() {
+			storage.BBLVersion = "1.2.3"
+			writeState(`{"version": 3, "bblVersion": "2.0.0"}`)
+
+			_, err := storage.GetState(tempDir)
+			Expect(err).To(MatchError("bbl-state.json version 3 was written by bbl 2.0.0, which is newer than this bbl (1.2.3, supports state version 2), please upgrade bbl"))
+
+			_, err = store.PlanMigration()
+			Expect(err).To(BeAssignableToTypeOf(storage.StateTooNewError{}))
+		})
+
+		It("refuses a state written by a newer release", func() {
+			storage.BBLVersion = "v1.2.3"
+			writeState(`{"version": 2, "bblVersion": "v1.10.0"}`)
+
+			_, err := storage.GetState(tempDir)
+			Expect(err).To(MatchError(ContainSubstring("was written by bbl v1.10.0")))
+		})
+
+		It("accepts a state written by an older or unversioned bbl", func() {
+			storage.BBLVersion = "1.2.3"
+
+			for _, writer := range []string{"1.2.3", "1.2.2", "0.9", "dev", ""} {
+				writeState(`{"version": 2, "bblVersion": "` + writer + `"}`)
+
+				_, err := storage.GetState(tempDir)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("does not compare versions when this bbl is a dev build", func() {
+			storage.BBLVersion = "dev"
+			writeState(`{"version": 2, "bblVersion": "9.9.9"}`)
+
+			_, err := storage.GetState(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	redactedPrefix = "((redacted:"
+	redactedSuffix = "))"
+)
+
+type secretField struct {
+	path  string
+	value func(state *State) *string
+}
+
+var secretFields = []secretField{
+	{"aws.accessKeyId", func(s *State) *string { return &s.AWS.AccessKeyID }},
+	{"aws.secretAccessKey", func(s *State) *string { return &s.AWS.SecretAccessKey }},
+	{"gcp.serviceAccountKey", func(s *State) *string { return &s.GCP.ServiceAccountKey }},
+	{"keyPair.privateKey", func(s *State) *string { return &s.KeyPair.PrivateKey }},
+	{"bosh.directorPassword", func(s *State) *string { return &s.BOSH.DirectorPassword }},
+	{"bosh.directorSSLPrivateKey", func(s *State) *string { return &s.BOSH.DirectorSSLPrivateKey }},
+	{"bosh.directorSSLRotationPrivateKey", func(s *State) *string { return &s.BOSH.DirectorSSLRotationPrivateKey }},
+	{"bosh.manifest", func(s *State) *string { return &s.BOSH.Manifest }},
+	{"lb.key", func(s *State) *string { return &s.LB.Key }},
+	{"tfState", func(s *State) *string { return &s.TFState }},
+}
+
+type secretValue struct {
+	path  string
+	value *string
+}
+
+// secretValues lists the secrets of the state along with the contents of the
+// files kept in it, such as ops files or cloudformation fragments, which can
+// hold passwords of their own.
+func secretValues(state *State) []secretValue {
+	values := []secretValue{}
+	for _, field := range secretFields {
+		values = append(values, secretValue{field.path, field.value(state)})
+	}
+
+	for i := range state.DirectorOpsFiles {
+		values = append(values, secretValue{fmt.Sprintf("directorOpsFiles[%d].contents", i), &state.DirectorOpsFiles[i].Contents})
+	}
+	for i := range state.CloudConfigOpsFiles {
+		values = append(values, secretValue{fmt.Sprintf("cloudConfigOpsFiles[%d].contents", i), &state.CloudConfigOpsFiles[i].Contents})
+	}
+	for i := range state.TerraformOverrides {
+		values = append(values, secretValue{fmt.Sprintf("terraformOverrides[%d].contents", i), &state.TerraformOverrides[i].Contents})
+	}
+	for i := range state.CloudFormationFragments {
+		values = append(values, secretValue{fmt.Sprintf("cloudFormationFragments[%d].contents", i), &state.CloudFormationFragments[i].Contents})
+	}
+
+	return values
+}
+
+// copyFiles gives the state its own copies of the files kept in it, so that
+// changing their contents leaves the state it was copied from alone.
+func copyFiles(state State) State {
+	if state.DirectorOpsFiles != nil {
+		state.DirectorOpsFiles = append([]OpsFile{}, state.DirectorOpsFiles...)
+	}
+	if state.CloudConfigOpsFiles != nil {
+		state.CloudConfigOpsFiles = append([]OpsFile{}, state.CloudConfigOpsFiles...)
+	}
+	if state.TerraformOverrides != nil {
+		state.TerraformOverrides = append([]TerraformOverride{}, state.TerraformOverrides...)
+	}
+	if state.CloudFormationFragments != nil {
+		state.CloudFormationFragments = append([]CloudFormationFragment{}, state.CloudFormationFragments...)
+	}
+
+	return state
+}
+
+func RedactedPlaceholder(path string) string {
+	return redactedPrefix + path + redactedSuffix
+}
+
+func RedactState(state State) State {
+	state = copyFiles(state)
+	for _, secret := range secretValues(&state) {
+		if *secret.value != "" {
+			*secret.value = RedactedPlaceholder(secret.path)
+		}
+	}
+
+	if state.BOSH.Credentials != nil {
+		credentials := map[string]string{}
+		for name, value := range state.BOSH.Credentials {
+			if value != "" {
+				value = RedactedPlaceholder("bosh.credentials." + name)
+			}
+			credentials[name] = value
+		}
+		state.BOSH.Credentials = credentials
+	}
+
+	return state
+}
+
+func RedactedPaths(state State) []string {
+	paths := []string{}
+	for _, secret := range secretValues(&state) {
+		if path, ok := redactedPath(*secret.value); ok {
+			paths = append(paths, path)
+		}
+	}
+
+	credentialPaths := []string{}
+	for _, value := range state.BOSH.Credentials {
+		if path, ok := redactedPath(value); ok {
+			credentialPaths = append(credentialPaths, path)
+		}
+	}
+	sort.Strings(credentialPaths)
+
+	return append(paths, credentialPaths...)
+}
+
+func HydrateState(state State, secrets map[string]string) (State, error) {
+	state = copyFiles(state)
+	for _, value := range secretValues(&state) {
+		if path, ok := redactedPath(*value.value); ok {
+			secret, ok := secrets[path]
+			if !ok {
+				return State{}, fmt.Errorf("missing secret for %s", path)
+			}
+			*value.value = secret
+		}
+	}
+
+	if state.BOSH.Credentials != nil {
+		credentials := map[string]string{}
+		for name, value := range state.BOSH.Credentials {
+			if path, ok := redactedPath(value); ok {
+				secret, ok := secrets[path]
+				if !ok {
+					return State{}, fmt.Errorf("missing secret for %s", path)
+				}
+				value = secret
+			}
+			credentials[name] = value
+		}
+		state.BOSH.Credentials = credentials
+	}
+
+	return state, nil
+}
+
+func redactedPath(value string) (string, bool) {
+	if !strings.HasPrefix(value, redactedPrefix) || !strings.HasSuffix(value, redactedSuffix) {
+		return "", false
+	}
+
+	return strings.TrimSuffix(strings.TrimPrefix(value, redactedPrefix), redactedSuffix), true
+}
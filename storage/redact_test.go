@@ -0,0 +1,185 @@
+package storage_test
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Redaction", func() {
+	var state storage.State
+
+	BeforeEach(func() {
+		state = storage.State{
+			Version: 2,
+			IAAS:    "aws",
+			AWS: storage.AWS{
+				AccessKeyID:     "some-access-key-id",
+				SecretAccessKey: "some-secret-access-key",
+				Region:          "some-region",
+			},
+			KeyPair: storage.KeyPair{
+				Name:       "some-keypair",
+				PrivateKey: "some-private-key",
+				PublicKey:  "some-public-key",
+			},
+			BOSH: storage.BOSH{
+				DirectorName:          "some-director",
+				DirectorUsername:      "some-username",
+				DirectorPassword:      "some-password",
+				DirectorAddress:       "some-address",
+				DirectorSSLCA:         "some-ca",
+				DirectorSSLPrivateKey: "some-ssl-private-key",
+				Credentials: map[string]string{
+					"natsPassword": "some-nats-password",
+					"hmPassword":   "some-hm-password",
+				},
+				Manifest: "name: bosh",
+			},
+			LB: storage.LB{
+				Type: "cf",
+				Cert: "some-cert",
+				Key:  "some-key",
+			},
+			EnvID:   "some-env-id",
+			TFState: `{"version": 3}`,
+			DirectorOpsFiles: []storage.OpsFile{
+				{Path: "director-ops.yml", Contents: "some-director-ops"},
+			},
+			CloudConfigOpsFiles: []storage.OpsFile{
+				{Path: "cloud-config-ops.yml", Contents: "some-cloud-config-ops"},
+			},
+			TerraformOverrides: []storage.TerraformOverride{
+				{Name: "database.tf", Contents: "some-override"},
+			},
+			CloudFormationFragments: []storage.CloudFormationFragment{
+				{Name: "buckets.json", Contents: "some-fragment"},
+			},
+		}
+	})
+
+	Describe("RedactState", func() {
+		It("replaces every secret with a placeholder and keeps everything else", func() {
+			redacted := storage.RedactState(state)
+
+			Expect(redacted).To(Equal(storage.State{
+				Version: 2,
+				IAAS:    "aws",
+				AWS: storage.AWS{
+					AccessKeyID:     "((redacted:aws.accessKeyId))",
+					SecretAccessKey: "((redacted:aws.secretAccessKey))",
+					Region:          "some-region",
+				},
+				KeyPair: storage.KeyPair{
+					Name:       "some-keypair",
+					PrivateKey: "((redacted:keyPair.privateKey))",
+					PublicKey:  "some-public-key",
+				},
+				BOSH: storage.BOSH{
+					DirectorName:          "some-director",
+					DirectorUsername:      "some-username",
+					DirectorPassword:      "((redacted:bosh.directorPassword))",
+					DirectorAddress:       "some-address",
+					DirectorSSLCA:         "some-ca",
+					DirectorSSLPrivateKey: "((redacted:bosh.directorSSLPrivateKey))",
+					Credentials: map[string]string{
+						"natsPassword": "((redacted:bosh.credentials.natsPassword))",
+						"hmPassword":   "((redacted:bosh.credentials.hmPassword))",
+					},
+					Manifest: "((redacted:bosh.manifest))",
+				},
+				LB: storage.LB{
+					Type: "cf",
+					Cert: "some-cert",
+					Key:  "((redacted:lb.key))",
+				},
+				EnvID:   "some-env-id",
+				TFState: "((redacted:tfState))",
+				DirectorOpsFiles: []storage.OpsFile{
+					{Path: "director-ops.yml", Contents: "((redacted:directorOpsFiles[0].contents))"},
+				},
+				CloudConfigOpsFiles: []storage.OpsFile{
+					{Path: "cloud-config-ops.yml", Contents: "((redacted:cloudConfigOpsFiles[0].contents))"},
+				},
+				TerraformOverrides: []storage.TerraformOverride{
+					{Name: "database.tf", Contents: "((redacted:terraformOverrides[0].contents))"},
+				},
+				CloudFormationFragments: []storage.CloudFormationFragment{
+					{Name: "buckets.json", Contents: "((redacted:cloudFormationFragments[0].contents))"},
+				},
+			}))
+		})
+
+		It("does not modify the original state", func() {
+			storage.RedactState(state)
+			Expect(state.BOSH.Credentials["natsPassword"]).To(Equal("some-nats-password"))
+			Expect(state.DirectorOpsFiles[0].Contents).To(Equal("some-director-ops"))
+			Expect(state.CloudConfigOpsFiles[0].Contents).To(Equal("some-cloud-config-ops"))
+			Expect(state.TerraformOverrides[0].Contents).To(Equal("some-override"))
+			Expect(state.CloudFormationFragments[0].Contents).To(Equal("some-fragment"))
+		})
+
+		It("leaves empty secrets empty", func() {
+			redacted := storage.RedactState(storage.State{IAAS: "gcp", GCP: storage.GCP{ProjectID: "some-project"}})
+			Expect(redacted).To(Equal(storage.State{IAAS: "gcp", GCP: storage.GCP{ProjectID: "some-project"}}))
+		})
+	})
+
+	Describe("RedactedPaths", func() {
+		It("lists the placeholders in the state", func() {
+			Expect(storage.RedactedPaths(storage.RedactState(state))).To(Equal([]string{
+				"aws.accessKeyId",
+				"aws.secretAccessKey",
+				"keyPair.privateKey",
+				"bosh.directorPassword",
+				"bosh.directorSSLPrivateKey",
+				"bosh.manifest",
+				"lb.key",
+				"tfState",
+				"directorOpsFiles[0].contents",
+				"cloudConfigOpsFiles[0].contents",
+				"terraformOverrides[0].contents",
+				"cloudFormationFragments[0].contents",
+				"bosh.credentials.hmPassword",
+				"bosh.credentials.natsPassword",
+			}))
+		})
+	})
+
+	Describe("HydrateState", func() {
+		It("restores the redacted secrets", func() {
+			secrets := map[string]string{
+				"aws.accessKeyId":                     "some-access-key-id",
+				"aws.secretAccessKey":                 "some-secret-access-key",
+				"keyPair.privateKey":                  "some-private-key",
+				"bosh.directorPassword":               "some-password",
+				"bosh.directorSSLPrivateKey":          "some-ssl-private-key",
+				"bosh.manifest":                       "name: bosh",
+				"lb.key":                              "some-key",
+				"tfState":                             `{"version": 3}`,
+				"directorOpsFiles[0].contents":        "some-director-ops",
+				"cloudConfigOpsFiles[0].contents":     "some-cloud-config-ops",
+				"terraformOverrides[0].contents":      "some-override",
+				"cloudFormationFragments[0].contents": "some-fragment",
+				"bosh.credentials.hmPassword":         "some-hm-password",
+				"bosh.credentials.natsPassword":       "some-nats-password",
+			}
+
+			redacted := storage.RedactState(state)
+			hydrated, err := storage.HydrateState(redacted, secrets)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hydrated).To(Equal(state))
+			Expect(redacted.CloudFormationFragments[0].Contents).To(Equal("((redacted:cloudFormationFragments[0].contents))"))
+		})
+
+		It("returns an error when a secret is missing", func() {
+			_, err := storage.HydrateState(storage.RedactState(state), map[string]string{})
+			Expect(err).To(MatchError("missing secret for aws.accessKeyId"))
+
+			redacted := storage.State{BOSH: storage.BOSH{Credentials: map[string]string{"hmPassword": "((redacted:bosh.credentials.hmPassword))"}}}
+			_, err = storage.HydrateState(redacted, map[string]string{})
+			Expect(err).To(MatchError("missing secret for bosh.credentials.hmPassword"))
+		})
+	})
+})
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	goaws "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const defaultS3Region = "us-east-1"
+
+type s3Client interface {
+	GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error)
+	PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error)
+	PutObjectRequest(input *s3.PutObjectInput) (*request.Request, *s3.PutObjectOutput)
+	DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error)
+	ListObjectsV2(input *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error)
+}
+
+type S3Backend struct {
+	client s3Client
+	bucket string
+	prefix string
+}
+
+func NewS3Backend(client s3Client, bucket, prefix string) S3Backend {
+	return S3Backend{
+		client: client,
+		bucket: bucket,
+		prefix: prefix,
+	}
+}
+
+func NewS3BackendFromEnvironment(bucket, prefix, region, endpoint string) (S3Backend, error) {
+	config := goaws.NewConfig()
+	if region != "" {
+		config.WithRegion(region)
+	}
+
+	if endpoint != "" {
+		config.WithEndpoint(endpoint).WithS3ForcePathStyle(true)
+	}
+
+	awsSession, err := session.NewSession(config)
+	if err != nil {
+		return S3Backend{}, err
+	}
+
+	if goaws.StringValue(awsSession.Config.Region) == "" {
+		awsSession.Config.WithRegion(defaultS3Region)
+	}
+
+	return NewS3Backend(s3.New(awsSession), bucket, prefix), nil
+}
+
+func (b S3Backend) Read(name string) ([]byte, error) {
+	output, err := b.client.GetObject(&s3.GetObjectInput{
+		Bucket: goaws.String(b.bucket),
+		Key:    goaws.String(objectKey(b.prefix, name)),
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return nil, &os.PathError{Op: "read", Path: b.objectURL(name), Err: os.ErrNotExist}
+		}
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	return ioutil.ReadAll(output.Body)
+}
+
+func (b S3Backend) Write(name string, contents []byte) error {
+	_, err := b.client.PutObject(&s3.PutObjectInput{
+		Bucket:      goaws.String(b.bucket),
+		Key:         goaws.String(objectKey(b.prefix, name)),
+		Body:        bytes.NewReader(contents),
+		ContentType: goaws.String("application/json"),
+	})
+	return err
+}
+
+func (b S3Backend) Create(name string, contents []byte) error {
+	putObjectRequest, _ := b.client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:      goaws.String(b.bucket),
+		Key:         goaws.String(objectKey(b.prefix, name)),
+		Body:        bytes.NewReader(contents),
+		ContentType: goaws.String("application/json"),
+	})
+	putObjectRequest.HTTPRequest.Header.Set("If-None-Match", "*")
+
+	err := putObjectRequest.Send()
+	if err != nil {
+		if awsErr, ok := err.(awserr.RequestFailure); ok && (awsErr.StatusCode() == 412 || awsErr.StatusCode() == 409) {
+			return &os.PathError{Op: "create", Path: b.objectURL(name), Err: os.ErrExist}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (b S3Backend) Delete(name string) error {
+	_, err := b.client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: goaws.String(b.bucket),
+		Key:    goaws.String(objectKey(b.prefix, name)),
+	})
+	if err != nil && !isS3NotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (b S3Backend) List(dir string) ([]string, error) {
+	prefix := objectKey(b.prefix, dir) + "/"
+
+	names := []string{}
+	input := &s3.ListObjectsV2Input{
+		Bucket:    goaws.String(b.bucket),
+		Prefix:    goaws.String(prefix),
+		Delimiter: goaws.String("/"),
+	}
+	for {
+		output, err := b.client.ListObjectsV2(input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, commonPrefix := range output.CommonPrefixes {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(goaws.StringValue(commonPrefix.Prefix), prefix), "/"))
+		}
+
+		if !goaws.BoolValue(output.IsTruncated) {
+			return names, nil
+		}
+		input.ContinuationToken = output.NextContinuationToken
+	}
+}
+
+func (b S3Backend) String() string {
+	return objectURL("s3", b.bucket, b.prefix)
+}
+
+func (b S3Backend) objectURL(name string) string {
+	return objectURL("s3", b.bucket, objectKey(b.prefix, name))
+}
+
+func isS3NotFound(err error) bool {
+	if awsErr, ok := err.(awserr.RequestFailure); ok && awsErr.StatusCode() == 404 {
+		return true
+	}
+
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == s3.ErrCodeNoSuchKey {
+		return true
+	}
+
+	return false
+}
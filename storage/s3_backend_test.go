@@ -0,0 +1,103 @@
+package storage_test
+
+import (
+	"net/http/httptest"
+	"os"
+
+	goaws "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cloudfoundry/bosh-bootloader/bbl/objectstorebackend"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("S3Backend", func() {
+	var (
+		objectStore *objectstorebackend.Backend
+		server      *httptest.Server
+		backend     storage.S3Backend
+	)
+
+	BeforeEach(func() {
+		objectStore = objectstorebackend.New()
+		server = objectStore.Start()
+
+		client := s3.New(session.New(&goaws.Config{
+			Credentials:      credentials.NewStaticCredentials("some-access-key-id", "some-secret-access-key", ""),
+			Region:           goaws.String("some-region"),
+			Endpoint:         goaws.String(server.URL),
+			S3ForcePathStyle: goaws.Bool(true),
+		}))
+
+		backend = storage.NewS3Backend(client, "some-bucket", "some/prefix")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("writes objects under the prefix", func() {
+		err := backend.Write("bbl-state.json", []byte("some-contents"))
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, ok := objectStore.Get("some-bucket", "some/prefix/bbl-state.json")
+		Expect(ok).To(BeTrue())
+		Expect(string(contents)).To(Equal("some-contents"))
+	})
+
+	It("creates objects that do not exist yet", func() {
+		err := backend.Create("bbl-state.lock", []byte("some-contents"))
+		Expect(err).NotTo(HaveOccurred())
+
+		err = backend.Create("bbl-state.lock", []byte("some-other-contents"))
+		Expect(os.IsExist(err)).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring("s3://some-bucket/some/prefix/bbl-state.lock")))
+
+		contents, ok := objectStore.Get("some-bucket", "some/prefix/bbl-state.lock")
+		Expect(ok).To(BeTrue())
+		Expect(string(contents)).To(Equal("some-contents"))
+	})
+
+	It("reads objects under the prefix", func() {
+		objectStore.Set("some-bucket", "some/prefix/bbl-state.json", []byte("some-contents"))
+
+		contents, err := backend.Read("bbl-state.json")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("some-contents"))
+	})
+
+	It("deletes objects under the prefix", func() {
+		objectStore.Set("some-bucket", "some/prefix/bbl-state.json", []byte("some-contents"))
+
+		err := backend.Delete("bbl-state.json")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, ok := objectStore.Get("some-bucket", "some/prefix/bbl-state.json")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("lists the directories under the prefix", func() {
+		objectStore.Set("some-bucket", "some/prefix/envs/first/bbl-state.json", []byte("some-contents"))
+		objectStore.Set("some-bucket", "some/prefix/envs/second/bbl-state.json", []byte("some-contents"))
+		objectStore.Set("some-bucket", "some/prefix/envs/some-file", []byte("some-contents"))
+		objectStore.Set("some-bucket", "other/prefix/envs/third/bbl-state.json", []byte("some-contents"))
+
+		names, err := backend.List("envs")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names).To(Equal([]string{"first", "second"}))
+	})
+
+	It("returns a not exist error when reading a missing object", func() {
+		_, err := backend.Read("bbl-state.json")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring("s3://some-bucket/some/prefix/bbl-state.json")))
+	})
+
+	It("describes itself as an s3 url", func() {
+		Expect(backend.String()).To(Equal("s3://some-bucket/some/prefix"))
+	})
+})
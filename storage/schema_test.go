@@ -0,0 +1,88 @@
+package storage_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StateSchema", func() {
+	It("describes every field of the state", func() {
+		schema := storage.StateSchema()
+
+		Expect(schema).To(HaveKeyWithValue("$schema", "http://json-schema.org/draft-04/schema#"))
+		Expect(schema).To(HaveKeyWithValue("title", "bbl-state.json"))
+		Expect(schema).To(HaveKeyWithValue("type", "object"))
+		Expect(schema).To(HaveKeyWithValue("additionalProperties", false))
+
+		properties := schema["properties"].(map[string]interface{})
+		Expect(properties).To(HaveLen(15))
+		Expect(properties).To(HaveKeyWithValue("version", map[string]interface{}{"type": "integer"}))
+		Expect(properties).To(HaveKeyWithValue("tfState", map[string]interface{}{"type": "string"}))
+		Expect(properties).To(HaveKeyWithValue("lb", map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"type":   map[string]interface{}{"type": "string"},
+				"cert":   map[string]interface{}{"type": "string"},
+				"key":    map[string]interface{}{"type": "string"},
+				"domain": map[string]interface{}{"type": "string"},
+			},
+			"additionalProperties": false,
+		}))
+		Expect(properties).To(HaveKeyWithValue("directorOpsFiles", map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path":     map[string]interface{}{"type": "string"},
+					"contents": map[string]interface{}{"type": "string"},
+				},
+				"additionalProperties": false,
+			},
+		}))
+		Expect(properties["cloudConfigOpsFiles"]).To(Equal(properties["directorOpsFiles"]))
+		Expect(properties).To(HaveKeyWithValue("terraformOverrides", map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name":     map[string]interface{}{"type": "string"},
+					"contents": map[string]interface{}{"type": "string"},
+				},
+				"additionalProperties": false,
+			},
+		}))
+		Expect(properties["cloudFormationFragments"]).To(Equal(properties["terraformOverrides"]))
+
+		bosh := properties["bosh"].(map[string]interface{})["properties"].(map[string]interface{})
+		Expect(bosh).To(HaveKeyWithValue("credentials", map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": map[string]interface{}{"type": "string"},
+		}))
+		Expect(bosh).To(HaveKeyWithValue("state", map[string]interface{}{"type": "object"}))
+	})
+
+	It("renders the schema as JSON", func() {
+		contents, err := storage.StateSchemaJSON()
+		Expect(err).NotTo(HaveOccurred())
+
+		var schema map[string]interface{}
+		Expect(json.Unmarshal(contents, &schema)).To(Succeed())
+		Expect(schema["properties"]).To(HaveKey("keyPair"))
+	})
+
+	It("matches the schema committed in docs", func() {
+		committed, err := ioutil.ReadFile("../docs/bbl-state.schema.json")
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := storage.StateSchemaJSON()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(string(committed)).To(Equal(string(contents)+"\n"),
+			"docs/bbl-state.schema.json is out of date, regenerate it with `bbl validate-state --schema > docs/bbl-state.schema.json`")
+	})
+})
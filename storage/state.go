@@ -1,12 +1,17 @@
 package storage
 
 import (
+	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
+	"time"
 )
 
 const (
@@ -49,58 +54,209 @@ type LB struct {
 	Domain string `json:"domain,omitempty"`
 }
 
+// OpsFile is an ops file given to bbl up, kept with its contents so that every
+// later deploy applies it too.
+type OpsFile struct {
+	Path     string `json:"path"`
+	Contents string `json:"contents"`
+}
+
+// TerraformOverride is a *.tf file of the terraform directory of an
+// environment, kept with its contents so that every later terraform run of
+// the environment includes it too.
+type TerraformOverride struct {
+	Name     string `json:"name"`
+	Contents string `json:"contents"`
+}
+
+// ValidateName checks that the override is written next to the template,
+// since its name may come from a state that was imported or shared.
+func (o TerraformOverride) ValidateName() error {
+	if o.Name == "template.tf" {
+		return errors.New("must not be template.tf, the name of the template bbl generates")
+	}
+
+	if o.Name != filepath.Base(o.Name) || strings.ContainsAny(o.Name, `/\`) || !strings.HasSuffix(o.Name, ".tf") || o.Name == ".tf" {
+		return fmt.Errorf("must be the name of a *.tf file, got %q", o.Name)
+	}
+
+	return nil
+}
+
+// CloudFormationFragment is a JSON or YAML file of the cloudformation
+// directory of an environment, kept with its contents so that every later
+// update of the stack of the environment merges it too.
+type CloudFormationFragment struct {
+	Name     string `json:"name"`
+	Contents string `json:"contents"`
+}
+
 type State struct {
-	Version int     `json:"version"`
-	IAAS    string  `json:"iaas"`
-	AWS     AWS     `json:"aws,omitempty"`
-	GCP     GCP     `json:"gcp,omitempty"`
-	KeyPair KeyPair `json:"keyPair,omitempty"`
-	BOSH    BOSH    `json:"bosh,omitempty"`
-	Stack   Stack   `json:"stack"`
-	EnvID   string  `json:"envID"`
-	TFState string  `json:"tfState"`
-	LB      LB      `json:"lb"`
+	Version    int     `json:"version"`
+	BBLVersion string  `json:"bblVersion,omitempty"`
+	IAAS       string  `json:"iaas"`
+	AWS        AWS     `json:"aws,omitempty"`
+	GCP        GCP     `json:"gcp,omitempty"`
+	KeyPair    KeyPair `json:"keyPair,omitempty"`
+	BOSH       BOSH    `json:"bosh,omitempty"`
+	Stack      Stack   `json:"stack"`
+	EnvID      string  `json:"envID"`
+	TFState    string  `json:"tfState"`
+	LB         LB      `json:"lb"`
+
+	DirectorOpsFiles    []OpsFile `json:"directorOpsFiles,omitempty"`
+	CloudConfigOpsFiles []OpsFile `json:"cloudConfigOpsFiles,omitempty"`
+
+	TerraformOverrides []TerraformOverride `json:"terraformOverrides,omitempty"`
+
+	CloudFormationFragments []CloudFormationFragment `json:"cloudFormationFragments,omitempty"`
 }
 
 type Store struct {
 	version   int
-	stateFile string
+	backend   Backend
+	cipher    *Cipher
+	history   history
+	varsStore *VarsStore
 }
 
 func NewStore(dir string) Store {
-	return Store{
-		version:   2,
-		stateFile: filepath.Join(dir, StateFileName),
+	return NewBackendStore(NewLocalBackend(dir), nil)
+}
+
+func NewEncryptedStore(dir string, passphrase []byte) Store {
+	return NewBackendStore(NewLocalBackend(dir), passphrase)
+}
+
+func NewBackendStore(backend Backend, passphrase []byte) Store {
+	store := Store{
+		version: CurrentStateVersion,
+		backend: backend,
+		history: history{
+			backend:    backend,
+			size:       DefaultHistorySize,
+			invocation: newInvocationID(),
+		},
+	}
+
+	if passphrase != nil {
+		store.cipher = NewCipher(passphrase)
+		store.history.cipher = store.cipher
+	}
+
+	return store
+}
+
+func (s Store) ForCommand(command string) Store {
+	s.history.command = command
+	return s
+}
+
+func (s Store) WithVarsStore(path string) Store {
+	if path != "" {
+		varsStore := NewVarsStore(path)
+		s.varsStore = &varsStore
 	}
+	return s
+}
+
+func (s Store) Get() (State, error) {
+	state, err := getState(s.backend, s.cipher)
+	if err != nil {
+		return State{}, err
+	}
+
+	if s.varsStore == nil {
+		return ResolveStateVars(state, "")
+	}
+
+	return s.varsStore.resolve(state)
+}
+
+func (s Store) History() ([]HistoryEntry, error) {
+	return s.history.entries()
+}
+
+func (s Store) Rollback(version int) (State, error) {
+	contents, err := s.history.snapshot(version)
+	if err != nil {
+		return State{}, err
+	}
+
+	// An empty snapshot records that the state was deleted.
+	state := State{}
+	if len(contents) > 0 {
+		state, err = decodeState(contents, s.cipher)
+		if err != nil {
+			return State{}, err
+		}
+	}
+
+	err = s.Set(state)
+	if err != nil {
+		return State{}, err
+	}
+
+	return state, nil
 }
 
 func (s Store) Set(state State) error {
-	_, err := os.Stat(filepath.Dir(s.stateFile))
+	previousContents, err := s.backend.Read(StateFileName)
 	if err != nil {
-		return err
+		previousContents = nil
+	}
+
+	previous, err := s.Get()
+	if err != nil {
+		previous = State{}
 	}
 
 	if reflect.DeepEqual(state, State{}) {
-		err := os.Remove(s.stateFile)
-		if err != nil && !os.IsNotExist(err) {
-			return err
+		if previousContents != nil {
+			err = s.history.record(previousContents, previous, State{}, []byte{})
+			if err != nil {
+				return err
+			}
 		}
 
-		return nil
+		return s.backend.Delete(StateFileName)
+	}
+
+	state.Version = s.version
+	if BBLVersion != "" {
+		state.BBLVersion = BBLVersion
 	}
 
-	file, err := os.OpenFile(s.stateFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, OS_READ_WRITE_MODE)
+	recorded := state
+	if s.varsStore != nil {
+		state, err = s.varsStore.extract(state)
+		if err != nil {
+			return err
+		}
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	err = encode(buffer, state)
 	if err != nil {
 		return err
 	}
 
-	state.Version = s.version
-	err = encode(file, state)
+	contents := buffer.Bytes()
+	if s.cipher != nil {
+		contents, err = s.cipher.Seal(contents)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The history is recorded before the state is written, so that a change
+	// is never applied without being recorded.
+	err = s.history.record(previousContents, previous, recorded, contents)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.backend.Write(StateFileName, contents)
 }
 
 func (g GCP) Empty() bool {
@@ -110,28 +266,49 @@ func (g GCP) Empty() bool {
 var GetStateLogger logger
 
 func GetState(dir string) (State, error) {
-	state := State{}
+	return getLocalState(dir, nil)
+}
+
+func GetEncryptedState(dir string, passphrase []byte) (State, error) {
+	return getLocalState(dir, NewCipher(passphrase))
+}
+
+func GetBackendState(backend Backend, passphrase []byte) (State, error) {
+	var stateCipher *Cipher
+	if passphrase != nil {
+		stateCipher = NewCipher(passphrase)
+	}
 
+	return getState(backend, stateCipher)
+}
+
+func getLocalState(dir string, stateCipher *Cipher) (State, error) {
 	_, err := os.Stat(dir)
 	if err != nil {
-		return state, err
+		return State{}, err
 	}
 
 	bothExist, err := stateAndBBLStateExist(dir)
 	if err != nil {
-		return state, err
+		return State{}, err
 	}
 
 	if bothExist {
-		return state, errors.New("Cannot proceed with state.json and bbl-state.json present. Please delete one of the files.")
+		return State{}, errors.New("Cannot proceed with state.json and bbl-state.json present. Please delete one of the files.")
 	}
 
 	err = renameStateToBBLState(dir)
 	if err != nil {
-		return state, err
+		return State{}, err
 	}
 
-	file, err := os.Open(filepath.Join(dir, StateFileName))
+	return getState(NewLocalBackend(dir), stateCipher)
+}
+
+func getState(backend Backend, stateCipher *Cipher) (State, error) {
+	state := State{}
+
+	contents, err := backend.Read(StateFileName)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return state, nil
@@ -139,22 +316,47 @@ func GetState(dir string) (State, error) {
 		return state, err
 	}
 
-	err = json.NewDecoder(file).Decode(&state)
+	return decodeState(contents, stateCipher)
+}
+
+func decodeState(contents []byte, stateCipher *Cipher) (State, error) {
+	contents, err := openState(contents, stateCipher)
 	if err != nil {
-		return state, err
+		return State{}, err
 	}
 
-	if state.Version == 1 {
-		state = migrateV1ToV2(state)
+	raw, err := unmarshalRawState(contents)
+	if err != nil {
+		return State{}, err
 	}
 
-	return state, nil
+	_, err = migrateRawState(raw)
+	if err != nil {
+		return State{}, err
+	}
+
+	return rawStateToState(raw)
+}
+
+func openState(contents []byte, stateCipher *Cipher) ([]byte, error) {
+	if !IsEncrypted(contents) {
+		return contents, nil
+	}
+
+	if stateCipher == nil {
+		return nil, StateEncryptedError
+	}
+
+	return stateCipher.Open(contents)
 }
 
-func migrateV1ToV2(state State) State {
-	state.Version = 2
-	state.IAAS = "aws"
-	return state
+func newInvocationID() string {
+	id := make([]byte, 8)
+	if _, err := io.ReadFull(randReader, id); err != nil {
+		return fmt.Sprintf("%d-%d", os.Getpid(), time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(id)
 }
 
 func renameStateToBBLState(dir string) error {
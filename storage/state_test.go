@@ -4,9 +4,12 @@ import (
 	"errors"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 
+	"github.com/cloudfoundry/bosh-bootloader/bbl/objectstorebackend"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"github.com/pivotal-cf-experimental/gomegamatchers"
@@ -236,6 +239,140 @@ var _ = Describe("Store", func() {
 		})
 	})
 
+	Context("when the store is encrypted", func() {
+		BeforeEach(func() {
+			store = storage.NewEncryptedStore(tempDir, []byte("some-passphrase"))
+		})
+
+		It("stores the state sealed with the passphrase", func() {
+			err := store.Set(storage.State{
+				IAAS: "aws",
+				AWS: storage.AWS{
+					SecretAccessKey: "some-aws-secret-access-key",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			data, err := ioutil.ReadFile(filepath.Join(tempDir, "bbl-state.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).NotTo(ContainSubstring("some-aws-secret-access-key"))
+			Expect(storage.IsEncrypted(data)).To(BeTrue())
+
+			state, err := storage.GetEncryptedState(tempDir, []byte("some-passphrase"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state).To(Equal(storage.State{
+				Version: 2,
+				IAAS:    "aws",
+				AWS: storage.AWS{
+					SecretAccessKey: "some-aws-secret-access-key",
+				},
+			}))
+		})
+
+		It("reads a plaintext state so that it can be converted", func() {
+			err := storage.NewStore(tempDir).Set(storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			state, err := storage.GetEncryptedState(tempDir, []byte("some-passphrase"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.IAAS).To(Equal("gcp"))
+		})
+
+		Context("failure cases", func() {
+			BeforeEach(func() {
+				err := store.Set(storage.State{IAAS: "aws"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error when reading without a passphrase", func() {
+				_, err := storage.GetState(tempDir)
+				Expect(err).To(Equal(storage.StateEncryptedError))
+			})
+
+			It("returns an error when reading with the wrong passphrase", func() {
+				_, err := storage.GetEncryptedState(tempDir, []byte("some-other-passphrase"))
+				Expect(err).To(Equal(storage.StateDecryptionError))
+			})
+		})
+	})
+
+	Context("when the store uses a remote backend", func() {
+		var (
+			objectStore *objectstorebackend.Backend
+			server      *httptest.Server
+			backend     storage.Backend
+		)
+
+		BeforeEach(func() {
+			objectStore = objectstorebackend.New()
+			server = objectStore.Start()
+
+			backend = storage.NewGCSBackend(http.DefaultClient, server.URL, "some-bucket", "some-env")
+			store = storage.NewBackendStore(backend, nil)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("stores and retrieves the state through the backend", func() {
+			err := store.Set(storage.State{IAAS: "gcp", EnvID: "some-env-id"})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, ok := objectStore.Get("some-bucket", "some-env/bbl-state.json")
+			Expect(ok).To(BeTrue())
+
+			state, err := storage.GetBackendState(backend, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state).To(Equal(storage.State{
+				Version: 2,
+				IAAS:    "gcp",
+				EnvID:   "some-env-id",
+			}))
+		})
+
+		It("removes the state from the backend when the state is empty", func() {
+			err := store.Set(storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = store.Set(storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, ok := objectStore.Get("some-bucket", "some-env/bbl-state.json")
+			Expect(ok).To(BeFalse())
+		})
+
+		It("reads the current state through the store", func() {
+			err := store.Set(storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			state, err := store.Get()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.IAAS).To(Equal("gcp"))
+		})
+
+		It("returns an empty state when the backend has no state", func() {
+			state, err := storage.GetBackendState(backend, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state).To(Equal(storage.State{}))
+		})
+
+		It("seals the state when a passphrase is provided", func() {
+			err := storage.NewBackendStore(backend, []byte("some-passphrase")).Set(storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, _ := objectStore.Get("some-bucket", "some-env/bbl-state.json")
+			Expect(storage.IsEncrypted(contents)).To(BeTrue())
+
+			state, err := storage.GetBackendState(backend, []byte("some-passphrase"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.IAAS).To(Equal("gcp"))
+
+			_, err = storage.GetBackendState(backend, nil)
+			Expect(err).To(Equal(storage.StateEncryptedError))
+		})
+	})
+
 	Describe("GCP", func() {
 		Describe("Empty", func() {
 			It("returns true when all fields are blank", func() {
@@ -0,0 +1,243 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+type StateProblem struct {
+	Path    string
+	Message string
+}
+
+func (p StateProblem) String() string {
+	if p.Path == "" {
+		return p.Message
+	}
+
+	return fmt.Sprintf("%s: %s", p.Path, p.Message)
+}
+
+func (s Store) Validate() ([]StateProblem, error) {
+	contents, err := s.backend.Read(StateFileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []StateProblem{}, nil
+		}
+		return nil, err
+	}
+
+	contents, err = openState(contents, s.cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	return ValidateState(contents), nil
+}
+
+func ValidateState(contents []byte) []StateProblem {
+	raw, err := unmarshalRawState(contents)
+	if err != nil {
+		return []StateProblem{{Message: fmt.Sprintf("is not valid JSON: %s", err)}}
+	}
+
+	problems := validateSchema("", raw, StateSchema())
+
+	state, err := rawStateToState(raw)
+	if err != nil {
+		if len(problems) == 0 {
+			problems = append(problems, StateProblem{Message: err.Error()})
+		}
+		return problems
+	}
+
+	return append(problems, validateSemantics(state)...)
+}
+
+func validateSchema(path string, value interface{}, schema map[string]interface{}) []StateProblem {
+	if value == nil {
+		return nil
+	}
+
+	problems := []StateProblem{}
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return append(problems, typeProblem(path, "an object", value))
+		}
+
+		properties, _ := schema["properties"].(map[string]interface{})
+		keys := []string{}
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			keyPath := schemaPath(path, key)
+			if propertySchema, ok := properties[key].(map[string]interface{}); ok {
+				problems = append(problems, validateSchema(keyPath, object[key], propertySchema)...)
+				continue
+			}
+
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					problems = append(problems, StateProblem{Path: keyPath, Message: "is not a known field"})
+				}
+			case map[string]interface{}:
+				problems = append(problems, validateSchema(keyPath, object[key], additional)...)
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return append(problems, typeProblem(path, "an array", value))
+		}
+
+		items, _ := schema["items"].(map[string]interface{})
+		for i, item := range array {
+			problems = append(problems, validateSchema(fmt.Sprintf("%s[%d]", path, i), item, items)...)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			problems = append(problems, typeProblem(path, "a string", value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			problems = append(problems, typeProblem(path, "a boolean", value))
+		}
+	case "integer":
+		number, ok := value.(json.Number)
+		if !ok {
+			return append(problems, typeProblem(path, "an integer", value))
+		}
+		if _, err := number.Int64(); err != nil {
+			problems = append(problems, typeProblem(path, "an integer", value))
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			problems = append(problems, typeProblem(path, "a number", value))
+		}
+	}
+
+	return problems
+}
+
+func typeProblem(path, expected string, value interface{}) StateProblem {
+	contents, _ := json.Marshal(value)
+	return StateProblem{Path: path, Message: fmt.Sprintf("must be %s, got %s", expected, contents)}
+}
+
+func schemaPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func validateSemantics(state State) []StateProblem {
+	problems := []StateProblem{}
+	missing := func(path string) {
+		problems = append(problems, StateProblem{Path: path, Message: "must be set"})
+	}
+
+	if state.Version != CurrentStateVersion {
+		problems = append(problems, StateProblem{
+			Path:    "version",
+			Message: fmt.Sprintf("is %d but this bbl writes version %d, run bbl migrate-state", state.Version, CurrentStateVersion),
+		})
+	}
+
+	switch state.IAAS {
+	case "aws":
+		if state.AWS.AccessKeyID == "" {
+			missing("aws.accessKeyId")
+		}
+		if state.AWS.SecretAccessKey == "" {
+			missing("aws.secretAccessKey")
+		}
+		if state.AWS.Region == "" {
+			missing("aws.region")
+		}
+		if !state.GCP.Empty() {
+			problems = append(problems, StateProblem{Path: "gcp", Message: "must be empty when iaas is aws"})
+		}
+	case "gcp":
+		if state.GCP.ServiceAccountKey == "" {
+			missing("gcp.serviceAccountKey")
+		}
+		if state.GCP.ProjectID == "" {
+			missing("gcp.projectID")
+		}
+		if state.GCP.Zone == "" {
+			missing("gcp.zone")
+		}
+		if state.GCP.Region == "" {
+			missing("gcp.region")
+		}
+		if state.AWS != (AWS{}) {
+			problems = append(problems, StateProblem{Path: "aws", Message: "must be empty when iaas is gcp"})
+		}
+		if state.Stack != (Stack{}) {
+			problems = append(problems, StateProblem{Path: "stack", Message: "must be empty when iaas is gcp"})
+		}
+	case "":
+		missing("iaas")
+	default:
+		problems = append(problems, StateProblem{Path: "iaas", Message: fmt.Sprintf("must be one of aws or gcp, got %q", state.IAAS)})
+	}
+
+	problems = append(problems, validateLBType("lb.type", state.LB.Type)...)
+	if state.LB.Type == "cf" {
+		if state.LB.Cert == "" {
+			missing("lb.cert")
+		}
+		if state.LB.Key == "" {
+			missing("lb.key")
+		}
+	}
+	if state.LB.Type == "" && (state.LB.Cert != "" || state.LB.Key != "" || state.LB.Domain != "") {
+		missing("lb.type")
+	}
+
+	problems = append(problems, validateLBType("stack.lbType", state.Stack.LBType)...)
+	if state.Stack.LBType == "cf" && state.Stack.CertificateName == "" {
+		missing("stack.certificateName")
+	}
+	if state.Stack.LBType != "" && state.Stack.Name == "" {
+		missing("stack.name")
+	}
+
+	if state.KeyPair.PrivateKey != "" && state.KeyPair.PublicKey == "" {
+		missing("keyPair.publicKey")
+	}
+
+	if state.TFState != "" {
+		var tfState map[string]interface{}
+		if err := json.Unmarshal([]byte(state.TFState), &tfState); err != nil {
+			problems = append(problems, StateProblem{Path: "tfState", Message: fmt.Sprintf("is not a parseable terraform state: %s", err)})
+		}
+	}
+
+	for i, override := range state.TerraformOverrides {
+		if err := override.ValidateName(); err != nil {
+			problems = append(problems, StateProblem{Path: fmt.Sprintf("terraformOverrides[%d].name", i), Message: err.Error()})
+		}
+	}
+
+	return problems
+}
+
+func validateLBType(path, lbType string) []StateProblem {
+	switch lbType {
+	case "", "concourse", "cf":
+		return nil
+	default:
+		return []StateProblem{{Path: path, Message: fmt.Sprintf("must be one of concourse or cf, got %q", lbType)}}
+	}
+}
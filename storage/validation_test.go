@@ -0,0 +1,166 @@
+package storage_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ValidateState", func() {
+	problemStrings := func(contents string) []string {
+		problems := []string{}
+		for _, problem := range storage.ValidateState([]byte(contents)) {
+			problems = append(problems, problem.String())
+		}
+		return problems
+	}
+
+	It("accepts a valid gcp state", func() {
+		Expect(problemStrings(`{
+			"version": 2,
+			"iaas": "gcp",
+			"gcp": {"serviceAccountKey": "some-key", "projectID": "some-project", "zone": "some-zone", "region": "some-region"},
+			"keyPair": {"privateKey": "some-private-key", "publicKey": "some-public-key"},
+			"lb": {"type": "cf", "cert": "some-cert", "key": "some-key"},
+			"tfState": "{\"version\": 3}"
+		}`)).To(BeEmpty())
+	})
+
+	It("accepts a valid aws state", func() {
+		Expect(problemStrings(`{
+			"version": 2,
+			"iaas": "aws",
+			"aws": {"accessKeyId": "some-id", "secretAccessKey": "some-secret", "region": "some-region"},
+			"stack": {"name": "some-stack", "lbType": "concourse"}
+		}`)).To(BeEmpty())
+	})
+
+	It("reports invalid JSON", func() {
+		Expect(problemStrings(`{"version": 2`)).To(Equal([]string{"is not valid JSON: unexpected EOF"}))
+	})
+
+	It("reports structural problems together with semantic problems", func() {
+		Expect(problemStrings(`{
+			"version": "2",
+			"iaas": "aws",
+			"aws": {"accessKeyId": "some-id", "secretAccessKey": "some-secret", "region": "some-region", "sessionToken": "some-token"},
+			"bosh": {"credentials": {"hmPassword": 1}},
+			"extra": true
+		}`)).To(Equal([]string{
+			`aws.sessionToken: is not a known field`,
+			`bosh.credentials.hmPassword: must be a string, got 1`,
+			`extra: is not a known field`,
+			`version: must be an integer, got "2"`,
+		}))
+
+		Expect(problemStrings(`{"version": 2, "iaas": "aws", "envID": "some-env-id", "unknown": 1}`)).To(Equal([]string{
+			"unknown: is not a known field",
+			"aws.accessKeyId: must be set",
+			"aws.secretAccessKey: must be set",
+			"aws.region: must be set",
+		}))
+	})
+
+	It("reports semantic problems", func() {
+		Expect(problemStrings(`{
+			"version": 1,
+			"iaas": "gcp",
+			"gcp": {"projectID": "some-project"},
+			"aws": {"region": "some-region"},
+			"keyPair": {"privateKey": "some-private-key"},
+			"lb": {"type": "cf"},
+			"tfState": "not terraform"
+		}`)).To(Equal([]string{
+			"version: is 1 but this bbl writes version 2, run bbl migrate-state",
+			"gcp.serviceAccountKey: must be set",
+			"gcp.zone: must be set",
+			"gcp.region: must be set",
+			"aws: must be empty when iaas is gcp",
+			"lb.cert: must be set",
+			"lb.key: must be set",
+			"keyPair.publicKey: must be set",
+			"tfState: is not a parseable terraform state: invalid character 'o' in literal null (expecting 'u')",
+		}))
+	})
+
+	It("reports unknown iaas and load balancer types", func() {
+		Expect(problemStrings(`{
+			"version": 2,
+			"iaas": "azure",
+			"lb": {"type": "tcp"},
+			"stack": {"lbType": "cf"}
+		}`)).To(Equal([]string{
+			`iaas: must be one of aws or gcp, got "azure"`,
+			`lb.type: must be one of concourse or cf, got "tcp"`,
+			"stack.certificateName: must be set",
+			"stack.name: must be set",
+		}))
+
+		Expect(problemStrings(`{"version": 2, "lb": {"cert": "some-cert"}}`)).To(Equal([]string{
+			"iaas: must be set",
+			"lb.type: must be set",
+		}))
+	})
+
+	It("reports terraform overrides that would be written outside the terraform directory", func() {
+		Expect(problemStrings(`{
+			"version": 2,
+			"iaas": "gcp",
+			"gcp": {"serviceAccountKey": "some-key", "projectID": "some-project", "zone": "some-zone", "region": "some-region"},
+			"terraformOverrides": [
+				{"name": "buckets.tf", "contents": "some-contents"},
+				{"name": "../../x.tf", "contents": "some-contents"},
+				{"name": "template.tf", "contents": "some-contents"},
+				{"name": "buckets.txt", "contents": "some-contents"}
+			]
+		}`)).To(Equal([]string{
+			`terraformOverrides[1].name: must be the name of a *.tf file, got "../../x.tf"`,
+			"terraformOverrides[2].name: must not be template.tf, the name of the template bbl generates",
+			`terraformOverrides[3].name: must be the name of a *.tf file, got "buckets.txt"`,
+		}))
+	})
+
+	Describe("Store.Validate", func() {
+		var tempDir string
+
+		BeforeEach(func() {
+			var err error
+			tempDir, err = ioutil.TempDir("", "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("validates the stored state", func() {
+			err := ioutil.WriteFile(filepath.Join(tempDir, storage.StateFileName), []byte(`{"version": 2, "iaas": "aws"}`), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			problems, err := storage.NewStore(tempDir).Validate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(problems).To(HaveLen(3))
+		})
+
+		It("decrypts the stored state", func() {
+			contents, err := storage.NewCipher([]byte("some-passphrase")).Seal([]byte(`{"version": 2, "iaas": "azure"}`))
+			Expect(err).NotTo(HaveOccurred())
+			err = ioutil.WriteFile(filepath.Join(tempDir, storage.StateFileName), contents, os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			problems, err := storage.NewEncryptedStore(tempDir, []byte("some-passphrase")).Validate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(problems).To(Equal([]storage.StateProblem{{Path: "iaas", Message: `must be one of aws or gcp, got "azure"`}}))
+
+			_, err = storage.NewStore(tempDir).Validate()
+			Expect(err).To(Equal(storage.StateEncryptedError))
+		})
+
+		It("returns no problems when there is no state", func() {
+			problems, err := storage.NewStore(tempDir).Validate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(problems).To(BeEmpty())
+		})
+	})
+})
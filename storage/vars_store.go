@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+)
+
+// envIDVarName names the var that records the environment a vars store
+// belongs to, so that the credentials of two environments are never mixed.
+const envIDVarName = "bbl_env_id"
+
+type stateVar struct {
+	name  string
+	value func(state *State) *string
+}
+
+var stateVars = []stateVar{
+	{"ssh_private_key", func(s *State) *string { return &s.KeyPair.PrivateKey }},
+	{"director_password", func(s *State) *string { return &s.BOSH.DirectorPassword }},
+	{"director_ssl_private_key", func(s *State) *string { return &s.BOSH.DirectorSSLPrivateKey }},
+	{"director_ssl_rotation_private_key", func(s *State) *string { return &s.BOSH.DirectorSSLRotationPrivateKey }},
+	{"director_manifest", func(s *State) *string { return &s.BOSH.Manifest }},
+}
+
+type VarsStore struct {
+	path string
+}
+
+func NewVarsStore(path string) VarsStore {
+	return VarsStore{
+		path: path,
+	}
+}
+
+func (v VarsStore) Read() (map[string]string, error) {
+	contents, err := ioutil.ReadFile(v.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+
+	vars := map[string]string{}
+	err = yaml.Unmarshal(contents, &vars)
+	if err != nil {
+		return nil, fmt.Errorf("vars store %s is not valid YAML: %s", v.path, err)
+	}
+
+	return vars, nil
+}
+
+func (v VarsStore) Write(vars map[string]string) error {
+	contents, err := yaml.Marshal(vars)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(v.path)
+	file, err := ioutil.TempFile(dir, "."+filepath.Base(v.path)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(contents)
+	if err == nil {
+		err = file.Sync()
+	}
+
+	closeErr := file.Close()
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+
+	return rename(file.Name(), v.path)
+}
+
+func (v VarsStore) String() string {
+	return v.path
+}
+
+func (v VarsStore) extract(state State) (State, error) {
+	vars, err := v.Read()
+	if err != nil {
+		return State{}, err
+	}
+
+	err = v.checkEnvID(vars, state.EnvID)
+	if err != nil {
+		return State{}, err
+	}
+
+	if state.EnvID != "" {
+		vars[envIDVarName] = state.EnvID
+	}
+
+	for _, stateVar := range stateVars {
+		value := stateVar.value(&state)
+		if *value != "" {
+			if *value != varReference(stateVar.name) {
+				vars[stateVar.name] = *value
+			}
+			*value = varReference(stateVar.name)
+		}
+	}
+
+	if state.BOSH.Credentials != nil {
+		credentials := map[string]string{}
+		for key, value := range state.BOSH.Credentials {
+			name := credentialVarName(key)
+			if value != varReference(name) {
+				vars[name] = value
+			}
+			credentials[key] = varReference(name)
+		}
+		state.BOSH.Credentials = credentials
+	}
+
+	err = v.Write(vars)
+	if err != nil {
+		return State{}, err
+	}
+
+	return state, nil
+}
+
+func (v VarsStore) resolve(state State) (State, error) {
+	vars, err := v.Read()
+	if err != nil {
+		return State{}, err
+	}
+
+	err = v.checkEnvID(vars, state.EnvID)
+	if err != nil {
+		return State{}, err
+	}
+
+	lookup := func(value string) (string, error) {
+		name, ok := varName(value)
+		if !ok {
+			return value, nil
+		}
+
+		resolved, ok := vars[name]
+		if !ok {
+			return "", fmt.Errorf("vars store %s has no value for %s", v.path, name)
+		}
+
+		return resolved, nil
+	}
+
+	for _, stateVar := range stateVars {
+		value := stateVar.value(&state)
+		*value, err = lookup(*value)
+		if err != nil {
+			return State{}, err
+		}
+	}
+
+	if state.BOSH.Credentials != nil {
+		credentials := map[string]string{}
+		for key, value := range state.BOSH.Credentials {
+			credentials[key], err = lookup(value)
+			if err != nil {
+				return State{}, err
+			}
+		}
+		state.BOSH.Credentials = credentials
+	}
+
+	return state, nil
+}
+
+func (v VarsStore) checkEnvID(vars map[string]string, envID string) error {
+	storeEnvID, ok := vars[envIDVarName]
+	if !ok || storeEnvID == "" || envID == "" || storeEnvID == envID {
+		return nil
+	}
+
+	return fmt.Errorf("vars store %s holds the credentials of environment %s, not %s, use a separate vars store for each environment", v.path, storeEnvID, envID)
+}
+
+func ResolveStateVars(state State, varsStorePath string) (State, error) {
+	if varsStorePath == "" {
+		if names := varReferences(state); len(names) > 0 {
+			return State{}, fmt.Errorf("bbl-state.json references %s from a vars store, pass its path with --vars-store", strings.Join(names, ", "))
+		}
+		return state, nil
+	}
+
+	return NewVarsStore(varsStorePath).resolve(state)
+}
+
+func varReferences(state State) []string {
+	names := []string{}
+	for _, stateVar := range stateVars {
+		if name, ok := varName(*stateVar.value(&state)); ok {
+			names = append(names, name)
+		}
+	}
+
+	for _, value := range state.BOSH.Credentials {
+		if name, ok := varName(value); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func varReference(name string) string {
+	return "((" + name + "))"
+}
+
+func varName(value string) (string, bool) {
+	if !strings.HasPrefix(value, "((") || !strings.HasSuffix(value, "))") || strings.HasPrefix(value, redactedPrefix) {
+		return "", false
+	}
+
+	name := strings.TrimSuffix(strings.TrimPrefix(value, "(("), "))")
+	if name == "" || strings.ContainsAny(name, " \n()") {
+		return "", false
+	}
+
+	return name, true
+}
+
+func credentialVarName(key string) string {
+	name := []rune{}
+	for i, r := range key {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				name = append(name, '_')
+			}
+			r = unicode.ToLower(r)
+		}
+		name = append(name, r)
+	}
+
+	return string(name)
+}
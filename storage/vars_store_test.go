@@ -0,0 +1,222 @@
+package storage_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("VarsStore", func() {
+	var (
+		tempDir       string
+		varsStorePath string
+		store         storage.Store
+		state         storage.State
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		varsStorePath = filepath.Join(tempDir, "vars-store.yml")
+		store = storage.NewStore(tempDir).WithVarsStore(varsStorePath)
+
+		state = storage.State{
+			IAAS: "gcp",
+			KeyPair: storage.KeyPair{
+				PrivateKey: "some-private-key",
+				PublicKey:  "some-public-key",
+			},
+			BOSH: storage.BOSH{
+				DirectorUsername:      "some-director-username",
+				DirectorPassword:      "some-director-password",
+				DirectorSSLPrivateKey: "some-ssl-private-key",
+				Manifest:              "name: bosh",
+				Credentials: map[string]string{
+					"natsPassword":              "some-nats-password",
+					"blobstoreDirectorUsername": "some-blobstore-director-username",
+				},
+			},
+		}
+	})
+
+	It("writes generated credentials to the vars store and references to the state", func() {
+		err := store.Set(state)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := ioutil.ReadFile(filepath.Join(tempDir, storage.StateFileName))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(ContainSubstring(`"privateKey":"((ssh_private_key))"`))
+		Expect(string(contents)).To(ContainSubstring(`"directorPassword":"((director_password))"`))
+		Expect(string(contents)).To(ContainSubstring(`"directorSSLPrivateKey":"((director_ssl_private_key))"`))
+		Expect(string(contents)).To(ContainSubstring(`"manifest":"((director_manifest))"`))
+		Expect(string(contents)).To(ContainSubstring(`"natsPassword":"((nats_password))"`))
+		Expect(string(contents)).To(ContainSubstring(`"blobstoreDirectorUsername":"((blobstore_director_username))"`))
+		Expect(string(contents)).To(ContainSubstring(`"directorUsername":"some-director-username"`))
+		Expect(string(contents)).NotTo(ContainSubstring("some-director-password"))
+
+		vars, err := storage.NewVarsStore(varsStorePath).Read()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vars).To(Equal(map[string]string{
+			"ssh_private_key":             "some-private-key",
+			"director_password":           "some-director-password",
+			"director_ssl_private_key":    "some-ssl-private-key",
+			"director_manifest":           "name: bosh",
+			"nats_password":               "some-nats-password",
+			"blobstore_director_username": "some-blobstore-director-username",
+		}))
+
+		fileInfo, err := os.Stat(varsStorePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fileInfo.Mode()).To(Equal(os.FileMode(0600)))
+	})
+
+	It("resolves the references when reading the state", func() {
+		err := store.Set(state)
+		Expect(err).NotTo(HaveOccurred())
+
+		readState, err := store.Get()
+		Expect(err).NotTo(HaveOccurred())
+
+		state.Version = 2
+		Expect(readState).To(Equal(state))
+
+		resolved, err := storage.GetState(tempDir)
+		Expect(err).NotTo(HaveOccurred())
+		resolved, err = storage.ResolveStateVars(resolved, varsStorePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resolved).To(Equal(state))
+	})
+
+	It("keeps the vars store values when the state is written again with references", func() {
+		err := store.Set(state)
+		Expect(err).NotTo(HaveOccurred())
+
+		referenced, err := storage.GetState(tempDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = store.Set(referenced)
+		Expect(err).NotTo(HaveOccurred())
+
+		vars, err := storage.NewVarsStore(varsStorePath).Read()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vars).To(HaveKeyWithValue("director_password", "some-director-password"))
+	})
+
+	Context("when the state belongs to an environment", func() {
+		BeforeEach(func() {
+			state.EnvID = "some-env-id"
+		})
+
+		It("records the environment in the vars store", func() {
+			err := store.Set(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			vars, err := storage.NewVarsStore(varsStorePath).Read()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vars).To(HaveKeyWithValue("bbl_env_id", "some-env-id"))
+		})
+
+		It("refuses to write the credentials of another environment to the vars store", func() {
+			err := store.Set(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			otherStore := storage.NewStore(filepath.Join(tempDir, "other")).WithVarsStore(varsStorePath)
+			Expect(os.Mkdir(filepath.Join(tempDir, "other"), os.ModePerm)).To(Succeed())
+
+			state.EnvID = "some-other-env-id"
+			state.BOSH.DirectorPassword = "some-other-director-password"
+			err = otherStore.Set(state)
+			Expect(err).To(MatchError(ContainSubstring("holds the credentials of environment some-env-id, not some-other-env-id")))
+
+			vars, err := storage.NewVarsStore(varsStorePath).Read()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vars).To(HaveKeyWithValue("director_password", "some-director-password"))
+		})
+
+		It("refuses to resolve the state of another environment from the vars store", func() {
+			err := store.Set(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			referenced, err := storage.GetState(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			referenced.EnvID = "some-other-env-id"
+			_, err = storage.ResolveStateVars(referenced, varsStorePath)
+			Expect(err).To(MatchError(ContainSubstring("holds the credentials of environment some-env-id, not some-other-env-id")))
+		})
+	})
+
+	It("keeps vars that are not part of the state", func() {
+		err := ioutil.WriteFile(varsStorePath, []byte("some_other_var: some-value\n"), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = store.Set(state)
+		Expect(err).NotTo(HaveOccurred())
+
+		vars, err := storage.NewVarsStore(varsStorePath).Read()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vars).To(HaveKeyWithValue("some_other_var", "some-value"))
+	})
+
+	It("records the changed fields of the resolved state in the history", func() {
+		err := store.Set(state)
+		Expect(err).NotTo(HaveOccurred())
+
+		nextStore := storage.NewStore(tempDir).WithVarsStore(varsStorePath)
+		state.EnvID = "some-env-id"
+		err = nextStore.Set(state)
+		Expect(err).NotTo(HaveOccurred())
+
+		entries, err := nextStore.History()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries[len(entries)-1].ChangedFields).To(ContainElement("envID"))
+		Expect(entries[len(entries)-1].ChangedFields).NotTo(ContainElement("bosh"))
+	})
+
+	Describe("ResolveStateVars", func() {
+		It("returns the state unchanged when it has no references", func() {
+			resolved, err := storage.ResolveStateVars(state, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved).To(Equal(state))
+		})
+
+		It("returns an error when the state has references and there is no vars store", func() {
+			err := store.Set(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			referenced, err := storage.GetState(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = storage.ResolveStateVars(referenced, "")
+			Expect(err).To(MatchError("bbl-state.json references blobstore_director_username, director_manifest, director_password, director_ssl_private_key, nats_password, ssh_private_key from a vars store, pass its path with --vars-store"))
+		})
+
+		It("returns an error when a reference is missing from the vars store", func() {
+			_, err := storage.ResolveStateVars(storage.State{BOSH: storage.BOSH{DirectorPassword: "((director_password))"}}, varsStorePath)
+			Expect(err).To(MatchError("vars store " + varsStorePath + " has no value for director_password"))
+		})
+
+		It("returns an error when the vars store is not valid YAML", func() {
+			err := ioutil.WriteFile(varsStorePath, []byte("%%%"), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = storage.ResolveStateVars(state, varsStorePath)
+			Expect(err).To(MatchError(ContainSubstring("is not valid YAML")))
+		})
+
+		It("does not treat redacted placeholders as references", func() {
+			redacted := storage.RedactState(state)
+
+			resolved, err := storage.ResolveStateVars(redacted, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved).To(Equal(redacted))
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package terraform
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -8,6 +9,7 @@ import (
 	"path/filepath"
 
 	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
 
 var tempDir func(dir, prefix string) (string, error) = ioutil.TempDir
@@ -27,16 +29,62 @@ func NewExecutor(cmd terraformCmd, debug bool) Executor {
 	return Executor{cmd: cmd, debug: debug}
 }
 
-func (e Executor) Apply(credentials, envID, projectID, zone, region, cert, key, domain, template, prevTFState string) (string, error) {
-	tempDir, err := tempDir("", "")
+func (e Executor) Apply(credentials, envID, projectID, zone, region, cert, key, domain, template string,
+	overrides []storage.TerraformOverride, prevTFState string) (string, error) {
+	tempDir, vars, err := e.writeInputs(credentials, envID, projectID, zone, region, cert, key, domain, template, overrides, prevTFState)
+	if err != nil {
+		return "", err
+	}
+
+	args := append([]string{"apply"}, vars...)
+	err = e.cmd.Run(os.Stdout, tempDir, args, e.debug)
+	if err != nil {
+		tfState, readErr := readFile(filepath.Join(tempDir, "terraform.tfstate"))
+		if readErr != nil {
+			errorList := helpers.Errors{}
+			errorList.Add(err)
+			errorList.Add(readErr)
+			return "", errorList
+		}
+		return string(tfState), NewTerraformApplyError(string(tfState), err)
+	}
+
+	tfState, err := readFile(filepath.Join(tempDir, "terraform.tfstate"))
 	if err != nil {
 		return "", err
 	}
 
+	return string(tfState), nil
+}
+
+func (e Executor) Plan(credentials, envID, projectID, zone, region, cert, key, domain, template string,
+	overrides []storage.TerraformOverride, prevTFState string) (Plan, error) {
+	tempDir, vars, err := e.writeInputs(credentials, envID, projectID, zone, region, cert, key, domain, template, overrides, prevTFState)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	args := append([]string{"plan", "-no-color"}, vars...)
+	buffer := bytes.NewBuffer([]byte{})
+	err = e.cmd.Run(buffer, tempDir, args, true)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	return parsePlan(buffer.String())
+}
+
+func (e Executor) writeInputs(credentials, envID, projectID, zone, region, cert, key, domain, template string,
+	overrides []storage.TerraformOverride, prevTFState string) (string, []string, error) {
+	tempDir, err := tempDir("", "")
+	if err != nil {
+		return "", nil, err
+	}
+
 	credentialsPath := filepath.Join(tempDir, "credentials.json")
 	err = writeFile(credentialsPath, []byte(credentials), os.ModePerm)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	var certPath string
@@ -44,7 +92,7 @@ func (e Executor) Apply(credentials, envID, projectID, zone, region, cert, key,
 		certPath = filepath.Join(tempDir, "cert")
 		err = writeFile(certPath, []byte(cert), os.ModePerm)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
 	}
 
@@ -53,56 +101,46 @@ func (e Executor) Apply(credentials, envID, projectID, zone, region, cert, key,
 		keyPath = filepath.Join(tempDir, "key")
 		err = writeFile(keyPath, []byte(key), os.ModePerm)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
 	}
 
 	err = writeFile(filepath.Join(tempDir, "template.tf"), []byte(template), os.ModePerm)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+
+	err = writeOverrides(tempDir, overrides)
+	if err != nil {
+		return "", nil, err
 	}
 
 	if prevTFState != "" {
 		err = writeFile(filepath.Join(tempDir, "terraform.tfstate"), []byte(prevTFState), os.ModePerm)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
 	}
 
-	args := []string{"apply"}
-	args = append(args, makeVar("project_id", projectID)...)
-	args = append(args, makeVar("env_id", envID)...)
-	args = append(args, makeVar("region", region)...)
-	args = append(args, makeVar("zone", zone)...)
+	vars := []string{}
+	vars = append(vars, makeVar("project_id", projectID)...)
+	vars = append(vars, makeVar("env_id", envID)...)
+	vars = append(vars, makeVar("region", region)...)
+	vars = append(vars, makeVar("zone", zone)...)
 	if certPath != "" {
-		args = append(args, makeVar("ssl_certificate", certPath)...)
+		vars = append(vars, makeVar("ssl_certificate", certPath)...)
 	}
 	if keyPath != "" {
-		args = append(args, makeVar("ssl_certificate_private_key", keyPath)...)
-	}
-	args = append(args, makeVar("credentials", credentialsPath)...)
-	args = append(args, makeVar("system_domain", domain)...)
-	err = e.cmd.Run(os.Stdout, tempDir, args, e.debug)
-	if err != nil {
-		tfState, readErr := readFile(filepath.Join(tempDir, "terraform.tfstate"))
-		if readErr != nil {
-			errorList := helpers.Errors{}
-			errorList.Add(err)
-			errorList.Add(readErr)
-			return "", errorList
-		}
-		return string(tfState), NewTerraformApplyError(string(tfState), err)
+		vars = append(vars, makeVar("ssl_certificate_private_key", keyPath)...)
 	}
+	vars = append(vars, makeVar("credentials", credentialsPath)...)
+	vars = append(vars, makeVar("system_domain", domain)...)
 
-	tfState, err := readFile(filepath.Join(tempDir, "terraform.tfstate"))
-	if err != nil {
-		return "", err
-	}
-
-	return string(tfState), nil
+	return tempDir, vars, nil
 }
 
-func (e Executor) Destroy(credentials, envID, projectID, zone, region, template, prevTFState string) (string, error) {
+func (e Executor) Destroy(credentials, envID, projectID, zone, region, template string, overrides []storage.TerraformOverride,
+	prevTFState string) (string, error) {
 	tempDir, err := tempDir("", "")
 	if err != nil {
 		return "", err
@@ -119,6 +157,11 @@ func (e Executor) Destroy(credentials, envID, projectID, zone, region, template,
 		return "", err
 	}
 
+	err = writeOverrides(tempDir, overrides)
+	if err != nil {
+		return "", err
+	}
+
 	err = writeFile(filepath.Join(tempDir, "terraform.tfstate"), []byte(prevTFState), os.ModePerm)
 	if err != nil {
 		return "", err
@@ -150,6 +193,23 @@ func (e Executor) Destroy(credentials, envID, projectID, zone, region, template,
 	return string(tfState), nil
 }
 
+// writeOverrides writes the override files kept in the state next to the
+// template so that terraform reads them as part of the same configuration.
+func writeOverrides(dir string, overrides []storage.TerraformOverride) error {
+	for _, override := range overrides {
+		if err := override.ValidateName(); err != nil {
+			return fmt.Errorf("terraform override name %s", err)
+		}
+
+		err := writeFile(filepath.Join(dir, override.Name), []byte(override.Contents), os.ModePerm)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func makeVar(name string, value string) []string {
 	return []string{"-var", fmt.Sprintf("%s=%s", name, value)}
 }
@@ -3,12 +3,14 @@ package terraform_test
 import (
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"github.com/cloudfoundry/bosh-bootloader/terraform"
 
 	. "github.com/onsi/ginkgo"
@@ -49,7 +51,7 @@ var _ = Describe("Executor", func() {
 	Describe("Apply", func() {
 		It("writes the terraform template to a file", func() {
 			_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-				"some-cert", "some-key", "some-domain", "some-template", "")
+				"some-cert", "some-key", "some-domain", "some-template", nil, "")
 			Expect(err).NotTo(HaveOccurred())
 
 			fileContents, err := ioutil.ReadFile(filepath.Join(tempDir, "template.tf"))
@@ -58,9 +60,21 @@ var _ = Describe("Executor", func() {
 			Expect(string(fileContents)).To(Equal("some-template"))
 		})
 
+		It("writes the terraform override files next to the template", func() {
+			_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
+				"some-cert", "some-key", "some-domain", "some-template", []storage.TerraformOverride{
+					{Name: "buckets.tf", Contents: "some-override"},
+				}, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			fileContents, err := ioutil.ReadFile(filepath.Join(tempDir, "buckets.tf"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(fileContents)).To(Equal("some-override"))
+		})
+
 		It("writes the cert when cert is provided", func() {
 			_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-				"some-cert", "some-key", "some-domain", "some-template", "")
+				"some-cert", "some-key", "some-domain", "some-template", nil, "")
 			Expect(err).NotTo(HaveOccurred())
 
 			fileContents, err := ioutil.ReadFile(filepath.Join(tempDir, "cert"))
@@ -71,7 +85,7 @@ var _ = Describe("Executor", func() {
 
 		It("writes the key when key is provided", func() {
 			_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-				"some-cert", "some-key", "some-domain", "some-template", "")
+				"some-cert", "some-key", "some-domain", "some-template", nil, "")
 			Expect(err).NotTo(HaveOccurred())
 
 			fileContents, err := ioutil.ReadFile(filepath.Join(tempDir, "key"))
@@ -82,7 +96,7 @@ var _ = Describe("Executor", func() {
 
 		It("does not write a cert when cert is not provided", func() {
 			_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-				"", "some-key", "some-domain", "some-template", "")
+				"", "some-key", "some-domain", "some-template", nil, "")
 			Expect(err).NotTo(HaveOccurred())
 
 			_, err = ioutil.ReadFile(filepath.Join(tempDir, "cert"))
@@ -91,7 +105,7 @@ var _ = Describe("Executor", func() {
 
 		It("does not write a key when key is not provided", func() {
 			_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-				"some-cert", "", "some-domain", "some-template", "")
+				"some-cert", "", "some-domain", "some-template", nil, "")
 			Expect(err).NotTo(HaveOccurred())
 
 			_, err = ioutil.ReadFile(filepath.Join(tempDir, "key"))
@@ -100,7 +114,7 @@ var _ = Describe("Executor", func() {
 
 		It("does not append ssl_certificate to args when cert is not provided", func() {
 			_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-				"", "some-key", "some-domain", "some-template", "")
+				"", "some-key", "some-domain", "some-template", nil, "")
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(cmd.RunCall.CallCount).To(Equal(1))
@@ -118,7 +132,7 @@ var _ = Describe("Executor", func() {
 
 		It("does not append ssl_certificate_private_key to args when key is not provided", func() {
 			_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-				"some-cert", "", "some-domain", "some-template", "")
+				"some-cert", "", "some-domain", "some-template", nil, "")
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(cmd.RunCall.CallCount).To(Equal(1))
@@ -136,7 +150,7 @@ var _ = Describe("Executor", func() {
 
 		It("passes the correct args and dir to run command", func() {
 			_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-				"some-cert", "some-key", "some-domain", "some-template", "")
+				"some-cert", "some-key", "some-domain", "some-template", nil, "")
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(cmd.RunCall.Receives.WorkingDirectory).To(Equal(tempDir))
@@ -163,7 +177,7 @@ var _ = Describe("Executor", func() {
 			})
 
 			terraformState, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-				"some-cert", "some-key", "some-domain", "some-template", "")
+				"some-cert", "some-key", "some-domain", "some-template", nil, "")
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(actualFilename).To(ContainSubstring("terraform.tfstate"))
@@ -173,7 +187,7 @@ var _ = Describe("Executor", func() {
 		Context("when previous tf state is blank", func() {
 			It("does not write the previous tf state file", func() {
 				_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-					"some-cert", "some-key", "some-domain", "some-template", "")
+					"some-cert", "some-key", "some-domain", "some-template", nil, "")
 				Expect(err).NotTo(HaveOccurred())
 
 				_, err = os.Stat(filepath.Join(tempDir, "terraform.tfstate"))
@@ -184,7 +198,7 @@ var _ = Describe("Executor", func() {
 		Context("when previous tf state is not blank", func() {
 			It("writes the tf state to a file", func() {
 				_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-					"some-cert", "some-key", "some-domain", "some-template", "some-tf-state")
+					"some-cert", "some-key", "some-domain", "some-template", nil, "some-tf-state")
 				Expect(err).NotTo(HaveOccurred())
 
 				fileContents, err := ioutil.ReadFile(filepath.Join(tempDir, "terraform.tfstate"))
@@ -200,7 +214,7 @@ var _ = Describe("Executor", func() {
 					return "", errors.New("failed to make temp dir")
 				})
 				_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-					"some-cert", "some-key", "some-domain", "some-template", "")
+					"some-cert", "some-key", "some-domain", "some-template", nil, "")
 				Expect(err).To(MatchError("failed to make temp dir"))
 			})
 
@@ -214,7 +228,7 @@ var _ = Describe("Executor", func() {
 				})
 
 				_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-					"some-cert", "some-key", "some-domain", "some-template", "")
+					"some-cert", "some-key", "some-domain", "some-template", nil, "")
 				Expect(err).To(MatchError("failed to write template file"))
 			})
 
@@ -228,7 +242,7 @@ var _ = Describe("Executor", func() {
 				})
 
 				_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-					"some-cert", "some-key", "some-domain", "some-template", "some-tf-state")
+					"some-cert", "some-key", "some-domain", "some-template", nil, "some-tf-state")
 				Expect(err).To(MatchError("failed to write tf state file"))
 			})
 
@@ -239,7 +253,7 @@ var _ = Describe("Executor", func() {
 				cmd.RunCall.Returns.Error = errors.New("failed to run terraform command")
 
 				_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-					"some-cert", "some-key", "some-domain", "some-template", "")
+					"some-cert", "some-key", "some-domain", "some-template", nil, "")
 				taErr := err.(terraform.TerraformApplyError)
 				Expect(taErr).To(MatchError("failed to run terraform command"))
 				Expect(taErr.TFState()).To(Equal("some-tf-state"))
@@ -252,7 +266,7 @@ var _ = Describe("Executor", func() {
 				})
 
 				_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-					"some-cert", "some-key", "some-domain", "some-template", "")
+					"some-cert", "some-key", "some-domain", "some-template", nil, "")
 				Expect(err).To(MatchError("the following errors occurred:\nfailed to run terraform command,\nfailed to read tf state file"))
 			})
 
@@ -262,10 +276,43 @@ var _ = Describe("Executor", func() {
 				})
 
 				_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-					"some-cert", "some-key", "some-domain", "some-template", "")
+					"some-cert", "some-key", "some-domain", "some-template", nil, "")
 				Expect(err).To(MatchError("failed to read tf state file"))
 			})
 
+			It("returns an error when it fails to write an override file", func() {
+				terraform.SetWriteFile(func(file string, _ []byte, _ os.FileMode) error {
+					if file == filepath.Join(tempDir, "buckets.tf") {
+						return errors.New("failed to write override file")
+					}
+
+					return nil
+				})
+
+				_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
+					"some-cert", "some-key", "some-domain", "some-template", []storage.TerraformOverride{
+						{Name: "buckets.tf", Contents: "some-override"},
+					}, "")
+				Expect(err).To(MatchError("failed to write override file"))
+				Expect(cmd.RunCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when an override would be written outside of the temp dir", func() {
+				var written []string
+				terraform.SetWriteFile(func(file string, _ []byte, _ os.FileMode) error {
+					written = append(written, file)
+					return nil
+				})
+
+				_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
+					"some-cert", "some-key", "some-domain", "some-template", []storage.TerraformOverride{
+						{Name: "../../x.tf", Contents: "some-override"},
+					}, "")
+				Expect(err).To(MatchError(`terraform override name must be the name of a *.tf file, got "../../x.tf"`))
+				Expect(written).NotTo(ContainElement(ContainSubstring("x.tf")))
+				Expect(cmd.RunCall.CallCount).To(Equal(0))
+			})
+
 			It("returns an error when it fails to write the cert", func() {
 				terraform.SetWriteFile(func(file string, _ []byte, _ os.FileMode) error {
 					if file == filepath.Join(tempDir, "cert") {
@@ -276,7 +323,7 @@ var _ = Describe("Executor", func() {
 				})
 
 				_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-					"some-cert", "some-key", "some-domain", "some-template", "")
+					"some-cert", "some-key", "some-domain", "some-template", nil, "")
 				Expect(err).To(MatchError("failed to write file"))
 			})
 
@@ -290,7 +337,79 @@ var _ = Describe("Executor", func() {
 				})
 
 				_, err := executor.Apply("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-					"some-cert", "some-key", "some-domain", "some-template", "")
+					"some-cert", "some-key", "some-domain", "some-template", nil, "")
+				Expect(err).To(MatchError("failed to write file"))
+			})
+		})
+	})
+
+	Describe("Plan", func() {
+		It("runs terraform plan with the template, state and variables and summarises the changes", func() {
+			cmd.RunCall.Stub = func(stdout io.Writer) {
+				fmt.Fprintln(stdout, "+ google_compute_network.bbl-network")
+				fmt.Fprintln(stdout, "Plan: 3 to add, 1 to change, 2 to destroy.")
+			}
+
+			plan, err := executor.Plan("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
+				"", "", "some-domain", "some-template", nil, "some-tf-state")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cmd.RunCall.Receives.WorkingDirectory).To(Equal(tempDir))
+			Expect(cmd.RunCall.Receives.Args).To(Equal([]string{
+				"plan", "-no-color",
+				"-var", "project_id=some-project-id",
+				"-var", "env_id=some-env-id",
+				"-var", "region=some-region",
+				"-var", "zone=some-zone",
+				"-var", fmt.Sprintf("credentials=%s/credentials.json", tempDir),
+				"-var", "system_domain=some-domain",
+			}))
+
+			tfState, err := ioutil.ReadFile(filepath.Join(tempDir, "terraform.tfstate"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(tfState)).To(Equal("some-tf-state"))
+
+			Expect(plan.Add).To(Equal(3))
+			Expect(plan.Change).To(Equal(1))
+			Expect(plan.Destroy).To(Equal(2))
+			Expect(plan.Empty()).To(BeFalse())
+			Expect(plan.Output).To(Equal("+ google_compute_network.bbl-network"))
+		})
+
+		It("returns an empty plan when terraform has no changes to make", func() {
+			cmd.RunCall.Stub = func(stdout io.Writer) {
+				fmt.Fprintln(stdout, "No changes. Infrastructure is up-to-date.")
+			}
+
+			plan, err := executor.Plan("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
+				"", "", "some-domain", "some-template", nil, "some-tf-state")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Empty()).To(BeTrue())
+			Expect(plan.Output).To(BeEmpty())
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when terraform plan fails", func() {
+				cmd.RunCall.Returns.Error = errors.New("failed to run terraform command")
+
+				_, err := executor.Plan("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
+					"", "", "some-domain", "some-template", nil, "")
+				Expect(err).To(MatchError("failed to run terraform command"))
+			})
+
+			It("returns an error when terraform plan prints no summary", func() {
+				_, err := executor.Plan("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
+					"", "", "some-domain", "some-template", nil, "")
+				Expect(err).To(MatchError("terraform plan did not print a summary of its changes"))
+			})
+
+			It("returns an error when the inputs cannot be written", func() {
+				terraform.SetWriteFile(func(string, []byte, os.FileMode) error {
+					return errors.New("failed to write file")
+				})
+
+				_, err := executor.Plan("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
+					"", "", "some-domain", "some-template", nil, "")
 				Expect(err).To(MatchError("failed to write file"))
 			})
 		})
@@ -299,7 +418,7 @@ var _ = Describe("Executor", func() {
 	Describe("Destroy", func() {
 		It("writes the template and tf state to a temp dir", func() {
 			_, err := executor.Destroy("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-				"some-template", "some-tf-state")
+				"some-template", nil, "some-tf-state")
 			Expect(err).NotTo(HaveOccurred())
 
 			templateContents, err := ioutil.ReadFile(filepath.Join(tempDir, "template.tf"))
@@ -311,9 +430,21 @@ var _ = Describe("Executor", func() {
 			Expect(string(tfStateContents)).To(Equal("some-tf-state"))
 		})
 
+		It("writes the terraform override files next to the template", func() {
+			_, err := executor.Destroy("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
+				"some-template", []storage.TerraformOverride{
+					{Name: "buckets.tf", Contents: "some-override"},
+				}, "some-tf-state")
+			Expect(err).NotTo(HaveOccurred())
+
+			fileContents, err := ioutil.ReadFile(filepath.Join(tempDir, "buckets.tf"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(fileContents)).To(Equal("some-override"))
+		})
+
 		It("writes credentials to a file", func() {
 			_, err := executor.Destroy("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-				"some-template", "some-tf-state")
+				"some-template", nil, "some-tf-state")
 			Expect(err).NotTo(HaveOccurred())
 
 			templateContents, err := ioutil.ReadFile(filepath.Join(tempDir, "credentials.json"))
@@ -323,7 +454,7 @@ var _ = Describe("Executor", func() {
 
 		It("passes the correct args and dir to run command", func() {
 			_, err := executor.Destroy("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-				"some-template", "some-tf-state")
+				"some-template", nil, "some-tf-state")
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(cmd.RunCall.Receives.WorkingDirectory).To(Equal(tempDir))
@@ -345,7 +476,7 @@ var _ = Describe("Executor", func() {
 			})
 
 			tfState, err := executor.Destroy("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region",
-				"some-template", "some-tf-state")
+				"some-template", nil, "some-tf-state")
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(tfState).To(Equal(""))
@@ -357,7 +488,7 @@ var _ = Describe("Executor", func() {
 					return "", errors.New("failed to make temp dir")
 				})
 
-				_, err := executor.Destroy("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region", "some-template", "")
+				_, err := executor.Destroy("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region", "some-template", nil, "")
 				Expect(err).To(MatchError("failed to make temp dir"))
 			})
 
@@ -370,7 +501,7 @@ var _ = Describe("Executor", func() {
 					return nil
 				})
 
-				_, err := executor.Destroy("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region", "some-template", "")
+				_, err := executor.Destroy("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region", "some-template", nil, "")
 				Expect(err).To(MatchError("failed to write credentials file"))
 			})
 
@@ -383,7 +514,7 @@ var _ = Describe("Executor", func() {
 					return nil
 				})
 
-				_, err := executor.Destroy("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region", "some-template", "")
+				_, err := executor.Destroy("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region", "some-template", nil, "")
 				Expect(err).To(MatchError("failed to write template file"))
 			})
 
@@ -396,7 +527,7 @@ var _ = Describe("Executor", func() {
 					return nil
 				})
 
-				_, err := executor.Destroy("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region", "some-template", "some-tf-state")
+				_, err := executor.Destroy("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region", "some-template", nil, "some-tf-state")
 				Expect(err).To(MatchError("failed to write tf state file"))
 			})
 
@@ -406,7 +537,7 @@ var _ = Describe("Executor", func() {
 				})
 				cmd.RunCall.Returns.Error = errors.New("failed to run terraform command")
 
-				tfState, err := executor.Destroy("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region", "some-template", "")
+				tfState, err := executor.Destroy("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region", "some-template", nil, "")
 				Expect(err).To(MatchError("failed to run terraform command"))
 				Expect(tfState).To(Equal("some-tf-state"))
 			})
@@ -417,7 +548,7 @@ var _ = Describe("Executor", func() {
 					return []byte{}, errors.New("failed to read tf state file")
 				})
 
-				_, err := executor.Destroy("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region", "some-template", "")
+				_, err := executor.Destroy("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region", "some-template", nil, "")
 				Expect(err).To(MatchError("the following errors occurred:\nfailed to run terraform command,\nfailed to read tf state file"))
 			})
 
@@ -426,7 +557,7 @@ var _ = Describe("Executor", func() {
 					return []byte{}, errors.New("failed to read tf state file")
 				})
 
-				_, err := executor.Destroy("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region", "some-template", "")
+				_, err := executor.Destroy("some-credentials-json", "some-env-id", "some-project-id", "some-zone", "some-region", "some-template", nil, "")
 				Expect(err).To(MatchError("failed to read tf state file"))
 			})
 
@@ -2,6 +2,7 @@ package terraform
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -16,6 +17,39 @@ func NewOutputter(cmd terraformCmd) Outputter {
 }
 
 func (o Outputter) Get(tfState, outputName string) (string, error) {
+	output, err := o.run(tfState, []string{"output", outputName})
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(output, "\n"), nil
+}
+
+// All returns every output of the terraform state by name, including the
+// outputs of override files.
+func (o Outputter) All(tfState string) (map[string]interface{}, error) {
+	output, err := o.run(tfState, []string{"output", "-json"})
+	if err != nil {
+		return nil, err
+	}
+
+	var jsonOutputs map[string]struct {
+		Value interface{} `json:"value"`
+	}
+	err = json.Unmarshal([]byte(output), &jsonOutputs)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := map[string]interface{}{}
+	for name, jsonOutput := range jsonOutputs {
+		outputs[name] = jsonOutput.Value
+	}
+
+	return outputs, nil
+}
+
+func (o Outputter) run(tfState string, args []string) (string, error) {
 	templateDir, err := tempDir("", "")
 	if err != nil {
 		return "", err
@@ -26,12 +60,11 @@ func (o Outputter) Get(tfState, outputName string) (string, error) {
 		return "", err
 	}
 
-	args := []string{"output", outputName}
 	buffer := bytes.NewBuffer([]byte{})
 	err = o.cmd.Run(buffer, templateDir, args, true)
 	if err != nil {
 		return "", err
 	}
 
-	return strings.TrimSuffix(buffer.String(), "\n"), nil
+	return buffer.String(), nil
 }
@@ -59,6 +59,44 @@ var _ = Describe("Outputter", func() {
 		Expect(cmd.RunCall.Receives.Debug).To(BeTrue())
 	})
 
+	Describe("All", func() {
+		It("returns every output of the terraform state", func() {
+			cmd.RunCall.Stub = func(stdout io.Writer) {
+				fmt.Fprintf(stdout, `{
+    "external_ip": {"sensitive": false, "type": "string", "value": "some-external-ip"},
+    "bucket_names": {"sensitive": false, "type": "list", "value": ["some-bucket"]}
+}`)
+			}
+			outputs, err := outputter.All("some-tf-state")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outputs).To(Equal(map[string]interface{}{
+				"external_ip":  "some-external-ip",
+				"bucket_names": []interface{}{"some-bucket"},
+			}))
+
+			Expect(cmd.RunCall.Receives.WorkingDirectory).To(Equal(tempDir))
+			Expect(cmd.RunCall.Receives.Args).To(Equal([]string{"output", "-json"}))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when it fails to call terraform command run", func() {
+				cmd.RunCall.Returns.Error = errors.New("failed to run terraform command")
+
+				_, err := outputter.All("some-tf-state")
+				Expect(err).To(MatchError("failed to run terraform command"))
+			})
+
+			It("returns an error when terraform does not print json", func() {
+				cmd.RunCall.Stub = func(stdout io.Writer) {
+					fmt.Fprintf(stdout, "some-invalid-json")
+				}
+
+				_, err := outputter.All("some-tf-state")
+				Expect(err).To(MatchError(ContainSubstring("invalid character")))
+			})
+		})
+	})
+
 	Context("failure cases", func() {
 		It("returns an error when it fails to create a temp dir", func() {
 			terraform.SetTempDir(func(dir, prefix string) (string, error) {
@@ -0,0 +1,61 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// OverridesDir is the directory of the state dir of an environment whose *.tf
+// files are applied and destroyed together with the template bbl generates.
+const OverridesDir = "terraform"
+
+type OverridesLoader struct {
+	dir string
+}
+
+func NewOverridesLoader(dir string) OverridesLoader {
+	return OverridesLoader{dir: dir}
+}
+
+// Load reads the *.tf files of the overrides directory in the order of their
+// names. It returns false when the directory does not exist, so that the
+// overrides already kept in the state are used.
+func (l OverridesLoader) Load() ([]storage.TerraformOverride, bool, error) {
+	if _, err := os.Stat(l.dir); err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(l.dir, "*.tf"))
+	if err != nil {
+		return nil, false, err
+	}
+	sort.Strings(paths)
+
+	overrides := []storage.TerraformOverride{}
+	for _, path := range paths {
+		name := filepath.Base(path)
+		if name == "template.tf" {
+			return nil, false, fmt.Errorf("terraform override file %s has the name of the template bbl generates, please rename it", path)
+		}
+
+		if err := (storage.TerraformOverride{Name: name}).ValidateName(); err != nil {
+			return nil, false, fmt.Errorf("terraform override file %s: name %s", path, err)
+		}
+
+		contents, err := readFile(path)
+		if err != nil {
+			return nil, false, err
+		}
+
+		overrides = append(overrides, storage.TerraformOverride{Name: name, Contents: string(contents)})
+	}
+
+	return overrides, true, nil
+}
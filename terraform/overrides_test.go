@@ -0,0 +1,103 @@
+package terraform_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OverridesLoader", func() {
+	var (
+		overridesDir string
+		loader       terraform.OverridesLoader
+	)
+
+	BeforeEach(func() {
+		var err error
+		overridesDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		loader = terraform.NewOverridesLoader(overridesDir)
+	})
+
+	AfterEach(func() {
+		terraform.ResetReadFile()
+	})
+
+	Describe("Load", func() {
+		It("reads the *.tf files of the overrides directory in the order of their names", func() {
+			err := ioutil.WriteFile(filepath.Join(overridesDir, "sql.tf"), []byte("some-sql-override"), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(filepath.Join(overridesDir, "buckets.tf"), []byte("some-buckets-override"), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(filepath.Join(overridesDir, "README.md"), []byte("some-readme"), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			overrides, found, err := loader.Load()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(overrides).To(Equal([]storage.TerraformOverride{
+				{Name: "buckets.tf", Contents: "some-buckets-override"},
+				{Name: "sql.tf", Contents: "some-sql-override"},
+			}))
+		})
+
+		It("returns no overrides when the overrides directory is empty", func() {
+			overrides, found, err := loader.Load()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(overrides).To(BeEmpty())
+		})
+
+		It("reports that the overrides directory does not exist", func() {
+			loader = terraform.NewOverridesLoader(filepath.Join(overridesDir, "missing"))
+
+			overrides, found, err := loader.Load()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeFalse())
+			Expect(overrides).To(BeNil())
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when an override file has the name of the template", func() {
+				err := ioutil.WriteFile(filepath.Join(overridesDir, "template.tf"), []byte("some-override"), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, err = loader.Load()
+				Expect(err).To(MatchError(fmt.Sprintf("terraform override file %s has the name of the template bbl generates, please rename it",
+					filepath.Join(overridesDir, "template.tf"))))
+			})
+
+			It("returns an error when the name of an override file is not a plain file name", func() {
+				err := ioutil.WriteFile(filepath.Join(overridesDir, `some\buckets.tf`), []byte("some-override"), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, err = loader.Load()
+				Expect(err).To(MatchError(fmt.Sprintf(`terraform override file %s: name must be the name of a *.tf file, got "some\\buckets.tf"`,
+					filepath.Join(overridesDir, `some\buckets.tf`))))
+			})
+
+			It("returns an error when an override file cannot be read", func() {
+				err := ioutil.WriteFile(filepath.Join(overridesDir, "buckets.tf"), []byte("some-override"), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				terraform.SetReadFile(func(string) ([]byte, error) {
+					return nil, errors.New("failed to read override file")
+				})
+
+				_, _, err = loader.Load()
+				Expect(err).To(MatchError("failed to read override file"))
+			})
+		})
+	})
+})
@@ -0,0 +1,40 @@
+package terraform
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var planSummaryPattern = regexp.MustCompile(`Plan: (\d+) to add, (\d+) to change, (\d+) to destroy`)
+
+// Plan holds the resource changes terraform plan printed, without its summary
+// line, and the counts from that summary.
+type Plan struct {
+	Add     int
+	Change  int
+	Destroy int
+	Output  string
+}
+
+func (p Plan) Empty() bool {
+	return p.Add == 0 && p.Change == 0 && p.Destroy == 0
+}
+
+func parsePlan(output string) (Plan, error) {
+	matches := planSummaryPattern.FindStringSubmatchIndex(output)
+	if matches == nil {
+		if strings.Contains(output, "No changes.") {
+			return Plan{}, nil
+		}
+		return Plan{}, errors.New("terraform plan did not print a summary of its changes")
+	}
+
+	plan := Plan{Output: strings.TrimSpace(output[:matches[0]])}
+	plan.Add, _ = strconv.Atoi(output[matches[2]:matches[3]])
+	plan.Change, _ = strconv.Atoi(output[matches[4]:matches[5]])
+	plan.Destroy, _ = strconv.Atoi(output[matches[6]:matches[7]])
+
+	return plan, nil
+}